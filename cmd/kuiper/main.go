@@ -994,6 +994,42 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:    "explain",
+			Aliases: []string{"explain"},
+			Usage:   "explain rule $rule_name [-analyze]",
+			Subcommands: []cli.Command{
+				{
+					Name:  "rule",
+					Usage: "explain rule $rule_name -analyze",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "analyze",
+							Usage: "annotate the running topo with the runtime statistics of each node",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						if len(c.Args()) != 1 {
+							fmt.Printf("Expect rule name.\n")
+							return nil
+						}
+						rname := c.Args()[0]
+						method := "Server.ExplainRule"
+						if c.Bool("analyze") {
+							method = "Server.ExplainAnalyzeRule"
+						}
+						var reply string
+						err = client.Call(method, rname, &reply)
+						if err != nil {
+							fmt.Println(err)
+						} else {
+							fmt.Println(reply)
+						}
+						return nil
+					},
+				},
+			},
+		},
 		{
 			Name:    "start",
 			Aliases: []string{"start"},
@@ -1198,7 +1234,41 @@ func main() {
 		{
 			Name:    "import",
 			Aliases: []string{"import"},
-			Usage:   "import ruleset | data -f file -p partial -s stop",
+			Usage:   "import ruleset | data -f file -p partial -s stop | -f yaml_file_or_dir [--plan] [--prune]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "the location of the yaml definition file or the directory of the yaml files",
+				},
+				cli.BoolFlag{
+					Name:  "plan",
+					Usage: "only show the changes to make without applying them",
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "delete the resources which are not defined in the files",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				sfile := c.String("file")
+				if sfile == "" {
+					fmt.Print("Required yaml definition file or directory to import")
+					return nil
+				}
+				args := &model.ReconcileDesc{
+					Path:   sfile,
+					Prune:  c.Bool("prune"),
+					DryRun: c.Bool("plan"),
+				}
+				var reply string
+				err = client.Call("Server.Reconcile", args, &reply)
+				if err != nil {
+					fmt.Println(err)
+				} else {
+					fmt.Println(reply)
+				}
+				return nil
+			},
 			Subcommands: []cli.Command{
 				{
 					Name:  "ruleset",
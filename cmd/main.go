@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"flag"
+	"fmt"
 	"os"
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
@@ -34,6 +35,7 @@ var (
 	dataPath     string
 	logPath      string
 	pluginsPath  string
+	migrateStore bool
 )
 
 func init() {
@@ -43,6 +45,7 @@ func init() {
 	fs.StringVar(&dataPath, "data", "", "data indicates the path of data dir")
 	fs.StringVar(&logPath, "log", "", "log indicates the path of log dir")
 	fs.StringVar(&pluginsPath, "plugins", "", "plugins indicates the path of plugins dir")
+	fs.BoolVar(&migrateStore, "migrateStore", false, "migrateStore copies the sqlite data to the store type in the configuration and exits")
 	_ = fs.Parse(os.Args[1:])
 
 	if len(loadFileType) > 0 {
@@ -65,5 +68,13 @@ func init() {
 }
 
 func Main() {
+	if migrateStore {
+		if err := server.MigrateStore(); err != nil {
+			fmt.Printf("migrate store error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("migrate store successfully")
+		return
+	}
 	server.StartUp(Version)
 }
@@ -30,9 +30,12 @@ import (
 	"github.com/lf-edge/ekuiper/v2/extensions/impl/sql/sqldatabase/sqlgen"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/util"
 	"github.com/lf-edge/ekuiper/v2/metrics"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 	"github.com/lf-edge/ekuiper/v2/pkg/connection"
+	"github.com/lf-edge/ekuiper/v2/pkg/model"
 	"github.com/lf-edge/ekuiper/v2/pkg/modules"
+	"github.com/lf-edge/ekuiper/v2/pkg/store"
 )
 
 type SQLSourceConnector struct {
@@ -100,6 +103,71 @@ func (s *SQLSourceConnector) Provision(ctx api.StreamContext, props map[string]a
 	return nil
 }
 
+// PushDown accepts the conditions which can be translated to sql. For template sql, the conditions and columns are
+// only accepted if the template refers to the pushDownWhere and pushDownColumns variables.
+func (s *SQLSourceConnector) PushDown(props map[string]any, conditions []ast.Expr, columns []string) ([]ast.Expr, []string) {
+	cfg := &struct {
+		DBUrl               string                      `json:"dburl"`
+		URL                 string                      `json:"url"`
+		TemplateSqlQueryCfg *sqlgen.TemplateSqlQueryCfg `json:"templateSqlQueryCfg"`
+		InternalSqlQueryCfg *sqlgen.InternalSqlQueryCfg `json:"internalSqlQueryCfg"`
+	}{}
+	if err := cast.MapToStruct(props, cfg); err != nil {
+		return nil, nil
+	}
+	if cfg.DBUrl == "" {
+		cfg.DBUrl = cfg.URL
+	}
+	// The columns in the where condition are quoted by the driver
+	driver, err := client2.ParseDriver(cfg.DBUrl)
+	var indexFields []*store.IndexField
+	acceptWhere, acceptColumns := true, true
+	switch {
+	case cfg.TemplateSqlQueryCfg != nil:
+		indexFields = cfg.TemplateSqlQueryCfg.IndexFields
+		acceptWhere = strings.Contains(cfg.TemplateSqlQueryCfg.TemplateSql, sqlgen.PushDownWhereVar)
+		acceptColumns = strings.Contains(cfg.TemplateSqlQueryCfg.TemplateSql, sqlgen.PushDownColumnsVar)
+	case cfg.InternalSqlQueryCfg != nil:
+		indexFields = cfg.InternalSqlQueryCfg.IndexFields
+	default:
+		return nil, nil
+	}
+	var (
+		accepted []ast.Expr
+		wheres   []string
+	)
+	if acceptWhere && err == nil {
+		for _, c := range conditions {
+			if w, ok := sqlgen.TranslateCondition(driver, c); ok {
+				accepted = append(accepted, c)
+				wheres = append(wheres, w)
+			}
+		}
+	}
+	if len(wheres) > 0 {
+		props[sqlgen.PushDownWhereVar] = strings.Join(wheres, " AND ")
+	}
+	if !acceptColumns || len(columns) == 0 {
+		return accepted, nil
+	}
+	// The index fields must be read to update the offset
+	cols := append([]string{}, columns...)
+	for _, f := range indexFields {
+		found := false
+		for _, c := range cols {
+			if c == f.IndexFieldName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			cols = append(cols, f.IndexFieldName)
+		}
+	}
+	props[sqlgen.PushDownColumnsVar] = cols
+	return accepted, cols
+}
+
 func (s *SQLSourceConnector) Connect(ctx api.StreamContext, sc api.StatusChangeHandler) error {
 	ctx.GetLogger().Infof("Connecting to sql server")
 	var cli *client2.SQLConnection
@@ -259,8 +327,9 @@ func GetSource() api.Source {
 }
 
 var (
-	_ api.PullTupleSource = &SQLSourceConnector{}
-	_ util.PingableConn   = &SQLSourceConnector{}
+	_ api.PullTupleSource  = &SQLSourceConnector{}
+	_ util.PingableConn    = &SQLSourceConnector{}
+	_ model.PushDownSource = &SQLSourceConnector{}
 )
 
 func (sc *SQLConf) resolveDBURL(props map[string]any) (map[string]any, error) {
@@ -31,7 +31,7 @@ func (q *CommonQueryGenerator) quoteIdentifier(identifier string) string {
 }
 
 func (q *CommonQueryGenerator) getSelect() string {
-	return "select * from " + q.Table + " "
+	return "select " + q.pushDown.selectColumns() + " from " + q.Table + " "
 }
 
 func (q *CommonQueryGenerator) getCondition() (string, error) {
@@ -98,6 +98,10 @@ func (q *OracleQueryGenerate) UpdateMaxIndexValue(row map[string]interface{}) {
 
 func getCondition(cfg *InternalSqlQueryCfg, quoteIdentifier func(string) string) (string, error) {
 	fieldlist := cfg.store.GetFieldList()
+	where := cfg.pushDown.where()
+	if len(fieldlist) == 0 && where != "" {
+		return "where " + where + " ", nil
+	}
 	if len(fieldlist) > 0 {
 		b := bytes.NewBufferString("where")
 		index := 0
@@ -114,6 +118,11 @@ func getCondition(cfg *InternalSqlQueryCfg, quoteIdentifier func(string) string)
 			}
 			index++
 		}
+		if where != "" {
+			b.WriteString("AND ")
+			b.WriteString(where)
+			b.WriteString(" ")
+		}
 		return b.String(), nil
 	}
 	return "", nil
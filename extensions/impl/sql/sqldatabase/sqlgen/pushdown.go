@@ -0,0 +1,157 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlgen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+const (
+	// PushDownWhereVar and PushDownColumnsVar are the template variables of the pushed down filter and columns
+	PushDownWhereVar   = "pushDownWhere"
+	PushDownColumnsVar = "pushDownColumns"
+)
+
+// PushDown is the filter and the columns pushed down by the rule
+type PushDown struct {
+	Where   string
+	Columns []string
+	// Driver is the sql driver to quote the columns
+	Driver string
+}
+
+func (pd *PushDown) selectColumns() string {
+	if pd == nil || len(pd.Columns) == 0 {
+		return "*"
+	}
+	cols := make([]string, len(pd.Columns))
+	for i, c := range pd.Columns {
+		cols[i] = QuoteIdentifier(pd.Driver, c)
+	}
+	return strings.Join(cols, ", ")
+}
+
+func (pd *PushDown) where() string {
+	if pd == nil {
+		return ""
+	}
+	return pd.Where
+}
+
+// QuoteIdentifier quotes the column name by the identifier quoting of the sql driver
+func QuoteIdentifier(driver, name string) string {
+	switch driver {
+	case "mysql":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case "sqlserver":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// TranslateCondition translates the condition of the rule to the sql condition of the driver.
+// It returns false if the condition has any function, field of other types or operator not supported by all databases.
+func TranslateCondition(driver string, expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		s, ok := TranslateCondition(driver, e.Expr)
+		if !ok {
+			return "", false
+		}
+		return "(" + s + ")", true
+	case *ast.BinaryExpr:
+		switch e.OP {
+		case ast.AND, ast.OR:
+			l, ok := TranslateCondition(driver, e.LHS)
+			if !ok {
+				return "", false
+			}
+			r, ok := TranslateCondition(driver, e.RHS)
+			if !ok {
+				return "", false
+			}
+			return "(" + l + " " + ast.Tokens[e.OP] + " " + r + ")", true
+		case ast.EQ, ast.NEQ, ast.LT, ast.LTE, ast.GT, ast.GTE:
+			l, ok := translateOperand(driver, e.LHS)
+			if !ok {
+				return "", false
+			}
+			r, ok := translateOperand(driver, e.RHS)
+			if !ok {
+				return "", false
+			}
+			if e.OP != ast.NEQ {
+				return l + " " + ast.Tokens[e.OP] + " " + r, true
+			}
+			// The null value is not equal to anything in the rule. Exclude it explicitly because the result of
+			// comparing null by <> depends on the database settings such as ANSI_NULLS.
+			cond := l + " <> " + r
+			for _, operand := range []ast.Expr{e.LHS, e.RHS} {
+				if fr, ok := operand.(*ast.FieldRef); ok {
+					cond += " AND " + QuoteIdentifier(driver, fr.Name) + " IS NOT NULL"
+				}
+			}
+			return "(" + cond + ")", true
+		case ast.LIKE, ast.NOTLIKE:
+			fr, ok := e.LHS.(*ast.FieldRef)
+			if !ok {
+				return "", false
+			}
+			lp, ok := e.RHS.(*ast.LikePattern)
+			if !ok {
+				return "", false
+			}
+			// The escape char of the rule is different from the databases
+			sl, ok := lp.Expr.(*ast.StringLiteral)
+			if !ok || strings.Contains(sl.Val, `\`) {
+				return "", false
+			}
+			op := "LIKE"
+			if e.OP == ast.NOTLIKE {
+				op = "NOT LIKE"
+			}
+			return QuoteIdentifier(driver, fr.Name) + " " + op + " " + quoteString(sl.Val), true
+		}
+	}
+	return "", false
+}
+
+func translateOperand(driver string, expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.FieldRef:
+		return QuoteIdentifier(driver, e.Name), true
+	case *ast.IntegerLiteral:
+		return strconv.FormatInt(e.Val, 10), true
+	case *ast.NumberLiteral:
+		return strconv.FormatFloat(e.Val, 'f', -1, 64), true
+	case *ast.StringLiteral:
+		// The backslash is an escape char in the string literals of some databases such as mysql by default,
+		// so the literal with it is kept in the rule instead of being pushed down
+		if strings.Contains(e.Val, `\`) {
+			return "", false
+		}
+		return quoteString(e.Val), true
+	default:
+		return "", false
+	}
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+)
+
+func TestTranslateCondition(t *testing.T) {
+	tests := []struct {
+		cond string
+		sql  string
+		ok   bool
+	}{
+		{cond: `a > 10`, sql: `"a" > 10`, ok: true},
+		{cond: `a != "x'y"`, sql: `("a" <> 'x''y' AND "a" IS NOT NULL)`, ok: true},
+		{cond: `a != b`, sql: `("a" <> "b" AND "a" IS NOT NULL AND "b" IS NOT NULL)`, ok: true},
+		{cond: `a = 1.5 OR b <= 2`, sql: `("a" = 1.5 OR "b" <= 2)`, ok: true},
+		{cond: `(a = 1 AND b LIKE "c%")`, sql: `(("a" = 1 AND "b" LIKE 'c%'))`, ok: true},
+		{cond: `b NOT LIKE "c_"`, sql: `"b" NOT LIKE 'c_'`, ok: true},
+		{cond: "`select` = 1", sql: `"select" = 1`, ok: true},
+		{cond: `b LIKE "c\\%"`, ok: false},
+		{cond: `a = "c\\"`, ok: false},
+		{cond: `a > 1 AND b = "x\\' OR 1 = 1 -- "`, ok: false},
+		{cond: `abs(a) > 1`, ok: false},
+		{cond: `a + 1 > 1`, ok: false},
+		{cond: `a > 1 OR abs(a) > 1`, ok: false},
+		{cond: `a IN (1, 2)`, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader("SELECT * FROM demo WHERE " + tt.cond)).Parse()
+			require.NoError(t, err)
+			sql, ok := TranslateCondition("postgres", stmt.Condition)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.sql, sql)
+		})
+	}
+}
+
+func TestTranslateConditionBackslash(t *testing.T) {
+	// The backslash escapes the closing quote in mysql, so the literal is never pushed down
+	stmt, err := xsql.NewParser(strings.NewReader(`SELECT * FROM demo WHERE a = 1 AND b = "x\\"`)).Parse()
+	require.NoError(t, err)
+	for _, driver := range []string{"mysql", "postgres", "sqlserver", "sqlite3"} {
+		_, ok := TranslateCondition(driver, stmt.Condition)
+		require.False(t, ok, driver)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, "`a``b`", QuoteIdentifier("mysql", "a`b"))
+	require.Equal(t, "[a]]b]", QuoteIdentifier("sqlserver", "a]b"))
+	require.Equal(t, `"a""b"`, QuoteIdentifier("sqlite3", `a"b`))
+}
+
+func TestPushDownQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		props  map[string]any
+		want   string
+	}{
+		{
+			name:   "where and columns",
+			driver: "mysql",
+			props: map[string]any{
+				"internalSqlQueryCfg": map[string]any{
+					"table": "t",
+				},
+				PushDownWhereVar:   "a > 1 AND b = 'x'",
+				PushDownColumnsVar: []string{"a", "b"},
+			},
+			want: "select `a`, `b` from t where a > 1 AND b = 'x' ",
+		},
+		{
+			name:   "with index",
+			driver: "mysql",
+			props: map[string]any{
+				"internalSqlQueryCfg": map[string]any{
+					"table": "t",
+					"limit": 2,
+					"indexFields": []map[string]any{
+						{"indexField": "id", "indexValue": 10},
+					},
+				},
+				PushDownWhereVar: "a > 1",
+			},
+			want: "select * from t where id > '10' AND a > 1 order by 'id' ASC limit 2",
+		},
+		{
+			name:   "sqlserver",
+			driver: "sqlserver",
+			props: map[string]any{
+				"internalSqlQueryCfg": map[string]any{
+					"table": "t",
+					"limit": 2,
+				},
+				PushDownWhereVar:   "a > 1",
+				PushDownColumnsVar: []string{"a"},
+			},
+			want: "select top 2 [a] from t where a > 1 ",
+		},
+		{
+			name:   "template",
+			driver: "mysql",
+			props: map[string]any{
+				"templateSqlQueryCfg": map[string]any{
+					"templateSql": "select {{.pushDownColumns}} from t where {{.pushDownWhere}}",
+				},
+				PushDownColumnsVar: []string{"a"},
+			},
+			want: "select `a` from t where 1=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := GetQueryGenerator(tt.driver, tt.props)
+			require.NoError(t, err)
+			sql, err := g.SqlQueryStatement()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, sql)
+		})
+	}
+}
@@ -28,9 +28,9 @@ func (q *SqlServerQueryGenerator) quoteIdentifier(identifier string) string {
 
 func (q *SqlServerQueryGenerator) getSelect() string {
 	if q.Limit != 0 {
-		return fmt.Sprintf("select top %d * from %s ", q.Limit, q.Table)
+		return fmt.Sprintf("select top %d %s from %s ", q.Limit, q.pushDown.selectColumns(), q.Table)
 	} else {
-		return "select * from " + q.Table + " "
+		return "select " + q.pushDown.selectColumns() + " from " + q.Table + " "
 	}
 }
 
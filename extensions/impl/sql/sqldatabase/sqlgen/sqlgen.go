@@ -41,6 +41,7 @@ type InternalSqlQueryCfg struct {
 	Limit       int                 `json:"limit"`
 	IndexFields []*store.IndexField `json:"indexFields"`
 	store       *store.IndexFieldStoreWrap
+	pushDown    *PushDown
 }
 
 func (i *InternalSqlQueryCfg) InitIndexFieldStore() {
@@ -69,6 +70,10 @@ func (i *InternalSqlQueryCfg) GetIndexValue() interface{} {
 type sqlConfig struct {
 	TemplateSqlQueryCfg *TemplateSqlQueryCfg `json:"templateSqlQueryCfg"`
 	InternalSqlQueryCfg *InternalSqlQueryCfg `json:"internalSqlQueryCfg"`
+	// set by the planner if the rule pushes down the filter and the columns
+	PushDownWhere   string   `json:"pushDownWhere"`
+	PushDownColumns []string `json:"pushDownColumns"`
+	pushDown        *PushDown
 }
 
 func (cfg *sqlConfig) Init(props map[string]interface{}) error {
@@ -80,6 +85,11 @@ func (cfg *sqlConfig) Init(props map[string]interface{}) error {
 	if cfg.TemplateSqlQueryCfg == nil && cfg.InternalSqlQueryCfg == nil {
 		return errors.New("either one of the internalSqlQueryCfg and templateSqlQueryCfg should be defined")
 	}
+	var pd *PushDown
+	if cfg.PushDownWhere != "" || len(cfg.PushDownColumns) > 0 {
+		pd = &PushDown{Where: cfg.PushDownWhere, Columns: cfg.PushDownColumns}
+	}
+	cfg.pushDown = pd
 
 	if cfg.TemplateSqlQueryCfg != nil {
 		if err := formatIndexFieldsDatetime(cfg.TemplateSqlQueryCfg.IndexFields); err != nil {
@@ -87,6 +97,7 @@ func (cfg *sqlConfig) Init(props map[string]interface{}) error {
 		}
 
 		cfg.TemplateSqlQueryCfg.InitIndexFieldStore()
+		cfg.TemplateSqlQueryCfg.pushDown = pd
 	}
 
 	if cfg.InternalSqlQueryCfg != nil {
@@ -94,6 +105,7 @@ func (cfg *sqlConfig) Init(props map[string]interface{}) error {
 			return err
 		}
 		cfg.InternalSqlQueryCfg.InitIndexFieldStore()
+		cfg.InternalSqlQueryCfg.pushDown = pd
 	}
 
 	return nil
@@ -119,6 +131,9 @@ func GetQueryGenerator(driver string, props map[string]interface{}) (SqlQueryGen
 	if err != nil {
 		return nil, err
 	}
+	if cfg.pushDown != nil {
+		cfg.pushDown.Driver = driver
+	}
 
 	if cfg.TemplateSqlQueryCfg != nil {
 		ge, err := NewTemplateSqlQuery(cfg.TemplateSqlQueryCfg)
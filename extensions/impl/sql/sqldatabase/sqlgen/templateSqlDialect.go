@@ -71,6 +71,12 @@ func (t *templateSqlQuery) SqlQueryStatement() (string, error) {
 		}
 		input[w.IndexFieldName] = val
 	}
+	where := t.pushDown.where()
+	if where == "" {
+		where = "1=1"
+	}
+	input[PushDownWhereVar] = where
+	input[PushDownColumnsVar] = t.pushDown.selectColumns()
 
 	var output bytes.Buffer
 	err := t.tp.Execute(&output, input)
@@ -95,6 +101,7 @@ type TemplateSqlQueryCfg struct {
 	TemplateSql string              `json:"templateSql"`
 	IndexFields []*store.IndexField `json:"indexFields"`
 	store       *store.IndexFieldStoreWrap
+	pushDown    *PushDown
 }
 
 func (t *TemplateSqlQueryCfg) InitIndexFieldStore() {
@@ -55,6 +55,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["count"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -64,6 +65,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT, notNull: true},
 	}
 	builtins["max"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -73,6 +75,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["min"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -82,6 +85,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["sum"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -112,6 +116,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["collect"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -122,6 +127,7 @@ func registerAggFunc() {
 			return make([]interface{}, 0), true
 		},
 		val: ValidateOneArg,
+		ret: returnType{dataType: ast.ARRAY, elemType: ast.STRUCT},
 	}
 	builtins["merge_agg"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -144,6 +150,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRUCT},
 	}
 	builtins["deduplicate"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -174,6 +181,7 @@ func registerAggFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.ARRAY, elemType: ast.STRUCT},
 	}
 	builtins["stddev"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -197,6 +205,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["stddevs"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -220,6 +229,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["var"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -243,6 +253,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["vars"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -266,6 +277,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["percentile_cont"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -303,6 +315,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateTwoNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["percentile_disc"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -339,6 +352,7 @@ func registerAggFunc() {
 		},
 		val:   ValidateTwoNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["last_value"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -377,5 +391,56 @@ func registerAggFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 }
+
+// DistinctArgs removes the rows with duplicate values of the first argument for aggregate functions with DISTINCT.
+// The other arguments which are evaluated for each row are filtered in the same way to keep them aligned.
+func DistinctArgs(args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	arg0, ok := args[0].([]interface{})
+	if !ok {
+		return args
+	}
+	seen := make(map[string]struct{}, len(arg0))
+	indexes := make([]int, 0, len(arg0))
+	for i, v := range arg0 {
+		k := distinctKey(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		indexes = append(indexes, i)
+	}
+	if len(indexes) == len(arg0) {
+		return args
+	}
+	result := make([]interface{}, len(args))
+	for i, arg := range args {
+		a, ok := arg.([]interface{})
+		if !ok || len(a) != len(arg0) {
+			result[i] = arg
+			continue
+		}
+		filtered := make([]interface{}, len(indexes))
+		for j, index := range indexes {
+			filtered[j] = a[index]
+		}
+		result[i] = filtered
+	}
+	return result
+}
+
+// distinctKey returns the key to compare the values for DISTINCT. Numbers of different types with the same value are equal.
+func distinctKey(v interface{}) string {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		if f, err := cast.ToFloat64(v, cast.CONVERT_SAMEKIND); err == nil {
+			return fmt.Sprintf("number:%v", f)
+		}
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
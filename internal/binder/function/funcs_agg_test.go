@@ -667,3 +667,37 @@ func TestLastValueValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestDistinctArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result []interface{}
+	}{
+		{
+			name:   "no duplicate",
+			args:   []interface{}{[]interface{}{1, 2, 3}},
+			result: []interface{}{[]interface{}{1, 2, 3}},
+		},
+		{
+			name:   "numbers of different types",
+			args:   []interface{}{[]interface{}{1, int64(1), 1.0, "1", nil, nil}},
+			result: []interface{}{[]interface{}{1, "1", nil}},
+		},
+		{
+			name:   "aligned args",
+			args:   []interface{}{[]interface{}{"a", "b", "a", "c"}, []interface{}{true, true, true, true}, "other"},
+			result: []interface{}{[]interface{}{"a", "b", "c"}, []interface{}{true, true, true}, "other"},
+		},
+		{
+			name:   "not array",
+			args:   []interface{}{1},
+			result: []interface{}{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.result, DistinctArgs(tt.args))
+		})
+	}
+}
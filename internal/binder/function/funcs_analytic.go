@@ -69,6 +69,7 @@ func registerAnalyticFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["had_changed"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -120,6 +121,7 @@ func registerAnalyticFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BOOLEAN},
 	}
 
 	builtins["lag"] = builtinFunc{
@@ -209,6 +211,7 @@ func registerAnalyticFunc() {
 			}
 			return nil
 		},
+		ret: returnType{argType: true},
 	}
 
 	builtins["latest"] = builtinFunc{
@@ -251,6 +254,7 @@ func registerAnalyticFunc() {
 			}
 			return nil
 		},
+		ret: returnType{argType: true},
 	}
 }
 
@@ -318,6 +322,7 @@ func registerGlobalAggFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return nil
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["acc_max"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -367,6 +372,7 @@ func registerGlobalAggFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(1, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["acc_min"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -416,6 +422,7 @@ func registerGlobalAggFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(1, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["acc_sum"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -458,6 +465,7 @@ func registerGlobalAggFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(1, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["acc_count"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -489,6 +497,7 @@ func registerGlobalAggFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(1, len(args))
 		},
+		ret: returnType{dataType: ast.BIGINT, notNull: true},
 	}
 }
 
@@ -81,6 +81,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["element_at"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -114,6 +115,7 @@ func registerArrayFunc() {
 			return ValidateLen(2, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{elemOfArg: true},
 	}
 	builtins["array_contains"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -135,6 +137,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["array_remove"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -160,6 +163,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["array_last_position"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -183,6 +187,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["array_contains_any"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -212,6 +217,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["array_intersect"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -250,6 +256,7 @@ func registerArrayFunc() {
 			return ValidateLen(2, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["array_union"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -289,6 +296,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["array_max"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -303,6 +311,7 @@ func registerArrayFunc() {
 			return ValidateLen(1, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{elemOfArg: true},
 	}
 	builtins["array_min"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -317,6 +326,7 @@ func registerArrayFunc() {
 			return ValidateLen(1, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{elemOfArg: true},
 	}
 	builtins["array_except"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -355,6 +365,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateLen(2, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["repeat"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -426,6 +437,7 @@ func registerArrayFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.ARRAY, elemType: ast.BIGINT},
 	}
 	builtins["array_cardinality"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -440,6 +452,7 @@ func registerArrayFunc() {
 			return ValidateLen(1, len(args))
 		},
 		check: return0IfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["array_flatten"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -500,6 +513,7 @@ func registerArrayFunc() {
 			return ValidateLen(1, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["array_map"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -591,6 +605,7 @@ func registerArrayFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["array_shuffle"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -611,6 +626,7 @@ func registerArrayFunc() {
 			return ValidateLen(1, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["array_sort"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -648,6 +664,7 @@ func registerArrayFunc() {
 			return ValidateLen(1, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["array_concat"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -674,6 +691,7 @@ func registerArrayFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateAtLeast(1, len(args))
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["kvpair_array_to_obj"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
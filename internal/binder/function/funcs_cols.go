@@ -58,6 +58,7 @@ func registerColsFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.STRUCT},
 	}
 }
 
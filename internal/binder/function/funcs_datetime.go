@@ -36,6 +36,7 @@ func registerDateTimeFunc() {
 		fType: ast.FuncTypeScalar,
 		exec:  execGetCurrentDateTime(false),
 		val:   validFspArgs(),
+		ret:   returnType{dataType: ast.DATETIME},
 	}
 	builtins["current_timestamp"] = builtins["now"]
 	builtins["local_time"] = builtins["now"]
@@ -45,6 +46,7 @@ func registerDateTimeFunc() {
 		fType: ast.FuncTypeScalar,
 		exec:  execGetCurrentDate(),
 		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["current_date"] = builtins["cur_date"]
 
@@ -52,6 +54,7 @@ func registerDateTimeFunc() {
 		fType: ast.FuncTypeScalar,
 		exec:  execGetCurrentDateTime(true),
 		val:   validFspArgs(),
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["current_time"] = builtins["cur_time"]
 
@@ -83,6 +86,7 @@ func registerDateTimeFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["date_calc"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -126,6 +130,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.DATETIME},
 	}
 	builtins["date_diff"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -174,6 +179,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.STRINGS},
 	}
 	builtins["day_of_month"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -194,6 +200,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["day"] = builtins["day_of_month"]
 
@@ -216,6 +223,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["day_of_year"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -236,6 +244,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["from_days"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -266,6 +275,7 @@ func registerDateTimeFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.DATETIME},
 	}
 	builtins["from_unix_time"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -295,6 +305,7 @@ func registerDateTimeFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.DATETIME},
 	}
 	builtins["hour"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -315,6 +326,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["last_day"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -341,6 +353,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.DATETIME},
 	}
 	builtins["microsecond"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -361,6 +374,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["minute"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -381,6 +395,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["month"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -401,6 +416,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["month_name"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -421,6 +437,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.STRINGS},
 	}
 	builtins["second"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -441,6 +458,7 @@ func registerDateTimeFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 }
 
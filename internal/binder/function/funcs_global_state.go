@@ -45,6 +45,7 @@ func registerGlobalStateFunc() {
 			return lv, true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.BIGINT, notNull: true},
 	}
 	builtins["last_hit_time"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -68,6 +69,7 @@ func registerGlobalStateFunc() {
 			return lv, true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["last_agg_hit_count"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -86,6 +88,7 @@ func registerGlobalStateFunc() {
 			return lv, true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.BIGINT, notNull: true},
 	}
 	builtins["last_agg_hit_time"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
@@ -109,5 +112,6 @@ func registerGlobalStateFunc() {
 			return lv, true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.BIGINT},
 	}
 }
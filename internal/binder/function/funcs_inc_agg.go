@@ -40,6 +40,21 @@ func IsSupportedIncAgg(name string) bool {
 	return ok
 }
 
+// supportedIncDistinctAggFunc are the incremental aggregate functions which support DISTINCT like count(DISTINCT a)
+var supportedIncDistinctAggFunc = map[string]struct{}{
+	"count":   {},
+	"avg":     {},
+	"max":     {},
+	"min":     {},
+	"sum":     {},
+	"collect": {},
+}
+
+func IsSupportedIncDistinctAgg(name string) bool {
+	_, ok := supportedIncDistinctAggFunc[name]
+	return ok
+}
+
 func registerIncAggFunc() {
 	builtins["inc_count"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -52,6 +67,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT, notNull: true},
 	}
 	builtins["inc_avg"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -72,6 +88,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["inc_max"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -85,6 +102,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["inc_min"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -98,6 +116,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["inc_sum"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -114,6 +133,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["inc_merge_agg"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -130,6 +150,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRUCT},
 	}
 	builtins["inc_collect"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -143,6 +164,7 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.ARRAY, elemType: ast.STRUCT},
 	}
 	builtins["inc_last_value"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -160,7 +182,57 @@ func registerIncAggFunc() {
 		},
 		val:   ValidateTwoNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
+	}
+	for name := range supportedIncDistinctAggFunc {
+		base := builtins["inc_"+name]
+		builtins["inc_distinct_"+name] = builtinFunc{
+			fType: ast.FuncTypeScalar,
+			exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+				return incrementalDistinct(ctx, args, base.exec)
+			},
+			val:   base.val,
+			check: base.check,
+			ret:   base.ret,
+		}
+	}
+}
+
+// incrementalDistinct only feeds the first seen values to the incremental function and returns the last result for the duplicates.
+// Only the distinct values are kept in the state, so the memory is bounded by the cardinality of each group instead of the window size.
+// The state lives in the function context of each window and group, which is dropped once the window is emitted, so the seen
+// values never leak into the next window.
+func incrementalDistinct(ctx api.FunctionContext, args []interface{}, exec func(ctx api.FunctionContext, args []interface{}) (interface{}, bool)) (interface{}, bool) {
+	seenKey := fmt.Sprintf("%v_inc_distinct", ctx.GetFuncId())
+	resultKey := fmt.Sprintf("%v_inc_distinct_result", ctx.GetFuncId())
+	v, err := ctx.GetState(seenKey)
+	if err != nil {
+		return err, false
+	}
+	seen, ok := v.(map[string]interface{})
+	if !ok {
+		seen = make(map[string]interface{})
+	}
+	k := distinctKey(args[0])
+	if _, ok := seen[k]; ok {
+		r, err := ctx.GetState(resultKey)
+		if err != nil {
+			return err, false
+		}
+		return r, true
+	}
+	r, ok := exec(ctx, args)
+	if !ok {
+		return r, ok
+	}
+	seen[k] = true
+	if err := ctx.PutState(seenKey, seen); err != nil {
+		return err, false
+	}
+	if err := ctx.PutState(resultKey, r); err != nil {
+		return err, false
 	}
+	return r, true
 }
 
 func incrementalLastValue(ctx api.FunctionContext, arg interface{}, ignoreNil bool) (interface{}, error) {
@@ -59,6 +59,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["acos"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -76,6 +77,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["asin"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -93,6 +95,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["atan"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -110,6 +113,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["atan2"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -131,6 +135,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateTwoNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["bitand"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -147,6 +152,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateTwoIntArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["bitor"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -163,6 +169,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateTwoIntArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["bitxor"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -179,6 +186,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateTwoIntArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["bitnot"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -199,6 +207,7 @@ func registerMathFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["ceiling"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -211,6 +220,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["ceil"] = builtins["ceiling"] // Synonym for CEILING.
 	builtins["cos"] = builtinFunc{
@@ -229,6 +239,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["cosh"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -246,6 +257,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["exp"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -263,6 +275,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["floor"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -275,6 +288,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["ln"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -292,6 +306,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["log"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -327,6 +342,7 @@ func registerMathFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["mod"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -343,6 +359,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateTwoNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["pi"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -351,6 +368,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateNoArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["power"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -367,6 +385,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateTwoNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["pow"] = builtins["power"] // Synonym for POWER.
 	builtins["rand"] = builtinFunc{
@@ -375,6 +394,7 @@ func registerMathFunc() {
 			return rand.Float64(), true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.FLOAT},
 	}
 	builtins["round"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -387,6 +407,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["sign"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -405,6 +426,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["sin"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -422,6 +444,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["sinh"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -439,6 +462,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["sqrt"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -456,6 +480,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["tan"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -473,6 +498,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["tanh"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -490,6 +516,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["cot"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -509,6 +536,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["radians"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -526,6 +554,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["degrees"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -543,6 +572,7 @@ func registerMathFunc() {
 		},
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["conv"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -574,6 +604,7 @@ func registerMathFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 }
 
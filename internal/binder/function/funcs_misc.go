@@ -54,6 +54,7 @@ func registerMiscFunc() {
 		check: func(args []interface{}) (interface{}, bool) {
 			return args, false
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["props"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -66,6 +67,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["cast"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -130,6 +132,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["to_json"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -142,6 +145,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["parse_json"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -192,6 +196,7 @@ func registerMiscFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["encode"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -230,6 +235,7 @@ func registerMiscFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["decode"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -272,6 +278,7 @@ func registerMiscFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BYTEA},
 	}
 	builtins["trunc"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -305,6 +312,7 @@ func registerMiscFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.FLOAT},
 	}
 	builtins["md5"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -319,6 +327,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["sha1"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -333,6 +342,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["sha256"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -347,6 +357,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["sha384"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -361,6 +372,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["sha512"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -375,6 +387,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["crc32"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -384,15 +397,18 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtinStatfulFuncs["compress"] = func() api.Function {
 		conf.Log.Infof("initializing compress function")
 		return &compressFunc{}
 	}
+	builtinStatfulReturnTypes["compress"] = returnType{dataType: ast.BYTEA}
 	builtinStatfulFuncs["decompress"] = func() api.Function {
 		conf.Log.Infof("initializing decompress function")
 		return &decompressFunc{}
 	}
+	builtinStatfulReturnTypes["decompress"] = returnType{dataType: ast.BYTEA}
 	builtins["isnull"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
@@ -409,6 +425,7 @@ func registerMiscFunc() {
 			}
 		},
 		val: ValidateOneArg,
+		ret: returnType{dataType: ast.BOOLEAN, notNull: true},
 	}
 	builtins["coalesce"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -426,6 +443,7 @@ func registerMiscFunc() {
 			}
 			return nil
 		},
+		ret: returnType{argType: true},
 	}
 	builtins["newuuid"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -437,6 +455,7 @@ func registerMiscFunc() {
 			}
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.STRINGS},
 	}
 	builtins["tstamp"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -444,6 +463,7 @@ func registerMiscFunc() {
 			return timex.GetNowInMilli(), true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.DATETIME},
 	}
 	builtins["mqtt"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -476,6 +496,7 @@ func registerMiscFunc() {
 			return ctx.GetRuleId(), true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.STRINGS},
 	}
 	builtins["rule_start"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -483,6 +504,7 @@ func registerMiscFunc() {
 			return ctx.Value(context.RuleStartKey), true
 		},
 		val: ValidateNoArg,
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["meta"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -521,6 +543,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneArg,
 		check: return0IfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["json_path_query"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -568,21 +591,25 @@ func registerMiscFunc() {
 			return e, true
 		},
 		val: ValidateJsonFunc,
+		ret: returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["window_start"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec:  nil, // directly return in the valuer
 		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["window_end"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec:  nil, // directly return in the valuer
 		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["event_time"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec:  nil, // directly return in the valuer
 		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 
 	builtins["delay"] = builtinFunc{
@@ -605,6 +632,7 @@ func registerMiscFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{argType: true},
 	}
 	builtins["get_keyed_state"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -657,6 +685,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["dec2hex"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -670,6 +699,7 @@ func registerMiscFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 }
 
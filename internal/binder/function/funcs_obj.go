@@ -41,6 +41,7 @@ func registerObjectFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.ARRAY, elemType: ast.STRINGS},
 	}
 	builtins["values"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -89,6 +90,7 @@ func registerObjectFunc() {
 			return ValidateLen(2, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRUCT},
 	}
 	builtins["zip"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -122,6 +124,7 @@ func registerObjectFunc() {
 		},
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRUCT},
 	}
 	builtins["items"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -163,6 +166,7 @@ func registerObjectFunc() {
 		val: func(_ api.FunctionContext, args []ast.Expr) error {
 			return ValidateAtLeast(2, len(args))
 		},
+		ret: returnType{dataType: ast.STRUCT},
 	}
 	builtins["object_construct"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -192,6 +196,7 @@ func registerObjectFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.STRUCT},
 	}
 	builtins["erase"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -236,6 +241,7 @@ func registerObjectFunc() {
 			return ValidateAtLeast(2, len(args))
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRUCT},
 	}
 	builtins["object_size"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -252,6 +258,7 @@ func registerObjectFunc() {
 		val: func(ctx api.FunctionContext, args []ast.Expr) error {
 			return ValidateOneArg(ctx, args)
 		},
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	// The argument can be {obj}, {string arr} OR {obj}, {string}...
 	builtins["object_pick"] = builtinFunc{
@@ -293,6 +300,7 @@ func registerObjectFunc() {
 		val: func(_ api.FunctionContext, args []ast.Expr) error {
 			return ValidateAtLeast(2, len(args))
 		},
+		ret: returnType{dataType: ast.STRUCT},
 	}
 	builtins["obj_to_kvpair_array"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -53,6 +53,7 @@ func registerStrFunc() {
 			}
 			return nil
 		},
+		ret: returnType{dataType: ast.STRINGS},
 	}
 	builtins["endswith"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -62,6 +63,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateTwoStrArg,
 		check: returnFalseIfHasAnyNil,
+		ret:   returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["indexof"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -73,6 +75,7 @@ func registerStrFunc() {
 			return strings.Index(arg0, arg1), true
 		},
 		val: ValidateTwoStrArg,
+		ret: returnType{dataType: ast.BIGINT},
 	}
 	builtins["length"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -91,6 +94,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneArg,
 		check: return0IfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["lower"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -100,6 +104,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["lpad"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -113,6 +118,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrOneInt,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["ltrim"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -122,6 +128,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["numbytes"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -131,6 +138,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: return0IfHasAnyNil,
+		ret:   returnType{dataType: ast.BIGINT},
 	}
 	builtins["regexp_matches"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -144,6 +152,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateTwoStrArg,
 		check: returnFalseIfHasAnyNil,
+		ret:   returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["regexp_replace"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -167,6 +176,7 @@ func registerStrFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["regexp_substr"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -180,6 +190,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateTwoStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["reverse"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -193,6 +204,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["rpad"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -206,6 +218,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrOneInt,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["rtrim"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -215,6 +228,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["substring"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -283,6 +297,7 @@ func registerStrFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["startswith"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -292,6 +307,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateTwoStrArg,
 		check: returnFalseIfHasAnyNil,
+		ret:   returnType{dataType: ast.BOOLEAN},
 	}
 	builtins["split_value"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -338,6 +354,7 @@ func registerStrFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["trim"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -347,6 +364,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["upper"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -356,6 +374,7 @@ func registerStrFunc() {
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 	builtins["format"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
@@ -399,5 +418,6 @@ func registerStrFunc() {
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
+		ret:   returnType{dataType: ast.STRINGS},
 	}
 }
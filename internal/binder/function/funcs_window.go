@@ -15,18 +15,95 @@
 package function
 
 import (
+	"fmt"
+
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
 func registerWindowFunc() {
+	// we implement window functions in windowFuncOperator instead of exec.
+	exec := func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+		return nil, true
+	}
 	builtins["row_number"] = builtinFunc{
 		fType: ast.FuncTypeWindow,
-		// we implement window functions in windowFuncOperator instead of exec.
-		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			return nil, true
+		exec:  exec,
+		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.BIGINT, notNull: true},
+	}
+	builtins["rank"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.BIGINT, notNull: true},
+	}
+	builtins["dense_rank"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.BIGINT, notNull: true},
+	}
+	builtins["percent_rank"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val:   ValidateNoArg,
+		ret:   returnType{dataType: ast.FLOAT},
+	}
+	builtins["ntile"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(1, len(args)); err != nil {
+				return err
+			}
+			return validatePositiveIntLiteral(0, args[0])
+		},
+		ret: returnType{dataType: ast.BIGINT, notNull: true},
+	}
+	builtins["lead"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			l := len(args)
+			if l < 1 || l > 3 {
+				return fmt.Errorf("expect one two or three args but got %d", l)
+			}
+			if l >= 2 {
+				return validatePositiveIntLiteral(1, args[1])
+			}
+			return nil
 		},
-		val: ValidateNoArg,
+		ret: returnType{argType: true},
+	}
+	builtins["first_value"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val:   ValidateOneArg,
+		ret:   returnType{argType: true},
+	}
+	builtins["nth_value"] = builtinFunc{
+		fType: ast.FuncTypeWindow,
+		exec:  exec,
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			return validatePositiveIntLiteral(1, args[1])
+		},
+		ret: returnType{argType: true},
+	}
+}
+
+// validatePositiveIntLiteral validates the argument at the index is a positive integer literal like the n of ntile(n)
+func validatePositiveIntLiteral(index int, arg ast.Expr) error {
+	s, ok := arg.(*ast.IntegerLiteral)
+	if !ok {
+		return ProduceErrInfo(index, "int literal")
+	}
+	if s.Val <= 0 {
+		return fmt.Errorf("the parameter %d should be a positive integer but got %d", index+1, s.Val)
 	}
+	return nil
 }
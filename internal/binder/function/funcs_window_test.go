@@ -26,17 +26,63 @@ func TestWindowFuncValidate(t *testing.T) {
 	testcases := []struct {
 		name string
 		args []ast.Expr
+		err  string
 	}{
 		{
 			name: "row_number",
 			args: nil,
 		},
+		{
+			name: "rank",
+			args: nil,
+		},
+		{
+			name: "dense_rank",
+			args: []ast.Expr{&ast.FieldRef{Name: "a"}},
+			err:  "Expect 0 arguments but found 1.",
+		},
+		{
+			name: "ntile",
+			args: []ast.Expr{&ast.IntegerLiteral{Val: 3}},
+		},
+		{
+			name: "ntile",
+			args: []ast.Expr{&ast.FieldRef{Name: "a"}},
+			err:  "Expect int literal type for parameter 1",
+		},
+		{
+			name: "ntile",
+			args: []ast.Expr{&ast.IntegerLiteral{Val: 0}},
+			err:  "the parameter 1 should be a positive integer but got 0",
+		},
+		{
+			name: "lead",
+			args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 2}, &ast.IntegerLiteral{Val: 0}},
+		},
+		{
+			name: "lead",
+			args: []ast.Expr{},
+			err:  "expect one two or three args but got 0",
+		},
+		{
+			name: "first_value",
+			args: []ast.Expr{&ast.FieldRef{Name: "a"}},
+		},
+		{
+			name: "nth_value",
+			args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}},
+			err:  "Expect int literal type for parameter 2",
+		},
 	}
 
 	for _, tc := range testcases {
 		f, ok := builtins[tc.name]
 		require.True(t, ok)
 		err := f.val(nil, tc.args)
-		require.NoError(t, err)
+		if tc.err != "" {
+			require.EqualError(t, err, tc.err, tc.name)
+		} else {
+			require.NoError(t, err, tc.name)
+		}
 	}
 }
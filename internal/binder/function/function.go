@@ -36,18 +36,23 @@ type builtinFunc struct {
 	exec  funcExe
 	val   funcVal
 	check funcCheckNil
+	// ret is the static return type used to infer the output schema
+	ret returnType
 }
 
 var (
 	builtins            map[string]builtinFunc
 	builtinStatfulFuncs map[string]func() api.Function
-	kvPairKName         = "key"
-	kvPairVName         = "value"
+	// builtinStatfulReturnTypes is the static return type of the stateful functions
+	builtinStatfulReturnTypes map[string]returnType
+	kvPairKName               = "key"
+	kvPairVName               = "value"
 )
 
 func init() {
 	builtins = make(map[string]builtinFunc)
 	builtinStatfulFuncs = make(map[string]func() api.Function)
+	builtinStatfulReturnTypes = make(map[string]returnType)
 	registerAggFunc()
 	registerIncAggFunc()
 	registerMathFunc()
@@ -82,7 +87,14 @@ var analyticFuncs = map[string]struct{}{
 }
 
 var windowFuncs = map[string]struct{}{
-	"row_number": {},
+	"row_number":   {},
+	"rank":         {},
+	"dense_rank":   {},
+	"percent_rank": {},
+	"ntile":        {},
+	"lead":         {},
+	"first_value":  {},
+	"nth_value":    {},
 }
 
 const AnalyticPrefix = "$$a"
@@ -0,0 +1,106 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+// returnType is the static return type of a builtin function which is used to infer the output schema of a rule
+type returnType struct {
+	// dataType is the return type. UNKNOWN means it depends on the runtime value
+	dataType ast.DataType
+	// elemType is the element type when the function returns an array
+	elemType ast.DataType
+	// argType means the function returns the same type as its first argument
+	argType bool
+	// elemOfArg means the function returns the element type of its first argument which is an array
+	elemOfArg bool
+	// notNull means the function never returns nil such as count
+	notNull bool
+}
+
+// InferReturnType infers the static return type of a builtin function by its arguments and their types.
+// The type is nil if it can only be decided at runtime. notNull is true if the function never returns nil.
+func InferReturnType(name string, args []ast.Expr, argTypes []ast.FieldType) (ft ast.FieldType, notNull bool) {
+	name = strings.ToLower(name)
+	if name == "cast" {
+		if len(args) == 2 {
+			if s, ok := args[1].(*ast.StringLiteral); ok {
+				if dt := castDataType(s.Val); dt != ast.UNKNOWN {
+					return &ast.BasicType{Type: dt}, false
+				}
+			}
+		}
+		return nil, false
+	}
+	rt := lookupReturnType(name)
+	switch {
+	case rt.argType:
+		if len(argTypes) > 0 {
+			ft = argTypes[0]
+		}
+	case rt.elemOfArg:
+		if len(argTypes) > 0 {
+			if at, ok := argTypes[0].(*ast.ArrayType); ok {
+				if at.FieldType != nil {
+					ft = at.FieldType
+				} else {
+					ft = &ast.BasicType{Type: at.Type}
+				}
+			}
+		}
+	case rt.dataType == ast.ARRAY:
+		if rt.elemType != ast.STRUCT {
+			ft = &ast.ArrayType{Type: rt.elemType}
+		}
+	case rt.dataType == ast.STRUCT:
+		// The fields of the object are decided at runtime
+	case rt.dataType != ast.UNKNOWN:
+		ft = &ast.BasicType{Type: rt.dataType}
+	}
+	return ft, rt.notNull
+}
+
+// lookupReturnType returns the return type declared where the function is registered.
+// The zero value means the type is unknown.
+func lookupReturnType(name string) returnType {
+	if f, ok := builtins[name]; ok {
+		return f.ret
+	}
+	return builtinStatfulReturnTypes[name]
+}
+
+// castDataType maps the target type of the cast function to the stream data type
+func castDataType(t string) ast.DataType {
+	switch t {
+	case "bigint":
+		return ast.BIGINT
+	case "float":
+		return ast.FLOAT
+	case "string":
+		return ast.STRINGS
+	case "boolean":
+		return ast.BOOLEAN
+	case "datetime":
+		return ast.DATETIME
+	case "bytea":
+		return ast.BYTEA
+	default:
+		return ast.UNKNOWN
+	}
+}
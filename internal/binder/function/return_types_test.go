@@ -0,0 +1,109 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func TestReturnTypesRegistered(t *testing.T) {
+	// The aliases share the registration so they must share the return type
+	aliases := map[string]string{
+		"ceil":              "ceiling",
+		"pow":               "power",
+		"day":               "day_of_month",
+		"current_date":      "cur_date",
+		"current_time":      "cur_time",
+		"current_timestamp": "now",
+		"local_time":        "now",
+		"local_timestamp":   "now",
+	}
+	for alias, name := range aliases {
+		require.Equal(t, lookupReturnType(name), lookupReturnType(alias), alias)
+		require.NotEqual(t, returnType{}, lookupReturnType(alias), alias)
+	}
+	for name := range supportedIncDistinctAggFunc {
+		require.Equal(t, lookupReturnType("inc_"+name), lookupReturnType("inc_distinct_"+name), name)
+	}
+	for _, name := range []string{"compress", "decompress"} {
+		require.Equal(t, returnType{dataType: ast.BYTEA}, lookupReturnType(name), name)
+	}
+	require.Equal(t, returnType{}, lookupReturnType("not_exist"))
+}
+
+func TestInferReturnType(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []ast.Expr
+		argTypes []ast.FieldType
+		ft       ast.FieldType
+		notNull  bool
+	}{
+		{
+			name:    "count",
+			args:    []ast.Expr{&ast.Wildcard{}},
+			ft:      &ast.BasicType{Type: ast.BIGINT},
+			notNull: true,
+		},
+		{
+			name:     "UPPER",
+			args:     []ast.Expr{&ast.FieldRef{Name: "a"}},
+			argTypes: []ast.FieldType{nil},
+			ft:       &ast.BasicType{Type: ast.STRINGS},
+		},
+		{
+			name:     "max",
+			args:     []ast.Expr{&ast.FieldRef{Name: "a"}},
+			argTypes: []ast.FieldType{&ast.BasicType{Type: ast.FLOAT}},
+			ft:       &ast.BasicType{Type: ast.FLOAT},
+		},
+		{
+			name:     "max",
+			args:     []ast.Expr{&ast.FieldRef{Name: "a"}},
+			argTypes: []ast.FieldType{nil},
+		},
+		{
+			name:     "element_at",
+			args:     []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 0}},
+			argTypes: []ast.FieldType{&ast.ArrayType{Type: ast.BIGINT}, &ast.BasicType{Type: ast.BIGINT}},
+			ft:       &ast.BasicType{Type: ast.BIGINT},
+		},
+		{
+			name:     "cast",
+			args:     []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "float"}},
+			argTypes: []ast.FieldType{nil, &ast.BasicType{Type: ast.STRINGS}},
+			ft:       &ast.BasicType{Type: ast.FLOAT},
+		},
+		{
+			name:     "collect",
+			args:     []ast.Expr{&ast.FieldRef{Name: "a"}},
+			argTypes: []ast.FieldType{nil},
+		},
+		{
+			name: "unknown_func",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft, notNull := InferReturnType(tt.name, tt.args, tt.argTypes)
+			require.Equal(t, tt.ft, ft)
+			require.Equal(t, tt.notNull, notNull)
+		})
+	}
+}
@@ -22,10 +22,16 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/io/http"
 	"github.com/lf-edge/ekuiper/v2/internal/io/http/httpserver"
 	"github.com/lf-edge/ekuiper/v2/internal/io/memory"
+	"github.com/lf-edge/ekuiper/v2/internal/io/modbus"
 	"github.com/lf-edge/ekuiper/v2/internal/io/mqtt"
 	"github.com/lf-edge/ekuiper/v2/internal/io/neuron"
+	"github.com/lf-edge/ekuiper/v2/internal/io/otlp"
+	"github.com/lf-edge/ekuiper/v2/internal/io/prometheus"
 	"github.com/lf-edge/ekuiper/v2/internal/io/simulator"
 	"github.com/lf-edge/ekuiper/v2/internal/io/sink"
+	"github.com/lf-edge/ekuiper/v2/internal/io/socket"
+	"github.com/lf-edge/ekuiper/v2/internal/io/syslog"
+	"github.com/lf-edge/ekuiper/v2/internal/io/view"
 	"github.com/lf-edge/ekuiper/v2/internal/io/websocket"
 	plugin2 "github.com/lf-edge/ekuiper/v2/internal/plugin"
 	"github.com/lf-edge/ekuiper/v2/pkg/modules"
@@ -41,6 +47,11 @@ func init() {
 	modules.RegisterSource("neuron", neuron.GetSource)
 	modules.RegisterSource("websocket", func() api.Source { return websocket.GetSource() })
 	modules.RegisterSource("simulator", func() api.Source { return simulator.GetSource() })
+	modules.RegisterSource("modbus", modbus.GetSource)
+	modules.RegisterSource("socket", socket.GetSource)
+	modules.RegisterSource("syslog", syslog.GetSource)
+	modules.RegisterSource("otlp", otlp.GetSource)
+	modules.RegisterSource("prometheus_scrape", prometheus.GetScrapeSource)
 
 	modules.RegisterSink("log", sink.NewLogSink)
 	modules.RegisterSink("logToMemory", sink.NewLogSinkToMemory)
@@ -51,6 +62,10 @@ func init() {
 	modules.RegisterSink("neuron", neuron.GetSink)
 	modules.RegisterSink("file", file.GetSink)
 	modules.RegisterSink("websocket", func() api.Sink { return websocket.GetSink() })
+	modules.RegisterSink("modbus", modbus.GetSink)
+	modules.RegisterSink("socket", socket.GetSink)
+	modules.RegisterSink("prometheus_remote_write", prometheus.GetRemoteWriteSink)
+	modules.RegisterSink("view", view.GetSink)
 
 	modules.RegisterLookupSource("memory", memory.GetLookupSource)
 	modules.RegisterLookupSource("httppull", http.GetLookUpSource)
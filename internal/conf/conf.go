@@ -197,6 +197,7 @@ type KuiperConf struct {
 		GracefulShutdownTimeout cast.DurationConf `yaml:"gracefulShutdownTimeout"`
 		EnableResourceProfiling bool              `yaml:"enableResourceProfiling"`
 		MetricsDumpConfig       MetricsDumpConfig `yaml:"metricsDumpConfig"`
+		GitOps                  GitOpsConfig      `yaml:"gitOps"`
 	}
 	Rule   def.RuleOption
 	Sink   *SinkConf
@@ -217,6 +218,21 @@ type KuiperConf struct {
 		Fdb struct {
 			Path string `yaml:"path"`
 		}
+		Pebble struct {
+			Path                     string `yaml:"path"`
+			MemTableSize             int64  `yaml:"memTableSize"`
+			CacheSize                int64  `yaml:"cacheSize"`
+			L0CompactionThreshold    int    `yaml:"l0CompactionThreshold"`
+			L0StopWritesThreshold    int    `yaml:"l0StopWritesThreshold"`
+			MaxConcurrentCompactions int    `yaml:"maxConcurrentCompactions"`
+			Sync                     bool   `yaml:"sync"`
+		}
+		Encryption struct {
+			Enable           bool              `yaml:"enable"`
+			KeyFile          string            `yaml:"keyFile"`
+			PreviousKeyFile  string            `yaml:"previousKeyFile"`
+			RotationInterval cast.DurationConf `yaml:"rotationInterval"`
+		}
 	}
 	Portable struct {
 		PythonBin   string            `yaml:"pythonBin"`
@@ -237,6 +253,20 @@ type MetricsDumpConfig struct {
 	RetainedDuration time.Duration `yaml:"retainedDuration"`
 }
 
+// GitOpsConfig is the config of the reconciler which keeps the streams, tables, rules, connections and schemas
+// the same as the yaml definitions in a directory or a git repository
+type GitOpsConfig struct {
+	Enable bool `yaml:"enable"`
+	// Dir is the directory of the definition files. If Repo is set, it is the path in the repository.
+	Dir string `yaml:"dir"`
+	// Repo is the url of the git repository to pull, such as a local bare repository
+	Repo     string            `yaml:"repo"`
+	Branch   string            `yaml:"branch"`
+	Interval cast.DurationConf `yaml:"interval"`
+	// Prune deletes the resources which are not defined in the files
+	Prune bool `yaml:"prune"`
+}
+
 type OpenTelemetry struct {
 	ServiceName           string `yaml:"serviceName"`
 	EnableRemoteCollector bool   `yaml:"enableRemoteCollector"`
@@ -429,6 +459,10 @@ func InitConf() {
 		Config.Source = &SourceConf{}
 	}
 
+	if time.Duration(Config.Basic.GitOps.Interval) < time.Second {
+		Config.Basic.GitOps.Interval = cast.DurationConf(time.Minute)
+	}
+
 	if Config.Basic.MetricsDumpConfig.RetainedDuration < 1 {
 		Config.Basic.MetricsDumpConfig.RetainedDuration = 6 * time.Hour
 	}
@@ -502,6 +536,23 @@ func ValidateRuleOption(option *def.RuleOption) error {
 			errs = errors.Join(errs, errors.New("invalidRestartJitterFactor:restart jitterFactor must between [0, 1)"))
 		}
 	}
+	if option.Backpressure != nil {
+		policies := []string{option.Backpressure.Policy}
+		for _, p := range option.Backpressure.Nodes {
+			policies = append(policies, p)
+		}
+		for _, p := range policies {
+			switch p {
+			case "", def.BackpressureDropOldest, def.BackpressureDropNewest, def.BackpressureBlock, def.BackpressureSpillToDisk:
+			default:
+				errs = errors.Join(errs, fmt.Errorf("invalidBackpressure:unknown backpressure policy %s", p))
+			}
+		}
+		if option.Backpressure.MaxSpill < 0 {
+			option.Backpressure.MaxSpill = 0
+			errs = errors.Join(errs, errors.New("invalidBackpressureMaxSpill:backpressure maxSpill must be greater than 0"))
+		}
+	}
 	if err := schedule.ValidateRanges(option.CronDatetimeRange); err != nil {
 		errs = errors.Join(errs, fmt.Errorf("validate cronDatetimeRange failed, err:%v", err))
 	}
@@ -226,6 +226,17 @@ func TestRuleOptionValidate(t *testing.T) {
 			},
 			err: "invalidRestartMultiplier:restart multiplier must be greater than 0\ninvalidRestartAttempts:restart attempts must be greater than 0\ninvalidRestartDelay:restart delay must be greater than 0\ninvalidRestartMaxDelay:restart maxDelay must be greater than 0\ninvalidRestartJitterFactor:restart jitterFactor must between [0, 1)",
 		},
+		{
+			s: &def.RuleOption{
+				Concurrency:  1,
+				BufferLength: 1024,
+				Backpressure: &def.BackpressureOption{
+					Policy: "block",
+					Nodes:  map[string]string{"project": "dropAll"},
+				},
+			},
+			err: "invalidBackpressure:unknown backpressure policy dropAll",
+		},
 	}
 	fmt.Printf("The test bucket size is %d.\n\n", len(tests))
 	for i, tt := range tests {
@@ -217,3 +217,49 @@ func (v *VaultSecret) callVault(req *http.Request) (io.ReadCloser, error) {
 func (v *VaultSecret) Jwt() string {
 	return v.authContext["token"].(string)
 }
+
+// GetSecret reads the value of the key in the secret path of this service in the EdgeX secret store
+func (v *VaultSecret) GetSecret(path, key string) (string, error) {
+	url := fmt.Sprintf("%s://%s:%d/v1/secret/edgex/%s/%s", v.scheme, v.host, v.port, v.secretName, path)
+
+	req, newReqErr := http.NewRequest(http.MethodGet, url, nil)
+	if newReqErr != nil {
+		return "", fmt.Errorf("getSecret failed. error creating request: %v", newReqErr)
+	}
+
+	respBody, callErr := v.callVault(req)
+	if callErr != nil {
+		return "", callErr
+	}
+	defer func() { _ = respBody.Close() }()
+
+	var resp struct {
+		Data map[string]string `json:"data"`
+	}
+	if decodeErr := json.NewDecoder(respBody).Decode(&resp); decodeErr != nil {
+		return "", fmt.Errorf("getSecret failed. error decoding JSON: %v", decodeErr)
+	}
+	value, ok := resp.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s is not found in secret path %s", key, path)
+	}
+	return value, nil
+}
+
+// VaultProvider resolves the secret references like {{secret "vault:mqtt/password"}} by the EdgeX secret store.
+// The last segment of the name is the key and the rest is the secret path.
+type VaultProvider struct {
+	Logger *logrus.Logger
+}
+
+func (p *VaultProvider) Get(name string) (string, error) {
+	i := strings.LastIndex(name, "/")
+	if i <= 0 || i == len(name)-1 {
+		return "", fmt.Errorf("invalid vault secret %s, it must be in the format of path/key", name)
+	}
+	v := SecretProvider(p.Logger)
+	if v == nil {
+		return "", fmt.Errorf("the EdgeX secret store is not available")
+	}
+	return v.GetSecret(name[:i], name[i+1:])
+}
@@ -15,12 +15,16 @@
 package http
 
 import (
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/httpx"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/model"
 )
 
 type HttpPullSource struct {
@@ -46,8 +50,13 @@ func (hps *HttpPullSource) Connect(ctx api.StreamContext, sch api.StatusChangeHa
 	return nil
 }
 
+const pushDownParamsKey = "pushDownParams"
+
 type pullSourceConfig struct {
 	Path string `json:"datasource"`
+	// PushDown enables sending the equal conditions of the rule as the url query parameters
+	PushDown       bool              `json:"pushDown"`
+	PushDownParams map[string]string `json:"pushDownParams"`
 }
 
 func (hps *HttpPullSource) Provision(ctx api.StreamContext, configs map[string]any) error {
@@ -58,7 +67,76 @@ func (hps *HttpPullSource) Provision(ctx api.StreamContext, configs map[string]a
 	if hps.ClientConf == nil {
 		hps.ClientConf = &ClientConf{}
 	}
-	return hps.InitConf(pc.Path, configs)
+	if err := hps.InitConf(pc.Path, configs); err != nil {
+		return err
+	}
+	if len(pc.PushDownParams) > 0 {
+		u, err := url.Parse(hps.config.Url)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		for k, v := range pc.PushDownParams {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+		hps.config.Url = u.String()
+	}
+	return nil
+}
+
+// PushDown accepts the conditions in the form of field = literal if the pushDown property is enabled.
+// They are sent as the url query parameters. The columns are not supported.
+func (hps *HttpPullSource) PushDown(props map[string]any, conditions []ast.Expr, _ []string) ([]ast.Expr, []string) {
+	pc := &pullSourceConfig{}
+	if err := cast.MapToStruct(props, pc); err != nil || !pc.PushDown {
+		return nil, nil
+	}
+	var accepted []ast.Expr
+	params := make(map[string]string)
+	for _, c := range conditions {
+		k, v, ok := equalParam(c)
+		if !ok {
+			continue
+		}
+		// Conflict conditions like a = 1 AND a = 2 cannot be expressed by a parameter
+		if ov, exists := params[k]; exists && ov != v {
+			continue
+		}
+		params[k] = v
+		accepted = append(accepted, c)
+	}
+	if len(accepted) > 0 {
+		props[pushDownParamsKey] = params
+	}
+	return accepted, nil
+}
+
+func equalParam(expr ast.Expr) (string, string, bool) {
+	be, ok := expr.(*ast.BinaryExpr)
+	if !ok || be.OP != ast.EQ {
+		return "", "", false
+	}
+	fr, lit := be.LHS, be.RHS
+	if _, ok := fr.(*ast.FieldRef); !ok {
+		fr, lit = lit, fr
+	}
+	f, ok := fr.(*ast.FieldRef)
+	if !ok {
+		return "", "", false
+	}
+	switch l := lit.(type) {
+	case *ast.StringLiteral:
+		return f.Name, l.Val, true
+	case *ast.IntegerLiteral:
+		return f.Name, strconv.FormatInt(l.Val, 10), true
+	case *ast.NumberLiteral:
+		return f.Name, strconv.FormatFloat(l.Val, 'f', -1, 64), true
+	case *ast.BooleanLiteral:
+		return f.Name, strconv.FormatBool(l.Val), true
+	default:
+		return "", "", false
+	}
 }
 
 func (hps *HttpPullSource) doPull(ctx api.StreamContext) ([]map[string]any, error) {
@@ -94,4 +172,7 @@ func GetSource() api.Source {
 	return &HttpPullSource{}
 }
 
-var _ api.PullTupleSource = &HttpPullSource{}
+var (
+	_ api.PullTupleSource  = &HttpPullSource{}
+	_ model.PushDownSource = &HttpPullSource{}
+)
@@ -24,6 +24,7 @@ import (
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 	"github.com/stretchr/testify/require"
 
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
 )
 
@@ -218,3 +219,29 @@ func TestSourceIncremental(t *testing.T) {
 	}, func(ctx api.StreamContext, err error) {})
 	require.Nil(t, <-dataCh)
 }
+
+func TestHttpPullSourcePushDown(t *testing.T) {
+	conditions := []ast.Expr{
+		&ast.BinaryExpr{OP: ast.EQ, LHS: &ast.FieldRef{Name: "id"}, RHS: &ast.IntegerLiteral{Val: 1}},
+		&ast.BinaryExpr{OP: ast.EQ, LHS: &ast.StringLiteral{Val: "a b"}, RHS: &ast.FieldRef{Name: "name"}},
+		&ast.BinaryExpr{OP: ast.GT, LHS: &ast.FieldRef{Name: "temp"}, RHS: &ast.IntegerLiteral{Val: 20}},
+		&ast.BinaryExpr{OP: ast.EQ, LHS: &ast.FieldRef{Name: "id"}, RHS: &ast.IntegerLiteral{Val: 2}},
+	}
+	source := &HttpPullSource{}
+	props := map[string]any{
+		"url":        "http://localhost:9090",
+		"datasource": "/get?x=y",
+	}
+	accepted, cols := source.PushDown(props, conditions, []string{"id"})
+	require.Nil(t, accepted)
+	require.Nil(t, cols)
+
+	props["pushDown"] = true
+	accepted, cols = source.PushDown(props, conditions, []string{"id"})
+	require.Equal(t, conditions[:2], accepted)
+	require.Nil(t, cols)
+
+	ctx := mockContext.NewMockContext("1", "2")
+	require.NoError(t, source.Provision(ctx, props))
+	require.Equal(t, "http://localhost:9090/get?id=1&name=a+b&x=y", source.config.Url)
+}
@@ -88,6 +88,17 @@ func (s *lookupsource) Lookup(ctx api.StreamContext, _ []string, keys []string,
 	return r, nil
 }
 
+// Scan returns all the rows of the table for the ad-hoc query
+func (s *lookupsource) Scan(ctx api.StreamContext) ([]map[string]any, error) {
+	ctx.GetLogger().Debugf("lookup source %s is scanning", s.topic)
+	tuples := s.table.All()
+	r := make([]map[string]any, len(tuples))
+	for i, t := range tuples {
+		r[i] = t.ToMap()
+	}
+	return r, nil
+}
+
 func (s *lookupsource) Close(ctx api.StreamContext) error {
 	ctx.GetLogger().Infof("lookup source %s is closing", s.topic)
 	return store.Unreg(s.topic, s.key)
@@ -172,6 +172,9 @@ func TestLookup(t *testing.T) {
 		}
 	}
 	assert.Equal(t, expected, result)
+	all, err := ls.(*lookupsource).Scan(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
 	err = ls.Close(ctx)
 	assert.NoError(t, err)
 }
@@ -0,0 +1,268 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modbus function codes
+const (
+	fcReadCoils              byte = 0x01
+	fcReadDiscreteInputs     byte = 0x02
+	fcReadHoldingRegisters   byte = 0x03
+	fcReadInputRegisters     byte = 0x04
+	fcWriteSingleCoil        byte = 0x05
+	fcWriteSingleRegister    byte = 0x06
+	fcWriteMultipleRegisters byte = 0x10
+)
+
+const (
+	mbapHeaderLen = 7
+	// maxRegisterQuantity is the protocol limit of registers in one read request
+	maxRegisterQuantity = 125
+	// maxBitQuantity is the protocol limit of coils or discrete inputs in one read request
+	maxBitQuantity = 2000
+	maxAduLength   = 260
+)
+
+var exceptionMessages = map[byte]string{
+	0x01: "illegal function",
+	0x02: "illegal data address",
+	0x03: "illegal data value",
+	0x04: "server device failure",
+	0x05: "acknowledge",
+	0x06: "server device busy",
+	0x08: "memory parity error",
+	0x0A: "gateway path unavailable",
+	0x0B: "gateway target device failed to respond",
+}
+
+// ExceptionError is returned when the server responds with a modbus exception
+type ExceptionError struct {
+	Function byte
+	Code     byte
+}
+
+func (e *ExceptionError) Error() string {
+	msg, ok := exceptionMessages[e.Code]
+	if !ok {
+		msg = "unknown exception"
+	}
+	return fmt.Sprintf("modbus exception %d (%s) for function %d", e.Code, msg, e.Function)
+}
+
+// client is a minimal modbus TCP client. It is safe for concurrent use, requests are serialized.
+type client struct {
+	sync.Mutex
+	address string
+	unitId  byte
+	timeout time.Duration
+	conn    net.Conn
+	txId    uint16
+}
+
+func newClient(server string, unitId byte, timeout time.Duration) (*client, error) {
+	addr, err := parseServer(server)
+	if err != nil {
+		return nil, err
+	}
+	return &client{address: addr, unitId: unitId, timeout: timeout}, nil
+}
+
+// parseServer accepts address in the form of tcp://host:port or host:port
+func parseServer(server string) (string, error) {
+	addr := server
+	if strings.Contains(server, "://") {
+		parts := strings.SplitN(server, "://", 2)
+		if parts[0] != "tcp" {
+			return "", fmt.Errorf("unsupported modbus scheme %s, only tcp is supported", parts[0])
+		}
+		addr = parts[1]
+	}
+	if addr == "" {
+		return "", fmt.Errorf("modbus server address is required")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return "", fmt.Errorf("invalid modbus server address %s: %v", server, err)
+	}
+	return addr, nil
+}
+
+func (c *client) connect() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.connectLocked()
+}
+
+func (c *client) connectLocked() error {
+	if c.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	return nil
+}
+
+func (c *client) connected() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.conn != nil
+}
+
+func (c *client) close() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.closeLocked()
+}
+
+func (c *client) closeLocked() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// send sends the pdu and returns the response pdu data without the function code
+func (c *client) send(function byte, data []byte) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.connectLocked(); err != nil {
+		return nil, err
+	}
+	c.txId++
+	adu := make([]byte, mbapHeaderLen+1+len(data))
+	binary.BigEndian.PutUint16(adu[0:], c.txId)
+	binary.BigEndian.PutUint16(adu[2:], 0)
+	binary.BigEndian.PutUint16(adu[4:], uint16(len(data)+2))
+	adu[6] = c.unitId
+	adu[7] = function
+	copy(adu[8:], data)
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(adu); err != nil {
+		_ = c.closeLocked()
+		return nil, err
+	}
+	for {
+		header := make([]byte, mbapHeaderLen)
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			_ = c.closeLocked()
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if length < 2 || length+6 > maxAduLength {
+			_ = c.closeLocked()
+			return nil, fmt.Errorf("invalid modbus response length %d", length)
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(c.conn, pdu); err != nil {
+			_ = c.closeLocked()
+			return nil, err
+		}
+		// Drop the stale responses of the timeout requests
+		if binary.BigEndian.Uint16(header[0:]) != c.txId {
+			continue
+		}
+		if pdu[0] == function|0x80 {
+			if len(pdu) < 2 {
+				return nil, fmt.Errorf("invalid modbus exception response")
+			}
+			return nil, &ExceptionError{Function: function, Code: pdu[1]}
+		}
+		if pdu[0] != function {
+			return nil, fmt.Errorf("modbus response function %d does not match request %d", pdu[0], function)
+		}
+		return pdu[1:], nil
+	}
+}
+
+// readBits reads coils or discrete inputs
+func (c *client) readBits(function byte, address, quantity uint16) ([]bool, error) {
+	if quantity < 1 || quantity > maxBitQuantity {
+		return nil, fmt.Errorf("quantity %d must be between 1 and %d", quantity, maxBitQuantity)
+	}
+	resp, err := c.send(function, pack(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	count := int(quantity+7) / 8
+	if len(resp) < 1 || int(resp[0]) != count || len(resp)-1 != count {
+		return nil, fmt.Errorf("invalid modbus response size, expect %d bytes", count)
+	}
+	result := make([]bool, quantity)
+	for i := range result {
+		result[i] = resp[1+i/8]&(1<<(uint(i)%8)) != 0
+	}
+	return result, nil
+}
+
+// readRegisters reads holding or input registers and returns the raw big endian bytes
+func (c *client) readRegisters(function byte, address, quantity uint16) ([]byte, error) {
+	if quantity < 1 || quantity > maxRegisterQuantity {
+		return nil, fmt.Errorf("quantity %d must be between 1 and %d", quantity, maxRegisterQuantity)
+	}
+	resp, err := c.send(function, pack(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	count := int(quantity) * 2
+	if len(resp) < 1 || int(resp[0]) != count || len(resp)-1 != count {
+		return nil, fmt.Errorf("invalid modbus response size, expect %d bytes", count)
+	}
+	return resp[1:], nil
+}
+
+func (c *client) writeSingleCoil(address uint16, value bool) error {
+	var v uint16
+	if value {
+		v = 0xFF00
+	}
+	_, err := c.send(fcWriteSingleCoil, pack(address, v))
+	return err
+}
+
+func (c *client) writeRegisters(address uint16, value []byte) error {
+	if len(value) == 2 {
+		_, err := c.send(fcWriteSingleRegister, append(pack(address), value...))
+		return err
+	}
+	quantity := len(value) / 2
+	data := pack(address, uint16(quantity))
+	data = append(data, byte(len(value)))
+	data = append(data, value...)
+	_, err := c.send(fcWriteMultipleRegisters, data)
+	return err
+}
+
+func pack(values ...uint16) []byte {
+	result := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(result[2*i:], v)
+	}
+	return result
+}
@@ -0,0 +1,275 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+)
+
+// Register areas
+const (
+	AreaCoil     = "coil"
+	AreaDiscrete = "discrete"
+	AreaInput    = "input"
+	AreaHolding  = "holding"
+)
+
+// RegisterMapping maps a modbus address to a named field
+type RegisterMapping struct {
+	Name    string `json:"name"`
+	Area    string `json:"area"`
+	Address uint16 `json:"address"`
+	// Type is the data type of the value. bool for coils and discrete inputs.
+	// int16, uint16, int32, uint32, float32, int64, uint64, float64 for registers.
+	Type string `json:"type"`
+	// ByteOrder is the order of the bytes for the value, ABCD (big endian), DCBA (little endian),
+	// BADC (big endian byte swap) or CDAB (little endian byte swap)
+	ByteOrder string `json:"byteOrder"`
+	// Scale multiplies the raw value when reading and divides the value when writing
+	Scale float64 `json:"scale"`
+
+	swapBytes bool
+	swapWords bool
+}
+
+// quantity returns the number of coils or registers occupied by the value
+func (r *RegisterMapping) quantity() uint16 {
+	switch r.Type {
+	case "int32", "uint32", "float32":
+		return 2
+	case "int64", "uint64", "float64":
+		return 4
+	default:
+		return 1
+	}
+}
+
+func (r *RegisterMapping) isBit() bool {
+	return r.Area == AreaCoil || r.Area == AreaDiscrete
+}
+
+func (r *RegisterMapping) writable() bool {
+	return r.Area == AreaCoil || r.Area == AreaHolding
+}
+
+func (r *RegisterMapping) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("register name is required")
+	}
+	r.Area = strings.ToLower(r.Area)
+	switch r.Area {
+	case AreaCoil, AreaDiscrete:
+		if r.Type == "" {
+			r.Type = "bool"
+		}
+		if r.Type != "bool" {
+			return fmt.Errorf("register %s: %s area only supports bool type", r.Name, r.Area)
+		}
+	case AreaInput, AreaHolding:
+		if r.Type == "" {
+			r.Type = "uint16"
+		}
+		switch r.Type {
+		case "int16", "uint16", "int32", "uint32", "float32", "int64", "uint64", "float64":
+		default:
+			return fmt.Errorf("register %s: unsupported type %s", r.Name, r.Type)
+		}
+	default:
+		return fmt.Errorf("register %s: unsupported area %s, must be one of coil, discrete, input or holding", r.Name, r.Area)
+	}
+	switch strings.ToUpper(r.ByteOrder) {
+	case "", "ABCD":
+	case "DCBA":
+		r.swapBytes, r.swapWords = true, true
+	case "BADC":
+		r.swapBytes = true
+	case "CDAB":
+		r.swapWords = true
+	default:
+		return fmt.Errorf("register %s: unsupported byte order %s", r.Name, r.ByteOrder)
+	}
+	if int(r.Address)+int(r.quantity()) > math.MaxUint16+1 {
+		return fmt.Errorf("register %s: address %d out of range", r.Name, r.Address)
+	}
+	return nil
+}
+
+// reorder converts between the device byte order and big endian. It is its own inverse.
+func (r *RegisterMapping) reorder(raw []byte) []byte {
+	result := make([]byte, len(raw))
+	copy(result, raw)
+	if r.swapWords {
+		words := len(result) / 2
+		for i := 0; i < words/2; i++ {
+			j := words - 1 - i
+			result[2*i], result[2*j] = result[2*j], result[2*i]
+			result[2*i+1], result[2*j+1] = result[2*j+1], result[2*i+1]
+		}
+	}
+	if r.swapBytes {
+		for i := 0; i+1 < len(result); i += 2 {
+			result[i], result[i+1] = result[i+1], result[i]
+		}
+	}
+	return result
+}
+
+// decode converts the raw register bytes in wire order to the field value
+func (r *RegisterMapping) decode(raw []byte) any {
+	b := r.reorder(raw)
+	var v any
+	switch r.Type {
+	case "int16":
+		v = int64(int16(binary.BigEndian.Uint16(b)))
+	case "uint16":
+		v = int64(binary.BigEndian.Uint16(b))
+	case "int32":
+		v = int64(int32(binary.BigEndian.Uint32(b)))
+	case "uint32":
+		v = int64(binary.BigEndian.Uint32(b))
+	case "float32":
+		v = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case "int64":
+		v = int64(binary.BigEndian.Uint64(b))
+	case "uint64":
+		v = binary.BigEndian.Uint64(b)
+	case "float64":
+		v = math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	if r.Scale == 0 || r.Scale == 1 {
+		return v
+	}
+	f, _ := cast.ToFloat64(v, cast.CONVERT_ALL)
+	return f * r.Scale
+}
+
+// encode converts the field value to the raw register bytes in wire order
+func (r *RegisterMapping) encode(value any) ([]byte, error) {
+	if r.Scale != 0 && r.Scale != 1 {
+		f, err := cast.ToFloat64(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		f = f / r.Scale
+		if r.Type != "float32" && r.Type != "float64" {
+			f = math.Round(f)
+		}
+		value = f
+	}
+	b := make([]byte, 2*r.quantity())
+	switch r.Type {
+	case "int16":
+		v, err := cast.ToInt16(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint16(b, uint16(v))
+	case "uint16":
+		v, err := cast.ToUint16(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint16(b, v)
+	case "int32":
+		v, err := cast.ToInt32(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint32(b, uint32(v))
+	case "uint32":
+		v, err := cast.ToUint32(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint32(b, v)
+	case "float32":
+		v, err := cast.ToFloat32(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint32(b, math.Float32bits(v))
+	case "int64":
+		v, err := cast.ToInt64(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint64(b, uint64(v))
+	case "uint64":
+		v, err := cast.ToUint64(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint64(b, v)
+	case "float64":
+		v, err := cast.ToFloat64(value, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %v", r.Name, err)
+		}
+		binary.BigEndian.PutUint64(b, math.Float64bits(v))
+	default:
+		return nil, fmt.Errorf("register %s: unsupported type %s", r.Name, r.Type)
+	}
+	return r.reorder(b), nil
+}
+
+// readBlock is a range of contiguous addresses in one area which can be read in one request
+type readBlock struct {
+	area     string
+	start    uint16
+	quantity uint16
+	items    []*RegisterMapping
+}
+
+// planReads merges the mappings of contiguous or overlapped addresses into as few requests as possible
+func planReads(mappings []*RegisterMapping) []*readBlock {
+	byArea := make(map[string][]*RegisterMapping)
+	for _, m := range mappings {
+		byArea[m.Area] = append(byArea[m.Area], m)
+	}
+	var blocks []*readBlock
+	for _, area := range []string{AreaCoil, AreaDiscrete, AreaInput, AreaHolding} {
+		ms := byArea[area]
+		if len(ms) == 0 {
+			continue
+		}
+		sort.SliceStable(ms, func(i, j int) bool {
+			return ms[i].Address < ms[j].Address
+		})
+		limit := uint16(maxRegisterQuantity)
+		if area == AreaCoil || area == AreaDiscrete {
+			limit = maxBitQuantity
+		}
+		var cur *readBlock
+		for _, m := range ms {
+			end := int(m.Address) + int(m.quantity())
+			if cur != nil && int(m.Address) <= int(cur.start)+int(cur.quantity) && end-int(cur.start) <= int(limit) {
+				if end > int(cur.start)+int(cur.quantity) {
+					cur.quantity = uint16(end - int(cur.start))
+				}
+				cur.items = append(cur.items, m)
+				continue
+			}
+			cur = &readBlock{area: area, start: m.Address, quantity: m.quantity(), items: []*RegisterMapping{m}}
+			blocks = append(blocks, cur)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterCodec(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     *RegisterMapping
+		raw   []byte
+		value any
+	}{
+		{
+			name:  "int16",
+			m:     &RegisterMapping{Name: "a", Area: "holding", Type: "int16"},
+			raw:   []byte{0xFF, 0xFE},
+			value: int64(-2),
+		},
+		{
+			name:  "uint16 little endian",
+			m:     &RegisterMapping{Name: "a", Area: "holding", Type: "uint16", ByteOrder: "DCBA"},
+			raw:   []byte{0x01, 0x02},
+			value: int64(0x0201),
+		},
+		{
+			name:  "uint32 ABCD",
+			m:     &RegisterMapping{Name: "a", Area: "input", Type: "uint32", ByteOrder: "ABCD"},
+			raw:   []byte{0x01, 0x02, 0x03, 0x04},
+			value: int64(0x01020304),
+		},
+		{
+			name:  "uint32 DCBA",
+			m:     &RegisterMapping{Name: "a", Area: "input", Type: "uint32", ByteOrder: "dcba"},
+			raw:   []byte{0x04, 0x03, 0x02, 0x01},
+			value: int64(0x01020304),
+		},
+		{
+			name:  "uint32 BADC",
+			m:     &RegisterMapping{Name: "a", Area: "input", Type: "uint32", ByteOrder: "BADC"},
+			raw:   []byte{0x02, 0x01, 0x04, 0x03},
+			value: int64(0x01020304),
+		},
+		{
+			name:  "uint32 CDAB",
+			m:     &RegisterMapping{Name: "a", Area: "input", Type: "uint32", ByteOrder: "CDAB"},
+			raw:   []byte{0x03, 0x04, 0x01, 0x02},
+			value: int64(0x01020304),
+		},
+		{
+			name:  "float32",
+			m:     &RegisterMapping{Name: "a", Area: "holding", Type: "float32"},
+			raw:   []byte{0x41, 0xC8, 0x00, 0x00},
+			value: float64(25),
+		},
+		{
+			name:  "float64 CDAB",
+			m:     &RegisterMapping{Name: "a", Area: "holding", Type: "float64", ByteOrder: "CDAB"},
+			raw:   []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x39},
+			value: float64(25),
+		},
+		{
+			name:  "int16 scale",
+			m:     &RegisterMapping{Name: "a", Area: "holding", Type: "int16", Scale: 0.1},
+			raw:   []byte{0x00, 0xFA},
+			value: float64(25),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.m.validate())
+			require.Equal(t, len(tt.raw), int(tt.m.quantity())*2)
+			v := tt.m.decode(tt.raw)
+			require.InDelta(t, tt.value, v, 1e-9)
+			raw, err := tt.m.encode(v)
+			require.NoError(t, err)
+			require.Equal(t, tt.raw, raw)
+		})
+	}
+}
+
+func TestRegisterValidate(t *testing.T) {
+	tests := []struct {
+		m   *RegisterMapping
+		err string
+	}{
+		{
+			m:   &RegisterMapping{Area: "holding"},
+			err: "register name is required",
+		},
+		{
+			m:   &RegisterMapping{Name: "a", Area: "memory"},
+			err: "register a: unsupported area memory, must be one of coil, discrete, input or holding",
+		},
+		{
+			m:   &RegisterMapping{Name: "a", Area: "coil", Type: "int16"},
+			err: "register a: coil area only supports bool type",
+		},
+		{
+			m:   &RegisterMapping{Name: "a", Area: "input", Type: "string"},
+			err: "register a: unsupported type string",
+		},
+		{
+			m:   &RegisterMapping{Name: "a", Area: "input", ByteOrder: "ABDC"},
+			err: "register a: unsupported byte order ABDC",
+		},
+		{
+			m:   &RegisterMapping{Name: "a", Area: "input", Type: "float64", Address: 65534},
+			err: "register a: address 65534 out of range",
+		},
+	}
+	for _, tt := range tests {
+		require.EqualError(t, tt.m.validate(), tt.err)
+	}
+}
+
+func TestPlanReads(t *testing.T) {
+	mappings := []*RegisterMapping{
+		{Name: "h3", Area: AreaHolding, Address: 10, Type: "float32"},
+		{Name: "h1", Area: AreaHolding, Address: 0, Type: "uint16"},
+		{Name: "h2", Area: AreaHolding, Address: 1, Type: "uint32"},
+		{Name: "h4", Area: AreaHolding, Address: 11, Type: "uint16"},
+		{Name: "c1", Area: AreaCoil, Address: 5, Type: "bool"},
+		{Name: "c2", Area: AreaCoil, Address: 6, Type: "bool"},
+		{Name: "i1", Area: AreaInput, Address: 0, Type: "uint16"},
+		{Name: "h5", Area: AreaHolding, Address: 200, Type: "uint16"},
+	}
+	blocks := planReads(mappings)
+	require.Len(t, blocks, 5)
+	exp := []struct {
+		area     string
+		start    uint16
+		quantity uint16
+		items    int
+	}{
+		{AreaCoil, 5, 2, 2},
+		{AreaInput, 0, 1, 1},
+		{AreaHolding, 0, 3, 2},
+		{AreaHolding, 10, 2, 2},
+		{AreaHolding, 200, 1, 1},
+	}
+	for i, e := range exp {
+		require.Equal(t, e.area, blocks[i].area)
+		require.Equal(t, e.start, blocks[i].start)
+		require.Equal(t, e.quantity, blocks[i].quantity)
+		require.Len(t, blocks[i].items, e.items)
+	}
+}
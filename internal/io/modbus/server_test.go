@@ -0,0 +1,149 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// simServer is a modbus TCP simulator holding all four areas in memory
+type simServer struct {
+	sync.Mutex
+	ln       net.Listener
+	coils    []bool
+	discrete []bool
+	input    []uint16
+	holding  []uint16
+	requests int
+}
+
+func newSimServer() (*simServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &simServer{
+		ln:       ln,
+		coils:    make([]bool, 100),
+		discrete: make([]bool, 100),
+		input:    make([]uint16, 100),
+		holding:  make([]uint16, 100),
+	}
+	go s.serve()
+	return s, nil
+}
+
+func (s *simServer) addr() string {
+	return "tcp://" + s.ln.Addr().String()
+}
+
+func (s *simServer) close() {
+	_ = s.ln.Close()
+}
+
+func (s *simServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *simServer) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		pdu := make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+		resp := s.process(pdu)
+		binary.BigEndian.PutUint16(header[4:], uint16(len(resp)+1))
+		if _, err := conn.Write(append(header, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *simServer) process(pdu []byte) []byte {
+	s.Lock()
+	defer s.Unlock()
+	s.requests++
+	fc := pdu[0]
+	addr := int(binary.BigEndian.Uint16(pdu[1:]))
+	val := int(binary.BigEndian.Uint16(pdu[3:]))
+	exception := []byte{fc | 0x80, 0x02}
+	switch fc {
+	case fcReadCoils, fcReadDiscreteInputs:
+		bank := s.coils
+		if fc == fcReadDiscreteInputs {
+			bank = s.discrete
+		}
+		if addr+val > len(bank) {
+			return exception
+		}
+		resp := make([]byte, 2+(val+7)/8)
+		resp[0], resp[1] = fc, byte((val+7)/8)
+		for i := 0; i < val; i++ {
+			if bank[addr+i] {
+				resp[2+i/8] |= 1 << (uint(i) % 8)
+			}
+		}
+		return resp
+	case fcReadHoldingRegisters, fcReadInputRegisters:
+		bank := s.holding
+		if fc == fcReadInputRegisters {
+			bank = s.input
+		}
+		if addr+val > len(bank) {
+			return exception
+		}
+		resp := []byte{fc, byte(val * 2)}
+		for i := 0; i < val; i++ {
+			resp = binary.BigEndian.AppendUint16(resp, bank[addr+i])
+		}
+		return resp
+	case fcWriteSingleCoil:
+		if addr >= len(s.coils) {
+			return exception
+		}
+		s.coils[addr] = val == 0xFF00
+		return pdu
+	case fcWriteSingleRegister:
+		if addr >= len(s.holding) {
+			return exception
+		}
+		s.holding[addr] = uint16(val)
+		return pdu
+	case fcWriteMultipleRegisters:
+		if addr+val > len(s.holding) {
+			return exception
+		}
+		for i := 0; i < val; i++ {
+			s.holding[addr+i] = binary.BigEndian.Uint16(pdu[6+2*i:])
+		}
+		return pdu[:5]
+	default:
+		return []byte{fc | 0x80, 0x01}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+// Sink writes the fields of the result to the mapped coils or holding registers as set points.
+// Fields which are not mapped or not in the result are ignored.
+type Sink struct {
+	conf   *conf
+	cli    *client
+	sch    api.StatusChangeHandler
+	status string
+}
+
+func (s *Sink) Provision(_ api.StreamContext, configs map[string]any) error {
+	c, err := parseConf(configs)
+	if err != nil {
+		return err
+	}
+	for _, r := range c.Registers {
+		if !r.writable() {
+			return fmt.Errorf("register %s: %s area is read only", r.Name, r.Area)
+		}
+	}
+	cli, err := newClient(c.Server, byte(c.UnitId), time.Duration(c.Timeout))
+	if err != nil {
+		return err
+	}
+	s.conf = c
+	s.cli = cli
+	return nil
+}
+
+func (s *Sink) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	s.sch = sch
+	ctx.GetLogger().Infof("Connecting to modbus server %s", s.conf.Server)
+	err := s.cli.connect()
+	s.status = reportStatus(s.cli, s.sch, s.status, err)
+	return err
+}
+
+func (s *Sink) Collect(ctx api.StreamContext, item api.MessageTuple) error {
+	return s.write(ctx, item.ToMap())
+}
+
+func (s *Sink) CollectList(ctx api.StreamContext, items api.MessageTupleList) error {
+	var err error
+	items.RangeOfTuples(func(_ int, tuple api.MessageTuple) bool {
+		err = s.write(ctx, tuple.ToMap())
+		return err == nil
+	})
+	return err
+}
+
+func (s *Sink) write(ctx api.StreamContext, data map[string]any) error {
+	for _, r := range s.conf.Registers {
+		v, ok := data[r.Name]
+		if !ok || v == nil {
+			continue
+		}
+		var err error
+		if r.isBit() {
+			var b bool
+			b, err = cast.ToBool(v, cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("register %s: %v", r.Name, err)
+			}
+			err = s.cli.writeSingleCoil(r.Address, b)
+		} else {
+			var raw []byte
+			raw, err = r.encode(v)
+			if err != nil {
+				return err
+			}
+			err = s.cli.writeRegisters(r.Address, raw)
+		}
+		if err != nil {
+			if _, ok := err.(*ExceptionError); ok {
+				return fmt.Errorf("write %s to address %d failed: %v", r.Name, r.Address, err)
+			}
+			s.status = reportStatus(s.cli, s.sch, s.status, err)
+			return errorx.NewIOErr(fmt.Sprintf("write %s to address %d failed: %v", r.Name, r.Address, err))
+		}
+		ctx.GetLogger().Debugf("write %v to %s %d success", v, r.Area, r.Address)
+	}
+	s.status = reportStatus(s.cli, s.sch, s.status, nil)
+	return nil
+}
+
+func (s *Sink) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing modbus sink")
+	if s.cli != nil {
+		return s.cli.close()
+	}
+	return nil
+}
+
+func GetSink() api.Sink {
+	return &Sink{}
+}
+
+var _ api.TupleCollector = &Sink{}
@@ -0,0 +1,71 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+func TestSinkProvision(t *testing.T) {
+	s := GetSink()
+	err := s.Provision(mockContext.NewMockContext("testSink", "op"), map[string]any{
+		"server":    "127.0.0.1:502",
+		"registers": []map[string]any{{"name": "a", "area": "input"}},
+	})
+	require.EqualError(t, err, "register a: input area is read only")
+}
+
+func TestSinkCollect(t *testing.T) {
+	server, err := newSimServer()
+	require.NoError(t, err)
+	defer server.close()
+	props := map[string]any{
+		"server": server.addr(),
+		"registers": []map[string]any{
+			{"name": "setpoint", "area": "holding", "address": 0, "type": "int16", "scale": 0.1},
+			{"name": "speed", "area": "holding", "address": 1, "type": "float32", "byteOrder": "CDAB"},
+			{"name": "enable", "area": "coil", "address": 2},
+		},
+	}
+	ctx := mockContext.NewMockContext("testSink", "op")
+	s := GetSink().(*Sink)
+	require.NoError(t, s.Provision(ctx, props))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	require.NoError(t, s.Collect(ctx, &xsql.Tuple{Message: map[string]any{
+		"setpoint": 22.5,
+		"speed":    float64(25),
+		"enable":   true,
+		"other":    1,
+	}}))
+	require.Equal(t, uint16(225), server.holding[0])
+	require.Equal(t, []uint16{0x0000, 0x41C8}, server.holding[1:3])
+	require.True(t, server.coils[2])
+
+	require.NoError(t, s.CollectList(ctx, &xsql.WindowTuples{Content: []xsql.Row{
+		&xsql.Tuple{Message: map[string]any{"enable": false}},
+		&xsql.Tuple{Message: map[string]any{"setpoint": -1}},
+	}}))
+	require.False(t, server.coils[2])
+	require.Equal(t, uint16(0xFFF6), server.holding[0])
+
+	err = s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"setpoint": "abc"}})
+	require.Error(t, err)
+	require.NoError(t, s.Close(ctx))
+}
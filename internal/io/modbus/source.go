@@ -0,0 +1,175 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+)
+
+type conf struct {
+	Server    string             `json:"server"`
+	UnitId    int                `json:"unitId"`
+	Timeout   cast.DurationConf  `json:"timeout"`
+	Registers []*RegisterMapping `json:"registers"`
+}
+
+func parseConf(props map[string]any) (*conf, error) {
+	c := &conf{UnitId: 1, Timeout: cast.DurationConf(5 * time.Second)}
+	if err := cast.MapToStruct(props, c); err != nil {
+		return nil, fmt.Errorf("read properties %v fail with error: %v", props, err)
+	}
+	if c.Server == "" {
+		return nil, fmt.Errorf("property server is required")
+	}
+	if c.UnitId < 0 || c.UnitId > 255 {
+		return nil, fmt.Errorf("unitId should be in range 0-255")
+	}
+	if len(c.Registers) == 0 {
+		return nil, fmt.Errorf("property registers is required")
+	}
+	names := make(map[string]struct{}, len(c.Registers))
+	for _, r := range c.Registers {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("duplicate register name %s", r.Name)
+		}
+		names[r.Name] = struct{}{}
+	}
+	return c, nil
+}
+
+// Source polls the configured registers in an interval and decodes them into one row
+type Source struct {
+	conf   *conf
+	cli    *client
+	blocks []*readBlock
+	sch    api.StatusChangeHandler
+	status string
+}
+
+func (s *Source) Provision(ctx api.StreamContext, configs map[string]any) error {
+	c, err := parseConf(configs)
+	if err != nil {
+		return err
+	}
+	cli, err := newClient(c.Server, byte(c.UnitId), time.Duration(c.Timeout))
+	if err != nil {
+		return err
+	}
+	s.conf = c
+	s.cli = cli
+	s.blocks = planReads(c.Registers)
+	return nil
+}
+
+func (s *Source) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	s.sch = sch
+	ctx.GetLogger().Infof("Connecting to modbus server %s", s.conf.Server)
+	err := s.cli.connect()
+	s.updateStatus(err)
+	return err
+}
+
+func (s *Source) Pull(ctx api.StreamContext, trigger time.Time, ingest api.TupleIngest, ingestError api.ErrorIngest) {
+	if !s.cli.connected() {
+		err := s.cli.connect()
+		s.updateStatus(err)
+		if err != nil {
+			ingestError(ctx, fmt.Errorf("reconnect to modbus server %s failed: %v", s.conf.Server, err))
+			return
+		}
+	}
+	result := make(map[string]any, len(s.conf.Registers))
+	for _, b := range s.blocks {
+		if err := s.readBlock(b, result); err != nil {
+			// Exception response means the server is still reachable
+			if _, ok := err.(*ExceptionError); !ok {
+				s.updateStatus(err)
+			}
+			ingestError(ctx, fmt.Errorf("read %s from address %d failed: %v", b.area, b.start, err))
+			return
+		}
+	}
+	ingest(ctx, result, nil, trigger)
+}
+
+func (s *Source) readBlock(b *readBlock, result map[string]any) error {
+	switch b.area {
+	case AreaCoil, AreaDiscrete:
+		fc := fcReadCoils
+		if b.area == AreaDiscrete {
+			fc = fcReadDiscreteInputs
+		}
+		bits, err := s.cli.readBits(fc, b.start, b.quantity)
+		if err != nil {
+			return err
+		}
+		for _, m := range b.items {
+			result[m.Name] = bits[m.Address-b.start]
+		}
+	default:
+		fc := fcReadHoldingRegisters
+		if b.area == AreaInput {
+			fc = fcReadInputRegisters
+		}
+		raw, err := s.cli.readRegisters(fc, b.start, b.quantity)
+		if err != nil {
+			return err
+		}
+		for _, m := range b.items {
+			offset := int(m.Address-b.start) * 2
+			result[m.Name] = m.decode(raw[offset : offset+int(m.quantity())*2])
+		}
+	}
+	return nil
+}
+
+// updateStatus closes the broken connection and reports the connection status only when it changes
+func (s *Source) updateStatus(err error) {
+	s.status = reportStatus(s.cli, s.sch, s.status, err)
+}
+
+func reportStatus(cli *client, sch api.StatusChangeHandler, last string, err error) string {
+	status, msg := api.ConnectionConnected, ""
+	if err != nil {
+		_ = cli.close()
+		status, msg = api.ConnectionDisconnected, err.Error()
+	}
+	if status != last && sch != nil {
+		sch(status, msg)
+	}
+	return status
+}
+
+func (s *Source) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing modbus source")
+	if s.cli != nil {
+		return s.cli.close()
+	}
+	return nil
+}
+
+func GetSource() api.Source {
+	return &Source{}
+}
+
+var _ api.PullTupleSource = &Source{}
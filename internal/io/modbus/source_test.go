@@ -0,0 +1,162 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+func TestSourceProvision(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]any
+		err   string
+	}{
+		{
+			name:  "no server",
+			props: map[string]any{},
+			err:   "property server is required",
+		},
+		{
+			name: "invalid scheme",
+			props: map[string]any{
+				"server":    "udp://127.0.0.1:502",
+				"registers": []map[string]any{{"name": "a", "area": "holding"}},
+			},
+			err: "unsupported modbus scheme udp, only tcp is supported",
+		},
+		{
+			name: "no registers",
+			props: map[string]any{
+				"server": "127.0.0.1:502",
+			},
+			err: "property registers is required",
+		},
+		{
+			name: "invalid unit",
+			props: map[string]any{
+				"server":    "127.0.0.1:502",
+				"unitId":    300,
+				"registers": []map[string]any{{"name": "a", "area": "holding"}},
+			},
+			err: "unitId should be in range 0-255",
+		},
+		{
+			name: "duplicate",
+			props: map[string]any{
+				"server":    "127.0.0.1:502",
+				"registers": []map[string]any{{"name": "a", "area": "holding"}, {"name": "a", "area": "input"}},
+			},
+			err: "duplicate register name a",
+		},
+	}
+	ctx := mockContext.NewMockContext("testProvision", "op")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := GetSource()
+			require.EqualError(t, s.Provision(ctx, tt.props), tt.err)
+		})
+	}
+}
+
+func TestSourcePull(t *testing.T) {
+	server, err := newSimServer()
+	require.NoError(t, err)
+	server.holding[0] = 250
+	server.holding[1] = 0x41C8
+	server.holding[2] = 0x0000
+	server.input[3] = 0xFFFF
+	server.coils[4] = true
+	server.discrete[7] = true
+
+	props := map[string]any{
+		"server": server.addr(),
+		"registers": []map[string]any{
+			{"name": "temperature", "area": "holding", "address": 0, "type": "int16", "scale": 0.1},
+			{"name": "setpoint", "area": "holding", "address": 1, "type": "float32"},
+			{"name": "counter", "area": "input", "address": 3, "type": "int16"},
+			{"name": "running", "area": "coil", "address": 4},
+			{"name": "alarm", "area": "discrete", "address": 7},
+			{"name": "idle", "area": "discrete", "address": 8},
+		},
+	}
+	ctx := mockContext.NewMockContext("testPull", "op")
+	s := GetSource().(*Source)
+	require.NoError(t, s.Provision(ctx, props))
+	var statuses []string
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {
+		statuses = append(statuses, status)
+	}))
+	recv := make(chan any, 10)
+	ingest := func(ctx api.StreamContext, data any, meta map[string]any, ts time.Time) {
+		recv <- data
+	}
+	ingestErr := func(ctx api.StreamContext, err error) {
+		recv <- err
+	}
+	s.Pull(ctx, time.Now(), ingest, ingestErr)
+	require.Equal(t, map[string]any{
+		"temperature": float64(25),
+		"setpoint":    float64(25),
+		"counter":     int64(-1),
+		"running":     true,
+		"alarm":       true,
+		"idle":        false,
+	}, <-recv)
+	// one request per area
+	require.Equal(t, 4, server.requests)
+
+	// server down
+	server.close()
+	_ = s.cli.close()
+	s.Pull(ctx, time.Now(), ingest, ingestErr)
+	require.Error(t, (<-recv).(error))
+	s.Pull(ctx, time.Now(), ingest, ingestErr)
+	require.Error(t, (<-recv).(error))
+	require.Equal(t, []string{api.ConnectionConnected, api.ConnectionDisconnected}, statuses)
+	require.NoError(t, s.Close(ctx))
+}
+
+func TestSourceException(t *testing.T) {
+	server, err := newSimServer()
+	require.NoError(t, err)
+	defer server.close()
+	props := map[string]any{
+		"server": server.addr(),
+		"registers": []map[string]any{
+			{"name": "a", "area": "holding", "address": 99, "type": "uint32"},
+		},
+	}
+	ctx := mockContext.NewMockContext("testException", "op")
+	s := GetSource().(*Source)
+	require.NoError(t, s.Provision(ctx, props))
+	var statuses []string
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {
+		statuses = append(statuses, status)
+	}))
+	var e error
+	s.Pull(ctx, time.Now(), func(ctx api.StreamContext, data any, meta map[string]any, ts time.Time) {}, func(ctx api.StreamContext, err error) {
+		e = err
+	})
+	require.EqualError(t, e, "read holding from address 99 failed: modbus exception 2 (illegal data address) for function 3")
+	require.Equal(t, []string{api.ConnectionConnected}, statuses)
+	require.NoError(t, s.Close(ctx))
+}
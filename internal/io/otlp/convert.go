@@ -0,0 +1,269 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"encoding/hex"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// batch is the flattened rows of a scope which share the same meta
+type batch struct {
+	rows []map[string]any
+	meta map[string]any
+}
+
+// flattenMetrics converts each data point into a row. The resource and scope are set in the meta.
+func flattenMetrics(rms []*metricspb.ResourceMetrics) []batch {
+	var result []batch
+	for _, rm := range rms {
+		for _, sm := range rm.GetScopeMetrics() {
+			b := batch{meta: buildMeta(rm.GetResource(), rm.GetSchemaUrl(), sm.GetScope(), sm.GetSchemaUrl())}
+			for _, m := range sm.GetMetrics() {
+				b.rows = append(b.rows, flattenMetric(m)...)
+			}
+			if len(b.rows) > 0 {
+				result = append(result, b)
+			}
+		}
+	}
+	return result
+}
+
+func flattenMetric(m *metricspb.Metric) []map[string]any {
+	var rows []map[string]any
+	newRow := func(typ string, attrs []*commonpb.KeyValue, start, ts uint64) map[string]any {
+		r := map[string]any{
+			"name":       m.GetName(),
+			"type":       typ,
+			"attributes": convertAttributes(attrs),
+		}
+		if m.GetDescription() != "" {
+			r["description"] = m.GetDescription()
+		}
+		if m.GetUnit() != "" {
+			r["unit"] = m.GetUnit()
+		}
+		setTime(r, "startTimestamp", start)
+		setTime(r, "timestamp", ts)
+		return r
+	}
+	switch data := m.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		for _, dp := range data.Gauge.GetDataPoints() {
+			r := newRow("gauge", dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano())
+			setNumber(r, dp)
+			rows = append(rows, r)
+		}
+	case *metricspb.Metric_Sum:
+		for _, dp := range data.Sum.GetDataPoints() {
+			r := newRow("sum", dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano())
+			setNumber(r, dp)
+			r["isMonotonic"] = data.Sum.GetIsMonotonic()
+			r["aggregationTemporality"] = temporality(data.Sum.GetAggregationTemporality())
+			rows = append(rows, r)
+		}
+	case *metricspb.Metric_Histogram:
+		for _, dp := range data.Histogram.GetDataPoints() {
+			r := newRow("histogram", dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano())
+			r["count"] = int64(dp.GetCount())
+			if dp.Sum != nil {
+				r["sum"] = dp.GetSum()
+			}
+			if dp.Min != nil {
+				r["min"] = dp.GetMin()
+			}
+			if dp.Max != nil {
+				r["max"] = dp.GetMax()
+			}
+			r["bucketCounts"] = convertCounts(dp.GetBucketCounts())
+			bounds := make([]any, len(dp.GetExplicitBounds()))
+			for i, b := range dp.GetExplicitBounds() {
+				bounds[i] = b
+			}
+			r["explicitBounds"] = bounds
+			r["aggregationTemporality"] = temporality(data.Histogram.GetAggregationTemporality())
+			rows = append(rows, r)
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		for _, dp := range data.ExponentialHistogram.GetDataPoints() {
+			r := newRow("exponentialHistogram", dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano())
+			r["count"] = int64(dp.GetCount())
+			if dp.Sum != nil {
+				r["sum"] = dp.GetSum()
+			}
+			if dp.Min != nil {
+				r["min"] = dp.GetMin()
+			}
+			if dp.Max != nil {
+				r["max"] = dp.GetMax()
+			}
+			r["scale"] = int64(dp.GetScale())
+			r["zeroCount"] = int64(dp.GetZeroCount())
+			r["zeroThreshold"] = dp.GetZeroThreshold()
+			r["positive"] = convertBuckets(dp.GetPositive())
+			r["negative"] = convertBuckets(dp.GetNegative())
+			r["aggregationTemporality"] = temporality(data.ExponentialHistogram.GetAggregationTemporality())
+			rows = append(rows, r)
+		}
+	case *metricspb.Metric_Summary:
+		for _, dp := range data.Summary.GetDataPoints() {
+			r := newRow("summary", dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano())
+			r["count"] = int64(dp.GetCount())
+			r["sum"] = dp.GetSum()
+			quantiles := make([]any, len(dp.GetQuantileValues()))
+			for i, q := range dp.GetQuantileValues() {
+				quantiles[i] = map[string]any{
+					"quantile": q.GetQuantile(),
+					"value":    q.GetValue(),
+				}
+			}
+			r["quantiles"] = quantiles
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// flattenLogs converts each log record into a row. The resource and scope are set in the meta.
+func flattenLogs(rls []*logspb.ResourceLogs) []batch {
+	var result []batch
+	for _, rl := range rls {
+		for _, sl := range rl.GetScopeLogs() {
+			b := batch{meta: buildMeta(rl.GetResource(), rl.GetSchemaUrl(), sl.GetScope(), sl.GetSchemaUrl())}
+			for _, lr := range sl.GetLogRecords() {
+				r := map[string]any{
+					"severityNumber": int64(lr.GetSeverityNumber()),
+					"body":           convertValue(lr.GetBody()),
+					"attributes":     convertAttributes(lr.GetAttributes()),
+					"flags":          int64(lr.GetFlags()),
+				}
+				setTime(r, "timestamp", lr.GetTimeUnixNano())
+				setTime(r, "observedTimestamp", lr.GetObservedTimeUnixNano())
+				if lr.GetSeverityText() != "" {
+					r["severityText"] = lr.GetSeverityText()
+				}
+				if len(lr.GetTraceId()) > 0 {
+					r["traceId"] = hex.EncodeToString(lr.GetTraceId())
+				}
+				if len(lr.GetSpanId()) > 0 {
+					r["spanId"] = hex.EncodeToString(lr.GetSpanId())
+				}
+				b.rows = append(b.rows, r)
+			}
+			if len(b.rows) > 0 {
+				result = append(result, b)
+			}
+		}
+	}
+	return result
+}
+
+func buildMeta(res *resourcepb.Resource, resSchemaUrl string, scope *commonpb.InstrumentationScope, scopeSchemaUrl string) map[string]any {
+	meta := map[string]any{
+		"resource": convertAttributes(res.GetAttributes()),
+		"scope": map[string]any{
+			"name":       scope.GetName(),
+			"version":    scope.GetVersion(),
+			"attributes": convertAttributes(scope.GetAttributes()),
+		},
+	}
+	if resSchemaUrl != "" {
+		meta["resourceSchemaUrl"] = resSchemaUrl
+	}
+	if scopeSchemaUrl != "" {
+		meta["scopeSchemaUrl"] = scopeSchemaUrl
+	}
+	return meta
+}
+
+func setNumber(r map[string]any, dp *metricspb.NumberDataPoint) {
+	switch v := dp.GetValue().(type) {
+	case *metricspb.NumberDataPoint_AsDouble:
+		r["value"] = v.AsDouble
+	case *metricspb.NumberDataPoint_AsInt:
+		r["value"] = v.AsInt
+	}
+}
+
+func setTime(r map[string]any, key string, nano uint64) {
+	if nano > 0 {
+		r[key] = time.Unix(0, int64(nano))
+	}
+}
+
+func temporality(t metricspb.AggregationTemporality) string {
+	switch t {
+	case metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA:
+		return "delta"
+	case metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE:
+		return "cumulative"
+	default:
+		return "unspecified"
+	}
+}
+
+func convertCounts(counts []uint64) []any {
+	result := make([]any, len(counts))
+	for i, c := range counts {
+		result[i] = int64(c)
+	}
+	return result
+}
+
+func convertBuckets(b *metricspb.ExponentialHistogramDataPoint_Buckets) map[string]any {
+	return map[string]any{
+		"offset":       int64(b.GetOffset()),
+		"bucketCounts": convertCounts(b.GetBucketCounts()),
+	}
+}
+
+func convertAttributes(kvs []*commonpb.KeyValue) map[string]any {
+	result := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		result[kv.GetKey()] = convertValue(kv.GetValue())
+	}
+	return result
+}
+
+func convertValue(v *commonpb.AnyValue) any {
+	switch val := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return val.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return val.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return val.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return val.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return val.BytesValue
+	case *commonpb.AnyValue_ArrayValue:
+		arr := make([]any, len(val.ArrayValue.GetValues()))
+		for i, e := range val.ArrayValue.GetValues() {
+			arr[i] = convertValue(e)
+		}
+		return arr
+	case *commonpb.AnyValue_KvlistValue:
+		return convertAttributes(val.KvlistValue.GetValues())
+	default:
+		return nil
+	}
+}
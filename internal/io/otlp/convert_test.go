@@ -0,0 +1,162 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const testTs = uint64(1700000000000000000)
+
+func strAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+func testResourceMetrics() []*metricspb.ResourceMetrics {
+	sum := 12.5
+	return []*metricspb.ResourceMetrics{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{strAttr("service.name", "app")}},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope: &commonpb.InstrumentationScope{Name: "meter", Version: "1.0"},
+			Metrics: []*metricspb.Metric{
+				{
+					Name: "temperature",
+					Unit: "Cel",
+					Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+						{Attributes: []*commonpb.KeyValue{strAttr("room", "a")}, TimeUnixNano: testTs, Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 21.5}},
+						{Attributes: []*commonpb.KeyValue{strAttr("room", "b")}, TimeUnixNano: testTs, Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 22}},
+					}}},
+				},
+				{
+					Name:        "requests",
+					Description: "request count",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						IsMonotonic:            true,
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						DataPoints: []*metricspb.NumberDataPoint{
+							{StartTimeUnixNano: testTs - 1000000000, TimeUnixNano: testTs, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 100}},
+						},
+					}},
+				},
+				{
+					Name: "latency",
+					Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+						DataPoints: []*metricspb.HistogramDataPoint{
+							{TimeUnixNano: testTs, Count: 3, Sum: &sum, BucketCounts: []uint64{1, 2, 0}, ExplicitBounds: []float64{5, 10}},
+						},
+					}},
+				},
+				{
+					Name: "size",
+					Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+						DataPoints: []*metricspb.SummaryDataPoint{
+							{TimeUnixNano: testTs, Count: 2, Sum: 3, QuantileValues: []*metricspb.SummaryDataPoint_ValueAtQuantile{{Quantile: 0.5, Value: 1}}},
+						},
+					}},
+				},
+			},
+		}},
+	}}
+}
+
+func TestFlattenMetrics(t *testing.T) {
+	ts := time.Unix(0, int64(testTs))
+	result := flattenMetrics(testResourceMetrics())
+	require.Equal(t, []batch{{
+		meta: map[string]any{
+			"resource": map[string]any{"service.name": "app"},
+			"scope": map[string]any{
+				"name":       "meter",
+				"version":    "1.0",
+				"attributes": map[string]any{},
+			},
+		},
+		rows: []map[string]any{
+			{"name": "temperature", "type": "gauge", "unit": "Cel", "attributes": map[string]any{"room": "a"}, "timestamp": ts, "value": 21.5},
+			{"name": "temperature", "type": "gauge", "unit": "Cel", "attributes": map[string]any{"room": "b"}, "timestamp": ts, "value": 22.0},
+			{
+				"name": "requests", "type": "sum", "description": "request count", "attributes": map[string]any{},
+				"startTimestamp": ts.Add(-time.Second), "timestamp": ts, "value": int64(100),
+				"isMonotonic": true, "aggregationTemporality": "cumulative",
+			},
+			{
+				"name": "latency", "type": "histogram", "attributes": map[string]any{}, "timestamp": ts,
+				"count": int64(3), "sum": 12.5, "bucketCounts": []any{int64(1), int64(2), int64(0)}, "explicitBounds": []any{5.0, 10.0},
+				"aggregationTemporality": "delta",
+			},
+			{
+				"name": "size", "type": "summary", "attributes": map[string]any{}, "timestamp": ts,
+				"count": int64(2), "sum": 3.0, "quantiles": []any{map[string]any{"quantile": 0.5, "value": 1.0}},
+			},
+		},
+	}}, result)
+}
+
+func TestFlattenLogs(t *testing.T) {
+	rls := []*logspb.ResourceLogs{{
+		Resource:  &resourcepb.Resource{Attributes: []*commonpb.KeyValue{strAttr("host.name", "edge1")}},
+		SchemaUrl: "https://opentelemetry.io/schemas/1.21.0",
+		ScopeLogs: []*logspb.ScopeLogs{
+			{Scope: &commonpb.InstrumentationScope{Name: "empty"}},
+			{
+				Scope: &commonpb.InstrumentationScope{Name: "logger"},
+				LogRecords: []*logspb.LogRecord{{
+					TimeUnixNano:   testTs,
+					SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+					SeverityText:   "ERROR",
+					Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: []*commonpb.KeyValue{
+						strAttr("msg", "disk full"),
+						{Key: "codes", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: []*commonpb.AnyValue{
+							{Value: &commonpb.AnyValue_IntValue{IntValue: 28}},
+							{Value: &commonpb.AnyValue_BoolValue{BoolValue: true}},
+						}}}}},
+					}}}},
+					TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+					SpanId:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				}},
+			},
+		},
+	}}
+	result := flattenLogs(rls)
+	require.Equal(t, []batch{{
+		meta: map[string]any{
+			"resource":          map[string]any{"host.name": "edge1"},
+			"resourceSchemaUrl": "https://opentelemetry.io/schemas/1.21.0",
+			"scope": map[string]any{
+				"name":       "logger",
+				"version":    "",
+				"attributes": map[string]any{},
+			},
+		},
+		rows: []map[string]any{{
+			"timestamp":      time.Unix(0, int64(testTs)),
+			"severityNumber": int64(17),
+			"severityText":   "ERROR",
+			"body":           map[string]any{"msg": "disk full", "codes": []any{int64(28), true}},
+			"attributes":     map[string]any{},
+			"flags":          int64(0),
+			"traceId":        "0102030405060708090a0b0c0d0e0f10",
+			"spanId":         "0102030405060708",
+		}},
+	}}, result)
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	logscol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricscol "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJson     = "application/json"
+)
+
+// handleHttp handles the OTLP/HTTP export request encoded in binary protobuf or json
+func (s *Source) handleHttp(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct != contentTypeProtobuf && ct != contentTypeJson {
+		http.Error(w, fmt.Sprintf("unsupported content type %s", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, s.conf.MaxBodySize)
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gr.Close()
+		body = io.LimitReader(gr, s.conf.MaxBodySize)
+	default:
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req, resp proto.Message
+	if s.conf.Signal == SignalMetrics {
+		req, resp = &metricscol.ExportMetricsServiceRequest{}, &metricscol.ExportMetricsServiceResponse{}
+	} else {
+		req, resp = &logscol.ExportLogsServiceRequest{}, &logscol.ExportLogsServiceResponse{}
+	}
+	if ct == contentTypeJson {
+		data, err = convertIds(data)
+		if err == nil {
+			err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, req)
+		}
+	} else {
+		err = proto.Unmarshal(data, req)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid otlp request: %v", err), http.StatusBadRequest)
+		return
+	}
+	switch rr := req.(type) {
+	case *metricscol.ExportMetricsServiceRequest:
+		s.ingestBatches(flattenMetrics(rr.GetResourceMetrics()))
+	case *logscol.ExportLogsServiceRequest:
+		s.ingestBatches(flattenLogs(rr.GetResourceLogs()))
+	}
+	var out []byte
+	if ct == contentTypeJson {
+		out, err = protojson.Marshal(resp)
+	} else {
+		out, err = proto.Marshal(resp)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ct)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// convertIds converts the hex encoded traceId and spanId of OTLP/JSON to base64 which is expected by protojson
+func convertIds(data []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if !walkIds(v) {
+		return data, nil
+	}
+	return json.Marshal(v)
+}
+
+func walkIds(v any) bool {
+	changed := false
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, e := range vv {
+			if s, ok := e.(string); ok && (k == "traceId" || k == "spanId") {
+				if b, err := hex.DecodeString(s); err == nil {
+					vv[k] = base64.StdEncoding.EncodeToString(b)
+					changed = true
+				}
+				continue
+			}
+			changed = walkIds(e) || changed
+		}
+	case []any:
+		for _, e := range vv {
+			changed = walkIds(e) || changed
+		}
+	}
+	return changed
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	logscol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricscol "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cert"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+// Supported signals and protocols
+const (
+	SignalMetrics = "metrics"
+	SignalLogs    = "logs"
+
+	ProtocolHttp = "http"
+	ProtocolGrpc = "grpc"
+)
+
+type conf struct {
+	// Protocol is http or grpc
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	// Signal is metrics or logs. The datasource is used if not set.
+	Signal      string `json:"signal"`
+	Datasource  string `json:"datasource"`
+	MaxBodySize int64  `json:"maxBodySize"`
+}
+
+// Source receives OTLP requests and flattens each data point or log record into a row.
+// The resource and scope are set in the meta.
+type Source struct {
+	conf      *conf
+	tlsConfig *tls.Config
+
+	mu         sync.Mutex
+	listener   net.Listener
+	httpServer *http.Server
+	grpcServer *grpc.Server
+	ingest     api.TupleIngest
+	ctx        api.StreamContext
+}
+
+func (s *Source) Provision(_ api.StreamContext, configs map[string]any) error {
+	c := &conf{Protocol: ProtocolHttp, MaxBodySize: 4 * 1024 * 1024}
+	if err := cast.MapToStruct(configs, c); err != nil {
+		return fmt.Errorf("read properties %v fail with error: %v", configs, err)
+	}
+	switch c.Protocol {
+	case ProtocolHttp, ProtocolGrpc:
+	default:
+		return fmt.Errorf("unsupported protocol %s, must be http or grpc", c.Protocol)
+	}
+	if c.Signal == "" {
+		c.Signal = c.Datasource
+	}
+	if c.Signal == "" || c.Signal == "/" {
+		c.Signal = SignalMetrics
+	}
+	switch c.Signal {
+	case SignalMetrics, SignalLogs:
+	default:
+		return fmt.Errorf("unsupported signal %s, must be metrics or logs", c.Signal)
+	}
+	if c.Address == "" {
+		if c.Protocol == ProtocolGrpc {
+			c.Address = "0.0.0.0:4317"
+		} else {
+			c.Address = "0.0.0.0:4318"
+		}
+	}
+	if c.MaxBodySize <= 0 {
+		return fmt.Errorf("maxBodySize must be positive")
+	}
+	tc, err := cert.GenTLSConfig(configs, "otlp")
+	if err != nil {
+		return err
+	}
+	if tc != nil && len(tc.Certificates) > 0 {
+		s.tlsConfig = &tls.Config{
+			Certificates: tc.Certificates,
+			MinVersion:   tc.MinVersion,
+		}
+		// Verify the client certificates if the root ca is specified
+		if tc.RootCAs != nil {
+			s.tlsConfig.ClientCAs = tc.RootCAs
+			s.tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	s.conf = c
+	return nil
+}
+
+func (s *Source) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	ctx.GetLogger().Infof("otlp source listening %s %s on %s", s.conf.Protocol, s.conf.Signal, s.conf.Address)
+	l, err := net.Listen("tcp", s.conf.Address)
+	if err != nil {
+		sch(api.ConnectionDisconnected, err.Error())
+		return err
+	}
+	s.listener = l
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *Source) Subscribe(ctx api.StreamContext, ingest api.TupleIngest, ingestError api.ErrorIngest) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.ingest = ingest
+	s.mu.Unlock()
+	if s.conf.Protocol == ProtocolGrpc {
+		var opts []grpc.ServerOption
+		if s.tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+		}
+		srv := grpc.NewServer(opts...)
+		if s.conf.Signal == SignalMetrics {
+			metricscol.RegisterMetricsServiceServer(srv, &metricsService{s: s})
+		} else {
+			logscol.RegisterLogsServiceServer(srv, &logsService{s: s})
+		}
+		s.mu.Lock()
+		s.grpcServer = srv
+		s.mu.Unlock()
+		go func() {
+			if err := srv.Serve(s.listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				ctx.GetLogger().Errorf("otlp grpc server stopped: %v", err)
+				ingestError(ctx, err)
+			}
+		}()
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/"+s.conf.Signal, s.handleHttp)
+	srv := &http.Server{Handler: mux, TLSConfig: s.tlsConfig}
+	s.mu.Lock()
+	s.httpServer = srv
+	s.mu.Unlock()
+	go func() {
+		var err error
+		if s.tlsConfig != nil {
+			err = srv.ServeTLS(s.listener, "", "")
+		} else {
+			err = srv.Serve(s.listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ctx.GetLogger().Errorf("otlp http server stopped: %v", err)
+			ingestError(ctx, err)
+		}
+	}()
+	return nil
+}
+
+func (s *Source) ingestBatches(batches []batch) {
+	s.mu.Lock()
+	ctx, ingest := s.ctx, s.ingest
+	s.mu.Unlock()
+	if ingest == nil {
+		return
+	}
+	for _, b := range batches {
+		ingest(ctx, b.rows, b.meta, timex.GetNow())
+	}
+}
+
+func (s *Source) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing otlp source")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.grpcServer != nil:
+		s.grpcServer.Stop()
+	case s.httpServer != nil:
+		return s.httpServer.Shutdown(context.Background())
+	case s.listener != nil:
+		return s.listener.Close()
+	}
+	return nil
+}
+
+type metricsService struct {
+	metricscol.UnimplementedMetricsServiceServer
+	s *Source
+}
+
+func (m *metricsService) Export(_ context.Context, req *metricscol.ExportMetricsServiceRequest) (*metricscol.ExportMetricsServiceResponse, error) {
+	m.s.ingestBatches(flattenMetrics(req.GetResourceMetrics()))
+	return &metricscol.ExportMetricsServiceResponse{}, nil
+}
+
+type logsService struct {
+	logscol.UnimplementedLogsServiceServer
+	s *Source
+}
+
+func (l *logsService) Export(_ context.Context, req *logscol.ExportLogsServiceRequest) (*logscol.ExportLogsServiceResponse, error) {
+	l.s.ingestBatches(flattenLogs(req.GetResourceLogs()))
+	return &logscol.ExportLogsServiceResponse{}, nil
+}
+
+func GetSource() api.Source {
+	return &Source{}
+}
+
+var _ api.TupleSource = &Source{}
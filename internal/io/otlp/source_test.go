@@ -0,0 +1,163 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+	logscol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricscol "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+type received struct {
+	rows []map[string]any
+	meta map[string]any
+}
+
+func startSource(t *testing.T, props map[string]any) (*Source, string, chan received, func()) {
+	ctx, cancel := mockContext.NewMockContext("testOtlp", "op").WithCancel()
+	s := GetSource().(*Source)
+	props["address"] = "127.0.0.1:0"
+	require.NoError(t, s.Provision(ctx, props))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	recv := make(chan received, 10)
+	require.NoError(t, s.Subscribe(ctx, func(ctx api.StreamContext, data any, meta map[string]any, ts time.Time) {
+		recv <- received{rows: data.([]map[string]any), meta: meta}
+	}, func(ctx api.StreamContext, err error) {
+		require.NoError(t, err)
+	}))
+	return s, s.listener.Addr().String(), recv, func() {
+		require.NoError(t, s.Close(ctx))
+		cancel()
+	}
+}
+
+func waitReceived(t *testing.T, recv chan received) received {
+	select {
+	case r := <-recv:
+		return r
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timeout")
+		return received{}
+	}
+}
+
+func TestProvision(t *testing.T) {
+	tests := []struct {
+		props map[string]any
+		err   string
+	}{
+		{
+			props: map[string]any{"protocol": "udp"},
+			err:   "unsupported protocol udp, must be http or grpc",
+		},
+		{
+			props: map[string]any{"datasource": "traces"},
+			err:   "unsupported signal traces, must be metrics or logs",
+		},
+		{
+			props: map[string]any{"maxBodySize": -1},
+			err:   "maxBodySize must be positive",
+		},
+	}
+	ctx := mockContext.NewMockContext("testOtlp", "op")
+	for _, tt := range tests {
+		require.EqualError(t, GetSource().Provision(ctx, tt.props), tt.err)
+	}
+	s := GetSource().(*Source)
+	require.NoError(t, s.Provision(ctx, map[string]any{"protocol": "grpc", "datasource": "logs"}))
+	require.Equal(t, &conf{Protocol: "grpc", Address: "0.0.0.0:4317", Signal: "logs", Datasource: "logs", MaxBodySize: 4194304}, s.conf)
+}
+
+func TestHttpMetrics(t *testing.T) {
+	_, addr, recv, closer := startSource(t, map[string]any{})
+	defer closer()
+	body, err := proto.Marshal(&metricscol.ExportMetricsServiceRequest{ResourceMetrics: testResourceMetrics()})
+	require.NoError(t, err)
+	resp, err := http.Post("http://"+addr+"/v1/metrics", contentTypeProtobuf, bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, contentTypeProtobuf, resp.Header.Get("Content-Type"))
+	_ = resp.Body.Close()
+	r := waitReceived(t, recv)
+	require.Len(t, r.rows, 5)
+	require.Equal(t, "temperature", r.rows[0]["name"])
+	require.Equal(t, map[string]any{"service.name": "app"}, r.meta["resource"])
+	// Wrong signal and content type
+	resp, err = http.Post("http://"+addr+"/v1/logs", contentTypeProtobuf, bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	_ = resp.Body.Close()
+	resp, err = http.Post("http://"+addr+"/v1/metrics", "text/plain", bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	_ = resp.Body.Close()
+	resp, err = http.Post("http://"+addr+"/v1/metrics", contentTypeProtobuf, bytes.NewReader([]byte("invalid")))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+}
+
+func TestHttpJsonLogs(t *testing.T) {
+	_, addr, recv, closer := startSource(t, map[string]any{"datasource": "logs"})
+	defer closer()
+	body := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"app"}}]},"scopeLogs":[{"scope":{"name":"logger"},"logRecords":[{"timeUnixNano":"1700000000000000000","severityNumber":9,"severityText":"INFO","body":{"stringValue":"started"},"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174"}]}]}]}`
+	resp, err := http.Post("http://"+addr+"/v1/logs", contentTypeJson, bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, contentTypeJson, resp.Header.Get("Content-Type"))
+	_ = resp.Body.Close()
+	r := waitReceived(t, recv)
+	require.Equal(t, []map[string]any{{
+		"timestamp":      time.Unix(0, int64(testTs)),
+		"severityNumber": int64(9),
+		"severityText":   "INFO",
+		"body":           "started",
+		"attributes":     map[string]any{},
+		"flags":          int64(0),
+		"traceId":        "5b8efff798038103d269b633813fc60c",
+		"spanId":         "eee19b7ec3c1b174",
+	}}, r.rows)
+	require.Equal(t, "logger", r.meta["scope"].(map[string]any)["name"])
+}
+
+func TestGrpc(t *testing.T) {
+	_, addr, recv, closer := startSource(t, map[string]any{"protocol": "grpc"})
+	defer closer()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = metricscol.NewMetricsServiceClient(conn).Export(ctx, &metricscol.ExportMetricsServiceRequest{ResourceMetrics: testResourceMetrics()})
+	require.NoError(t, err)
+	r := waitReceived(t, recv)
+	require.Len(t, r.rows, 5)
+	require.Equal(t, int64(100), r.rows[2]["value"])
+	// The logs service is not registered for the metrics source
+	_, err = logscol.NewLogsServiceClient(conn).Export(ctx, &logscol.ExportLogsServiceRequest{})
+	require.Error(t, err)
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// seriesSet groups the samples by the label set in the arrival order
+type seriesSet struct {
+	index  map[string]int
+	series []*timeSeries
+}
+
+func newSeriesSet() *seriesSet {
+	return &seriesSet{index: make(map[string]int)}
+}
+
+// add appends a sample to the series of the labels. The labels must be sorted by name.
+func (s *seriesSet) add(labels []label, smp sample) {
+	var sb strings.Builder
+	for _, l := range labels {
+		sb.WriteString(l.name)
+		sb.WriteByte(0xff)
+		sb.WriteString(l.value)
+		sb.WriteByte(0xff)
+	}
+	key := sb.String()
+	if i, ok := s.index[key]; ok {
+		s.series[i].samples = append(s.series[i].samples, smp)
+		return
+	}
+	s.index[key] = len(s.series)
+	s.series = append(s.series, &timeSeries{labels: labels, samples: []sample{smp}})
+}
+
+func sortLabels(labels []label) {
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+}
+
+// encode marshals the series as the remote write WriteRequest protobuf and compresses it by snappy block format.
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func (s *seriesSet) encode() []byte {
+	var req []byte
+	for _, ts := range s.series {
+		var tsb []byte
+		for _, l := range ts.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			tsb = protowire.AppendTag(tsb, 1, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, lb)
+		}
+		for _, smp := range ts.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.timestamp))
+			tsb = protowire.AppendTag(tsb, 2, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, sb)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, tsb)
+	}
+	return snappy.Encode(nil, req)
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cert"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+const acceptHeader = "text/plain;version=0.0.4;q=1,*/*;q=0.1"
+
+type scrapeConf struct {
+	Targets    []string          `json:"targets"`
+	Datasource string            `json:"datasource"`
+	Headers    map[string]string `json:"headers"`
+	Timeout    cast.DurationConf `json:"timeout"`
+}
+
+// ScrapeSource scrapes the targets in the Prometheus text exposition format on each pull.
+// Each metric, which is a series of a metric family, is a row.
+type ScrapeSource struct {
+	conf   *scrapeConf
+	client *http.Client
+}
+
+func (s *ScrapeSource) Provision(_ api.StreamContext, configs map[string]any) error {
+	c := &scrapeConf{Timeout: cast.DurationConf(5 * time.Second)}
+	if err := cast.MapToStruct(configs, c); err != nil {
+		return fmt.Errorf("read properties %v fail with error: %v", configs, err)
+	}
+	if len(c.Targets) == 0 && c.Datasource != "" && c.Datasource != "/" {
+		c.Targets = []string{c.Datasource}
+	}
+	if len(c.Targets) == 0 {
+		return fmt.Errorf("property targets is required")
+	}
+	for _, t := range c.Targets {
+		if !strings.HasPrefix(t, "http://") && !strings.HasPrefix(t, "https://") {
+			return fmt.Errorf("invalid target %s, must be a http or https url", t)
+		}
+	}
+	tc, err := cert.GenTLSConfig(configs, "prometheus_scrape")
+	if err != nil {
+		return err
+	}
+	s.conf = c
+	s.client = &http.Client{
+		Timeout:   time.Duration(c.Timeout),
+		Transport: &http.Transport{TLSClientConfig: tc, Proxy: http.ProxyFromEnvironment},
+	}
+	return nil
+}
+
+func (s *ScrapeSource) Connect(_ api.StreamContext, sch api.StatusChangeHandler) error {
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *ScrapeSource) Pull(ctx api.StreamContext, _ time.Time, ingest api.TupleIngest, ingestError api.ErrorIngest) {
+	for _, t := range s.conf.Targets {
+		rows, err := s.scrape(t)
+		if err != nil {
+			ingestError(ctx, fmt.Errorf("scrape %s failed: %v", t, err))
+			continue
+		}
+		if len(rows) > 0 {
+			ingest(ctx, rows, map[string]any{"target": t}, timex.GetNow())
+		}
+	}
+}
+
+func (s *ScrapeSource) scrape(target string) ([]map[string]any, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("User-Agent", "eKuiper")
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return parseText(resp.Body)
+}
+
+// parseText parses the text exposition format. Each metric is converted into a row.
+func parseText(r io.Reader) ([]map[string]any, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var rows []map[string]any
+	for _, name := range names {
+		mf := families[name]
+		for _, m := range mf.GetMetric() {
+			rows = append(rows, convertMetric(mf, m))
+		}
+	}
+	return rows, nil
+}
+
+func convertMetric(mf *dto.MetricFamily, m *dto.Metric) map[string]any {
+	labels := make(map[string]any, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	row := map[string]any{
+		"name":   mf.GetName(),
+		"type":   strings.ToLower(mf.GetType().String()),
+		"labels": labels,
+	}
+	if mf.GetHelp() != "" {
+		row["help"] = mf.GetHelp()
+	}
+	if m.TimestampMs != nil {
+		row["timestamp"] = m.GetTimestampMs()
+	}
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		row["value"] = m.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		row["value"] = m.GetGauge().GetValue()
+	case dto.MetricType_UNTYPED:
+		row["value"] = m.GetUntyped().GetValue()
+	case dto.MetricType_SUMMARY:
+		row["count"] = int64(m.GetSummary().GetSampleCount())
+		row["sum"] = m.GetSummary().GetSampleSum()
+		quantiles := make([]any, len(m.GetSummary().GetQuantile()))
+		for i, q := range m.GetSummary().GetQuantile() {
+			quantiles[i] = map[string]any{"quantile": q.GetQuantile(), "value": q.GetValue()}
+		}
+		row["quantiles"] = quantiles
+	case dto.MetricType_HISTOGRAM:
+		row["count"] = int64(m.GetHistogram().GetSampleCount())
+		row["sum"] = m.GetHistogram().GetSampleSum()
+		buckets := make([]any, len(m.GetHistogram().GetBucket()))
+		for i, b := range m.GetHistogram().GetBucket() {
+			buckets[i] = map[string]any{"le": b.GetUpperBound(), "count": int64(b.GetCumulativeCount())}
+		}
+		row["buckets"] = buckets
+	}
+	return row
+}
+
+func (s *ScrapeSource) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing prometheus scrape source")
+	if s.client != nil {
+		s.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func GetScrapeSource() api.Source {
+	return &ScrapeSource{}
+}
+
+var _ api.PullTupleSource = &ScrapeSource{}
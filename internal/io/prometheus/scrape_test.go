@@ -0,0 +1,103 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+const exposition = `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"}    3 1395066363000
+# TYPE temperature gauge
+temperature 21.5
+# HELP rpc_duration_seconds A summary of the RPC duration in seconds.
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 4773
+rpc_duration_seconds{quantile="0.9"} 9001
+rpc_duration_seconds_sum 1.7560473e+07
+rpc_duration_seconds_count 2693
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 3
+request_duration_seconds_bucket{le="+Inf"} 5
+request_duration_seconds_sum 0.9
+request_duration_seconds_count 5
+`
+
+func TestScrapeProvision(t *testing.T) {
+	ctx := mockContext.NewMockContext("testScrape", "op")
+	require.EqualError(t, GetScrapeSource().Provision(ctx, map[string]any{}), "property targets is required")
+	require.EqualError(t, GetScrapeSource().Provision(ctx, map[string]any{"targets": []string{"localhost:9100"}}), "invalid target localhost:9100, must be a http or https url")
+	s := GetScrapeSource().(*ScrapeSource)
+	require.NoError(t, s.Provision(ctx, map[string]any{"datasource": "http://localhost:9100/metrics"}))
+	require.Equal(t, []string{"http://localhost:9100/metrics"}, s.conf.Targets)
+}
+
+func TestScrape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(exposition))
+	}))
+	defer server.Close()
+
+	ctx := mockContext.NewMockContext("testScrape", "op")
+	s := GetScrapeSource().(*ScrapeSource)
+	require.NoError(t, s.Provision(ctx, map[string]any{
+		"targets": []string{server.URL + "/metrics", server.URL + "/notfound"},
+	}))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	var (
+		rows []map[string]any
+		meta map[string]any
+		errs []error
+	)
+	s.Pull(ctx, time.Now(), func(ctx api.StreamContext, data any, m map[string]any, ts time.Time) {
+		rows = data.([]map[string]any)
+		meta = m
+	}, func(ctx api.StreamContext, err error) {
+		errs = append(errs, err)
+	})
+	require.Equal(t, map[string]any{"target": server.URL + "/metrics"}, meta)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "scrape "+server.URL+"/notfound failed: unexpected status 404")
+	require.Equal(t, []map[string]any{
+		{"name": "http_requests_total", "type": "counter", "help": "The total number of HTTP requests.", "labels": map[string]any{"method": "post", "code": "200"}, "timestamp": int64(1395066363000), "value": 1027.0},
+		{"name": "http_requests_total", "type": "counter", "help": "The total number of HTTP requests.", "labels": map[string]any{"method": "post", "code": "400"}, "timestamp": int64(1395066363000), "value": 3.0},
+		{
+			"name": "request_duration_seconds", "type": "histogram", "labels": map[string]any{}, "count": int64(5), "sum": 0.9,
+			"buckets": []any{map[string]any{"le": 0.1, "count": int64(3)}, map[string]any{"le": math.Inf(1), "count": int64(5)}},
+		},
+		{
+			"name": "rpc_duration_seconds", "type": "summary", "help": "A summary of the RPC duration in seconds.", "labels": map[string]any{}, "count": int64(2693), "sum": 1.7560473e+07,
+			"quantiles": []any{map[string]any{"quantile": 0.5, "value": 4773.0}, map[string]any{"quantile": 0.9, "value": 9001.0}},
+		},
+		{"name": "temperature", "type": "gauge", "labels": map[string]any{}, "value": 21.5},
+	}, rows)
+	require.NoError(t, s.Close(ctx))
+}
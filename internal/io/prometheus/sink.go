@@ -0,0 +1,236 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cert"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// MetricMapping maps a field of the result to a metric
+type MetricMapping struct {
+	Field string `json:"field"`
+	// Name is the metric name, default to the field name
+	Name string `json:"name"`
+}
+
+type sinkConf struct {
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Timeout cast.DurationConf `json:"timeout"`
+	Metrics []MetricMapping   `json:"metrics"`
+	// Labels are the fields used as the labels of all metrics
+	Labels      []string          `json:"labels"`
+	ConstLabels map[string]string `json:"constLabels"`
+	// TimestampField is the field of the sample timestamp in milliseconds. Use the current time if not set.
+	TimestampField string `json:"timestampField"`
+}
+
+// RemoteWriteSink converts the results into samples and sends them by Prometheus remote write protocol.
+// A batch of results is sent in one request when batchSize or lingerInterval is set.
+type RemoteWriteSink struct {
+	conf   *sinkConf
+	client *http.Client
+}
+
+func (s *RemoteWriteSink) Provision(_ api.StreamContext, configs map[string]any) error {
+	c := &sinkConf{Timeout: cast.DurationConf(5 * time.Second)}
+	if err := cast.MapToStruct(configs, c); err != nil {
+		return fmt.Errorf("read properties %v fail with error: %v", configs, err)
+	}
+	if c.Url == "" {
+		return fmt.Errorf("property url is required")
+	}
+	if len(c.Metrics) == 0 {
+		return fmt.Errorf("property metrics is required")
+	}
+	for i, m := range c.Metrics {
+		if m.Field == "" {
+			return fmt.Errorf("metrics[%d]: field is required", i)
+		}
+		if m.Name == "" {
+			c.Metrics[i].Name = m.Field
+		}
+		if !nameRegex.MatchString(c.Metrics[i].Name) {
+			return fmt.Errorf("metrics[%d]: invalid metric name %s", i, c.Metrics[i].Name)
+		}
+	}
+	for _, l := range c.Labels {
+		if !nameRegex.MatchString(l) || l == "__name__" {
+			return fmt.Errorf("invalid label name %s", l)
+		}
+	}
+	for l := range c.ConstLabels {
+		if !nameRegex.MatchString(l) || l == "__name__" {
+			return fmt.Errorf("invalid label name %s", l)
+		}
+	}
+	tc, err := cert.GenTLSConfig(configs, "prometheus_remote_write")
+	if err != nil {
+		return err
+	}
+	s.conf = c
+	s.client = &http.Client{
+		Timeout:   time.Duration(c.Timeout),
+		Transport: &http.Transport{TLSClientConfig: tc, Proxy: http.ProxyFromEnvironment},
+	}
+	return nil
+}
+
+func (s *RemoteWriteSink) Connect(_ api.StreamContext, sch api.StatusChangeHandler) error {
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *RemoteWriteSink) Collect(ctx api.StreamContext, item api.MessageTuple) error {
+	set := newSeriesSet()
+	if err := s.addSamples(set, item.ToMap()); err != nil {
+		return err
+	}
+	return s.send(ctx, set)
+}
+
+func (s *RemoteWriteSink) CollectList(ctx api.StreamContext, items api.MessageTupleList) error {
+	set := newSeriesSet()
+	var err error
+	items.RangeOfTuples(func(_ int, tuple api.MessageTuple) bool {
+		err = s.addSamples(set, tuple.ToMap())
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, set)
+}
+
+// addSamples converts the mapped fields of a result into samples. The fields absent in the result are ignored.
+func (s *RemoteWriteSink) addSamples(set *seriesSet, data map[string]any) error {
+	ts := timex.GetNow().UnixMilli()
+	if s.conf.TimestampField != "" {
+		if v, ok := data[s.conf.TimestampField]; ok && v != nil {
+			switch tv := v.(type) {
+			case time.Time:
+				ts = tv.UnixMilli()
+			default:
+				t, err := cast.ToInt64(v, cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return fmt.Errorf("invalid timestamp field %s: %v", s.conf.TimestampField, err)
+				}
+				ts = t
+			}
+		}
+	}
+	common := make([]label, 0, len(s.conf.Labels)+len(s.conf.ConstLabels)+1)
+	for k, v := range s.conf.ConstLabels {
+		common = append(common, label{name: k, value: v})
+	}
+	for _, l := range s.conf.Labels {
+		if v, ok := data[l]; ok && v != nil {
+			sv, err := cast.ToString(v, cast.CONVERT_ALL)
+			if err != nil {
+				return fmt.Errorf("invalid label %s: %v", l, err)
+			}
+			if sv != "" {
+				common = append(common, label{name: l, value: sv})
+			}
+		}
+	}
+	for _, m := range s.conf.Metrics {
+		v, ok := data[m.Field]
+		if !ok || v == nil {
+			continue
+		}
+		var f float64
+		switch bv := v.(type) {
+		case bool:
+			if bv {
+				f = 1
+			}
+		default:
+			var err error
+			f, err = cast.ToFloat64(v, cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("invalid metric field %s: %v", m.Field, err)
+			}
+		}
+		labels := make([]label, 0, len(common)+1)
+		labels = append(labels, label{name: "__name__", value: m.Name})
+		labels = append(labels, common...)
+		sortLabels(labels)
+		set.add(labels, sample{value: f, timestamp: ts})
+	}
+	return nil
+}
+
+func (s *RemoteWriteSink) send(ctx api.StreamContext, set *seriesSet) error {
+	if len(set.series) == 0 {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPost, s.conf.Url, bytes.NewReader(set.encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "eKuiper")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errorx.NewIOErr(fmt.Sprintf("remote write to %s failed: %v", s.conf.Url, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		ctx.GetLogger().Debugf("remote write %d series to %s", len(set.series), s.conf.Url)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	msg := fmt.Sprintf("remote write to %s failed with status %d: %s", s.conf.Url, resp.StatusCode, body)
+	// Only server errors and throttling are recoverable by retry
+	if resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests {
+		return errorx.NewIOErr(msg)
+	}
+	return errors.New(msg)
+}
+
+func (s *RemoteWriteSink) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing prometheus remote write sink")
+	if s.client != nil {
+		s.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func GetRemoteWriteSink() api.Sink {
+	return &RemoteWriteSink{}
+}
+
+var _ api.TupleCollector = &RemoteWriteSink{}
@@ -0,0 +1,194 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+// decodeWriteRequest decodes the snappy compressed WriteRequest for verification
+func decodeWriteRequest(t *testing.T, data []byte) []timeSeries {
+	raw, err := snappy.Decode(nil, data)
+	require.NoError(t, err)
+	var result []timeSeries
+	forEachField(t, raw, func(num protowire.Number, tsb []byte) {
+		require.Equal(t, protowire.Number(1), num)
+		ts := timeSeries{}
+		forEachField(t, tsb, func(num protowire.Number, b []byte) {
+			switch num {
+			case 1:
+				l := label{}
+				forEachField(t, b, func(num protowire.Number, v []byte) {
+					if num == 1 {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+				})
+				ts.labels = append(ts.labels, l)
+			case 2:
+				smp := sample{}
+				for len(b) > 0 {
+					num, typ, n := protowire.ConsumeTag(b)
+					require.True(t, n > 0)
+					b = b[n:]
+					if num == 1 {
+						require.Equal(t, protowire.Fixed64Type, typ)
+						v, n := protowire.ConsumeFixed64(b)
+						smp.value = math.Float64frombits(v)
+						b = b[n:]
+					} else {
+						require.Equal(t, protowire.VarintType, typ)
+						v, n := protowire.ConsumeVarint(b)
+						smp.timestamp = int64(v)
+						b = b[n:]
+					}
+				}
+				ts.samples = append(ts.samples, smp)
+			}
+		})
+		result = append(result, ts)
+	})
+	return result
+}
+
+func forEachField(t *testing.T, b []byte, f func(num protowire.Number, v []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		require.Equal(t, protowire.BytesType, typ)
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		f(num, v)
+	}
+}
+
+func TestSinkProvision(t *testing.T) {
+	tests := []struct {
+		props map[string]any
+		err   string
+	}{
+		{
+			props: map[string]any{},
+			err:   "property url is required",
+		},
+		{
+			props: map[string]any{"url": "http://localhost:9090/api/v1/write"},
+			err:   "property metrics is required",
+		},
+		{
+			props: map[string]any{"url": "http://localhost:9090/api/v1/write", "metrics": []map[string]any{{"name": "a"}}},
+			err:   "metrics[0]: field is required",
+		},
+		{
+			props: map[string]any{"url": "http://localhost:9090/api/v1/write", "metrics": []map[string]any{{"field": "a-b"}}},
+			err:   "metrics[0]: invalid metric name a-b",
+		},
+		{
+			props: map[string]any{"url": "http://localhost:9090/api/v1/write", "metrics": []map[string]any{{"field": "a"}}, "labels": []string{"__name__"}},
+			err:   "invalid label name __name__",
+		},
+	}
+	ctx := mockContext.NewMockContext("testRemoteWrite", "op")
+	for _, tt := range tests {
+		require.EqualError(t, GetRemoteWriteSink().Provision(ctx, tt.props), tt.err)
+	}
+}
+
+func TestRemoteWriteSink(t *testing.T) {
+	var (
+		status   = http.StatusNoContent
+		received []timeSeries
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		require.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = decodeWriteRequest(t, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	ctx := mockContext.NewMockContext("testRemoteWrite", "op")
+	s := GetRemoteWriteSink().(*RemoteWriteSink)
+	require.NoError(t, s.Provision(ctx, map[string]any{
+		"url":            server.URL,
+		"headers":        map[string]any{"Authorization": "Bearer token"},
+		"metrics":        []map[string]any{{"field": "temperature", "name": "room_temperature"}, {"field": "on"}},
+		"labels":         []string{"room"},
+		"constLabels":    map[string]any{"job": "ekuiper"},
+		"timestampField": "ts",
+	}))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+
+	// A batch with two series, the sample of the same series are grouped
+	require.NoError(t, s.CollectList(ctx, &xsql.WindowTuples{Content: []xsql.Row{
+		&xsql.Tuple{Message: map[string]any{"temperature": 21.5, "room": "a", "ts": int64(1000)}},
+		&xsql.Tuple{Message: map[string]any{"temperature": 22, "room": "b", "ts": int64(1000)}},
+		&xsql.Tuple{Message: map[string]any{"temperature": 21.7, "room": "a", "ts": int64(2000), "on": true}},
+	}}))
+	require.Equal(t, []timeSeries{
+		{
+			labels:  []label{{"__name__", "room_temperature"}, {"job", "ekuiper"}, {"room", "a"}},
+			samples: []sample{{21.5, 1000}, {21.7, 2000}},
+		},
+		{
+			labels:  []label{{"__name__", "room_temperature"}, {"job", "ekuiper"}, {"room", "b"}},
+			samples: []sample{{22, 1000}},
+		},
+		{
+			labels:  []label{{"__name__", "on"}, {"job", "ekuiper"}, {"room", "a"}},
+			samples: []sample{{1, 2000}},
+		},
+	}, received)
+
+	// Single tuple without the label
+	require.NoError(t, s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"temperature": 20, "ts": int64(3000)}}))
+	require.Equal(t, []timeSeries{{
+		labels:  []label{{"__name__", "room_temperature"}, {"job", "ekuiper"}},
+		samples: []sample{{20, 3000}},
+	}}, received)
+
+	// Invalid value
+	require.EqualError(t, s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"temperature": "hot"}}), "invalid metric field temperature: cannot convert string(hot) to float64")
+
+	// Server error is recoverable
+	status = http.StatusInternalServerError
+	err := s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"temperature": 20}})
+	require.Error(t, err)
+	require.True(t, errorx.IsIOError(err))
+	status = http.StatusBadRequest
+	err = s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"temperature": 20}})
+	require.Error(t, err)
+	require.False(t, errorx.IsIOError(err))
+	require.NoError(t, s.Close(ctx))
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+)
+
+const (
+	ModeServer = "server"
+	ModeClient = "client"
+)
+
+type conf struct {
+	// Network is tcp, udp or unix
+	Network string `json:"network"`
+	// Mode is server or client for tcp and unix. Udp source always listens and udp sink always sends.
+	Mode string `json:"mode"`
+	// Address is the host:port for tcp and udp, or the socket file path for unix
+	Address           string            `json:"address"`
+	Datasource        string            `json:"datasource"`
+	ReconnectInterval cast.DurationConf `json:"reconnectInterval"`
+}
+
+func parseConf(props map[string]any, defaultMode string) (*conf, *framer, error) {
+	c := &conf{Network: "tcp", Mode: defaultMode, ReconnectInterval: cast.DurationConf(time.Second)}
+	if err := cast.MapToStruct(props, c); err != nil {
+		return nil, nil, fmt.Errorf("read properties %v fail with error: %v", props, err)
+	}
+	if c.Address == "" {
+		c.Address = c.Datasource
+	}
+	if c.Address == "" {
+		return nil, nil, fmt.Errorf("property address is required")
+	}
+	fc := &FramingConf{}
+	if err := cast.MapToStruct(props, fc); err != nil {
+		return nil, nil, fmt.Errorf("read properties %v fail with error: %v", props, err)
+	}
+	switch c.Network {
+	case "tcp", "unix":
+		if fc.Framing == "" {
+			fc.Framing = FramingNewline
+		}
+		if c.Mode != ModeServer && c.Mode != ModeClient {
+			return nil, nil, fmt.Errorf("mode must be server or client")
+		}
+	case "udp":
+		if fc.Framing == "" {
+			fc.Framing = FramingNone
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported network %s, must be tcp, udp or unix", c.Network)
+	}
+	if c.ReconnectInterval <= 0 {
+		return nil, nil, fmt.Errorf("reconnectInterval must be positive")
+	}
+	f, err := newFramer(fc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, f, nil
+}
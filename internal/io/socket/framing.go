@@ -0,0 +1,204 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Framing types
+const (
+	FramingNone      = "none"
+	FramingNewline   = "newline"
+	FramingFixed     = "fixed"
+	FramingLength    = "length"
+	FramingDelimiter = "delimiter"
+)
+
+const defaultMaxFrameSize = 64 * 1024
+
+type FramingConf struct {
+	// Framing is how to split the byte stream into frames, newline, fixed, length, delimiter or none.
+	// None means each read (a datagram for udp) is a frame.
+	Framing string `json:"framing"`
+	// FrameLength is the length of each frame for fixed framing
+	FrameLength int `json:"frameLength"`
+	// LengthSize is the byte size of the length header for length framing, 1, 2 or 4
+	LengthSize int `json:"lengthSize"`
+	// LengthOrder is the byte order of the length header, big or little
+	LengthOrder string `json:"lengthOrder"`
+	// Delimiter is the bytes to end each frame for delimiter framing
+	Delimiter    string `json:"delimiter"`
+	MaxFrameSize int    `json:"maxFrameSize"`
+}
+
+// framer splits the incoming byte stream into frames and wraps the outgoing payload into a frame
+type framer struct {
+	conf      *FramingConf
+	delimiter []byte
+	order     binary.ByteOrder
+}
+
+func newFramer(c *FramingConf) (*framer, error) {
+	f := &framer{conf: c}
+	if c.MaxFrameSize <= 0 {
+		c.MaxFrameSize = defaultMaxFrameSize
+	}
+	switch c.Framing {
+	case FramingNone, FramingNewline:
+	case FramingFixed:
+		if c.FrameLength <= 0 || c.FrameLength > c.MaxFrameSize {
+			return nil, fmt.Errorf("frameLength must be between 1 and %d for fixed framing", c.MaxFrameSize)
+		}
+	case FramingLength:
+		switch c.LengthSize {
+		case 1, 2, 4:
+		default:
+			return nil, fmt.Errorf("lengthSize must be 1, 2 or 4 for length framing")
+		}
+		switch c.LengthOrder {
+		case "", "big":
+			f.order = binary.BigEndian
+		case "little":
+			f.order = binary.LittleEndian
+		default:
+			return nil, fmt.Errorf("lengthOrder must be big or little")
+		}
+	case FramingDelimiter:
+		if c.Delimiter == "" {
+			return nil, fmt.Errorf("delimiter is required for delimiter framing")
+		}
+		f.delimiter = []byte(c.Delimiter)
+	default:
+		return nil, fmt.Errorf("unsupported framing %s", c.Framing)
+	}
+	return f, nil
+}
+
+// split is a bufio.SplitFunc to read frames from the byte stream
+func (f *framer) split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	switch f.conf.Framing {
+	case FramingNone:
+		return len(data), data, nil
+	case FramingNewline:
+		advance, token, err = bufio.ScanLines(data, atEOF)
+	case FramingDelimiter:
+		if i := bytes.Index(data, f.delimiter); i >= 0 {
+			return i + len(f.delimiter), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+	case FramingFixed:
+		if len(data) >= f.conf.FrameLength {
+			return f.conf.FrameLength, data[:f.conf.FrameLength], nil
+		}
+	case FramingLength:
+		if len(data) < f.conf.LengthSize {
+			break
+		}
+		var l int
+		switch f.conf.LengthSize {
+		case 1:
+			l = int(data[0])
+		case 2:
+			l = int(f.order.Uint16(data))
+		case 4:
+			l = int(f.order.Uint32(data))
+		}
+		if l > f.conf.MaxFrameSize {
+			return 0, nil, fmt.Errorf("frame length %d exceeds maxFrameSize %d", l, f.conf.MaxFrameSize)
+		}
+		end := f.conf.LengthSize + l
+		if len(data) >= end {
+			return end, data[f.conf.LengthSize:end], nil
+		}
+	}
+	return
+}
+
+// splitAll splits a complete message such as a datagram into frames. The incomplete tail is dropped.
+func (f *framer) splitAll(data []byte) ([][]byte, error) {
+	var result [][]byte
+	for len(data) > 0 {
+		advance, token, err := f.split(data, true)
+		if err != nil {
+			return result, err
+		}
+		if advance == 0 {
+			break
+		}
+		if token != nil {
+			result = append(result, token)
+		}
+		data = data[advance:]
+	}
+	return result, nil
+}
+
+// encode wraps the payload into a frame
+func (f *framer) encode(payload []byte) ([]byte, error) {
+	if len(payload) > f.conf.MaxFrameSize {
+		return nil, fmt.Errorf("payload size %d exceeds maxFrameSize %d", len(payload), f.conf.MaxFrameSize)
+	}
+	switch f.conf.Framing {
+	case FramingNewline:
+		return concat(payload, []byte{'\n'}), nil
+	case FramingDelimiter:
+		return concat(payload, f.delimiter), nil
+	case FramingFixed:
+		if len(payload) != f.conf.FrameLength {
+			return nil, fmt.Errorf("payload size %d does not match frameLength %d", len(payload), f.conf.FrameLength)
+		}
+		return payload, nil
+	case FramingLength:
+		l := len(payload)
+		header := make([]byte, f.conf.LengthSize)
+		switch f.conf.LengthSize {
+		case 1:
+			if l > 0xFF {
+				return nil, fmt.Errorf("payload size %d exceeds the max length of 1 byte header", l)
+			}
+			header[0] = byte(l)
+		case 2:
+			if l > 0xFFFF {
+				return nil, fmt.Errorf("payload size %d exceeds the max length of 2 bytes header", l)
+			}
+			f.order.PutUint16(header, uint16(l))
+		case 4:
+			f.order.PutUint32(header, uint32(l))
+		}
+		return concat(header, payload), nil
+	default:
+		return payload, nil
+	}
+}
+
+func concat(a, b []byte) []byte {
+	result := make([]byte, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
+
+// bufferSize is the max buffer size for the scanner to hold a frame
+func (f *framer) bufferSize() int {
+	return f.conf.MaxFrameSize + f.conf.LengthSize + len(f.delimiter) + 2
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraming(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   *FramingConf
+		stream []byte
+		frames [][]byte
+	}{
+		{
+			name:   "newline",
+			conf:   &FramingConf{Framing: FramingNewline},
+			stream: []byte("{\"a\":1}\n{\"a\":2}\n"),
+			frames: [][]byte{[]byte(`{"a":1}`), []byte(`{"a":2}`)},
+		},
+		{
+			name:   "delimiter",
+			conf:   &FramingConf{Framing: FramingDelimiter, Delimiter: "\x03\x04"},
+			stream: []byte("ab\x03\x04cd\x03\x04"),
+			frames: [][]byte{[]byte("ab"), []byte("cd")},
+		},
+		{
+			name:   "fixed",
+			conf:   &FramingConf{Framing: FramingFixed, FrameLength: 3},
+			stream: []byte("abcdef"),
+			frames: [][]byte{[]byte("abc"), []byte("def")},
+		},
+		{
+			name:   "length 1",
+			conf:   &FramingConf{Framing: FramingLength, LengthSize: 1},
+			stream: []byte("\x02ab\x03cde"),
+			frames: [][]byte{[]byte("ab"), []byte("cde")},
+		},
+		{
+			name:   "length 2 big",
+			conf:   &FramingConf{Framing: FramingLength, LengthSize: 2},
+			stream: []byte("\x00\x02ab\x00\x03cde"),
+			frames: [][]byte{[]byte("ab"), []byte("cde")},
+		},
+		{
+			name:   "length 4 little",
+			conf:   &FramingConf{Framing: FramingLength, LengthSize: 4, LengthOrder: "little"},
+			stream: []byte("\x02\x00\x00\x00ab\x03\x00\x00\x00cde"),
+			frames: [][]byte{[]byte("ab"), []byte("cde")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFramer(tt.conf)
+			require.NoError(t, err)
+			// split from a stream
+			scanner := bufio.NewScanner(bytes.NewReader(tt.stream))
+			scanner.Buffer(make([]byte, 0, 2), f.bufferSize())
+			scanner.Split(f.split)
+			var frames [][]byte
+			for scanner.Scan() {
+				frames = append(frames, append([]byte{}, scanner.Bytes()...))
+			}
+			require.NoError(t, scanner.Err())
+			require.Equal(t, tt.frames, frames)
+			// split a datagram
+			frames, err = f.splitAll(tt.stream)
+			require.NoError(t, err)
+			require.Equal(t, tt.frames, frames)
+			// encode
+			var encoded []byte
+			for _, frame := range tt.frames {
+				r, err := f.encode(frame)
+				require.NoError(t, err)
+				encoded = append(encoded, r...)
+			}
+			require.Equal(t, tt.stream, encoded)
+		})
+	}
+}
+
+func TestFramingError(t *testing.T) {
+	tests := []struct {
+		conf *FramingConf
+		err  string
+	}{
+		{
+			conf: &FramingConf{Framing: "json"},
+			err:  "unsupported framing json",
+		},
+		{
+			conf: &FramingConf{Framing: FramingFixed},
+			err:  "frameLength must be between 1 and 65536 for fixed framing",
+		},
+		{
+			conf: &FramingConf{Framing: FramingLength, LengthSize: 3},
+			err:  "lengthSize must be 1, 2 or 4 for length framing",
+		},
+		{
+			conf: &FramingConf{Framing: FramingLength, LengthSize: 2, LengthOrder: "middle"},
+			err:  "lengthOrder must be big or little",
+		},
+		{
+			conf: &FramingConf{Framing: FramingDelimiter},
+			err:  "delimiter is required for delimiter framing",
+		},
+	}
+	for _, tt := range tests {
+		_, err := newFramer(tt.conf)
+		require.EqualError(t, err, tt.err)
+	}
+
+	f, err := newFramer(&FramingConf{Framing: FramingLength, LengthSize: 1, MaxFrameSize: 300})
+	require.NoError(t, err)
+	_, err = f.encode(make([]byte, 256))
+	require.EqualError(t, err, "payload size 256 exceeds the max length of 1 byte header")
+	f, err = newFramer(&FramingConf{Framing: FramingLength, LengthSize: 2, MaxFrameSize: 10})
+	require.NoError(t, err)
+	_, err = f.splitAll([]byte{0, 11})
+	require.EqualError(t, err, "frame length 11 exceeds maxFrameSize 10")
+	f, err = newFramer(&FramingConf{Framing: FramingFixed, FrameLength: 2})
+	require.NoError(t, err)
+	_, err = f.encode([]byte("abc"))
+	require.EqualError(t, err, "payload size 3 does not match frameLength 2")
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+// Sink writes each encoded result as a frame to the socket.
+// In server mode, the frame is sent to all connected clients.
+type Sink struct {
+	conf   *conf
+	framer *framer
+	sch    api.StatusChangeHandler
+
+	mu       sync.Mutex
+	listener net.Listener
+	conn     net.Conn
+	clients  map[net.Conn]struct{}
+	closed   bool
+}
+
+func (s *Sink) Provision(_ api.StreamContext, configs map[string]any) error {
+	c, f, err := parseConf(configs, ModeClient)
+	if err != nil {
+		return err
+	}
+	s.conf = c
+	s.framer = f
+	s.clients = make(map[net.Conn]struct{})
+	return nil
+}
+
+func (s *Sink) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	s.sch = sch
+	var err error
+	if s.conf.Network != "udp" && s.conf.Mode == ModeServer {
+		ctx.GetLogger().Infof("socket sink listening on %s %s", s.conf.Network, s.conf.Address)
+		s.listener, err = net.Listen(s.conf.Network, s.conf.Address)
+		if err == nil {
+			go s.accept(ctx)
+		}
+	} else {
+		ctx.GetLogger().Infof("socket sink connecting to %s %s", s.conf.Network, s.conf.Address)
+		s.conn, err = net.Dial(s.conf.Network, s.conf.Address)
+	}
+	if err != nil {
+		sch(api.ConnectionDisconnected, err.Error())
+		return err
+	}
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *Sink) accept(ctx api.StreamContext) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			ctx.GetLogger().Infof("socket sink stop accepting: %v", err)
+			return
+		}
+		ctx.GetLogger().Infof("socket sink accepted connection from %s", conn.RemoteAddr())
+		s.mu.Lock()
+		if s.closed {
+			_ = conn.Close()
+			s.mu.Unlock()
+			return
+		}
+		s.clients[conn] = struct{}{}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Sink) Collect(ctx api.StreamContext, item api.RawTuple) error {
+	frame, err := s.framer.encode(item.Raw())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		for c := range s.clients {
+			if _, err := c.Write(frame); err != nil {
+				ctx.GetLogger().Infof("socket sink drop client %s: %v", c.RemoteAddr(), err)
+				_ = c.Close()
+				delete(s.clients, c)
+			}
+		}
+		return nil
+	}
+	if s.conn == nil {
+		conn, err := net.Dial(s.conf.Network, s.conf.Address)
+		if err != nil {
+			return errorx.NewIOErr(fmt.Sprintf("reconnect to %s failed: %v", s.conf.Address, err))
+		}
+		s.conn = conn
+		s.sch(api.ConnectionConnected, "")
+	}
+	if _, err := s.conn.Write(frame); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.sch(api.ConnectionDisconnected, err.Error())
+		return errorx.NewIOErr(fmt.Sprintf("write to %s failed: %v", s.conf.Address, err))
+	}
+	ctx.GetLogger().Debugf("socket sink sent %d bytes", len(frame))
+	return nil
+}
+
+func (s *Sink) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing socket sink")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var errs []error
+	if s.listener != nil {
+		errs = append(errs, s.listener.Close())
+	}
+	if s.conn != nil {
+		errs = append(errs, s.conn.Close())
+		s.conn = nil
+	}
+	for c := range s.clients {
+		_ = c.Close()
+		delete(s.clients, c)
+	}
+	return errors.Join(errs...)
+}
+
+func GetSink() api.Sink {
+	return &Sink{}
+}
+
+var _ api.BytesCollector = &Sink{}
@@ -0,0 +1,102 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+func TestTcpClientSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	recv := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b := make([]byte, 9)
+		_, _ = io.ReadFull(conn, b)
+		recv <- b
+	}()
+	ctx := mockContext.NewMockContext("testSocketSink", "op")
+	s := GetSink().(*Sink)
+	require.NoError(t, s.Provision(ctx, map[string]any{
+		"address":     ln.Addr().String(),
+		"framing":     "length",
+		"lengthSize":  1,
+		"lengthOrder": "big",
+	}))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	require.NoError(t, s.Collect(ctx, &xsql.RawTuple{Rawdata: []byte("abcd")}))
+	require.NoError(t, s.Collect(ctx, &xsql.RawTuple{Rawdata: []byte("efg")}))
+	require.Equal(t, []byte("\x04abcd\x03efg"), <-recv)
+	require.NoError(t, s.Close(ctx))
+}
+
+func TestTcpServerSink(t *testing.T) {
+	addr := freeAddr(t)
+	ctx := mockContext.NewMockContext("testSocketSink", "op")
+	s := GetSink().(*Sink)
+	require.NoError(t, s.Provision(ctx, map[string]any{
+		"address": addr,
+		"mode":    "server",
+	}))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.clients) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, s.Collect(ctx, &xsql.RawTuple{Rawdata: []byte(`{"a":1}`)}))
+	b := make([]byte, 8)
+	_, err = io.ReadFull(conn, b)
+	require.NoError(t, err)
+	require.Equal(t, "{\"a\":1}\n", string(b))
+	require.NoError(t, s.Close(ctx))
+}
+
+func TestUdpSink(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+	ctx := mockContext.NewMockContext("testSocketSink", "op")
+	s := GetSink().(*Sink)
+	require.NoError(t, s.Provision(ctx, map[string]any{
+		"address": pc.LocalAddr().String(),
+		"network": "udp",
+	}))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	require.NoError(t, s.Collect(ctx, &xsql.RawTuple{Rawdata: []byte("datagram")}))
+	b := make([]byte, 100)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(b)
+	require.NoError(t, err)
+	require.Equal(t, "datagram", string(b[:n]))
+	require.NoError(t, s.Close(ctx))
+}
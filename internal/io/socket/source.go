@@ -0,0 +1,253 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+// Source receives the frames from a socket. Each frame is decoded by the stream format.
+type Source struct {
+	conf   *conf
+	framer *framer
+	sch    api.StatusChangeHandler
+
+	mu       sync.Mutex
+	listener net.Listener
+	packet   net.PacketConn
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func (s *Source) Provision(_ api.StreamContext, configs map[string]any) error {
+	c, f, err := parseConf(configs, ModeServer)
+	if err != nil {
+		return err
+	}
+	s.conf = c
+	s.framer = f
+	s.conns = make(map[net.Conn]struct{})
+	return nil
+}
+
+func (s *Source) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	s.sch = sch
+	var err error
+	switch {
+	case s.conf.Network == "udp":
+		ctx.GetLogger().Infof("socket source listening on udp %s", s.conf.Address)
+		s.packet, err = net.ListenPacket("udp", s.conf.Address)
+	case s.conf.Mode == ModeServer:
+		ctx.GetLogger().Infof("socket source listening on %s %s", s.conf.Network, s.conf.Address)
+		s.listener, err = net.Listen(s.conf.Network, s.conf.Address)
+	default:
+		ctx.GetLogger().Infof("socket source connecting to %s %s", s.conf.Network, s.conf.Address)
+		var conn net.Conn
+		conn, err = net.Dial(s.conf.Network, s.conf.Address)
+		if err == nil {
+			s.addConn(conn)
+		}
+	}
+	if err != nil {
+		sch(api.ConnectionDisconnected, err.Error())
+		return err
+	}
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *Source) Subscribe(ctx api.StreamContext, ingest api.BytesIngest, ingestError api.ErrorIngest) error {
+	switch {
+	case s.packet != nil:
+		go s.readPackets(ctx, ingest, ingestError)
+	case s.listener != nil:
+		go s.accept(ctx, ingest, ingestError)
+	default:
+		go s.runClient(ctx, ingest, ingestError)
+	}
+	return nil
+}
+
+func (s *Source) accept(ctx api.StreamContext, ingest api.BytesIngest, ingestError api.ErrorIngest) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !s.isClosed() {
+				ctx.GetLogger().Errorf("socket source stop accepting: %v", err)
+				ingestError(ctx, err)
+			}
+			return
+		}
+		ctx.GetLogger().Infof("socket source accepted connection from %s", conn.RemoteAddr())
+		if !s.addConn(conn) {
+			return
+		}
+		go func() {
+			err := s.readFrames(ctx, conn, ingest)
+			s.removeConn(conn)
+			if err != nil && !s.isClosed() {
+				ingestError(ctx, fmt.Errorf("read from %s error: %v", conn.RemoteAddr(), err))
+			}
+		}()
+	}
+}
+
+// runClient reads from the connected socket and reconnects when the connection is broken
+func (s *Source) runClient(ctx api.StreamContext, ingest api.BytesIngest, ingestError api.ErrorIngest) {
+	var conn net.Conn
+	s.mu.Lock()
+	for c := range s.conns {
+		conn = c
+	}
+	s.mu.Unlock()
+	for {
+		if conn != nil {
+			err := s.readFrames(ctx, conn, ingest)
+			s.removeConn(conn)
+			conn = nil
+			if s.isClosed() {
+				return
+			}
+			if err == nil {
+				err = errors.New("connection closed by peer")
+			}
+			s.sch(api.ConnectionDisconnected, err.Error())
+			ingestError(ctx, fmt.Errorf("read from %s error: %v", s.conf.Address, err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(s.conf.ReconnectInterval)):
+		}
+		c, err := net.Dial(s.conf.Network, s.conf.Address)
+		if err != nil {
+			ctx.GetLogger().Debugf("socket source reconnect to %s failed: %v", s.conf.Address, err)
+			continue
+		}
+		if !s.addConn(c) {
+			return
+		}
+		conn = c
+		s.sch(api.ConnectionConnected, "")
+	}
+}
+
+func (s *Source) readFrames(ctx api.StreamContext, conn net.Conn, ingest api.BytesIngest) error {
+	meta := map[string]any{"remoteAddr": remoteAddr(conn.RemoteAddr())}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), s.framer.bufferSize())
+	scanner.Split(s.framer.split)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		frame := scanner.Bytes()
+		payload := make([]byte, len(frame))
+		copy(payload, frame)
+		ingest(ctx, payload, meta, timex.GetNow())
+	}
+	return scanner.Err()
+}
+
+func (s *Source) readPackets(ctx api.StreamContext, ingest api.BytesIngest, ingestError api.ErrorIngest) {
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := s.packet.ReadFrom(buf)
+		if err != nil {
+			if !s.isClosed() {
+				ctx.GetLogger().Errorf("socket source stop reading: %v", err)
+				ingestError(ctx, err)
+			}
+			return
+		}
+		frames, err := s.framer.splitAll(buf[:n])
+		if err != nil {
+			ingestError(ctx, fmt.Errorf("split datagram from %s error: %v", addr, err))
+		}
+		meta := map[string]any{"remoteAddr": remoteAddr(addr)}
+		for _, frame := range frames {
+			payload := make([]byte, len(frame))
+			copy(payload, frame)
+			ingest(ctx, payload, meta, timex.GetNow())
+		}
+	}
+}
+
+func (s *Source) addConn(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		_ = conn.Close()
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Source) removeConn(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = conn.Close()
+	delete(s.conns, conn)
+}
+
+func (s *Source) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Source) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing socket source")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var errs []error
+	if s.listener != nil {
+		errs = append(errs, s.listener.Close())
+	}
+	if s.packet != nil {
+		errs = append(errs, s.packet.Close())
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+		delete(s.conns, conn)
+	}
+	return errors.Join(errs...)
+}
+
+func remoteAddr(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+func GetSource() api.Source {
+	return &Source{}
+}
+
+var _ api.BytesSource = &Source{}
@@ -0,0 +1,181 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package socket
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+type frame struct {
+	payload string
+	addr    string
+}
+
+func runSource(t *testing.T, props map[string]any, send func(), count int) []frame {
+	ctx, cancel := mockContext.NewMockContext("testSocket", "op").WithCancel()
+	defer cancel()
+	s := GetSource().(*Source)
+	require.NoError(t, s.Provision(ctx, props))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	recv := make(chan frame, count)
+	require.NoError(t, s.Subscribe(ctx, func(ctx api.StreamContext, payload []byte, meta map[string]any, ts time.Time) {
+		recv <- frame{payload: string(payload), addr: meta["remoteAddr"].(string)}
+	}, func(ctx api.StreamContext, err error) {}))
+	send()
+	var result []frame
+	timeout := time.After(5 * time.Second)
+	for len(result) < count {
+		select {
+		case f := <-recv:
+			result = append(result, f)
+		case <-timeout:
+			require.Fail(t, "timeout")
+		}
+	}
+	require.NoError(t, s.Close(ctx))
+	return result
+}
+
+func TestProvision(t *testing.T) {
+	tests := []struct {
+		props map[string]any
+		err   string
+	}{
+		{
+			props: map[string]any{},
+			err:   "property address is required",
+		},
+		{
+			props: map[string]any{"address": "127.0.0.1:0", "network": "sctp"},
+			err:   "unsupported network sctp, must be tcp, udp or unix",
+		},
+		{
+			props: map[string]any{"address": "127.0.0.1:0", "mode": "peer"},
+			err:   "mode must be server or client",
+		},
+		{
+			props: map[string]any{"address": "127.0.0.1:0", "framing": "length"},
+			err:   "lengthSize must be 1, 2 or 4 for length framing",
+		},
+	}
+	ctx := mockContext.NewMockContext("testSocket", "op")
+	for _, tt := range tests {
+		require.EqualError(t, GetSource().Provision(ctx, tt.props), tt.err)
+	}
+}
+
+func TestTcpServerSource(t *testing.T) {
+	addr := freeAddr(t)
+	var local string
+	result := runSource(t, map[string]any{
+		"address":    addr,
+		"framing":    "length",
+		"lengthSize": 2,
+	}, func() {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		local = conn.LocalAddr().String()
+		_, _ = conn.Write([]byte("\x00\x07{\"a\":1}\x00\x07{\"a\""))
+		time.Sleep(10 * time.Millisecond)
+		_, _ = conn.Write([]byte(":2}"))
+		time.Sleep(100 * time.Millisecond)
+	}, 2)
+	require.Equal(t, []frame{{`{"a":1}`, local}, {`{"a":2}`, local}}, result)
+}
+
+func TestTcpClientSource(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		// The first connection is closed after sending to test the reconnection
+		for i := 0; i < 2; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("hello\n"))
+			time.Sleep(10 * time.Millisecond)
+			_ = conn.Close()
+		}
+	}()
+	result := runSource(t, map[string]any{
+		"address":           ln.Addr().String(),
+		"mode":              "client",
+		"reconnectInterval": "10ms",
+	}, func() {}, 2)
+	require.Equal(t, []frame{{"hello", ln.Addr().String()}, {"hello", ln.Addr().String()}}, result)
+}
+
+func TestUdpSource(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := pc.LocalAddr().String()
+	require.NoError(t, pc.Close())
+	var local string
+	result := runSource(t, map[string]any{
+		"address": addr,
+		"network": "udp",
+	}, func() {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		local = conn.LocalAddr().String()
+		_, _ = conn.Write([]byte("d1"))
+		_, _ = conn.Write([]byte("d2"))
+	}, 2)
+	require.Equal(t, []frame{{"d1", local}, {"d2", local}}, result)
+}
+
+func TestUnixSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	result := runSource(t, map[string]any{
+		"address":   path,
+		"network":   "unix",
+		"framing":   "delimiter",
+		"delimiter": "|",
+	}, func() {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("a|b|"))
+		time.Sleep(100 * time.Millisecond)
+	}, 2)
+	require.Equal(t, "a", result[0].payload)
+	require.Equal(t, "b", result[1].payload)
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
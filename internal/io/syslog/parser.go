@@ -0,0 +1,270 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported message formats
+const (
+	FormatAuto    = "auto"
+	FormatRFC5424 = "rfc5424"
+	FormatRFC3164 = "rfc3164"
+)
+
+const nilValue = "-"
+
+var (
+	errNoPri = errors.New("message does not start with a valid priority")
+	utf8BOM  = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// parser parses a syslog message into the row. The time source is replaceable for test.
+type parser struct {
+	format string
+	now    func() time.Time
+}
+
+func (p *parser) parse(msg []byte) (map[string]any, error) {
+	msg = bytes.TrimRight(msg, "\r\n\x00")
+	pri, rest, err := parsePri(msg)
+	if err != nil {
+		return nil, err
+	}
+	format := p.format
+	if format == FormatAuto {
+		// RFC5424 has the version right after the priority
+		if len(rest) > 1 && rest[0] >= '1' && rest[0] <= '9' && (rest[1] == ' ' || (len(rest) > 2 && rest[1] >= '0' && rest[1] <= '9' && rest[2] == ' ')) {
+			format = FormatRFC5424
+		} else {
+			format = FormatRFC3164
+		}
+	}
+	result := map[string]any{
+		"priority": int64(pri),
+		"facility": int64(pri / 8),
+		"severity": int64(pri % 8),
+	}
+	if format == FormatRFC5424 {
+		err = parse5424(rest, result)
+	} else {
+		p.parse3164(rest, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parsePri(msg []byte) (int, []byte, error) {
+	if len(msg) < 3 || msg[0] != '<' {
+		return 0, nil, errNoPri
+	}
+	end := bytes.IndexByte(msg[:min(len(msg), 5)], '>')
+	if end < 2 {
+		return 0, nil, errNoPri
+	}
+	pri, err := strconv.Atoi(string(msg[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, nil, errNoPri
+	}
+	return pri, msg[end+1:], nil
+}
+
+// parse5424 parses VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parse5424(msg []byte, result map[string]any) error {
+	fields := make([]string, 6)
+	for i := range fields {
+		sp := bytes.IndexByte(msg, ' ')
+		if sp < 0 {
+			// Tolerate the missing structured data for the last header field
+			if i < 5 {
+				return fmt.Errorf("invalid rfc5424 message: missing header fields")
+			}
+			sp = len(msg)
+		}
+		fields[i] = string(msg[:sp])
+		if sp < len(msg) {
+			msg = msg[sp+1:]
+		} else {
+			msg = msg[sp:]
+		}
+	}
+	version, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return fmt.Errorf("invalid rfc5424 version %s", fields[0])
+	}
+	result["version"] = int64(version)
+	if fields[1] != nilValue {
+		ts, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid rfc5424 timestamp %s", fields[1])
+		}
+		result["timestamp"] = ts
+	}
+	setNilable(result, "hostname", fields[2])
+	setNilable(result, "appName", fields[3])
+	setNilable(result, "procId", fields[4])
+	setNilable(result, "msgId", fields[5])
+	sd, rest, err := parseStructuredData(msg)
+	if err != nil {
+		return err
+	}
+	if sd != nil {
+		result["structuredData"] = sd
+	}
+	if len(rest) > 0 && rest[0] == ' ' {
+		rest = rest[1:]
+	}
+	rest = bytes.TrimPrefix(rest, utf8BOM)
+	result["message"] = string(rest)
+	return nil
+}
+
+// parseStructuredData parses the sd elements like [id param="value"][id2 param="value"] into a map of maps
+func parseStructuredData(msg []byte) (map[string]any, []byte, error) {
+	if len(msg) == 0 {
+		return nil, msg, nil
+	}
+	if msg[0] == '-' {
+		return nil, msg[1:], nil
+	}
+	if msg[0] != '[' {
+		return nil, nil, fmt.Errorf("invalid rfc5424 structured data")
+	}
+	result := make(map[string]any)
+	for len(msg) > 0 && msg[0] == '[' {
+		msg = msg[1:]
+		end := bytes.IndexAny(msg, " ]")
+		if end <= 0 {
+			return nil, nil, fmt.Errorf("invalid rfc5424 structured data: missing sd id")
+		}
+		id := string(msg[:end])
+		msg = msg[end:]
+		params := make(map[string]any)
+		for {
+			if len(msg) == 0 {
+				return nil, nil, fmt.Errorf("invalid rfc5424 structured data: unterminated element %s", id)
+			}
+			if msg[0] == ']' {
+				msg = msg[1:]
+				break
+			}
+			// skip the space
+			msg = msg[1:]
+			eq := bytes.IndexByte(msg, '=')
+			if eq <= 0 || len(msg) < eq+2 || msg[eq+1] != '"' {
+				return nil, nil, fmt.Errorf("invalid rfc5424 structured data: invalid param in element %s", id)
+			}
+			name := string(msg[:eq])
+			msg = msg[eq+2:]
+			var (
+				value   strings.Builder
+				escaped bool
+				closed  bool
+				i       int
+			)
+			for ; i < len(msg); i++ {
+				c := msg[i]
+				if escaped {
+					// Only ", \ and ] are escaped, keep the backslash for others
+					if c != '"' && c != '\\' && c != ']' {
+						value.WriteByte('\\')
+					}
+					value.WriteByte(c)
+					escaped = false
+					continue
+				}
+				if c == '\\' {
+					escaped = true
+					continue
+				}
+				if c == '"' {
+					closed = true
+					break
+				}
+				value.WriteByte(c)
+			}
+			if !closed {
+				return nil, nil, fmt.Errorf("invalid rfc5424 structured data: unterminated param %s", name)
+			}
+			params[name] = value.String()
+			msg = msg[i+1:]
+		}
+		result[id] = params
+	}
+	return result, msg, nil
+}
+
+// parse3164 parses TIMESTAMP SP HOSTNAME SP TAG[PID]: MSG leniently. The parts that cannot be recognized are kept in the message.
+func (p *parser) parse3164(msg []byte, result map[string]any) {
+	const stampLen = len(time.Stamp)
+	if len(msg) >= stampLen {
+		if ts, err := time.ParseInLocation(time.Stamp, string(msg[:stampLen]), time.Local); err == nil {
+			now := p.now()
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// The message is sent in last year
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			result["timestamp"] = ts
+			msg = bytes.TrimPrefix(msg[stampLen:], []byte(" "))
+			if sp := bytes.IndexByte(msg, ' '); sp > 0 {
+				result["hostname"] = string(msg[:sp])
+				msg = msg[sp+1:]
+			}
+		}
+	}
+	// The tag is alphanumeric and terminated by [, : or space
+	end := 0
+	for end < len(msg) && end <= 48 {
+		c := msg[end]
+		if c == '[' || c == ':' || c == ' ' {
+			break
+		}
+		end++
+	}
+	if end > 0 && end < len(msg) && (msg[end] == '[' || msg[end] == ':') {
+		tag := string(msg[:end])
+		rest := msg[end:]
+		var pid string
+		if rest[0] == '[' {
+			if closeIdx := bytes.IndexByte(rest, ']'); closeIdx > 0 {
+				pid = string(rest[1:closeIdx])
+				rest = rest[closeIdx+1:]
+			}
+		}
+		if len(rest) > 0 && rest[0] == ':' {
+			result["appName"] = tag
+			if pid != "" {
+				result["procId"] = pid
+			}
+			msg = bytes.TrimPrefix(rest[1:], []byte(" "))
+		}
+	}
+	result["message"] = string(msg)
+}
+
+func setNilable(result map[string]any, key string, value string) {
+	if value != nilValue && value != "" {
+		result[key] = value
+	}
+}
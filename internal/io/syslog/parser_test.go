@@ -0,0 +1,170 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse5424(t *testing.T) {
+	p := &parser{format: FormatAuto, now: time.Now}
+	tests := []struct {
+		name string
+		msg  string
+		exp  map[string]any
+	}{
+		{
+			name: "no structured data",
+			msg:  "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - \xEF\xBB\xBF'su root' failed for lonvick on /dev/pts/8",
+			exp: map[string]any{
+				"priority":  int64(34),
+				"facility":  int64(4),
+				"severity":  int64(2),
+				"version":   int64(1),
+				"timestamp": time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				"hostname":  "mymachine.example.com",
+				"appName":   "su",
+				"msgId":     "ID47",
+				"message":   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "structured data",
+			msg:  `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication\]" eventID="1011"][examplePriority@32473 class="high"] An application event`,
+			exp: map[string]any{
+				"priority":  int64(165),
+				"facility":  int64(20),
+				"severity":  int64(5),
+				"version":   int64(1),
+				"timestamp": time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				"hostname":  "mymachine.example.com",
+				"appName":   "evntslog",
+				"procId":    "1234",
+				"msgId":     "ID47",
+				"structuredData": map[string]any{
+					"exampleSDID@32473": map[string]any{
+						"iut":         "3",
+						"eventSource": `App"lication]`,
+						"eventID":     "1011",
+					},
+					"examplePriority@32473": map[string]any{
+						"class": "high",
+					},
+				},
+				"message": "An application event",
+			},
+		},
+		{
+			name: "all nil",
+			msg:  "<13>1 - - - - - -",
+			exp: map[string]any{
+				"priority": int64(13),
+				"facility": int64(1),
+				"severity": int64(5),
+				"version":  int64(1),
+				"message":  "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := p.parse([]byte(tt.msg))
+			require.NoError(t, err)
+			if ts, ok := r["timestamp"].(time.Time); ok {
+				require.True(t, tt.exp["timestamp"].(time.Time).Equal(ts))
+				r["timestamp"] = tt.exp["timestamp"]
+			}
+			require.Equal(t, tt.exp, r)
+		})
+	}
+}
+
+func TestParse3164(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+	p := &parser{format: FormatAuto, now: func() time.Time { return now }}
+	tests := []struct {
+		name string
+		msg  string
+		exp  map[string]any
+	}{
+		{
+			name: "full",
+			msg:  "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8\n",
+			exp: map[string]any{
+				"priority":  int64(34),
+				"facility":  int64(4),
+				"severity":  int64(2),
+				"timestamp": time.Date(2023, 10, 11, 22, 14, 15, 0, time.Local),
+				"hostname":  "mymachine",
+				"appName":   "su",
+				"procId":    "123",
+				"message":   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "padded day without pid",
+			msg:  "<13>Jan  1 08:00:00 router1 kernel: link down",
+			exp: map[string]any{
+				"priority":  int64(13),
+				"facility":  int64(1),
+				"severity":  int64(5),
+				"timestamp": time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local),
+				"hostname":  "router1",
+				"appName":   "kernel",
+				"message":   "link down",
+			},
+		},
+		{
+			name: "no header",
+			msg:  "<0>Use the BFG!",
+			exp: map[string]any{
+				"priority": int64(0),
+				"facility": int64(0),
+				"severity": int64(0),
+				"message":  "Use the BFG!",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := p.parse([]byte(tt.msg))
+			require.NoError(t, err)
+			require.Equal(t, tt.exp, r)
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	p := &parser{format: FormatRFC5424, now: time.Now}
+	tests := []struct {
+		msg string
+		err string
+	}{
+		{msg: "no pri", err: "message does not start with a valid priority"},
+		{msg: "<192>1 - - - - - -", err: "message does not start with a valid priority"},
+		{msg: "<13>1 -", err: "invalid rfc5424 message: missing header fields"},
+		{msg: "<13>1 yesterday - - - - -", err: "invalid rfc5424 timestamp yesterday"},
+		{msg: "<13>1 - - - - - [id a=\"1\"", err: "invalid rfc5424 structured data: unterminated element id"},
+		{msg: "<13>1 - - - - - [id a=1]", err: "invalid rfc5424 structured data: invalid param in element id"},
+		{msg: "<13>1 - - - - - msg", err: "invalid rfc5424 structured data"},
+	}
+	for _, tt := range tests {
+		_, err := p.parse([]byte(tt.msg))
+		require.EqualError(t, err, tt.err, tt.msg)
+	}
+}
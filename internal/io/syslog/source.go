@@ -0,0 +1,261 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cert"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+type conf struct {
+	// Network is udp, tcp or tls
+	Network        string `json:"network"`
+	Address        string `json:"address"`
+	Datasource     string `json:"datasource"`
+	Format         string `json:"rfc"`
+	MaxMessageSize int    `json:"maxMessageSize"`
+}
+
+// Source listens for syslog messages and parses each message into a row
+type Source struct {
+	conf      *conf
+	parser    *parser
+	tlsConfig *tls.Config
+
+	mu       sync.Mutex
+	listener net.Listener
+	packet   net.PacketConn
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func (s *Source) Provision(_ api.StreamContext, configs map[string]any) error {
+	c := &conf{Network: "udp", Format: FormatAuto, MaxMessageSize: 8192}
+	if err := cast.MapToStruct(configs, c); err != nil {
+		return fmt.Errorf("read properties %v fail with error: %v", configs, err)
+	}
+	if c.Address == "" {
+		c.Address = c.Datasource
+	}
+	if c.Address == "" {
+		return fmt.Errorf("property address is required")
+	}
+	switch c.Network {
+	case "udp", "tcp":
+	case "tls":
+		tc, err := cert.GenTLSConfig(configs, "syslog")
+		if err != nil {
+			return err
+		}
+		if tc == nil || len(tc.Certificates) == 0 {
+			return fmt.Errorf("certificationPath and privateKeyPath are required for tls")
+		}
+		s.tlsConfig = &tls.Config{
+			Certificates: tc.Certificates,
+			MinVersion:   tc.MinVersion,
+		}
+		// Verify the client certificates if the root ca is specified
+		if tc.RootCAs != nil {
+			s.tlsConfig.ClientCAs = tc.RootCAs
+			s.tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	default:
+		return fmt.Errorf("unsupported network %s, must be udp, tcp or tls", c.Network)
+	}
+	switch c.Format {
+	case FormatAuto, FormatRFC5424, FormatRFC3164:
+	default:
+		return fmt.Errorf("unsupported rfc %s, must be auto, rfc5424 or rfc3164", c.Format)
+	}
+	if c.MaxMessageSize <= 0 {
+		return fmt.Errorf("maxMessageSize must be positive")
+	}
+	s.conf = c
+	s.parser = &parser{format: c.Format, now: timex.GetNow}
+	s.conns = make(map[net.Conn]struct{})
+	return nil
+}
+
+func (s *Source) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	ctx.GetLogger().Infof("syslog source listening on %s %s", s.conf.Network, s.conf.Address)
+	var err error
+	switch s.conf.Network {
+	case "udp":
+		s.packet, err = net.ListenPacket("udp", s.conf.Address)
+	case "tcp":
+		s.listener, err = net.Listen("tcp", s.conf.Address)
+	case "tls":
+		s.listener, err = tls.Listen("tcp", s.conf.Address, s.tlsConfig)
+	}
+	if err != nil {
+		sch(api.ConnectionDisconnected, err.Error())
+		return err
+	}
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *Source) Subscribe(ctx api.StreamContext, ingest api.TupleIngest, ingestError api.ErrorIngest) error {
+	if s.packet != nil {
+		go s.readPackets(ctx, ingest, ingestError)
+	} else {
+		go s.accept(ctx, ingest, ingestError)
+	}
+	return nil
+}
+
+func (s *Source) readPackets(ctx api.StreamContext, ingest api.TupleIngest, ingestError api.ErrorIngest) {
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := s.packet.ReadFrom(buf)
+		if err != nil {
+			if !s.isClosed() {
+				ctx.GetLogger().Errorf("syslog source stop reading: %v", err)
+				ingestError(ctx, err)
+			}
+			return
+		}
+		s.ingestMessage(ctx, buf[:n], addr, ingest, ingestError)
+	}
+}
+
+func (s *Source) accept(ctx api.StreamContext, ingest api.TupleIngest, ingestError api.ErrorIngest) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !s.isClosed() {
+				ctx.GetLogger().Errorf("syslog source stop accepting: %v", err)
+				ingestError(ctx, err)
+			}
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				_ = conn.Close()
+			}()
+			scanner := bufio.NewScanner(conn)
+			scanner.Buffer(make([]byte, 0, 4096), s.conf.MaxMessageSize+16)
+			scanner.Split(splitFrame)
+			for scanner.Scan() {
+				s.ingestMessage(ctx, scanner.Bytes(), conn.RemoteAddr(), ingest, ingestError)
+			}
+			if err := scanner.Err(); err != nil && !s.isClosed() {
+				ingestError(ctx, fmt.Errorf("read from %s error: %v", conn.RemoteAddr(), err))
+			}
+		}()
+	}
+}
+
+func (s *Source) ingestMessage(ctx api.StreamContext, msg []byte, addr net.Addr, ingest api.TupleIngest, ingestError api.ErrorIngest) {
+	if len(bytes.TrimSpace(msg)) == 0 {
+		return
+	}
+	result, err := s.parser.parse(msg)
+	if err != nil {
+		ingestError(ctx, fmt.Errorf("parse syslog message %q error: %v", msg, err))
+		return
+	}
+	meta := map[string]any{}
+	if addr != nil {
+		meta["remoteAddr"] = addr.String()
+	}
+	ingest(ctx, result, meta, timex.GetNow())
+}
+
+// splitFrame splits the tcp stream by octet counting or non-transparent framing with LF (RFC 6587)
+func splitFrame(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	// Octet counting starts with the message length
+	if data[0] >= '1' && data[0] <= '9' {
+		sp := bytes.IndexByte(data, ' ')
+		if sp < 0 {
+			if len(data) > 10 {
+				return 0, nil, errors.New("invalid octet counting frame")
+			}
+			if atEOF {
+				return len(data), nil, nil
+			}
+			return 0, nil, nil
+		}
+		l, err := strconv.Atoi(string(data[:sp]))
+		if err != nil {
+			return 0, nil, errors.New("invalid octet counting frame")
+		}
+		end := sp + 1 + l
+		if len(data) >= end {
+			return end, data[sp+1 : end], nil
+		}
+		if atEOF {
+			return len(data), data[sp+1:], nil
+		}
+		return 0, nil, nil
+	}
+	return bufio.ScanLines(data, atEOF)
+}
+
+func (s *Source) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Source) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Infof("Closing syslog source")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var errs []error
+	if s.listener != nil {
+		errs = append(errs, s.listener.Close())
+	}
+	if s.packet != nil {
+		errs = append(errs, s.packet.Close())
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	return errors.Join(errs...)
+}
+
+func GetSource() api.Source {
+	return &Source{}
+}
+
+var _ api.TupleSource = &Source{}
@@ -0,0 +1,178 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+func runSource(t *testing.T, props map[string]any, dial func(addr string) (net.Conn, error), payload string, count int) []map[string]any {
+	ctx, cancel := mockContext.NewMockContext("testSyslog", "op").WithCancel()
+	defer cancel()
+	s := GetSource().(*Source)
+	require.NoError(t, s.Provision(ctx, props))
+	require.NoError(t, s.Connect(ctx, func(status string, message string) {}))
+	recv := make(chan any, count+1)
+	require.NoError(t, s.Subscribe(ctx, func(ctx api.StreamContext, data any, meta map[string]any, ts time.Time) {
+		r := data.(map[string]any)
+		r["remoteAddr"] = meta["remoteAddr"]
+		recv <- r
+	}, func(ctx api.StreamContext, err error) {
+		recv <- err
+	}))
+	var addr string
+	if s.packet != nil {
+		addr = s.packet.LocalAddr().String()
+	} else {
+		addr = s.listener.Addr().String()
+	}
+	conn, err := dial(addr)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(payload))
+	require.NoError(t, err)
+	var result []map[string]any
+	timeout := time.After(5 * time.Second)
+	for len(result) < count {
+		select {
+		case r := <-recv:
+			if e, ok := r.(error); ok {
+				require.NoError(t, e)
+			}
+			m := r.(map[string]any)
+			require.Equal(t, conn.LocalAddr().String(), m["remoteAddr"])
+			delete(m, "remoteAddr")
+			result = append(result, m)
+		case <-timeout:
+			require.Fail(t, "timeout")
+		}
+	}
+	require.NoError(t, conn.Close())
+	require.NoError(t, s.Close(ctx))
+	return result
+}
+
+func TestProvision(t *testing.T) {
+	tests := []struct {
+		props map[string]any
+		err   string
+	}{
+		{
+			props: map[string]any{},
+			err:   "property address is required",
+		},
+		{
+			props: map[string]any{"address": ":514", "network": "sctp"},
+			err:   "unsupported network sctp, must be udp, tcp or tls",
+		},
+		{
+			props: map[string]any{"address": ":514", "network": "tls"},
+			err:   "certificationPath and privateKeyPath are required for tls",
+		},
+		{
+			props: map[string]any{"address": ":514", "rfc": "rfc5425"},
+			err:   "unsupported rfc rfc5425, must be auto, rfc5424 or rfc3164",
+		},
+	}
+	ctx := mockContext.NewMockContext("testSyslog", "op")
+	for _, tt := range tests {
+		require.EqualError(t, GetSource().Provision(ctx, tt.props), tt.err)
+	}
+}
+
+func TestUdpSource(t *testing.T) {
+	result := runSource(t, map[string]any{
+		"address": "127.0.0.1:0",
+	}, func(addr string) (net.Conn, error) {
+		return net.Dial("udp", addr)
+	}, "<13>1 - host app - - - hello", 1)
+	require.Equal(t, []map[string]any{{
+		"priority": int64(13),
+		"facility": int64(1),
+		"severity": int64(5),
+		"version":  int64(1),
+		"hostname": "host",
+		"appName":  "app",
+		"message":  "hello",
+	}}, result)
+}
+
+func TestTcpSource(t *testing.T) {
+	m1 := "<13>1 - host app - - - hello"
+	m2 := "<13>1 - host app - - - multi\nline"
+	result := runSource(t, map[string]any{
+		"address": "127.0.0.1:0",
+		"network": "tcp",
+	}, func(addr string) (net.Conn, error) {
+		return net.Dial("tcp", addr)
+	}, strconv.Itoa(len(m1))+" "+m1+strconv.Itoa(len(m2))+" "+m2+"<14>plain message\n", 3)
+	require.Equal(t, "hello", result[0]["message"])
+	require.Equal(t, "multi\nline", result[1]["message"])
+	require.Equal(t, "plain message", result[2]["message"])
+	require.Equal(t, int64(6), result[2]["severity"])
+}
+
+func TestTlsSource(t *testing.T) {
+	certPath, keyPath := genCert(t)
+	result := runSource(t, map[string]any{
+		"address":           "127.0.0.1:0",
+		"network":           "tls",
+		"certificationPath": certPath,
+		"privateKeyPath":    keyPath,
+	}, func(addr string) (net.Conn, error) {
+		return tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	}, "<13>Oct 11 22:14:15 host app: secure\n", 1)
+	require.Equal(t, "secure", result[0]["message"])
+	require.Equal(t, "host", result[0]["hostname"])
+}
+
+func genCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certPath, keyPath
+}
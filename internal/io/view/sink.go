@@ -0,0 +1,118 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+)
+
+type config struct {
+	Name string   `json:"name"`
+	Keys []string `json:"keys"`
+}
+
+// sink maintains the rows of a materialized view. The rows are upserted by the key fields.
+// If no key fields are set, the view only keeps the latest result.
+type sink struct {
+	name  string
+	keys  []string
+	store *state.ViewStore
+}
+
+func (s *sink) Provision(_ api.StreamContext, props map[string]any) error {
+	cfg := &config{}
+	err := cast.MapToStruct(props, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Name == "" {
+		return fmt.Errorf("view name is required")
+	}
+	s.name = cfg.Name
+	s.keys = cfg.Keys
+	return nil
+}
+
+func (s *sink) Connect(ctx api.StreamContext, sch api.StatusChangeHandler) error {
+	ctx.GetLogger().Debugf("Opening view sink: %v", s.name)
+	st, err := state.GetViewStore(s.name)
+	if err != nil {
+		return err
+	}
+	s.store = st
+	sch(api.ConnectionConnected, "")
+	return nil
+}
+
+func (s *sink) Collect(ctx api.StreamContext, data api.MessageTuple) error {
+	row := data.ToMap()
+	if len(s.keys) == 0 {
+		return s.store.Replace(map[string]map[string]any{"": row})
+	}
+	key, err := s.key(row)
+	if err != nil {
+		return err
+	}
+	ctx.GetLogger().Debugf("upsert view %s row %s", s.name, key)
+	return s.store.Upsert(key, row)
+}
+
+func (s *sink) CollectList(ctx api.StreamContext, tuples api.MessageTupleList) error {
+	if len(s.keys) == 0 {
+		rows := make(map[string]map[string]any, tuples.Len())
+		tuples.RangeOfTuples(func(index int, tuple api.MessageTuple) bool {
+			rows[fmt.Sprintf("%010d", index)] = tuple.ToMap()
+			return true
+		})
+		return s.store.Replace(rows)
+	}
+	var err error
+	tuples.RangeOfTuples(func(_ int, tuple api.MessageTuple) bool {
+		err = s.Collect(ctx, tuple)
+		return err == nil
+	})
+	return err
+}
+
+func (s *sink) key(row map[string]any) (string, error) {
+	vals := make([]any, len(s.keys))
+	for i, k := range s.keys {
+		v, ok := row[k]
+		if !ok {
+			return "", fmt.Errorf("key field %s not found in data %v", k, row)
+		}
+		vals[i] = v
+	}
+	b, err := json.Marshal(vals)
+	if err != nil {
+		return "", fmt.Errorf("invalid key of data %v: %v", row, err)
+	}
+	return string(b), nil
+}
+
+func (s *sink) Close(ctx api.StreamContext) error {
+	ctx.GetLogger().Debugf("closing view sink")
+	return nil
+}
+
+func GetSink() api.Sink {
+	return &sink{}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view
+
+import (
+	"testing"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+)
+
+func TestViewSink(t *testing.T) {
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(t, err)
+	require.NoError(t, store.SetupDefault(dataDir))
+	defer func() {
+		require.NoError(t, state.DropViewStore("keyed"))
+		require.NoError(t, state.DropViewStore("latest"))
+	}()
+	ctx := mockContext.NewMockContext("rule1", "test")
+	sch := func(status string, message string) {}
+
+	s := GetSink().(api.TupleCollector)
+	require.EqualError(t, s.Provision(ctx, map[string]any{}), "view name is required")
+	require.NoError(t, s.Provision(ctx, map[string]any{"name": "keyed", "keys": []any{"deviceId"}}))
+	require.NoError(t, s.Connect(ctx, sch))
+	require.NoError(t, s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"deviceId": "d1", "temp": 20.0}}))
+	require.NoError(t, s.CollectList(ctx, &xsql.WindowTuples{Content: []xsql.Row{
+		&xsql.Tuple{Message: map[string]any{"deviceId": "d2", "temp": 21.0}},
+		&xsql.Tuple{Message: map[string]any{"deviceId": "d1", "temp": 22.0}},
+	}}))
+	err = s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"temp": 23.0}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "key field deviceId not found")
+	st, err := state.GetViewStore("keyed")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"deviceId": "d1", "temp": 22.0},
+		{"deviceId": "d2", "temp": 21.0},
+	}, st.Rows())
+	require.NoError(t, s.Close(ctx))
+
+	s = GetSink().(api.TupleCollector)
+	require.NoError(t, s.Provision(ctx, map[string]any{"name": "latest"}))
+	require.NoError(t, s.Connect(ctx, sch))
+	require.NoError(t, s.CollectList(ctx, &xsql.WindowTuples{Content: []xsql.Row{
+		&xsql.Tuple{Message: map[string]any{"count": 1}},
+		&xsql.Tuple{Message: map[string]any{"count": 2}},
+	}}))
+	require.NoError(t, s.Collect(ctx, &xsql.Tuple{Message: map[string]any{"count": 3}}))
+	st, err = state.GetViewStore("latest")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"count": 3}}, st.Rows())
+}
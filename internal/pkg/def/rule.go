@@ -42,6 +42,38 @@ type RuleOption struct {
 	CronDatetimeRange    []schedule.DatetimeRange `json:"cronDatetimeRange,omitempty" yaml:"cronDatetimeRange,omitempty"`
 	PlanOptimizeStrategy *PlanOptimizeStrategy    `json:"planOptimizeStrategy,omitempty" yaml:"planOptimizeStrategy,omitempty"`
 	NotifySub            bool                     `json:"notifySub,omitempty" yaml:"notifySub,omitempty"`
+	Backpressure         *BackpressureOption      `json:"backpressure,omitempty" yaml:"backpressure,omitempty"`
+}
+
+const (
+	BackpressureDropOldest  = "dropOldest"
+	BackpressureDropNewest  = "dropNewest"
+	BackpressureBlock       = "block"
+	BackpressureSpillToDisk = "spillToDisk"
+)
+
+// BackpressureOption defines what a node does when the buffer of its downstream node is full
+type BackpressureOption struct {
+	// Policy is the default policy of all nodes. Defaults to dropOldest
+	Policy string `json:"policy,omitempty" yaml:"policy,omitempty"`
+	// Nodes overrides the policy of the outputs of the nodes by node name
+	Nodes map[string]string `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	// MaxSpill is the max count of the spilled messages of each edge for spillToDisk policy
+	MaxSpill int `json:"maxSpill,omitempty" yaml:"maxSpill,omitempty"`
+}
+
+// GetPolicy returns the backpressure policy of the node
+func (b *BackpressureOption) GetPolicy(node string) string {
+	if b == nil {
+		return BackpressureDropOldest
+	}
+	if p, ok := b.Nodes[node]; ok && p != "" {
+		return p
+	}
+	if b.Policy != "" {
+		return b.Policy
+	}
+	return BackpressureDropOldest
 }
 
 type PlanOptimizeStrategy struct {
@@ -38,3 +38,9 @@ type ExportDataDesc struct {
 	Rules    []string
 	FileName string
 }
+
+type ReconcileDesc struct {
+	Path   string
+	Prune  bool
+	DryRun bool
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Buffer accounts the bytes of the messages buffered between the nodes of all rules in a namespace.
+// Each buffer channel is tracked by a BufferQueue which adds the size of the sent message. As the channel is FIFO,
+// the received messages are released from the oldest by comparing the count of the tracked messages with the length
+// of the channel, so the receivers do not need to report.
+type Buffer struct {
+	limit atomic.Int64
+	used  atomic.Int64
+	mu    sync.Mutex
+	// queues are all the tracked channels to release the received messages when checking the limit
+	queues map[*BufferQueue]struct{}
+}
+
+var buffers sync.Map
+
+// BufferOf returns the buffer account of the namespace. The limit is loaded from the quota when it is first used and
+// updated when the quota changes.
+func BufferOf(ns string) *Buffer {
+	if ns == "" {
+		ns = Default
+	}
+	if b, ok := buffers.Load(ns); ok {
+		return b.(*Buffer)
+	}
+	b := &Buffer{queues: make(map[*BufferQueue]struct{})}
+	if n, err := Get(ns); err == nil {
+		b.limit.Store(n.Quota.MaxBufferBytes)
+	}
+	actual, _ := buffers.LoadOrStore(ns, b)
+	return actual.(*Buffer)
+}
+
+// setBufferLimit updates the limit of the namespace if its buffer is used
+func setBufferLimit(ns string, limit int64) {
+	if b, ok := buffers.Load(ns); ok {
+		b.(*Buffer).limit.Store(limit)
+	}
+}
+
+// Limit returns the max bytes of the buffered messages. 0 means unlimited.
+func (b *Buffer) Limit() int64 {
+	return b.limit.Load()
+}
+
+// Used returns the bytes of the buffered messages after releasing the received ones
+func (b *Buffer) Used() int64 {
+	b.release()
+	return b.used.Load()
+}
+
+// Full checks whether buffering a message of the size exceeds the limit. The received messages are released before
+// deciding it is full. A message is always allowed when nothing is buffered, so a message larger than the limit does
+// not block forever.
+func (b *Buffer) Full(size int64) bool {
+	limit := b.limit.Load()
+	if limit <= 0 || b.used.Load()+size <= limit {
+		return false
+	}
+	used := b.Used()
+	return used > 0 && used+size > limit
+}
+
+func (b *Buffer) release() {
+	b.mu.Lock()
+	qs := make([]*BufferQueue, 0, len(b.queues))
+	for q := range b.queues {
+		qs = append(qs, q)
+	}
+	b.mu.Unlock()
+	for _, q := range qs {
+		q.mu.Lock()
+		q.releaseLocked()
+		q.mu.Unlock()
+	}
+}
+
+// NewQueue tracks the messages of a buffer channel. The length function returns the count of the messages in the channel.
+func (b *Buffer) NewQueue(length func() int) *BufferQueue {
+	q := &BufferQueue{b: b, length: length}
+	b.mu.Lock()
+	b.queues[q] = struct{}{}
+	b.mu.Unlock()
+	return q
+}
+
+// BufferQueue is the sizes of the messages in a buffer channel from the oldest to the newest
+type BufferQueue struct {
+	b      *Buffer
+	length func() int
+	mu     sync.Mutex
+	sizes  []int64
+}
+
+// Add records the size of a message which is just sent to the channel
+func (q *BufferQueue) Add(size int64) {
+	q.mu.Lock()
+	q.releaseLocked()
+	q.sizes = append(q.sizes, size)
+	q.mu.Unlock()
+	q.b.used.Add(size)
+}
+
+// releaseLocked releases the oldest messages which are received from the channel
+func (q *BufferQueue) releaseLocked() {
+	n := len(q.sizes) - q.length()
+	if n <= 0 {
+		return
+	}
+	var released int64
+	for _, s := range q.sizes[:n] {
+		released += s
+	}
+	q.sizes = q.sizes[n:]
+	q.b.used.Add(-released)
+}
+
+// Close releases all the messages of the channel and stops tracking it. It is called when the channel is discarded.
+func (q *BufferQueue) Close() {
+	q.b.mu.Lock()
+	delete(q.b.queues, q)
+	q.b.mu.Unlock()
+	q.mu.Lock()
+	var released int64
+	for _, s := range q.sizes {
+		released += s
+	}
+	q.sizes = nil
+	q.mu.Unlock()
+	q.b.used.Add(-released)
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+// nsKV is the view of the keys of a namespace in a shared store
+type nsKV struct {
+	db kv.KeyValue
+	ns string
+}
+
+// KV returns the view of the namespace of the store. The keys are the names without namespace.
+// The view of the default namespace hides the keys of other namespaces.
+func KV(db kv.KeyValue, ns string) kv.KeyValue {
+	if ns == "" {
+		ns = Default
+	}
+	return &nsKV{db: db, ns: ns}
+}
+
+func (n *nsKV) key(name string) string {
+	return Qualify(n.ns, name)
+}
+
+// name returns the name of the key and whether it belongs to the namespace
+func (n *nsKV) name(key string) (string, bool) {
+	ns, name := Split(key)
+	return name, ns == n.ns
+}
+
+func (n *nsKV) Setnx(key string, value interface{}) error {
+	return n.db.Setnx(n.key(key), value)
+}
+
+func (n *nsKV) Set(key string, value interface{}) error {
+	return n.db.Set(n.key(key), value)
+}
+
+func (n *nsKV) Get(key string, val interface{}) (bool, error) {
+	if n.ns == Default && strings.Contains(key, Separator) {
+		return false, nil
+	}
+	return n.db.Get(n.key(key), val)
+}
+
+func (n *nsKV) GetKeyedState(key string) (interface{}, error) {
+	return n.db.GetKeyedState(n.key(key))
+}
+
+func (n *nsKV) SetKeyedState(key string, value interface{}) error {
+	return n.db.SetKeyedState(n.key(key), value)
+}
+
+func (n *nsKV) Delete(key string) error {
+	return n.db.Delete(n.key(key))
+}
+
+func (n *nsKV) Keys() ([]string, error) {
+	keys, err := n.db.Keys()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if name, ok := n.name(k); ok {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+func (n *nsKV) All() (map[string]string, error) {
+	all, err := n.db.All()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(all))
+	for k, v := range all {
+		if name, ok := n.name(k); ok {
+			result[name] = v
+		}
+	}
+	return result, nil
+}
+
+// Clean deletes the keys of the namespace only
+func (n *nsKV) Clean() error {
+	keys, err := n.Keys()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := n.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drop is the same as Clean as the underlying store is shared
+func (n *nsKV) Drop() error {
+	return n.Clean()
+}
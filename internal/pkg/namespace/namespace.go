@@ -0,0 +1,250 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package namespace isolates the streams, rules, connections and uploads of different tenants.
+// The plugins, schemas and the configurations of confKey are global and shared by all namespaces.
+// The resources of a namespace are saved in the same stores as the default namespace with the qualified id `<namespace>/<name>`.
+// Since `/` is not allowed in the names, the qualified ids never conflict with the names of the default namespace.
+package namespace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+	"github.com/lf-edge/ekuiper/v2/pkg/validate"
+)
+
+const (
+	// Default is the namespace of the resources created by the paths without namespace
+	Default   = "default"
+	Separator = "/"
+)
+
+// Qualify returns the global unique id of the resource in the namespace
+func Qualify(ns, name string) string {
+	if ns == "" || ns == Default {
+		return name
+	}
+	return ns + Separator + name
+}
+
+// Split splits the qualified id to the namespace and the name
+func Split(id string) (string, string) {
+	if i := strings.Index(id, Separator); i >= 0 {
+		return id[:i], id[i+1:]
+	}
+	return Default, id
+}
+
+// Of returns the namespace of the qualified id
+func Of(id string) string {
+	ns, _ := Split(id)
+	return ns
+}
+
+// Name returns the name of the qualified id without namespace
+func Name(id string) string {
+	_, name := Split(id)
+	return name
+}
+
+// ValidateName checks the name of a resource in a namespace before it is qualified
+func ValidateName(name string) error {
+	if strings.Contains(name, Separator) {
+		return fmt.Errorf("name %s should not contain %s", name, Separator)
+	}
+	return nil
+}
+
+// Validate checks the namespace name
+func Validate(ns string) error {
+	if ns == "" {
+		return errors.New("namespace name is required")
+	}
+	return validate.ValidateID(ns)
+}
+
+// Quota limits the resources of a namespace. The zero value means unlimited.
+type Quota struct {
+	// MaxRules is the max count of the rules
+	MaxRules int `json:"maxRules,omitempty"`
+	// MaxBufferBytes is the max bytes of the messages buffered between the nodes of all running rules
+	MaxBufferBytes int64 `json:"maxBufferBytes,omitempty"`
+}
+
+type Namespace struct {
+	Name  string `json:"name"`
+	Quota Quota  `json:"quota"`
+}
+
+var (
+	mu sync.Mutex
+	db kv.KeyValue
+	// locks serializes the quota check and the creation of the resources in each namespace
+	locks sync.Map
+)
+
+// Lock locks the namespace until the returned function is called
+func Lock(ns string) func() {
+	l, _ := locks.LoadOrStore(ns, &sync.Mutex{})
+	m := l.(*sync.Mutex)
+	m.Lock()
+	return m.Unlock
+}
+
+func getDb() (kv.KeyValue, error) {
+	if db != nil {
+		return db, nil
+	}
+	d, err := store.GetKV("namespace")
+	if err != nil {
+		return nil, err
+	}
+	db = d
+	return db, nil
+}
+
+// Create saves the namespace. The default namespace always exists, but it can be saved to set the quota.
+func Create(n *Namespace) error {
+	if err := Validate(n.Name); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	d, err := getDb()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if n.Name == Default {
+		err = d.Set(n.Name, string(v))
+	} else if err = d.Setnx(n.Name, string(v)); err != nil {
+		return fmt.Errorf("namespace %s already exists", n.Name)
+	}
+	if err == nil {
+		setBufferLimit(n.Name, n.Quota.MaxBufferBytes)
+	}
+	return err
+}
+
+// Update replaces the quota of the namespace
+func Update(n *Namespace) error {
+	if n.Name != Default {
+		if _, err := Get(n.Name); err != nil {
+			return err
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	d, err := getDb()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if err := d.Set(n.Name, string(v)); err != nil {
+		return err
+	}
+	setBufferLimit(n.Name, n.Quota.MaxBufferBytes)
+	return nil
+}
+
+// Get returns the namespace or a NOT_FOUND error
+func Get(name string) (*Namespace, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	d, err := getDb()
+	if err != nil {
+		return nil, err
+	}
+	var v string
+	ok, _ := d.Get(name, &v)
+	if !ok {
+		if name == Default || name == "" {
+			return &Namespace{Name: Default}, nil
+		}
+		return nil, errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("namespace %s is not found", name))
+	}
+	n := &Namespace{}
+	if err := json.Unmarshal([]byte(v), n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// List returns all namespaces including the default one sorted by name
+func List() ([]*Namespace, error) {
+	mu.Lock()
+	d, err := getDb()
+	mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := d.Keys()
+	if err != nil {
+		return nil, err
+	}
+	hasDefault := false
+	for _, k := range keys {
+		if k == Default {
+			hasDefault = true
+		}
+	}
+	if !hasDefault {
+		keys = append(keys, Default)
+	}
+	sort.Strings(keys)
+	result := make([]*Namespace, 0, len(keys))
+	for _, k := range keys {
+		n, err := Get(k)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// Delete removes the namespace definition. The caller must make sure the namespace is empty.
+func Delete(name string) error {
+	if name == Default {
+		return errors.New("the default namespace cannot be deleted")
+	}
+	if _, err := Get(name); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	d, err := getDb()
+	if err != nil {
+		return err
+	}
+	if err := d.Delete(name); err != nil {
+		return err
+	}
+	setBufferLimit(name, 0)
+	return nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/testx"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+func init() {
+	testx.InitEnv("namespace")
+}
+
+func TestQualify(t *testing.T) {
+	tests := []struct {
+		ns   string
+		name string
+		id   string
+	}{
+		{ns: "", name: "r1", id: "r1"},
+		{ns: Default, name: "r1", id: "r1"},
+		{ns: "tenant1", name: "r1", id: "tenant1/r1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			id := Qualify(tt.ns, tt.name)
+			assert.Equal(t, tt.id, id)
+			assert.Equal(t, tt.name, Name(id))
+			if tt.ns == "" {
+				assert.Equal(t, Default, Of(id))
+			} else {
+				assert.Equal(t, tt.ns, Of(id))
+			}
+		})
+	}
+	assert.Error(t, Validate(""))
+	assert.Error(t, Validate("a/b"))
+	assert.NoError(t, Validate("tenant1"))
+}
+
+func TestKV(t *testing.T) {
+	db, err := store.GetKV("nstest")
+	require.NoError(t, err)
+	require.NoError(t, db.Clean())
+	defer db.Clean()
+
+	def := KV(db, Default)
+	t1 := KV(db, "t1")
+	t2 := KV(db, "t2")
+	require.NoError(t, def.Setnx("s1", "default"))
+	require.NoError(t, t1.Setnx("s1", "t1"))
+	require.NoError(t, t1.Setnx("s2", "t1"))
+	require.Error(t, t1.Setnx("s1", "again"))
+
+	var v string
+	ok, err := t1.Get("s1", &v)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "t1", v)
+	ok, _ = def.Get("s1", &v)
+	require.True(t, ok)
+	assert.Equal(t, "default", v)
+	// the default view cannot see other namespaces by the qualified id
+	ok, _ = def.Get("t1/s1", &v)
+	assert.False(t, ok)
+	ok, _ = t2.Get("s1", &v)
+	assert.False(t, ok)
+
+	keys, err := t1.Keys()
+	require.NoError(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"s1", "s2"}, keys)
+	keys, err = def.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1"}, keys)
+	all, err := db.All()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"s1": "default", "t1/s1": "t1", "t1/s2": "t1"}, all)
+
+	require.NoError(t, t1.Clean())
+	all, err = db.All()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"s1": "default"}, all)
+}
+
+func TestCRUD(t *testing.T) {
+	d, err := getDb()
+	require.NoError(t, err)
+	require.NoError(t, d.Clean())
+	defer d.Clean()
+
+	n, err := Get(Default)
+	require.NoError(t, err)
+	assert.Equal(t, &Namespace{Name: Default}, n)
+	_, err = Get("t1")
+	require.Error(t, err)
+	assert.Equal(t, errorx.NOT_FOUND, err.(errorx.ErrorWithCode).Code())
+
+	require.NoError(t, Create(&Namespace{Name: "t1", Quota: Quota{MaxRules: 2}}))
+	require.Error(t, Create(&Namespace{Name: "t1"}))
+	require.Error(t, Create(&Namespace{Name: "a/b"}))
+	n, err = Get("t1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n.Quota.MaxRules)
+
+	require.NoError(t, Update(&Namespace{Name: "t1", Quota: Quota{MaxRules: 5, MaxBufferBytes: 2048}}))
+	n, err = Get("t1")
+	require.NoError(t, err)
+	assert.Equal(t, Quota{MaxRules: 5, MaxBufferBytes: 2048}, n.Quota)
+	require.Error(t, Update(&Namespace{Name: "t2"}))
+
+	list, err := List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, Default, list[0].Name)
+	assert.Equal(t, "t1", list[1].Name)
+
+	require.Error(t, Delete(Default))
+	require.NoError(t, Delete("t1"))
+	_, err = Get("t1")
+	require.Error(t, err)
+}
+
+func TestValidateName(t *testing.T) {
+	require.NoError(t, ValidateName("demo"))
+	require.Error(t, ValidateName("t1/demo"))
+}
+
+func TestLock(t *testing.T) {
+	var (
+		wg    sync.WaitGroup
+		count int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := Lock("t1")
+			defer unlock()
+			// check then act
+			if count < 5 {
+				c := count
+				time.Sleep(time.Millisecond)
+				count = c + 1
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 5, count)
+}
+
+func TestBuffer(t *testing.T) {
+	require.NoError(t, Create(&Namespace{Name: "tb", Quota: Quota{MaxBufferBytes: 100}}))
+	defer func() {
+		_ = Delete("tb")
+	}()
+	b := BufferOf("tb")
+	assert.Equal(t, int64(100), b.Limit())
+	ch := make(chan int, 10)
+	q := b.NewQueue(func() int { return len(ch) })
+	// A message is always allowed when nothing is buffered
+	assert.False(t, b.Full(200))
+	ch <- 1
+	q.Add(60)
+	ch <- 2
+	q.Add(30)
+	assert.Equal(t, int64(90), b.Used())
+	assert.True(t, b.Full(20))
+	assert.False(t, b.Full(10))
+	// The oldest one is received
+	<-ch
+	assert.Equal(t, int64(30), b.Used())
+	assert.False(t, b.Full(20))
+	// Other queues of the namespace share the limit
+	ch2 := make(chan int, 10)
+	q2 := BufferOf("tb").NewQueue(func() int { return len(ch2) })
+	ch2 <- 1
+	q2.Add(50)
+	assert.True(t, b.Full(30))
+	q2.Close()
+	assert.Equal(t, int64(30), b.Used())
+
+	require.NoError(t, Update(&Namespace{Name: "tb", Quota: Quota{MaxBufferBytes: 20}}))
+	assert.Equal(t, int64(20), b.Limit())
+	assert.True(t, b.Full(1))
+	q.Close()
+	assert.Equal(t, int64(0), b.Used())
+	// Unlimited
+	require.NoError(t, Update(&Namespace{Name: "tb"}))
+	assert.False(t, b.Full(1000))
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secret resolves the secret references like {{secret "mqtt_pwd"}} in the configurations by the providers.
+package secret
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultProvider is the provider of the references without a provider prefix. It is the secret store of eKuiper.
+const DefaultProvider = "store"
+
+// Provider gets the secret values by name
+type Provider interface {
+	// Get returns the value of the secret. It returns an error if the secret is not found.
+	Get(name string) (string, error)
+}
+
+var (
+	providers = map[string]Provider{DefaultProvider: defaultStore}
+	lock      sync.RWMutex
+	// refRegex matches {{secret "name"}} or {{secret "provider:name"}}
+	refRegex = regexp.MustCompile(`{{\s*secret\s+"([^"]*)"\s*}}`)
+)
+
+// RegisterProvider registers the provider to resolve the references like {{secret "name:path"}}.
+func RegisterProvider(name string, p Provider) {
+	lock.Lock()
+	defer lock.Unlock()
+	providers[name] = p
+}
+
+// HasRef returns whether the string refers to any secret
+func HasRef(s string) bool {
+	return refRegex.MatchString(s)
+}
+
+// Resolve returns a copy of the props with the secret references replaced by the values. The props are not
+// changed, so the definitions and the exports keep the references.
+func Resolve(props map[string]any) (map[string]any, error) {
+	if props == nil {
+		return nil, nil
+	}
+	r, err := resolveValue(props)
+	if err != nil {
+		return nil, err
+	}
+	return r.(map[string]any), nil
+}
+
+func resolveValue(v any) (any, error) {
+	switch vt := v.(type) {
+	case string:
+		return resolveString(vt)
+	case map[string]any:
+		result := make(map[string]any, len(vt))
+		for k, val := range vt {
+			r, err := resolveValue(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			result[k] = r
+		}
+		return result, nil
+	case []map[string]any:
+		result := make([]map[string]any, len(vt))
+		for i, val := range vt {
+			r, err := resolveValue(val)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = r.(map[string]any)
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(vt))
+		for i, val := range vt {
+			r, err := resolveValue(val)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = r
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+func resolveString(s string) (string, error) {
+	if !HasRef(s) {
+		return s, nil
+	}
+	var err error
+	result := refRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		var v string
+		v, err = lookup(refRegex.FindStringSubmatch(ref)[1])
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func lookup(ref string) (string, error) {
+	pn, name := DefaultProvider, ref
+	if i := strings.Index(ref, ":"); i > 0 {
+		pn, name = ref[:i], ref[i+1:]
+	}
+	lock.RLock()
+	p, ok := providers[pn]
+	lock.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("secret provider %s is not found", pn)
+	}
+	v, err := p.Get(name)
+	if err != nil {
+		return "", fmt.Errorf("get secret %s error: %v", ref, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/testx"
+)
+
+func init() {
+	testx.InitEnv("secret")
+}
+
+type mockProvider map[string]string
+
+func (m mockProvider) Get(name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+func TestResolve(t *testing.T) {
+	RegisterProvider("mock", mockProvider{"pwd": "p@ss", "token": "abc"})
+	props := map[string]any{
+		"server":   "tcp://127.0.0.1:1883",
+		"password": `{{secret "mock:pwd"}}`,
+		"qos":      1,
+		"headers": map[string]any{
+			"Authorization": `Bearer {{ secret "mock:token" }}`,
+			"topic":         "{{.topic}}",
+		},
+		"fields": []any{`{{secret "mock:pwd"}}`, 2},
+		"tables": []map[string]any{{"pwd": `{{secret "mock:pwd"}}`}},
+	}
+	r, err := Resolve(props)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"server":   "tcp://127.0.0.1:1883",
+		"password": "p@ss",
+		"qos":      1,
+		"headers": map[string]any{
+			"Authorization": "Bearer abc",
+			"topic":         "{{.topic}}",
+		},
+		"fields": []any{"p@ss", 2},
+		"tables": []map[string]any{{"pwd": "p@ss"}},
+	}, r)
+	// The props keep the references
+	require.Equal(t, `{{secret "mock:pwd"}}`, props["password"])
+	require.Equal(t, `Bearer {{ secret "mock:token" }}`, props["headers"].(map[string]any)["Authorization"])
+
+	_, err = Resolve(map[string]any{"password": `{{secret "none:pwd"}}`})
+	require.EqualError(t, err, "password: secret provider none is not found")
+	_, err = Resolve(map[string]any{"password": `{{secret "mock:none"}}`})
+	require.EqualError(t, err, "password: get secret mock:none error: not found")
+	r, err = Resolve(nil)
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func TestStore(t *testing.T) {
+	require.NoError(t, Create("mqtt_pwd", "secret1"))
+	defer func() {
+		_ = Delete("mqtt_pwd")
+	}()
+	require.Error(t, Create("mqtt_pwd", "secret2"))
+	require.EqualError(t, Create("mqtt:pwd", "secret2"), `invalid secret name "mqtt:pwd", only letters, digits, '_', '.' and '-' are allowed`)
+	r, err := Resolve(map[string]any{"password": `{{secret "mqtt_pwd"}}`})
+	require.NoError(t, err)
+	require.Equal(t, "secret1", r["password"])
+
+	// The value is not saved in plain text
+	var b []byte
+	found, err := defaultStore.kv.Get("mqtt_pwd", &b)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.NotContains(t, string(b), "secret1")
+
+	require.NoError(t, Set("mqtt_pwd", "secret2"))
+	require.NoError(t, Set("http.token", "t"))
+	defer func() {
+		_ = Delete("http.token")
+	}()
+	r, err = Resolve(map[string]any{"password": `{{secret "store:mqtt_pwd"}}`})
+	require.NoError(t, err)
+	require.Equal(t, "secret2", r["password"])
+	names, err := List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"http.token", "mqtt_pwd"}, names)
+
+	// The value cannot be moved to another name
+	require.NoError(t, defaultStore.kv.Set("http.token", b))
+	_, err = defaultStore.Get("http.token")
+	require.Error(t, err)
+
+	require.NoError(t, Delete("mqtt_pwd"))
+	require.Error(t, Delete("mqtt_pwd"))
+	_, err = Resolve(map[string]any{"password": `{{secret "mqtt_pwd"}}`})
+	require.EqualError(t, err, "password: get secret mqtt_pwd error: secret mqtt_pwd is not found")
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/encryption"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+const (
+	// KeyEnv is the environment variable of the base64 encoded AES key to encrypt the secrets
+	KeyEnv = "KUIPER_SECRET_KEY"
+	// KeyFile is the key file in the data folder. It is generated if KeyEnv is not set.
+	KeyFile = "secret.key"
+	table   = "secret"
+)
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// secretStore saves the secrets in the metadata store. The values are encrypted by the key, so they are never saved
+// in plain text even if the store encryption is disabled.
+type secretStore struct {
+	mu   sync.Mutex
+	kv   kv.KeyValue
+	aead cipher.AEAD
+}
+
+var defaultStore = &secretStore{}
+
+// Create saves a new secret. It returns an error if the secret exists.
+func Create(name, value string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := defaultStore.init(); err != nil {
+		return err
+	}
+	b, err := defaultStore.encrypt(name, value)
+	if err != nil {
+		return err
+	}
+	return defaultStore.kv.Setnx(name, b)
+}
+
+// Set creates the secret or replaces its value
+func Set(name, value string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := defaultStore.init(); err != nil {
+		return err
+	}
+	b, err := defaultStore.encrypt(name, value)
+	if err != nil {
+		return err
+	}
+	return defaultStore.kv.Set(name, b)
+}
+
+// Delete removes the secret. The rules referring to it fail to start after that.
+func Delete(name string) error {
+	if err := defaultStore.init(); err != nil {
+		return err
+	}
+	return defaultStore.kv.Delete(name)
+}
+
+// List returns the sorted names of the secrets. The values are write only and never returned.
+func List() ([]string, error) {
+	if err := defaultStore.init(); err != nil {
+		return nil, err
+	}
+	names, err := defaultStore.kv.Keys()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *secretStore) Get(name string) (string, error) {
+	if err := s.init(); err != nil {
+		return "", err
+	}
+	var b []byte
+	found, err := s.kv.Get(name, &b)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("secret %s is not found", name))
+	}
+	return s.decrypt(name, b)
+}
+
+// init opens the store and loads the key lazily, so the secrets work once the store is set up
+func (s *secretStore) init() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.kv != nil {
+		return nil
+	}
+	dataDir, err := conf.GetDataLoc()
+	if err != nil {
+		return err
+	}
+	key, err := loadKey(filepath.Join(dataDir, KeyFile))
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	a, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	st, err := store.GetKV(table)
+	if err != nil {
+		return err
+	}
+	s.aead = a
+	s.kv = st
+	return nil
+}
+
+// encrypt returns nonce + sealed value. The name is the additional data, so the value cannot be moved to another name.
+func (s *secretStore) encrypt(name, value string) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(value)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, []byte(value), []byte(name)), nil
+}
+
+func (s *secretStore) decrypt(name string, data []byte) (string, error) {
+	if len(data) < s.aead.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	plain, err := s.aead.Open(nil, data[:s.aead.NonceSize()], data[s.aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret %s error, is the secret key changed? %v", name, err)
+	}
+	return string(plain), nil
+}
+
+// loadKey reads the key from KeyEnv or the key file. If neither exists, a random key is generated into the file.
+func loadKey(file string) ([]byte, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) && os.Getenv(KeyEnv) == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, []byte(base64.StdEncoding.EncodeToString(key)), 0o600); err != nil {
+			return nil, fmt.Errorf("create secret key file %s error: %v", file, err)
+		}
+	}
+	return encryption.LoadKey(KeyEnv, file)
+}
+
+func validateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q, only letters, digits, '_', '.' and '-' are allowed", name)
+	}
+	return nil
+}
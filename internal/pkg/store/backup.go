@@ -0,0 +1,99 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/filex"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+)
+
+// Snapshot writes a copy of the metadata, cache and external state stores into the dir while they are being written.
+// The writes of all the databases are paused together, so the copies are consistent with each other such as a rule
+// and its checkpoint. It returns the relative paths of the copies. The remote stores like redis are not supported and
+// must be backed up by their own tools.
+func Snapshot(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	var (
+		paths []string
+		dbs   = make(map[string]definition.Snapshotter)
+	)
+	for _, s := range []*stores{globalStores, cacheStores, extStateStores} {
+		if s == nil {
+			return nil, fmt.Errorf("stores are not initialized")
+		}
+		var sn definition.Snapshotter
+		if sb, ok := s.kvBuilder.(definition.SnapshotBuilder); ok {
+			sn = sb.Snapshotter()
+		}
+		if sn == nil {
+			return nil, fmt.Errorf("the store of %s does not support backup, please back up it with its own tool", s.name)
+		}
+		p := sn.SnapshotPath()
+		if _, ok := dbs[p]; !ok {
+			dbs[p] = sn
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range paths {
+		resume := dbs[p].Pause()
+		defer resume()
+	}
+	for _, p := range paths {
+		if err := dbs[p].Snapshot(dir); err != nil {
+			return nil, fmt.Errorf("snapshot the store %s error: %v", p, err)
+		}
+	}
+	return paths, nil
+}
+
+// RestoreSnapshot moves the copies created by Snapshot in the dir to the store locations. It must run before the
+// stores are set up. The copies which are moved already are skipped, so it can be run again if interrupted.
+func RestoreSnapshot(sc *StoreConf, dir string, paths []string) error {
+	for _, p := range paths {
+		src := filepath.Join(dir, p)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		var target string
+		if strings.HasPrefix(filepath.ToSlash(p), "pebble/") {
+			target = filepath.Join(sc.PebbleConfig.Path, p)
+		} else {
+			target = filepath.Join(sc.SqliteConfig.Path, p)
+			// Remove the write ahead log of the old database
+			for _, suffix := range []string{"-wal", "-shm"} {
+				if err := os.RemoveAll(target + suffix); err != nil {
+					return err
+				}
+			}
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		if err := filex.Move(src, target); err != nil {
+			return fmt.Errorf("restore store %s error: %v", p, err)
+		}
+	}
+	return nil
+}
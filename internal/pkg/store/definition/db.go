@@ -21,12 +21,30 @@ type Database interface {
 	Disconnect() error
 }
 
+// SnapshotBuilder is implemented by the store builders of the local databases which support backup
+type SnapshotBuilder interface {
+	// Snapshotter returns the database to back up or nil if the database does not support it
+	Snapshotter() Snapshotter
+}
+
+// Snapshotter writes a copy of the database for backup
+type Snapshotter interface {
+	// SnapshotPath is the relative path of the copy. The stores sharing the same database have the same path.
+	SnapshotPath() string
+	// Pause blocks the writes of the database until resume is called
+	Pause() (resume func())
+	// Snapshot writes a copy of the paused database into the dir
+	Snapshot(dir string) error
+}
+
 type Config struct {
 	Type         string
 	ExtStateType string
 	Redis        RedisConfig
 	Sqlite       SqliteConfig
 	Fdb          FdbConfig
+	Pebble       PebbleConfig
+	Encryption   EncryptionConfig
 }
 
 type RedisConfig struct {
@@ -41,6 +59,35 @@ type SqliteConfig struct {
 	Name string
 }
 
+// PebbleConfig is the config of the embedded LSM store. The zero values use the defaults of pebble.
+type PebbleConfig struct {
+	Path string
+	// MemTableSize is the size in bytes of a memtable before flushing to L0
+	MemTableSize int64
+	// CacheSize is the size in bytes of the block cache
+	CacheSize int64
+	// L0CompactionThreshold is the count of L0 files to trigger a compaction
+	L0CompactionThreshold int
+	// L0StopWritesThreshold is the count of L0 files to stop writes until the compaction catches up
+	L0StopWritesThreshold int
+	// MaxConcurrentCompactions is the max count of concurrent compactions
+	MaxConcurrentCompactions int
+	// Sync decides whether to sync the WAL on each write. It trades durability for the write throughput.
+	Sync bool
+}
+
+// EncryptionConfig is the config of the encryption at rest for all the stores. The master key is read from the
+// environment variable or the key file, never from the config file.
+type EncryptionConfig struct {
+	Enable bool
+	// Path is the directory of the keyring
+	Path            string
+	KeyFile         string
+	PreviousKeyFile string
+	// RotationInterval is the interval to rotate the data key. 0 means never rotate.
+	RotationInterval time.Duration
+}
+
 type FdbConfig struct {
 	Path       string
 	APIVersion int
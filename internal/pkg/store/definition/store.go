@@ -25,3 +25,11 @@ type StoreBuilder interface {
 type TsBuilder interface {
 	CreateTs(table string) (kv.Tskv, error)
 }
+
+// Reencrypter is implemented by the store builders which can rewrite the values of all the kv and ts tables in the
+// database, including the tables which are not opened.
+type Reencrypter interface {
+	// Reencrypt encrypts the values which are plaintext or encrypted by the old keys with the current key.
+	// It returns the count of the rewritten values.
+	Reencrypt() (int, error)
+}
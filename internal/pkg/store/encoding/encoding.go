@@ -1,4 +1,4 @@
-// Copyright 2021-2023 EMQ Technologies Co., Ltd.
+// Copyright 2021-2024 EMQ Technologies Co., Ltd.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -17,13 +17,113 @@ package encoding
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
+	"sync/atomic"
 )
 
+// EncryptedPrefix is the prefix of the encrypted value. A gob stream never starts with 0x00.
+var EncryptedPrefix = []byte{0x00, 'E', 'K'}
+
+// Cipher encrypts the encoded values of all the stores transparently
+type Cipher interface {
+	Encrypt(plain []byte) ([]byte, error)
+	// Decrypt returns the data as is if it is not encrypted, which is written before the encryption is enabled
+	Decrypt(data []byte) ([]byte, error)
+	// IsStale returns true if the data is not encrypted by the current key
+	IsStale(data []byte) bool
+}
+
+type cipherHolder struct {
+	c Cipher
+}
+
+var currentCipher atomic.Pointer[cipherHolder]
+
+// SetCipher sets the cipher for all the stores. Set nil to disable the encryption.
+func SetCipher(c Cipher) {
+	currentCipher.Store(&cipherHolder{c: c})
+}
+
+// Enabled returns true if the values are encrypted
+func Enabled() bool {
+	return getCipher() != nil
+}
+
+func getCipher() Cipher {
+	if h := currentCipher.Load(); h != nil {
+		return h.c
+	}
+	return nil
+}
+
+// Raw is the gob encoded value without decoding. It is used to re-encrypt the values without knowing the type.
+type Raw struct {
+	Data []byte
+	// Stale is true if the value is not encrypted by the current key
+	Stale bool
+}
+
 func Encode(value interface{}) ([]byte, error) {
+	var b []byte
+	if r, ok := value.(*Raw); ok {
+		b = r.Data
+	} else {
+		var err error
+		b, err = EncodePlain(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c := getCipher(); c != nil {
+		return c.Encrypt(b)
+	}
+	return b, nil
+}
+
+// EncodePlain encodes the value without encryption. It is used for the values which are read by the external tools.
+func EncodePlain(value interface{}) ([]byte, error) {
 	var buff bytes.Buffer
-	enc := gob.NewEncoder(&buff)
-	if err := enc.Encode(value); err != nil {
+	if err := gob.NewEncoder(&buff).Encode(value); err != nil {
 		return nil, err
 	}
 	return buff.Bytes(), nil
 }
+
+// Decode decodes the value encoded by Encode into the pointer
+func Decode(data []byte, value interface{}) error {
+	stale := false
+	if c := getCipher(); c != nil {
+		stale = c.IsStale(data)
+		var err error
+		data, err = c.Decrypt(data)
+		if err != nil {
+			return err
+		}
+	} else if bytes.HasPrefix(data, EncryptedPrefix) {
+		return fmt.Errorf("the value is encrypted, please enable the store encryption")
+	}
+	if r, ok := value.(*Raw); ok {
+		r.Data = data
+		r.Stale = stale
+		return nil
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
+
+// Reencrypt encrypts the encoded value with the current key if it is plaintext or encrypted by an old key.
+// It returns false if the value is up to date or the encryption is disabled.
+func Reencrypt(data []byte) ([]byte, bool, error) {
+	c := getCipher()
+	if c == nil || !c.IsStale(data) {
+		return nil, false, nil
+	}
+	plain, err := c.Decrypt(data)
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := c.Encrypt(plain)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
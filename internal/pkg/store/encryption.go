@@ -0,0 +1,145 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf/logger"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/encryption"
+)
+
+var (
+	keyring      *encryption.Keyring
+	stopRotation context.CancelFunc
+)
+
+func setupEncryption(c definition.EncryptionConfig) error {
+	if stopRotation != nil {
+		stopRotation()
+		stopRotation = nil
+	}
+	keyring = nil
+	if !c.Enable {
+		kvEncoding.SetCipher(nil)
+		return nil
+	}
+	master, err := encryption.LoadKey(encryption.KeyEnv, c.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load the store encryption key error: %v", err)
+	}
+	if master == nil {
+		return fmt.Errorf("the store encryption is enabled but the key is not set by the environment variable %s or the key file", encryption.KeyEnv)
+	}
+	previous, err := encryption.LoadKey(encryption.PreviousKeyEnv, c.PreviousKeyFile)
+	if err != nil {
+		return fmt.Errorf("load the previous store encryption key error: %v", err)
+	}
+	k, err := encryption.OpenKeyring(c.Path, master, previous)
+	if err != nil {
+		return err
+	}
+	kvEncoding.SetCipher(k)
+	keyring = k
+	logger.Log.Infof("store encryption is enabled with data key %d", k.CurrentKeyId())
+	if c.RotationInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		stopRotation = cancel
+		go rotate(ctx, k, c.RotationInterval)
+	}
+	return nil
+}
+
+// rotate rotates the data key by the interval and re-encrypts all the stores in the background
+func rotate(ctx context.Context, k *encryption.Keyring, interval time.Duration) {
+	for {
+		next := time.Until(k.RotatedAt().Add(interval))
+		if next < 0 {
+			next = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next):
+		}
+		if err := k.Rotate(); err != nil {
+			logger.Log.Errorf("rotate the store encryption key error: %v", err)
+			// retry in the next interval
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			continue
+		}
+		logger.Log.Infof("rotated the store encryption key to %d", k.CurrentKeyId())
+		reencryptStores(k)
+	}
+}
+
+var reencryptMu sync.Mutex
+
+// reencryptStores re-encrypts all the tables of the kv and cache stores with the current key. The old keys are
+// retired from the keyring once all the values are re-encrypted. The ext state is not touched because it is
+// kept in plaintext for the external tools.
+func reencryptStores(k *encryption.Keyring) {
+	reencryptMu.Lock()
+	defer reencryptMu.Unlock()
+	done := true
+	for _, s := range []*stores{globalStores, cacheStores} {
+		if s != nil && !s.reencryptAll() {
+			done = false
+		}
+	}
+	if !done {
+		logger.Log.Warnf("the store is not fully re-encrypted, the old encryption keys are kept")
+		return
+	}
+	if n, err := k.Retire(); err != nil {
+		logger.Log.Errorf("retire the old store encryption keys error: %v", err)
+	} else if n > 0 {
+		logger.Log.Infof("retired %d old store encryption keys", n)
+	}
+}
+
+// maxReencryptPasses limits the passes to re-encrypt the values written with an old key during the previous pass
+const maxReencryptPasses = 3
+
+// reencryptAll re-encrypts every table in the database of the stores including the tables which are not opened.
+// It returns true if no stale value is left.
+func (s *stores) reencryptAll() bool {
+	r, ok := s.kvBuilder.(definition.Reencrypter)
+	if !ok {
+		logger.Log.Warnf("the store %s does not support re-encryption", s.name)
+		return false
+	}
+	for i := 0; i < maxReencryptPasses; i++ {
+		n, err := r.Reencrypt()
+		if err != nil {
+			logger.Log.Warnf("re-encrypt store %s error: %v", s.name, err)
+			return false
+		}
+		if n == 0 {
+			return true
+		}
+		logger.Log.Infof("re-encrypted %d values of store %s", n, s.name)
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+)
+
+const (
+	// KeyEnv is the environment variable of the base64 encoded master key. It has priority over the key file.
+	KeyEnv = "KUIPER_STORE_KEY"
+	// PreviousKeyEnv is the environment variable of the previous master key to rotate the master key
+	PreviousKeyEnv = "KUIPER_STORE_PREVIOUS_KEY"
+	// KeyringFile is the file name of the data keys which are encrypted by the master key
+	KeyringFile = "keyring.json"
+
+	version    = 1
+	dataKeyLen = 32
+	keyIdLen   = 4
+)
+
+// Keyring implements the envelope encryption. The values are encrypted by the data keys with AES-GCM, and the data keys
+// are encrypted by the master key and saved in the keyring file. The master key is never saved.
+// The encrypted value is prefix + version + key id + nonce + sealed data.
+type Keyring struct {
+	mu      sync.RWMutex
+	path    string
+	master  cipher.AEAD
+	current uint32
+	// the time when the current key is created in unix milli
+	rotatedAt int64
+	keys      map[uint32]cipher.AEAD
+	// the plain data keys to save them with the master key
+	raw map[uint32][]byte
+}
+
+type keyringFile struct {
+	Current   uint32            `json:"current"`
+	RotatedAt int64             `json:"rotatedAt"`
+	Keys      map[string]string `json:"keys"`
+}
+
+// LoadKey reads the base64 encoded AES key from the environment variable or the file. It returns nil if both are empty.
+func LoadKey(env, file string) ([]byte, error) {
+	var encoded string
+	if v, ok := os.LookupEnv(env); ok && v != "" {
+		encoded = v
+	} else if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read key file %s error: %v", file, err)
+		}
+		encoded = string(b)
+	} else {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("the key must be base64 encoded: %v", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("invalid key size %d, the key must be 16, 24 or 32 bytes for AES-128, AES-192 or AES-256", len(key))
+	}
+}
+
+// OpenKeyring loads the keyring in the dir by the master key, or creates a new one if not exists.
+// If the data keys are encrypted by the previous master key, they are encrypted by the master key again.
+func OpenKeyring(dir string, master, previous []byte) (*Keyring, error) {
+	m, err := newAEAD(master)
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{
+		path:   filepath.Join(dir, KeyringFile),
+		master: m,
+		keys:   make(map[uint32]cipher.AEAD),
+		raw:    make(map[uint32][]byte),
+	}
+	kf, err := readKeyringFile(k.path)
+	if err != nil {
+		return nil, err
+	}
+	if kf == nil {
+		return k, k.Rotate()
+	}
+	if err := k.load(kf, m); err == nil {
+		return k, nil
+	} else if previous == nil {
+		return nil, fmt.Errorf("the master key cannot decrypt the keyring: %v", err)
+	}
+	p, err := newAEAD(previous)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.load(kf, p); err != nil {
+		return nil, fmt.Errorf("neither the master key nor the previous key can decrypt the keyring: %v", err)
+	}
+	// The data keys are saved by the new master key in the rotation. Rotate the data key as well in case the
+	// previous master key and the keyring are leaked together.
+	return k, k.Rotate()
+}
+
+func (k *Keyring) load(kf *keyringFile, m cipher.AEAD) error {
+	for idStr, w := range kf.Keys {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid key id %s", idStr)
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(w)
+		if err != nil {
+			return fmt.Errorf("invalid key %s: %v", idStr, err)
+		}
+		dk, err := open(m, wrapped, []byte(idStr))
+		if err != nil {
+			return fmt.Errorf("decrypt key %s error: %v", idStr, err)
+		}
+		a, err := newAEAD(dk)
+		if err != nil {
+			return err
+		}
+		k.keys[uint32(id)] = a
+		k.raw[uint32(id)] = dk
+	}
+	if _, ok := k.keys[kf.Current]; !ok {
+		return fmt.Errorf("the current key %d is not found", kf.Current)
+	}
+	k.current = kf.Current
+	k.rotatedAt = kf.RotatedAt
+	return nil
+}
+
+// Rotate generates a new data key as the current key. The old keys are kept to decrypt the values which are not
+// re-encrypted yet until Retire is called.
+func (k *Keyring) Rotate() error {
+	dk := make([]byte, dataKeyLen)
+	if _, err := rand.Read(dk); err != nil {
+		return err
+	}
+	a, err := newAEAD(dk)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var id uint32
+	for i := range k.keys {
+		if i > id {
+			id = i
+		}
+	}
+	id++
+	old, oldRotatedAt := k.current, k.rotatedAt
+	k.keys[id] = a
+	k.raw[id] = dk
+	k.current = id
+	k.rotatedAt = time.Now().UnixMilli()
+	if err := k.save(); err != nil {
+		delete(k.keys, id)
+		delete(k.raw, id)
+		k.current, k.rotatedAt = old, oldRotatedAt
+		return err
+	}
+	return nil
+}
+
+// Retire removes the old data keys after all the values are re-encrypted by the current key. It returns the count of
+// the removed keys.
+func (k *Keyring) Retire() (int, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.keys) <= 1 {
+		return 0, nil
+	}
+	keys, raw := k.keys, k.raw
+	k.keys = map[uint32]cipher.AEAD{k.current: keys[k.current]}
+	k.raw = map[uint32][]byte{k.current: raw[k.current]}
+	if err := k.save(); err != nil {
+		k.keys, k.raw = keys, raw
+		return 0, err
+	}
+	return len(keys) - 1, nil
+}
+
+// KeyCount returns the count of the data keys in the keyring
+func (k *Keyring) KeyCount() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys)
+}
+
+// CurrentKeyId returns the id of the data key to encrypt the new values
+func (k *Keyring) CurrentKeyId() uint32 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// RotatedAt returns the time when the current data key is created
+func (k *Keyring) RotatedAt() time.Time {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return time.UnixMilli(k.rotatedAt)
+}
+
+func (k *Keyring) Encrypt(plain []byte) ([]byte, error) {
+	k.mu.RLock()
+	id := k.current
+	a := k.keys[id]
+	k.mu.RUnlock()
+	header := make([]byte, 0, len(kvEncoding.EncryptedPrefix)+1+keyIdLen)
+	header = append(header, kvEncoding.EncryptedPrefix...)
+	header = append(header, version)
+	header = binary.BigEndian.AppendUint32(header, id)
+	sealed, err := seal(a, plain, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, kvEncoding.EncryptedPrefix) {
+		return data, nil
+	}
+	id, err := keyIdOf(data)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	a, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("the data key %d is not found in the keyring", id)
+	}
+	headerLen := len(kvEncoding.EncryptedPrefix) + 1 + keyIdLen
+	return open(a, data[headerLen:], data[:headerLen])
+}
+
+func (k *Keyring) IsStale(data []byte) bool {
+	if !bytes.HasPrefix(data, kvEncoding.EncryptedPrefix) {
+		return true
+	}
+	id, err := keyIdOf(data)
+	return err != nil || id != k.CurrentKeyId()
+}
+
+func keyIdOf(data []byte) (uint32, error) {
+	offset := len(kvEncoding.EncryptedPrefix)
+	if len(data) < offset+1+keyIdLen {
+		return 0, errors.New("invalid encrypted data")
+	}
+	if data[offset] != version {
+		return 0, fmt.Errorf("unsupported encryption version %d", data[offset])
+	}
+	return binary.BigEndian.Uint32(data[offset+1:]), nil
+}
+
+// save writes the data keys encrypted by the master key. Write to a temp file and rename to avoid the broken keyring.
+func (k *Keyring) save() error {
+	kf := &keyringFile{
+		Current:   k.current,
+		RotatedAt: k.rotatedAt,
+		Keys:      make(map[string]string, len(k.raw)),
+	}
+	for id, dk := range k.raw {
+		idStr := strconv.FormatUint(uint64(id), 10)
+		wrapped, err := seal(k.master, dk, []byte(idStr))
+		if err != nil {
+			return err
+		}
+		kf.Keys[idStr] = base64.StdEncoding.EncodeToString(wrapped)
+	}
+	b, err := json.Marshal(kf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, k.path)
+}
+
+func readKeyringFile(path string) (*keyringFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	kf := &keyringFile{}
+	if err := json.Unmarshal(b, kf); err != nil {
+		return nil, fmt.Errorf("invalid keyring %s: %v", path, err)
+	}
+	return kf, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce + sealed data
+func seal(a cipher.AEAD, plain, ad []byte) ([]byte, error) {
+	nonce := make([]byte, a.NonceSize(), a.NonceSize()+len(plain)+a.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return a.Seal(nonce, nonce, plain, ad), nil
+}
+
+func open(a cipher.AEAD, data, ad []byte) ([]byte, error) {
+	if len(data) < a.NonceSize() {
+		return nil, errors.New("invalid encrypted data")
+	}
+	return a.Open(nil, data[:a.NonceSize()], data[a.NonceSize():], ad)
+}
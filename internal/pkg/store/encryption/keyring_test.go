@@ -0,0 +1,169 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+)
+
+func TestLoadKey(t *testing.T) {
+	key := newKey(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(file, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600))
+
+	k, err := LoadKey("TEST_STORE_KEY", "")
+	require.NoError(t, err)
+	require.Nil(t, k)
+	k, err = LoadKey("TEST_STORE_KEY", file)
+	require.NoError(t, err)
+	require.Equal(t, key, k)
+	// env has priority
+	envKey := newKey(t)
+	t.Setenv("TEST_STORE_KEY", base64.StdEncoding.EncodeToString(envKey))
+	k, err = LoadKey("TEST_STORE_KEY", file)
+	require.NoError(t, err)
+	require.Equal(t, envKey, k)
+
+	t.Setenv("TEST_STORE_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = LoadKey("TEST_STORE_KEY", file)
+	require.EqualError(t, err, "invalid key size 5, the key must be 16, 24 or 32 bytes for AES-128, AES-192 or AES-256")
+	t.Setenv("TEST_STORE_KEY", "not base64!")
+	_, err = LoadKey("TEST_STORE_KEY", file)
+	require.Error(t, err)
+}
+
+func TestKeyring(t *testing.T) {
+	dir := t.TempDir()
+	master := newKey(t)
+	k, err := OpenKeyring(dir, master, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), k.CurrentKeyId())
+
+	plain := []byte("connection password")
+	data, err := k.Encrypt(plain)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), string(plain))
+	require.False(t, k.IsStale(data))
+	require.True(t, k.IsStale(plain))
+	result, err := k.Decrypt(data)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+	// plaintext is returned as is
+	result, err = k.Decrypt(plain)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+	// tampered
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = k.Decrypt(tampered)
+	require.Error(t, err)
+
+	// The old key can still decrypt after rotation
+	require.NoError(t, k.Rotate())
+	require.Equal(t, uint32(2), k.CurrentKeyId())
+	require.True(t, k.IsStale(data))
+	result, err = k.Decrypt(data)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+
+	// Retire the old key after re-encryption
+	k2, err := OpenKeyring(t.TempDir(), master, nil)
+	require.NoError(t, err)
+	old, err := k2.Encrypt(plain)
+	require.NoError(t, err)
+	require.NoError(t, k2.Rotate())
+	current, err := k2.Encrypt(plain)
+	require.NoError(t, err)
+	require.Equal(t, 2, k2.KeyCount())
+	n, err := k2.Retire()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, k2.KeyCount())
+	_, err = k2.Decrypt(old)
+	require.EqualError(t, err, "the data key 1 is not found in the keyring")
+	result, err = k2.Decrypt(current)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+
+	// Reopen
+	k, err = OpenKeyring(dir, master, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), k.CurrentKeyId())
+	result, err = k.Decrypt(data)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+
+	// Wrong master key
+	newMaster := newKey(t)
+	_, err = OpenKeyring(dir, newMaster, nil)
+	require.Error(t, err)
+
+	// Rotate the master key
+	k, err = OpenKeyring(dir, newMaster, master)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), k.CurrentKeyId())
+	result, err = k.Decrypt(data)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+	// The previous master key is not needed anymore
+	k, err = OpenKeyring(dir, newMaster, nil)
+	require.NoError(t, err)
+	result, err = k.Decrypt(data)
+	require.NoError(t, err)
+	require.Equal(t, plain, result)
+	_, err = OpenKeyring(dir, master, nil)
+	require.Error(t, err)
+}
+
+func TestEncoding(t *testing.T) {
+	k, err := OpenKeyring(t.TempDir(), newKey(t), nil)
+	require.NoError(t, err)
+	plain, err := kvEncoding.Encode("secret")
+	require.NoError(t, err)
+	kvEncoding.SetCipher(k)
+	defer kvEncoding.SetCipher(nil)
+
+	data, err := kvEncoding.Encode("secret")
+	require.NoError(t, err)
+	var v string
+	require.NoError(t, kvEncoding.Decode(data, &v))
+	require.Equal(t, "secret", v)
+	// The plaintext before the encryption is enabled
+	require.NoError(t, kvEncoding.Decode(plain, &v))
+	require.Equal(t, "secret", v)
+	var r kvEncoding.Raw
+	require.NoError(t, kvEncoding.Decode(plain, &r))
+	require.True(t, r.Stale)
+	require.Equal(t, plain, r.Data)
+
+	kvEncoding.SetCipher(nil)
+	require.EqualError(t, kvEncoding.Decode(data, &v), "the value is encrypted, please enable the store encryption")
+}
+
+func newKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/encryption"
+)
+
+func TestEncryption(t *testing.T) {
+	dir := t.TempDir()
+	c := definition.Config{
+		Type:         "sqlite",
+		ExtStateType: "sqlite",
+		Sqlite:       definition.SqliteConfig{Path: dir},
+		Encryption:   definition.EncryptionConfig{Path: dir},
+	}
+	require.NoError(t, Setup(c))
+	ks, err := GetKV("connections")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set("mqtt", "password=public"))
+	// The table is never opened after the encryption is enabled
+	sch, err := GetKV("schemas")
+	require.NoError(t, err)
+	require.NoError(t, sch.Set("proto", "message"))
+
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	require.NoError(t, err)
+	c.Encryption.Enable = true
+	require.EqualError(t, Setup(c), "the store encryption is enabled but the key is not set by the environment variable KUIPER_STORE_KEY or the key file")
+	t.Setenv(encryption.KeyEnv, base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, Setup(c))
+	defer kvEncoding.SetCipher(nil)
+
+	// All the plaintext is encrypted at startup
+	for _, b := range rawValues(t, dir, "schemas") {
+		require.False(t, keyring.IsStale(b))
+	}
+	ks, err = GetKV("connections")
+	require.NoError(t, err)
+	var v string
+	ok, err := ks.Get("mqtt", &v)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "password=public", v)
+	require.NoError(t, ks.Set("http", "token=abc"))
+	all, err := ks.All()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"mqtt": "password=public", "http": "token=abc"}, all)
+	raw := rawValues(t, dir, "connections")
+	require.Len(t, raw, 2)
+	for _, b := range raw {
+		require.Equal(t, kvEncoding.EncryptedPrefix, b[:len(kvEncoding.EncryptedPrefix)])
+		require.False(t, keyring.IsStale(b))
+	}
+	tts, err := GetTS("rule1")
+	require.NoError(t, err)
+	_, err = tts.Set(1, map[string]interface{}{"op": "state"})
+	require.NoError(t, err)
+
+	// All the tables including the ts and the closed tables are encrypted with the new key after rotation
+	require.NoError(t, keyring.Rotate())
+	require.Equal(t, 2, keyring.KeyCount())
+	for _, table := range []string{"connections", "schemas", "rule1"} {
+		for _, b := range rawValues(t, dir, table) {
+			require.True(t, keyring.IsStale(b))
+		}
+	}
+	reencryptStores(keyring)
+	for _, table := range []string{"connections", "schemas", "rule1"} {
+		raw = rawValues(t, dir, table)
+		require.NotEmpty(t, raw)
+		for _, b := range raw {
+			require.False(t, keyring.IsStale(b))
+		}
+	}
+	// The old key is retired
+	require.Equal(t, 1, keyring.KeyCount())
+	all, err = ks.All()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"mqtt": "password=public", "http": "token=abc"}, all)
+	var m map[string]interface{}
+	_, err = tts.Last(&m)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"op": "state"}, m)
+
+	// Cannot read without the key
+	c.Encryption.Enable = false
+	require.NoError(t, Setup(c))
+	ks, err = GetKV("connections")
+	require.NoError(t, err)
+	_, err = ks.Get("mqtt", &v)
+	require.Error(t, err)
+}
+
+func rawValues(t *testing.T, dir string, table string) [][]byte {
+	d, err := sql.Open("sqlite", "file:"+dir+"/sqliteKV.db")
+	require.NoError(t, err)
+	defer d.Close()
+	rows, err := d.Query(fmt.Sprintf("SELECT val FROM '%s'", table))
+	require.NoError(t, err)
+	defer rows.Close()
+	var result [][]byte
+	for rows.Next() {
+		var b []byte
+		require.NoError(t, rows.Scan(&b))
+		result = append(result, b)
+	}
+	return result
+}
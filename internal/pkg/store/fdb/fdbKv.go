@@ -17,11 +17,8 @@
 package fdb
 
 import (
-	"bytes"
-	"encoding/gob"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/directory"
@@ -88,8 +85,7 @@ func (kv fdbKvStore) Get(key string, value interface{}) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	dec := gob.NewDecoder(strings.NewReader(string(val.([]byte))))
-	if err := dec.Decode(value); err != nil {
+	if err := kvEncoding.Decode(val.([]byte), value); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -168,8 +164,7 @@ func (kv fdbKvStore) All() (map[string]string, error) {
 				return nil, err
 			}
 			var value string
-			dec := gob.NewDecoder(bytes.NewBuffer(keyVal.Value))
-			if err := dec.Decode(&value); err != nil {
+			if err := kvEncoding.Decode(keyVal.Value, &value); err != nil {
 				return nil, err
 			}
 			alls[ks[0].(string)] = value
@@ -17,9 +17,6 @@
 package fdb
 
 import (
-	"encoding/gob"
-	"strings"
-
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/directory"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
@@ -73,8 +70,7 @@ func (t *ts) Get(key int64, value interface{}) (bool, error) {
 	if err != nil || string(val.([]byte)) == "" {
 		return false, err
 	}
-	dec := gob.NewDecoder(strings.NewReader(string(val.([]byte))))
-	if err := dec.Decode(value); err != nil {
+	if err := kvEncoding.Decode(val.([]byte), value); err != nil {
 		return false, err
 	}
 	return true, nil
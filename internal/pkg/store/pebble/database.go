@@ -0,0 +1,221 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf/logger"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+)
+
+const (
+	kvPrefix = "k"
+	tsPrefix = "t"
+	sep      = "\x00"
+)
+
+// Database is a pebble instance. Each store name like sqliteKV.db has its own instance in a sub folder.
+type Database struct {
+	db   *pebble.DB
+	Path string
+	wo   *pebble.WriteOptions
+	// guard the read and write which must be atomic like setnx. All the writes hold it, so the backup can pause them.
+	mu sync.Mutex
+}
+
+var (
+	databases = make(map[string]*Database)
+	dbMu      sync.Mutex
+)
+
+// NewPebbleDatabase opens the pebble instance of the store name. The instance is shared if it is already opened
+// because pebble holds an exclusive lock of the directory.
+func NewPebbleDatabase(c definition.Config, name string) (*Database, error) {
+	dir := filepath.Join(c.Pebble.Path, "pebble", strings.TrimSuffix(name, filepath.Ext(name)))
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	if d, ok := databases[dir]; ok {
+		return d, nil
+	}
+	logger.Log.Infof("use pebble as store %v", name)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	opts := options(c.Pebble)
+	if opts.Cache != nil {
+		defer opts.Cache.Unref()
+	}
+	db, err := pebble.Open(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	wo := pebble.NoSync
+	if c.Pebble.Sync {
+		wo = pebble.Sync
+	}
+	d := &Database{
+		db:   db,
+		Path: dir,
+		wo:   wo,
+	}
+	databases[dir] = d
+	return d, nil
+}
+
+func options(c definition.PebbleConfig) *pebble.Options {
+	opts := &pebble.Options{
+		Logger: logger.Log,
+	}
+	if c.MemTableSize > 0 {
+		opts.MemTableSize = uint64(c.MemTableSize)
+	}
+	if c.CacheSize > 0 {
+		opts.Cache = pebble.NewCache(c.CacheSize)
+	}
+	if c.L0CompactionThreshold > 0 {
+		opts.L0CompactionThreshold = c.L0CompactionThreshold
+	}
+	if c.L0StopWritesThreshold > 0 {
+		opts.L0StopWritesThreshold = c.L0StopWritesThreshold
+	}
+	if c.MaxConcurrentCompactions > 0 {
+		n := c.MaxConcurrentCompactions
+		opts.MaxConcurrentCompactions = func() int { return n }
+	}
+	return opts
+}
+
+// Close closes the pebble instance and removes it from the opened instances
+func (d *Database) Close() error {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	delete(databases, d.Path)
+	return d.db.Close()
+}
+
+func (d *Database) get(key []byte) ([]byte, bool, error) {
+	val, closer, err := d.db.Get(key)
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer closer.Close()
+	result := make([]byte, len(val))
+	copy(result, val)
+	return result, true, nil
+}
+
+func (d *Database) set(key, val []byte) error {
+	return d.db.Set(key, val, d.wo)
+}
+
+// prefixRange returns the lower and upper bound of all the keys of a table
+func prefixRange(prefix string) ([]byte, []byte) {
+	lower := []byte(prefix)
+	upper := make([]byte, len(lower))
+	copy(upper, lower)
+	// The prefix ends with the separator 0x00, so increase it as the exclusive upper bound
+	upper[len(upper)-1]++
+	return lower, upper
+}
+
+func (d *Database) dropPrefix(prefix string) error {
+	lower, upper := prefixRange(prefix)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.db.DeleteRange(lower, upper, d.wo)
+}
+
+// reencrypt rewrites the stale values of all the kv and ts tables. A value is only replaced if it is not changed
+// since it was read.
+func (d *Database) reencrypt() (int, error) {
+	count := 0
+	for _, prefix := range []string{kvPrefix + sep, tsPrefix + sep} {
+		lower, upper := prefixRange(prefix)
+		iter, err := d.db.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+		if err != nil {
+			return count, err
+		}
+		type pair struct{ key, old, new []byte }
+		var stale []pair
+		for iter.First(); iter.Valid(); iter.Next() {
+			nb, ok, err := kvEncoding.Reencrypt(iter.Value())
+			if err != nil {
+				_ = iter.Close()
+				return count, fmt.Errorf("re-encrypt %s error: %v", iter.Key(), err)
+			}
+			if ok {
+				stale = append(stale, pair{key: bytes.Clone(iter.Key()), old: bytes.Clone(iter.Value()), new: nb})
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return count, err
+		}
+		for _, p := range stale {
+			n, err := d.compareAndSet(p.key, p.old, p.new)
+			if err != nil {
+				return count, err
+			}
+			count += n
+		}
+	}
+	return count, nil
+}
+
+func (d *Database) compareAndSet(key, old, val []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current, found, err := d.get(key)
+	if err != nil || !found || !bytes.Equal(current, old) {
+		return 0, err
+	}
+	return 1, d.set(key, val)
+}
+
+// SnapshotPath is the checkpoint folder under the pebble folder of the backup
+func (d *Database) SnapshotPath() string {
+	return filepath.Join("pebble", filepath.Base(d.Path))
+}
+
+// Pause blocks the writes which all hold the lock
+func (d *Database) Pause() func() {
+	d.mu.Lock()
+	return d.mu.Unlock
+}
+
+// Snapshot writes a checkpoint of the database into the dir. The checkpoint hard links the immutable sst files, so it
+// is fast.
+func (d *Database) Snapshot(dir string) error {
+	target := filepath.Join(dir, d.SnapshotPath())
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	return d.db.Checkpoint(target, pebble.WithFlushedWAL())
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf/logger"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/sql/sqlite"
+)
+
+// MigrateFromSqlite copies all the kv and ts tables of the sqlite store to the pebble store of the same name.
+// The existing keys in pebble are overwritten. It returns the count of the copied tables.
+func MigrateFromSqlite(c definition.Config, name string) (int, error) {
+	sdb, err := sqlite.NewSqliteDatabase(c, name)
+	if err != nil {
+		return 0, err
+	}
+	src := sdb.(*sqlite.Database)
+	if _, err := os.Stat(src.Path); os.IsNotExist(err) {
+		logger.Log.Infof("sqlite store %s does not exist, skip the migration", src.Path)
+		return 0, nil
+	}
+	if err := src.Connect(); err != nil {
+		return 0, err
+	}
+	defer src.Disconnect()
+	dest, err := NewPebbleDatabase(c, name)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = src.Apply(func(db *sql.DB) error {
+		tables, err := sqliteTables(db)
+		if err != nil {
+			return err
+		}
+		for table, isTs := range tables {
+			if isTs {
+				err = copyTs(db, dest, table)
+			} else {
+				err = copyKv(db, dest, table)
+			}
+			if err != nil {
+				return fmt.Errorf("migrate table %s of %s error: %v", table, name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, dest.db.Flush()
+}
+
+// sqliteTables returns the table names and whether it is a ts table whose key is an integer
+func sqliteTables(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name, sql FROM sqlite_master WHERE type='table';")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]bool)
+	for rows.Next() {
+		var name, ddl string
+		if err := rows.Scan(&name, &ddl); err != nil {
+			return nil, err
+		}
+		// The trace table is not a kv table and is always kept in sqlite
+		if !strings.Contains(ddl, "'key'") {
+			continue
+		}
+		result[name] = strings.Contains(ddl, "'key' INTEGER")
+	}
+	return result, rows.Err()
+}
+
+func copyKv(db *sql.DB, dest *Database, table string) error {
+	kv, _ := createPebbleKvStore(dest, table)
+	rows, err := db.Query(fmt.Sprintf("SELECT key, val FROM '%s';", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	batch := dest.db.NewBatch()
+	defer batch.Close()
+	for rows.Next() {
+		var (
+			key string
+			val interface{}
+		)
+		if err := rows.Scan(&key, &val); err != nil {
+			return err
+		}
+		var b []byte
+		switch v := val.(type) {
+		case []byte:
+			// The gob encoded value set by Set
+			b = v
+		default:
+			// The raw value set by SetKeyedState
+			b, err = encodeKeyedState(v)
+			if err != nil {
+				return err
+			}
+		}
+		if err := batch.Set(kv.tableKey(key), b, nil); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+func copyTs(db *sql.DB, dest *Database, table string) error {
+	t := &ts{database: dest, table: table, prefix: tsPrefix + sep + table + sep}
+	rows, err := db.Query(fmt.Sprintf("SELECT key, val FROM '%s';", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	batch := dest.db.NewBatch()
+	defer batch.Close()
+	for rows.Next() {
+		var (
+			key int64
+			val []byte
+		)
+		if err := rows.Scan(&key, &val); err != nil {
+			return err
+		}
+		if err := batch.Set(t.tsKey(key), val, nil); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return batch.Commit(pebble.Sync)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/sql"
+)
+
+func TestMigrateFromSqlite(t *testing.T) {
+	dir := t.TempDir()
+	c := definition.Config{
+		Type:   "pebble",
+		Sqlite: definition.SqliteConfig{Path: dir},
+		Pebble: definition.PebbleConfig{Path: dir},
+	}
+	// Not exist
+	count, err := MigrateFromSqlite(c, "cache.db")
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	kb, tb, err := sql.BuildStores(c, "sqliteKV.db")
+	require.NoError(t, err)
+	ks, err := kb.CreateStore("stream")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set("demo", "create stream demo"))
+	require.NoError(t, ks.SetKeyedState("count", 10))
+	ts, err := tb.CreateTs("rule1")
+	require.NoError(t, err)
+	_, err = ts.Set(100, map[string]interface{}{"op1": "state1"})
+	require.NoError(t, err)
+	_, err = ts.Set(200, map[string]interface{}{"op1": "state2"})
+	require.NoError(t, err)
+
+	count, err = MigrateFromSqlite(c, "sqliteKV.db")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	d, err := NewPebbleDatabase(c, "sqliteKV.db")
+	require.NoError(t, err)
+	defer d.Close()
+	pks, err := NewStoreBuilder(d).CreateStore("stream")
+	require.NoError(t, err)
+	var v string
+	ok, err := pks.Get("demo", &v)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "create stream demo", v)
+	kv, err := pks.GetKeyedState("count")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), kv)
+
+	pts, err := NewTsBuilder(d).CreateTs("rule1")
+	require.NoError(t, err)
+	var m map[string]interface{}
+	last, err := pts.Last(&m)
+	require.NoError(t, err)
+	require.Equal(t, int64(200), last)
+	require.Equal(t, map[string]interface{}{"op1": "state2"}, m)
+	ok, err = pts.Get(100, &m)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{"op1": "state1"}, m)
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+type pebbleKvStore struct {
+	database *Database
+	table    string
+	prefix   string
+}
+
+func createPebbleKvStore(database *Database, table string) (*pebbleKvStore, error) {
+	return &pebbleKvStore{
+		database: database,
+		table:    table,
+		prefix:   kvPrefix + sep + table + sep,
+	}, nil
+}
+
+func (kv *pebbleKvStore) Setnx(key string, value interface{}) error {
+	b, err := kvEncoding.Encode(value)
+	if err != nil {
+		return err
+	}
+	kv.database.mu.Lock()
+	defer kv.database.mu.Unlock()
+	_, found, err := kv.database.get(kv.tableKey(key))
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf(`Item %s already exists`, key)
+	}
+	return kv.database.set(kv.tableKey(key), b)
+}
+
+func (kv *pebbleKvStore) Set(key string, value interface{}) error {
+	b, err := kvEncoding.Encode(value)
+	if err != nil {
+		return err
+	}
+	kv.database.mu.Lock()
+	defer kv.database.mu.Unlock()
+	return kv.database.set(kv.tableKey(key), b)
+}
+
+func (kv *pebbleKvStore) Get(key string, value interface{}) (bool, error) {
+	val, found, err := kv.database.get(kv.tableKey(key))
+	if err != nil || !found {
+		return false, nil
+	}
+	if err := kvEncoding.Decode(val, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetKeyedState returns the value set by SetKeyedState. The value type is kept by encoding it as an interface.
+func (kv *pebbleKvStore) GetKeyedState(key string) (interface{}, error) {
+	val, found, err := kv.database.get(kv.tableKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s is not found", key)
+	}
+	var value interface{}
+	if err := kvEncoding.Decode(val, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (kv *pebbleKvStore) SetKeyedState(key string, value interface{}) error {
+	b, err := encodeKeyedState(value)
+	if err != nil {
+		return err
+	}
+	kv.database.mu.Lock()
+	defer kv.database.mu.Unlock()
+	return kv.database.set(kv.tableKey(key), b)
+}
+
+func (kv *pebbleKvStore) Delete(key string) error {
+	kv.database.mu.Lock()
+	defer kv.database.mu.Unlock()
+	_, found, err := kv.database.get(kv.tableKey(key))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("%s is not found", key))
+	}
+	return kv.database.db.Delete(kv.tableKey(key), kv.database.wo)
+}
+
+func (kv *pebbleKvStore) Keys() ([]string, error) {
+	keys := make([]string, 0)
+	err := kv.iterate(func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
+
+func (kv *pebbleKvStore) All() (map[string]string, error) {
+	all := make(map[string]string)
+	err := kv.iterate(func(key string, val []byte) error {
+		var value string
+		if err := kvEncoding.Decode(val, &value); err != nil {
+			return err
+		}
+		all[key] = value
+		return nil
+	})
+	return all, err
+}
+
+func (kv *pebbleKvStore) Clean() error {
+	return kv.database.dropPrefix(kv.prefix)
+}
+
+func (kv *pebbleKvStore) Drop() error {
+	return kv.Clean()
+}
+
+func (kv *pebbleKvStore) iterate(f func(key string, val []byte) error) error {
+	lower, upper := prefixRange(kv.prefix)
+	iter, err := kv.database.db.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.First(); iter.Valid(); iter.Next() {
+		if err := f(string(iter.Key()[len(kv.prefix):]), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (kv *pebbleKvStore) tableKey(key string) []byte {
+	return []byte(kv.prefix + key)
+}
+
+// encodeKeyedState encodes the keyed state without encryption because the ext state is shared with the external tools
+func encodeKeyedState(value interface{}) ([]byte, error) {
+	return kvEncoding.EncodePlain(&value)
+}
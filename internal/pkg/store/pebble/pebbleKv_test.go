@@ -0,0 +1,182 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"bytes"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/encryption"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+func TestPebbleKvSetnx(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvSetnx(ks, t)
+}
+
+func TestPebbleKvSet(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvSet(ks, t)
+}
+
+func TestPebbleKvSetGet(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvSetGet(ks, t)
+}
+
+func TestPebbleKvGet(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvGet(ks, t)
+}
+
+func TestPebbleKvKeys(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvKeys(10, ks, t)
+}
+
+func TestPebbleKvAll(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvAll(10, ks, t)
+}
+
+func TestPebbleKvGetKeyedState(t *testing.T) {
+	ks := setupPebbleKv(t, "test")
+	common.TestKvGetKeyedState(ks, t)
+}
+
+func TestPebbleKvTables(t *testing.T) {
+	d := setupPebbleDatabase(t)
+	b := NewStoreBuilder(d)
+	ks1, err := b.CreateStore("sink/rule1")
+	require.NoError(t, err)
+	ks2, err := b.CreateStore("sink/rule")
+	require.NoError(t, err)
+	require.NoError(t, ks1.Set("a", "1"))
+	require.NoError(t, ks2.Set("a", "2"))
+	require.NoError(t, ks2.Set("b", "3"))
+	keys, err := ks1.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, keys)
+	all, err := ks2.All()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "2", "b": "3"}, all)
+
+	err = ks2.Delete("c")
+	require.Error(t, err)
+	withCode, ok := err.(errorx.ErrorWithCode)
+	require.True(t, ok)
+	require.Equal(t, errorx.NOT_FOUND, withCode.Code())
+	require.NoError(t, ks2.Drop())
+	keys, err = ks2.Keys()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	keys, err = ks1.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, keys)
+}
+
+func TestPebbleReencrypt(t *testing.T) {
+	d := setupPebbleDatabase(t)
+	common.TestReencrypt(NewStoreBuilder(d), NewTsBuilder(d), t)
+}
+
+func TestPebbleKeyedStatePlain(t *testing.T) {
+	master := make([]byte, 32)
+	_, err := rand.Read(master)
+	require.NoError(t, err)
+	k, err := encryption.OpenKeyring(t.TempDir(), master, nil)
+	require.NoError(t, err)
+	kvEncoding.SetCipher(k)
+	defer kvEncoding.SetCipher(nil)
+
+	d := setupPebbleDatabase(t)
+	ks, err := NewStoreBuilder(d).CreateStore("state")
+	require.NoError(t, err)
+	require.NoError(t, ks.SetKeyedState("foo", int64(1)))
+	val, found, err := d.get([]byte(kvPrefix + sep + "state" + sep + "foo"))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, bytes.HasPrefix(val, kvEncoding.EncryptedPrefix))
+	v, err := ks.GetKeyedState("foo")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), v)
+}
+
+func setupPebbleDatabase(t *testing.T) *Database {
+	d, err := NewPebbleDatabase(definition.Config{
+		Pebble: definition.PebbleConfig{
+			Path:                     t.TempDir(),
+			MemTableSize:             1 << 20,
+			CacheSize:                1 << 20,
+			L0CompactionThreshold:    2,
+			L0StopWritesThreshold:    8,
+			MaxConcurrentCompactions: 1,
+		},
+	}, "sqliteKV.db")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = d.Close()
+	})
+	return d
+}
+
+func setupPebbleKv(t *testing.T, table string) kv.KeyValue {
+	ks, err := NewStoreBuilder(setupPebbleDatabase(t)).CreateStore(table)
+	require.NoError(t, err)
+	return ks
+}
+
+func TestPebbleSnapshotPause(t *testing.T) {
+	d := setupPebbleDatabase(t)
+	ks, err := NewStoreBuilder(d).CreateStore("snapshot")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set("a", "1"))
+	resume := d.Pause()
+	done := make(chan error)
+	go func() {
+		done <- ks.Set("b", "2")
+	}()
+	dir := t.TempDir()
+	require.NoError(t, d.Snapshot(dir))
+	select {
+	case <-done:
+		require.Fail(t, "the write is not paused")
+	default:
+	}
+	resume()
+	require.NoError(t, <-done)
+
+	cp, err := pebble.Open(filepath.Join(dir, d.SnapshotPath()), &pebble.Options{})
+	require.NoError(t, err)
+	defer cp.Close()
+	restored, err := NewStoreBuilder(&Database{db: cp}).CreateStore("snapshot")
+	require.NoError(t, err)
+	keys, err := restored.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, keys)
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+)
+
+type ts struct {
+	database *Database
+	table    string
+	prefix   string
+	last     int64
+}
+
+func createPebbleTs(database *Database, table string) (*ts, error) {
+	t := &ts{
+		database: database,
+		table:    table,
+		prefix:   tsPrefix + sep + table + sep,
+	}
+	last, err := t.Last(nil)
+	if err != nil {
+		return nil, err
+	}
+	t.last = last
+	return t, nil
+}
+
+func (t *ts) Set(key int64, value interface{}) (bool, error) {
+	if key <= t.last {
+		return false, nil
+	}
+	b, err := kvEncoding.Encode(value)
+	if err != nil {
+		return false, err
+	}
+	t.database.mu.Lock()
+	err = t.database.set(t.tsKey(key), b)
+	t.database.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+	t.last = key
+	return true, nil
+}
+
+func (t *ts) Get(key int64, value interface{}) (bool, error) {
+	val, found, err := t.database.get(t.tsKey(key))
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, nil
+	}
+	if err := kvEncoding.Decode(val, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (t *ts) Last(value interface{}) (int64, error) {
+	lower, upper := prefixRange(t.prefix)
+	iter, err := t.database.db.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	if !iter.Last() {
+		return 0, iter.Error()
+	}
+	if value != nil {
+		if err := kvEncoding.Decode(iter.Value(), value); err != nil {
+			return 0, err
+		}
+	}
+	return t.decodeKey(iter.Key())
+}
+
+func (t *ts) Delete(key int64) error {
+	t.database.mu.Lock()
+	defer t.database.mu.Unlock()
+	return t.database.db.Delete(t.tsKey(key), t.database.wo)
+}
+
+func (t *ts) DeleteBefore(key int64) error {
+	lower, _ := prefixRange(t.prefix)
+	t.database.mu.Lock()
+	defer t.database.mu.Unlock()
+	return t.database.db.DeleteRange(lower, t.tsKey(key), t.database.wo)
+}
+
+func (t *ts) Close() error {
+	return nil
+}
+
+func (t *ts) Drop() error {
+	t.last = 0
+	return t.database.dropPrefix(t.prefix)
+}
+
+// tsKey appends the key in big endian with the sign bit flipped so that the keys are sorted by the number
+func (t *ts) tsKey(key int64) []byte {
+	b := make([]byte, len(t.prefix)+8)
+	copy(b, t.prefix)
+	binary.BigEndian.PutUint64(b[len(t.prefix):], uint64(key)^(1<<63))
+	return b
+}
+
+func (t *ts) decodeKey(k []byte) (int64, error) {
+	if len(k) != len(t.prefix)+8 {
+		return 0, fmt.Errorf("invalid key %x of ts %s", k, t.table)
+	}
+	return int64(binary.BigEndian.Uint64(k[len(t.prefix):]) ^ (1 << 63)), nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build pebbledb || !core
+// +build pebbledb !core
+
+package pebble
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+func TestPebbleTsSet(t *testing.T) {
+	common.TestTsSet(setupPebbleTs(t), t)
+}
+
+func TestPebbleTsLast(t *testing.T) {
+	common.TestTsLast(setupPebbleTs(t), t)
+}
+
+func TestPebbleTsGet(t *testing.T) {
+	common.TestTsGet(setupPebbleTs(t), t)
+}
+
+func TestPebbleTsDelete(t *testing.T) {
+	common.TestTsDelete(setupPebbleTs(t), t)
+}
+
+func TestPebbleTsDeleteBefore(t *testing.T) {
+	common.TestTsDeleteBefore(setupPebbleTs(t), t)
+}
+
+func TestPebbleTsReopen(t *testing.T) {
+	d := setupPebbleDatabase(t)
+	b := NewTsBuilder(d)
+	ks, err := b.CreateTs("rule1")
+	require.NoError(t, err)
+	for _, k := range []int64{1, 256, 70000} {
+		ok, err := ks.Set(k, map[string]interface{}{"op": k})
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	// The other table with the prefix does not affect the last
+	other, err := b.CreateTs("rule10")
+	require.NoError(t, err)
+	_, err = other.Set(80000, "other")
+	require.NoError(t, err)
+
+	ks, err = b.CreateTs("rule1")
+	require.NoError(t, err)
+	var v map[string]interface{}
+	last, err := ks.Last(&v)
+	require.NoError(t, err)
+	require.Equal(t, int64(70000), last)
+	require.Equal(t, map[string]interface{}{"op": int64(70000)}, v)
+	ok, err := ks.Set(300, "old")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, ks.Drop())
+	last, err = ks.Last(nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), last)
+	last, err = other.Last(nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(80000), last)
+}
+
+func setupPebbleTs(t *testing.T) kv.Tskv {
+	ks, err := NewTsBuilder(setupPebbleDatabase(t)).CreateTs("test")
+	require.NoError(t, err)
+	return ks
+}
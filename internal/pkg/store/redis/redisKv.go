@@ -18,7 +18,6 @@ package redis
 
 import (
 	"context"
-	"encoding/gob"
 	"fmt"
 	"strings"
 
@@ -73,8 +72,7 @@ func (kv redisKvStore) Get(key string, value interface{}) (bool, error) {
 	if err != nil {
 		return false, nil
 	}
-	dec := gob.NewDecoder(strings.NewReader(val))
-	if err := dec.Decode(value); err != nil {
+	if err := kvEncoding.Decode([]byte(val), value); err != nil {
 		return false, err
 	}
 	return true, nil
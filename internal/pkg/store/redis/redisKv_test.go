@@ -74,6 +74,13 @@ func TestRedisKvGetKeyedState(t *testing.T) {
 	common.TestKvGetKeyedState(ks, t)
 }
 
+func TestRedisReencrypt(t *testing.T) {
+	_, db, minRedis := setupRedisKv()
+	defer cleanRedisKv(db, minRedis)
+
+	common.TestReencrypt(NewStoreBuilder(db), NewTsBuilder(db), t)
+}
+
 func setupRedisKv() (kv.KeyValue, *redis.Client, *miniredis.Miniredis) {
 	minRedis, err := miniredis.Run()
 	if err != nil {
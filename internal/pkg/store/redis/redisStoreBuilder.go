@@ -18,8 +18,13 @@
 package redis
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/redis/go-redis/v9"
 
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
 
@@ -36,3 +41,76 @@ func NewStoreBuilder(redis *redis.Client) StoreBuilder {
 func (b StoreBuilder) CreateStore(table string) (kv.KeyValue, error) {
 	return createRedisKvStore(b.database, table)
 }
+
+// Reencrypt rewrites the stale values of all the kv and ts tables. Each value is replaced in a transaction which
+// is aborted if the value is changed concurrently. The aborted values are left to the next run.
+func (b StoreBuilder) Reencrypt() (int, error) {
+	ctx := context.Background()
+	count := 0
+	iter := b.database.Scan(ctx, 0, fmt.Sprintf("%s:*", KvPrefix), 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		err := b.database.Watch(ctx, func(tx *redis.Tx) error {
+			val, err := tx.Get(ctx, key).Bytes()
+			if err != nil {
+				return err
+			}
+			nb, stale, err := kvEncoding.Reencrypt(val)
+			if err != nil || !stale {
+				return err
+			}
+			if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, nb, 0)
+				return nil
+			}); err != nil {
+				return err
+			}
+			count++
+			return nil
+		}, key)
+		if err != nil && !errors.Is(err, redis.Nil) && !errors.Is(err, redis.TxFailedErr) {
+			return count, fmt.Errorf("re-encrypt %s error: %v", key, err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return count, err
+	}
+	iter = b.database.Scan(ctx, 0, fmt.Sprintf("%s:*", TsPrefix), 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		err := b.database.Watch(ctx, func(tx *redis.Tx) error {
+			members, err := tx.ZRangeWithScores(ctx, key, 0, -1).Result()
+			if err != nil {
+				return err
+			}
+			var rem []interface{}
+			var add []redis.Z
+			for _, m := range members {
+				nb, stale, err := kvEncoding.Reencrypt([]byte(m.Member.(string)))
+				if err != nil {
+					return err
+				}
+				if stale {
+					rem = append(rem, m.Member)
+					add = append(add, redis.Z{Score: m.Score, Member: nb})
+				}
+			}
+			if len(add) == 0 {
+				return nil
+			}
+			if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.ZRem(ctx, key, rem...)
+				pipe.ZAdd(ctx, key, add...)
+				return nil
+			}); err != nil {
+				return err
+			}
+			count += len(add)
+			return nil
+		}, key)
+		if err != nil && !errors.Is(err, redis.TxFailedErr) {
+			return count, fmt.Errorf("re-encrypt %s error: %v", key, err)
+		}
+	}
+	return count, iter.Err()
+}
@@ -19,10 +19,8 @@ package redis
 
 import (
 	"context"
-	"encoding/gob"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/redis/go-redis/v9"
 
@@ -84,8 +82,7 @@ func (t *ts) Get(key int64, value interface{}) (bool, error) {
 	if len(reply) == 0 {
 		return false, fmt.Errorf("record under %s key and %d score not found", t.key, key)
 	}
-	dec := gob.NewDecoder(strings.NewReader(reply[0]))
-	err := dec.Decode(value)
+	err := kvEncoding.Decode([]byte(reply[0]), value)
 	if err != nil {
 		return false, err
 	}
@@ -118,8 +115,7 @@ func getLast(db *redis.Client, key string, value interface{}) (int64, error) {
 	if len(reply) > 0 {
 		if value != nil {
 			v := reply[0].Member.(string)
-			dec := gob.NewDecoder(strings.NewReader(v))
-			if err := dec.Decode(value); err != nil {
+			if err := kvEncoding.Decode([]byte(v), value); err != nil {
 				return 0, err
 			}
 		}
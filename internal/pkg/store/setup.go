@@ -16,17 +16,21 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
 
+	"github.com/lf-edge/ekuiper/v2/internal/conf/logger"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
 	sqldb "github.com/lf-edge/ekuiper/v2/internal/pkg/store/sql"
 )
 
 type StoreConf struct {
-	Type         string
-	ExtStateType string
-	RedisConfig  definition.RedisConfig
-	SqliteConfig definition.SqliteConfig
-	FdbConfig    definition.FdbConfig
+	Type             string
+	ExtStateType     string
+	RedisConfig      definition.RedisConfig
+	SqliteConfig     definition.SqliteConfig
+	FdbConfig        definition.FdbConfig
+	PebbleConfig     definition.PebbleConfig
+	EncryptionConfig definition.EncryptionConfig
 }
 
 func SetupDefault(dataDir string) error {
@@ -45,17 +49,25 @@ func SetupDefault(dataDir string) error {
 }
 
 func SetupWithConfig(sc *StoreConf) error {
-	c := definition.Config{
+	return Setup(sc.config())
+}
+
+func (sc *StoreConf) config() definition.Config {
+	return definition.Config{
 		Type:         sc.Type,
 		ExtStateType: sc.ExtStateType,
 		Redis:        sc.RedisConfig,
 		Sqlite:       sc.SqliteConfig,
 		Fdb:          sc.FdbConfig,
+		Pebble:       sc.PebbleConfig,
+		Encryption:   sc.EncryptionConfig,
 	}
-	return Setup(c)
 }
 
 func Setup(config definition.Config) error {
+	if err := setupEncryption(config.Encryption); err != nil {
+		return err
+	}
 	s, err := newStores(config, "sqliteKV.db")
 	if err != nil {
 		return err
@@ -71,6 +83,10 @@ func Setup(config definition.Config) error {
 		return err
 	}
 	extStateStores = s
+	if keyring != nil {
+		// encrypt the plaintext values and the values of the previous master key
+		reencryptStores(keyring)
+	}
 	db, err := sqldb.BuildSqliteStore(config, "trace.db")
 	if err != nil {
 		return err
@@ -81,3 +97,31 @@ func Setup(config definition.Config) error {
 		return err
 	})
 }
+
+// MigrateFromSqlite copies the existing sqlite data to the store types in the config, so that the data is kept after
+// switching the store type. The stores whose type is sqlite are skipped.
+func MigrateFromSqlite(sc *StoreConf) error {
+	c := sc.config()
+	for _, s := range []struct {
+		name string
+		typ  string
+	}{
+		{name: "sqliteKV.db", typ: c.Type},
+		{name: "cache.db", typ: c.Type},
+		{name: "extState.db", typ: c.ExtStateType},
+	} {
+		if s.typ == "sqlite" {
+			continue
+		}
+		migrator, ok := storeMigrators[s.typ]
+		if !ok {
+			return fmt.Errorf("migrating from sqlite to %s is not supported", s.typ)
+		}
+		count, err := migrator(c, s.name)
+		if err != nil {
+			return fmt.Errorf("migrate %s to %s error: %v", s.name, s.typ, err)
+		}
+		logger.Log.Infof("migrated %d tables of %s to %s", count, s.name, s.typ)
+	}
+	return nil
+}
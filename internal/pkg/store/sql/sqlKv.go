@@ -15,9 +15,7 @@
 package sql
 
 import (
-	"bytes"
 	"database/sql"
-	"encoding/gob"
 	"fmt"
 	"strings"
 
@@ -105,8 +103,7 @@ func (kv *sqlKvStore) Get(key string, value interface{}) (bool, error) {
 			result = false
 			return nil
 		}
-		dec := gob.NewDecoder(bytes.NewBuffer(tmp))
-		if err := dec.Decode(value); err != nil {
+		if err := kvEncoding.Decode(tmp, value); err != nil {
 			return err
 		}
 		result = true
@@ -212,8 +209,7 @@ func (kv *sqlKvStore) All() (all map[string]string, err error) {
 			if nil != e {
 				return e
 			} else {
-				dec := gob.NewDecoder(bytes.NewBuffer(valBytes))
-				if err := dec.Decode(&value); err != nil {
+				if err := kvEncoding.Decode(valBytes, &value); err != nil {
 					return err
 				}
 				all[key] = value
@@ -102,6 +102,20 @@ func TestInvalidTableName(t *testing.T) {
 	require.EqualError(t, err, "invalid table name: 1_abc")
 }
 
+func TestSqlReencrypt(t *testing.T) {
+	config := definition.Config{
+		Type: "sqlite",
+		Sqlite: definition.SqliteConfig{
+			Path: t.TempDir(),
+			Name: SDbName,
+		},
+	}
+	db, _ := sqlite.NewSqliteDatabase(config, "sqliteKV.db")
+	require.NoError(t, db.Connect())
+	defer db.Disconnect()
+	common.TestReencrypt(NewStoreBuilder(db.(Database)), NewTsBuilder(db.(Database)), t)
+}
+
 func deleteIfExists(abs string) error {
 	absPath := path.Join(abs, SDbName)
 	if f, _ := os.Stat(absPath); f != nil {
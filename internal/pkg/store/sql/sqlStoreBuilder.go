@@ -15,6 +15,11 @@
 package sql
 
 import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
 
@@ -31,3 +36,91 @@ func NewStoreBuilder(d Database) StoreBuilder {
 func (b StoreBuilder) CreateStore(table string) (kv.KeyValue, error) {
 	return createSqlKvStore(b.database, table)
 }
+
+func (b StoreBuilder) Snapshotter() definition.Snapshotter {
+	if s, ok := b.database.(definition.Snapshotter); ok {
+		return s
+	}
+	return nil
+}
+
+// Reencrypt rewrites the stale values of all the kv and ts tables in the database. Each table is rewritten in a
+// transaction while holding the database, so the concurrent updates are not overwritten.
+func (b StoreBuilder) Reencrypt() (int, error) {
+	var tables []string
+	err := b.database.Apply(func(db *sql.DB) error {
+		rows, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND sql LIKE '%''key''%''val'' BLOB%';")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			tables = append(tables, name)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, table := range tables {
+		err := b.database.Apply(func(db *sql.DB) error {
+			n, err := reencryptTable(db, table)
+			count += n
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("re-encrypt table %s error: %v", table, err)
+		}
+	}
+	return count, nil
+}
+
+func reencryptTable(db *sql.DB, table string) (int, error) {
+	type pair struct {
+		key interface{}
+		val []byte
+	}
+	var stale []pair
+	rows, err := db.Query(fmt.Sprintf("SELECT key, val FROM '%s';", table))
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var (
+			key interface{}
+			val []byte
+		)
+		if err := rows.Scan(&key, &val); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		nb, ok, err := kvEncoding.Reencrypt(val)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if ok {
+			stale = append(stale, pair{key: key, val: nb})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(stale) == 0 {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range stale {
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE '%s' SET val=? WHERE key=?;", table), p.val, p.key); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(stale), tx.Commit()
+}
@@ -15,9 +15,7 @@
 package sql
 
 import (
-	"bytes"
 	"database/sql"
-	"encoding/gob"
 	"fmt"
 
 	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
@@ -99,8 +97,7 @@ func (t ts) Get(key int64, value interface{}) (bool, error) {
 			return err
 		}
 
-		dec := gob.NewDecoder(bytes.NewBuffer(tmp))
-		if err := dec.Decode(value); err != nil {
+		if err := kvEncoding.Decode(tmp, value); err != nil {
 			return err
 		}
 		result = true
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"sync"
 
 	// introduce sqlite
@@ -79,3 +80,20 @@ func (d *Database) Apply(f func(db *sql.DB) error) error {
 	d.mu.Unlock()
 	return err
 }
+
+// SnapshotPath is the database file name. The stores may share the same database file.
+func (d *Database) SnapshotPath() string {
+	return filepath.Base(d.Path)
+}
+
+// Pause blocks all the operations which are applied with the lock
+func (d *Database) Pause() func() {
+	d.mu.Lock()
+	return d.mu.Unlock
+}
+
+// Snapshot writes a copy of the database into the dir. It runs without the lock which is held by Pause.
+func (d *Database) Snapshot(dir string) error {
+	_, err := d.db.Exec("VACUUM INTO ?;", filepath.Join(dir, d.SnapshotPath()))
+	return err
+}
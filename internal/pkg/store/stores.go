@@ -27,10 +27,14 @@ import (
 
 type StoreCreator func(conf definition.Config, name string) (definition.StoreBuilder, definition.TsBuilder, error)
 
+// StoreMigrator copies the data of the sqlite store of the name to another store type. It returns the count of the tables.
+type StoreMigrator func(conf definition.Config, name string) (int, error)
+
 var (
 	storeBuilders = map[string]StoreCreator{
 		"sqlite": sql.BuildStores,
 	}
+	storeMigrators         = map[string]StoreMigrator{}
 	globalStores   *stores = nil
 	cacheStores    *stores = nil
 	extStateStores *stores = nil
@@ -39,6 +43,7 @@ var (
 )
 
 type stores struct {
+	name      string
 	kv        map[string]kv.KeyValue
 	ts        map[string]kv.Tskv
 	mu        sync.Mutex
@@ -54,6 +59,7 @@ func newStores(c definition.Config, name string) (*stores, error) {
 			return nil, err
 		} else {
 			return &stores{
+				name:      name,
 				kv:        make(map[string]kv.KeyValue),
 				ts:        make(map[string]kv.Tskv),
 				mu:        sync.Mutex{},
@@ -74,6 +80,7 @@ func newExtStateStores(c definition.Config, name string) (*stores, error) {
 			return nil, err
 		} else {
 			return &stores{
+				name:      name,
 				kv:        make(map[string]kv.KeyValue),
 				ts:        make(map[string]kv.Tskv),
 				mu:        sync.Mutex{},
@@ -106,7 +113,7 @@ func (s *stores) DropKV(table string) {
 
 	if ks, contains := s.kv[table]; contains {
 		_ = ks.Drop()
-		delete(s.ts, table)
+		delete(s.kv, table)
 	}
 }
 
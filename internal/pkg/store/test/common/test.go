@@ -15,10 +15,16 @@
 package common
 
 import (
+	"crypto/rand"
 	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/encryption"
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
 
@@ -162,3 +168,47 @@ func TestKvAll(length int, ks kv.KeyValue, t *testing.T) {
 		t.Errorf("All values do not match expected %s != %s", all, expected)
 	}
 }
+
+// TestReencrypt checks that the values of the kv and ts tables created by the builders are re-encrypted
+func TestReencrypt(kvBuilder definition.StoreBuilder, tsBuilder definition.TsBuilder, t *testing.T) {
+	r, ok := kvBuilder.(definition.Reencrypter)
+	require.True(t, ok)
+	ks, err := kvBuilder.CreateStore("conf")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set("a", "1"))
+	require.NoError(t, ks.Set("b", "2"))
+	tts, err := tsBuilder.CreateTs("checkpoint")
+	require.NoError(t, err)
+	_, err = tts.Set(1, "state")
+	require.NoError(t, err)
+
+	master := make([]byte, 32)
+	_, err = rand.Read(master)
+	require.NoError(t, err)
+	k, err := encryption.OpenKeyring(t.TempDir(), master, nil)
+	require.NoError(t, err)
+	kvEncoding.SetCipher(k)
+	defer kvEncoding.SetCipher(nil)
+	// plaintext
+	n, err := r.Reencrypt()
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	n, err = r.Reencrypt()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	// old key
+	require.NoError(t, k.Rotate())
+	n, err = r.Reencrypt()
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	_, err = k.Retire()
+	require.NoError(t, err)
+
+	all, err := ks.All()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, all)
+	var v string
+	_, err = tts.Last(&v)
+	require.NoError(t, err)
+	require.Equal(t, "state", v)
+}
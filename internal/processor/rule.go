@@ -22,6 +22,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/xsql"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
@@ -147,6 +148,10 @@ func (p *RuleProcessor) GetRuleByJsonValidated(id, ruleJson string) (*def.Rule,
 	if rule.Options == nil {
 		rule.Options = &opt
 	}
+	// The rule json in a namespace only has the name as the id
+	if ns := namespace.Of(id); ns != namespace.Default && namespace.Of(rule.Id) == namespace.Default {
+		rule.Id = namespace.Qualify(ns, rule.Id)
+	}
 	return rule, nil
 }
 
@@ -162,7 +167,15 @@ func (p *RuleProcessor) GetRuleByJson(id, ruleJson string) (*def.Rule, error) {
 	if id != "" && rule.Id != "" && id != rule.Id {
 		return nil, fmt.Errorf("RuleId is not consistent with rule id.")
 	}
-	if err := validateRuleID(rule.Id); err != nil {
+	// The rule in a namespace is validated by its name. The namespace is validated when it is created.
+	name := rule.Id
+	if ns := namespace.Of(id); ns != namespace.Default {
+		if namespace.Of(rule.Id) != ns {
+			return nil, fmt.Errorf("Rule %s is not in namespace %s.", rule.Id, ns)
+		}
+		name = namespace.Name(rule.Id)
+	}
+	if err := validateRuleID(name); err != nil {
 		return nil, err
 	}
 	if rule.Sql != "" {
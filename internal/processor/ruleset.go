@@ -1,4 +1,4 @@
-// Copyright 2022-2023 EMQ Technologies Co., Ltd.
+// Copyright 2022-2024 EMQ Technologies Co., Ltd.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -21,11 +21,13 @@ import (
 	"io"
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 )
 
 type RulesetProcessor struct {
 	r *RuleProcessor
 	s *StreamProcessor
+	v *ViewProcessor
 }
 
 type Ruleset struct {
@@ -38,6 +40,7 @@ func NewRulesetProcessor(r *RuleProcessor, s *StreamProcessor) *RulesetProcessor
 	return &RulesetProcessor{
 		r: r,
 		s: s,
+		v: NewViewProcessor(),
 	}
 }
 
@@ -76,6 +79,12 @@ func (rs *RulesetProcessor) ExportRuleSet() *Ruleset {
 		conf.Log.Errorf("fail to get all rules: %v", err)
 		return nil
 	}
+	// The rules of the views are created by the view statements
+	for id := range rules {
+		if _, ok := rs.v.ViewOfRule(id); ok {
+			delete(rules, id)
+		}
+	}
 	all.Rules = rules
 	return all
 }
@@ -112,7 +121,7 @@ func (rs *RulesetProcessor) Import(content []byte) ([]string, []int, error) {
 	counts := make([]int, 3)
 	// restore streams
 	for k, v := range all.Streams {
-		_, e := rs.s.ExecStreamSql(v)
+		_, e := rs.s.InNamespace(namespace.Of(k)).ExecStreamSql(v)
 		if e != nil {
 			conf.Log.Errorf("Fail to import stream %s(%s) with error: %v", k, v, e)
 		} else {
@@ -121,7 +130,7 @@ func (rs *RulesetProcessor) Import(content []byte) ([]string, []int, error) {
 	}
 	// restore tables
 	for k, v := range all.Tables {
-		_, e := rs.s.ExecStreamSql(v)
+		_, e := rs.s.InNamespace(namespace.Of(k)).ExecStreamSql(v)
 		if e != nil {
 			conf.Log.Errorf("Fail to import table %s(%s) with error: %v", k, v, e)
 		} else {
@@ -156,7 +165,7 @@ func (rs *RulesetProcessor) ImportRuleSet(all Ruleset) Ruleset {
 	counts := make([]int, 3)
 	// restore streams
 	for k, v := range all.Streams {
-		_, e := rs.s.ExecStreamSql(v)
+		_, e := rs.s.InNamespace(namespace.Of(k)).ExecStreamSql(v)
 		if e != nil {
 			conf.Log.Errorf("Fail to import stream %s(%s) with error: %v", k, v, e)
 			_ = rs.s.streamStatusDb.Set(k, e.Error())
@@ -167,7 +176,7 @@ func (rs *RulesetProcessor) ImportRuleSet(all Ruleset) Ruleset {
 	}
 	// restore tables
 	for k, v := range all.Tables {
-		_, e := rs.s.ExecStreamSql(v)
+		_, e := rs.s.InNamespace(namespace.Of(k)).ExecStreamSql(v)
 		if e != nil {
 			conf.Log.Errorf("Fail to import table %s(%s) with error: %v", k, v, e)
 			_ = rs.s.tableStatusDb.Set(k, e.Error())
@@ -24,6 +24,7 @@ import (
 	"golang.org/x/text/language"
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/schema"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
@@ -40,6 +41,8 @@ type StreamProcessor struct {
 	db             kv.KeyValue
 	streamStatusDb kv.KeyValue
 	tableStatusDb  kv.KeyValue
+	// ns is the namespace of the view, empty for the processor of all namespaces
+	ns string
 }
 
 type StreamDetail struct {
@@ -69,6 +72,16 @@ func NewStreamProcessor() *StreamProcessor {
 	return processor
 }
 
+// InNamespace returns the processor which only sees the streams and tables of the namespace by their names
+func (p *StreamProcessor) InNamespace(ns string) *StreamProcessor {
+	return &StreamProcessor{
+		db:             namespace.KV(p.db, ns),
+		streamStatusDb: namespace.KV(p.streamStatusDb, ns),
+		tableStatusDb:  namespace.KV(p.tableStatusDb, ns),
+		ns:             ns,
+	}
+}
+
 func (p *StreamProcessor) ExecStmt(statement string) (result []string, err error) {
 	defer func() {
 		if err != nil {
@@ -149,8 +162,9 @@ func (p *StreamProcessor) RecoverLookupTable() (err error) {
 				}
 				switch s := stmt.(type) {
 				case *ast.StreamStmt:
-					log.Infof("Starting lookup table %s", s.Name)
-					e = lookup.CreateInstance(string(s.Name), s.Options.TYPE, s.Options)
+					log.Infof("Starting lookup table %s", k)
+					// the key is the qualified name of the table
+					e = lookup.CreateInstance(k, s.Options.TYPE, s.Options)
 					if e != nil {
 						log.Errorf("%s", e.Error())
 					}
@@ -165,10 +179,14 @@ func (p *StreamProcessor) RecoverLookupTable() (err error) {
 }
 
 func (p *StreamProcessor) execSave(stmt *ast.StreamStmt, statement string, replace bool) error {
+	if err := namespace.ValidateName(string(stmt.Name)); err != nil {
+		return err
+	}
 	if stmt.StreamType == ast.TypeTable && stmt.Options.KIND == ast.StreamKindLookup {
-		_ = lookup.DropInstance(string(stmt.Name))
-		log.Infof("Creating lookup table %s", stmt.Name)
-		err := lookup.CreateInstance(string(stmt.Name), stmt.Options.TYPE, stmt.Options)
+		name := namespace.Qualify(p.ns, string(stmt.Name))
+		_ = lookup.DropInstance(name)
+		log.Infof("Creating lookup table %s", name)
+		err := lookup.CreateInstance(name, stmt.Options.TYPE, stmt.Options)
 		if err != nil {
 			return err
 		}
@@ -379,7 +397,7 @@ func (p *StreamProcessor) execDescribe(stmt ast.NameNode, st ast.StreamType) (r
 		buff.WriteString("Fields\n--------------------------------------------------------------------------------\n")
 		for _, f := range s.StreamFields {
 			buff.WriteString(f.Name + "\t")
-			buff.WriteString(printFieldType(f.FieldType))
+			buff.WriteString(ast.PrintFieldType(f.FieldType))
 			buff.WriteString("\n")
 		}
 		buff.WriteString("\n")
@@ -530,7 +548,7 @@ func (p *StreamProcessor) DropStream(name string, st ast.StreamType) (r string,
 		}
 	}()
 	if st == ast.TypeTable {
-		err := lookup.DropInstance(name)
+		err := lookup.DropInstance(namespace.Qualify(p.ns, name))
 		if err != nil {
 			return "", err
 		}
@@ -548,34 +566,6 @@ func (p *StreamProcessor) DropStream(name string, st ast.StreamType) (r string,
 	}
 }
 
-func printFieldType(ft ast.FieldType) (result string) {
-	switch t := ft.(type) {
-	case *ast.BasicType:
-		result = t.Type.String()
-	case *ast.ArrayType:
-		result = "array("
-		if t.FieldType != nil {
-			result += printFieldType(t.FieldType)
-		} else {
-			result += t.Type.String()
-		}
-		result += ")"
-	case *ast.RecType:
-		result = "struct("
-		isFirst := true
-		for _, f := range t.StreamFields {
-			if isFirst {
-				isFirst = false
-			} else {
-				result += ", "
-			}
-			result = result + f.Name + " " + printFieldType(f.FieldType)
-		}
-		result += ")"
-	}
-	return
-}
-
 // GetAll return all streams and tables defined to export.
 func (p *StreamProcessor) GetAll() (result map[string]map[string]string, err error) {
 	defs, e := p.db.All()
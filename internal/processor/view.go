@@ -0,0 +1,259 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+var (
+	viewNameRegex   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	viewPrefixRegex = regexp.MustCompile(`(?is)^\s*CREATE\s+VIEW\s+\S+\s+AS\s+`)
+)
+
+// ViewInfo is the definition of a materialized view
+type ViewInfo struct {
+	Name      string `json:"name"`
+	Statement string `json:"statement"`
+	// Sql is the select statement to maintain the view
+	Sql string `json:"sql"`
+	// Keys are the output fields of the GROUP BY dimensions to upsert the rows
+	Keys []string `json:"keys,omitempty"`
+}
+
+// ViewRulePrefix is the prefix of the ids of the rules which maintain the views. Only the ids of the existing views are
+// reserved, so the user rules with the same prefix are still managed by the rule APIs.
+const ViewRulePrefix = "view_"
+
+// RuleId is the id of the rule which maintains the view continuously
+func (v *ViewInfo) RuleId() string {
+	return ViewRulePrefix + v.Name
+}
+
+// RuleJson is the definition of the rule which maintains the view continuously
+func (v *ViewInfo) RuleJson() (string, error) {
+	props := map[string]any{"name": v.Name}
+	if len(v.Keys) > 0 {
+		props["keys"] = v.Keys
+	}
+	b, err := json.Marshal(map[string]any{
+		"id":  v.RuleId(),
+		"sql": v.Sql,
+		"actions": []map[string]any{
+			{"view": props},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+type ViewProcessor struct {
+	db kv.KeyValue
+}
+
+func NewViewProcessor() *ViewProcessor {
+	db, err := store.GetKV("view")
+	if err != nil {
+		panic(fmt.Sprintf("Can not initialize store for the view processor at path 'view': %v", err))
+	}
+	return &ViewProcessor{db: db}
+}
+
+// ExecCreate parses the CREATE VIEW statement and saves the view definition
+func (p *ViewProcessor) ExecCreate(statement string) (*ViewInfo, error) {
+	parser := xsql.NewParser(strings.NewReader(statement))
+	stmt, err := xsql.Language.Parse(parser)
+	if err != nil {
+		return nil, err
+	}
+	vs, ok := stmt.(*ast.ViewStmt)
+	if !ok {
+		return nil, fmt.Errorf("Invalid view statement: %s", statement)
+	}
+	if !viewNameRegex.MatchString(vs.Name) {
+		return nil, fmt.Errorf("invalid view name %s: only letters, digits and underscore are allowed", vs.Name)
+	}
+	keys, err := viewKeys(vs.Select)
+	if err != nil {
+		return nil, err
+	}
+	vi := &ViewInfo{
+		Name:      vs.Name,
+		Statement: statement,
+		Sql:       viewPrefixRegex.ReplaceAllString(statement, ""),
+		Keys:      keys,
+	}
+	s, err := json.Marshal(vi)
+	if err != nil {
+		return nil, fmt.Errorf("error when saving to db: %v.", err)
+	}
+	if err := p.db.Setnx(vi.Name, string(s)); err != nil {
+		return nil, fmt.Errorf("view %s already exists", vi.Name)
+	}
+	return vi, nil
+}
+
+// viewKeys finds the output field names of the GROUP BY dimensions
+func viewKeys(stmt *ast.SelectStatement) ([]string, error) {
+	var keys []string
+	for _, d := range stmt.Dimensions {
+		fr, ok := d.Expr.(*ast.FieldRef)
+		if !ok {
+			if _, isWindow := d.Expr.(*ast.Window); isWindow {
+				continue
+			}
+			return nil, fmt.Errorf("view only supports group by fields")
+		}
+		found := false
+		for _, f := range stmt.Fields {
+			if _, ok := f.Expr.(*ast.Wildcard); ok {
+				keys = append(keys, fr.Name)
+				found = true
+				break
+			}
+			if r, ok := f.Expr.(*ast.FieldRef); (ok && r.Name == fr.Name) || f.AName == fr.Name {
+				keys = append(keys, f.GetName())
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("group by field %s must be selected in the view", fr.Name)
+		}
+	}
+	return keys, nil
+}
+
+func (p *ViewProcessor) GetView(name string) (*ViewInfo, error) {
+	var v string
+	ok, err := p.db.Get(name, &v)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("view %s is not found", name)
+	}
+	vi := &ViewInfo{}
+	if err := json.Unmarshal(cast.StringToBytes(v), vi); err != nil {
+		return nil, fmt.Errorf("error unmarshall view %s, the data in db may be corrupted", name)
+	}
+	return vi, nil
+}
+
+func (p *ViewProcessor) ShowViews() ([]string, error) {
+	keys, err := p.db.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("Show views fails, error when loading data from db: %v.", err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ViewOfRule returns the view name if the rule maintains an existing view. Such rules can only be created and dropped
+// by the view statements.
+func (p *ViewProcessor) ViewOfRule(ruleId string) (string, bool) {
+	name, ok := strings.CutPrefix(ruleId, ViewRulePrefix)
+	if !ok {
+		return "", false
+	}
+	if _, err := p.GetView(name); err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// DropView deletes the view definition and all its rows
+func (p *ViewProcessor) DropView(name string) error {
+	if err := p.db.Delete(name); err != nil {
+		return fmt.Errorf("Drop view fails: %s.", err)
+	}
+	return state.DropViewStore(name)
+}
+
+// QueryWhere returns the rows of the view filtered by the condition expression
+func (p *ViewProcessor) QueryWhere(name string, where string) ([]map[string]any, error) {
+	var cond ast.Expr
+	if where != "" {
+		var err error
+		cond, err = xsql.NewParser(strings.NewReader(where)).ParseExpr()
+		if err != nil {
+			return nil, fmt.Errorf("invalid where condition %s: %v", where, err)
+		}
+	}
+	return p.Query(name, nil, cond)
+}
+
+// Query returns the rows of the view filtered by the condition and projected by the fields.
+// All fields are returned if fields is empty.
+func (p *ViewProcessor) Query(name string, fields ast.Fields, cond ast.Expr) ([]map[string]any, error) {
+	if _, err := p.GetView(name); err != nil {
+		return nil, err
+	}
+	vs, err := state.GetViewStore(name)
+	if err != nil {
+		return nil, err
+	}
+	fv, _ := xsql.NewFunctionValuersForOp(kctx.Background())
+	result := make([]map[string]any, 0)
+	for _, row := range vs.Rows() {
+		ve := &xsql.ValuerEval{Valuer: xsql.MultiValuer(&xsql.Tuple{Message: row}, fv)}
+		if cond != nil {
+			switch r := ve.Eval(cond).(type) {
+			case error:
+				return nil, fmt.Errorf("run Where error: %s", r)
+			case bool:
+				if !r {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if len(fields) == 0 {
+			result = append(result, row)
+			continue
+		}
+		projected := make(map[string]any, len(fields))
+		for _, f := range fields {
+			if _, ok := f.Expr.(*ast.Wildcard); ok {
+				for k, v := range row {
+					projected[k] = v
+				}
+				continue
+			}
+			v := ve.Eval(f.Expr)
+			if e, ok := v.(error); ok {
+				return nil, fmt.Errorf("run Select error: %s", e)
+			}
+			projected[f.GetName()] = v
+		}
+		result = append(result, projected)
+	}
+	return result, nil
+}
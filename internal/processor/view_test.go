@@ -0,0 +1,89 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+)
+
+func TestViewProcessor(t *testing.T) {
+	p := NewViewProcessor()
+	vi, err := p.ExecCreate("CREATE VIEW latest_by_device AS SELECT deviceId AS id, last_value(temp, true) AS temp FROM demo GROUP BY deviceId")
+	require.NoError(t, err)
+	defer func() {
+		_ = p.DropView("latest_by_device")
+	}()
+	require.Equal(t, &ViewInfo{
+		Name:      "latest_by_device",
+		Statement: "CREATE VIEW latest_by_device AS SELECT deviceId AS id, last_value(temp, true) AS temp FROM demo GROUP BY deviceId",
+		Sql:       "SELECT deviceId AS id, last_value(temp, true) AS temp FROM demo GROUP BY deviceId",
+		Keys:      []string{"id"},
+	}, vi)
+	rj, err := vi.RuleJson()
+	require.NoError(t, err)
+	require.Equal(t, `{"actions":[{"view":{"keys":["id"],"name":"latest_by_device"}}],"id":"view_latest_by_device","sql":"SELECT deviceId AS id, last_value(temp, true) AS temp FROM demo GROUP BY deviceId"}`, rj)
+
+	// only the rule ids of the existing views are reserved
+	name, ok := p.ViewOfRule("view_latest_by_device")
+	require.True(t, ok)
+	require.Equal(t, "latest_by_device", name)
+	_, ok = p.ViewOfRule("view_not_exist")
+	require.False(t, ok)
+	_, ok = p.ViewOfRule("latest_by_device")
+	require.False(t, ok)
+
+	_, err = p.ExecCreate("CREATE VIEW latest_by_device AS SELECT * FROM demo")
+	require.EqualError(t, err, "view latest_by_device already exists")
+	_, err = p.ExecCreate("CREATE VIEW v2 AS SELECT count(*) FROM demo GROUP BY deviceId, TUMBLINGWINDOW(ss, 10)")
+	require.EqualError(t, err, "group by field deviceId must be selected in the view")
+	_, err = p.ExecCreate("CREATE STREAM demo () WITH (DATASOURCE=\"demo\")")
+	require.EqualError(t, err, "Invalid view statement: CREATE STREAM demo () WITH (DATASOURCE=\"demo\")")
+
+	views, err := p.ShowViews()
+	require.NoError(t, err)
+	require.Equal(t, []string{"latest_by_device"}, views)
+
+	vs, err := state.GetViewStore("latest_by_device")
+	require.NoError(t, err)
+	require.NoError(t, vs.Upsert(`["d1"]`, map[string]any{"id": "d1", "temp": 20.5}))
+	require.NoError(t, vs.Upsert(`["d2"]`, map[string]any{"id": "d2", "temp": 25.0}))
+
+	rows, err := p.QueryWhere("latest_by_device", "")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	rows, err = p.QueryWhere("latest_by_device", "temp > 21")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"id": "d2", "temp": 25.0}}, rows)
+	_, err = p.QueryWhere("latest_by_device", "temp >")
+	require.Error(t, err)
+	_, err = p.QueryWhere("nonexist", "")
+	require.EqualError(t, err, "view nonexist is not found")
+
+	sel, err := xsql.NewParser(strings.NewReader("SELECT id, temp * 2 AS t2 FROM latest_by_device WHERE id = \"d1\"")).Parse()
+	require.NoError(t, err)
+	rows, err = p.Query("latest_by_device", sel.Fields, sel.Condition)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"id": "d1", "t2": 41.0}}, rows)
+
+	require.NoError(t, p.DropView("latest_by_device"))
+	_, err = p.GetView("latest_by_device")
+	require.EqualError(t, err, "view latest_by_device is not found")
+}
@@ -181,6 +181,71 @@ func CreateOrUpdateSchema(info *Info) error {
 	return nil
 }
 
+// UpdateSchema replaces a registered schema. The new files are prepared aside at first and then swapped in,
+// so that the old schema is still available if the new one fails to be prepared.
+func UpdateSchema(info *Info) error {
+	if _, err := GetSchemaFile(info.Type, info.Name); err != nil {
+		return err
+	}
+	dataDir, _ := conf.GetDataLoc()
+	etcDir := filepath.Join(dataDir, "schemas", string(info.Type))
+	if err := os.MkdirAll(etcDir, os.ModePerm); err != nil {
+		return err
+	}
+	// The staging dir is out of the schema type dirs, so it is never loaded as schemas
+	stageDir, err := os.MkdirTemp(filepath.Join(dataDir, "schemas"), "staging")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+	// the staged file to the target file
+	staged := make(map[string]string, 2)
+	ffs := &Files{}
+	if info.Content != "" || info.FilePath != "" {
+		name := info.Name + schemaExt[info.Type]
+		tmp := filepath.Join(stageDir, name)
+		if info.Content != "" {
+			err = os.WriteFile(tmp, cast.StringToBytes(info.Content), 0o666)
+		} else {
+			err = httpx.DownloadFile(tmp, info.FilePath)
+		}
+		if err != nil {
+			return err
+		}
+		ffs.SchemaFile = filepath.Join(etcDir, name)
+		staged[tmp] = ffs.SchemaFile
+	}
+	if info.SoPath != "" {
+		name := info.Name + ".so"
+		tmp := filepath.Join(stageDir, name)
+		if err := httpx.DownloadFile(tmp, info.SoPath); err != nil {
+			return err
+		}
+		ffs.SoFile = filepath.Join(etcDir, name)
+		staged[tmp] = ffs.SoFile
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	old := registry.schemas[info.Type][info.Name]
+	for tmp, target := range staged {
+		if err := os.Rename(tmp, target); err != nil {
+			return err
+		}
+	}
+	if old != nil {
+		if old.SchemaFile != "" && ffs.SchemaFile == "" {
+			_ = os.Remove(old.SchemaFile)
+		}
+		if old.SoFile != "" && ffs.SoFile == "" {
+			_ = os.Remove(old.SoFile)
+		}
+	}
+	registry.schemas[info.Type][info.Name] = ffs
+	storeSchemaInstallScript(info)
+	return nil
+}
+
 func GetSchema(schemaType def.SchemaType, name string) (*Info, error) {
 	schemaFile, err := GetSchemaFile(schemaType, name)
 	if err != nil {
@@ -1,4 +1,4 @@
-// Copyright 2022-2023 EMQ Technologies Co., Ltd.
+// Copyright 2022-2024 EMQ Technologies Co., Ltd.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -22,6 +22,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/testx"
 )
@@ -303,3 +305,40 @@ func checkFile(etcDir string, schemas []string, t *testing.T) {
 		}
 	}
 }
+
+func TestUpdateSchema(t *testing.T) {
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(t, err)
+	etcDir := filepath.Join(dataDir, "schemas", "protobuf")
+	defer os.RemoveAll(etcDir)
+	require.NoError(t, InitRegistry())
+	s := httptest.NewServer(
+		http.FileServer(http.Dir("test")),
+	)
+	defer s.Close()
+
+	require.EqualError(t, UpdateSchema(&Info{Name: "upd", Type: "protobuf", Content: "message Book{required string name = 1;}"}), "schema type protobuf, file upd not found")
+	require.NoError(t, Register(&Info{Name: "upd", Type: "protobuf", Content: "message Book{required string name = 1;}"}))
+	defer DeleteSchema("protobuf", "upd")
+	// The old schema is kept if the new one cannot be prepared
+	err = UpdateSchema(&Info{Name: "upd", Type: "protobuf", FilePath: s.URL + "/notexist.proto"})
+	require.Error(t, err)
+	info, err := GetSchema("protobuf", "upd")
+	require.NoError(t, err)
+	require.Equal(t, "message Book{required string name = 1;}", info.Content)
+	entries, err := os.ReadDir(filepath.Join(dataDir, "schemas"))
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.NotContains(t, e.Name(), "staging")
+	}
+	// Swap to the new one
+	require.NoError(t, UpdateSchema(&Info{Name: "upd", Type: "protobuf", FilePath: s.URL + "/test2.proto", SoPath: s.URL + "/fake.so"}))
+	info, err = GetSchema("protobuf", "upd")
+	require.NoError(t, err)
+	content, err := os.ReadFile("test/test2.proto")
+	require.NoError(t, err)
+	require.Equal(t, string(content), info.Content)
+	require.Equal(t, filepath.Join(etcDir, "upd.so"), info.SoPath)
+	_, script := GetSchemaInstallScript("protobuf_upd")
+	require.Contains(t, script, "test2.proto")
+}
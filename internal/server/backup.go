@@ -0,0 +1,536 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/filex"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/encryption"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+const (
+	backupVersion      = 1
+	backupManifestFile = "manifest.json"
+	// The folder in the data dir to stage the restore which is applied in the next start
+	restoreDir = ".restore"
+)
+
+// The paths in the backup. The ones of data and plugins are replaced as a whole in restore, while the files of etc
+// are overwritten one by one because etc also has the default configurations of the installation.
+// The secret key is not backed up, otherwise anyone with the archive can decrypt the secrets in it. It must be kept and
+// restored separately.
+var (
+	backupDataPaths   = []string{"uploads", "schemas", "connections", "sources", "sinks", "functions", "services", encryption.KeyringFile}
+	backupPluginPaths = []string{"portable", "sources", "sinks", "functions"}
+	backupEtcPaths    = []string{"connections", "sources", "sinks", "functions", "services"}
+)
+
+// backupManifest describes the archive. The archive has the store snapshots under store, and the files under data,
+// plugins and etc which are relative to the corresponding dir.
+type backupManifest struct {
+	Version       int    `json:"version"`
+	KuiperVersion string `json:"kuiperVersion"`
+	CreatedAt     int64  `json:"createdAt"`
+	StoreType     string `json:"storeType"`
+	ExtStateType  string `json:"extStateType"`
+	// Stores are the paths of the store snapshots under store
+	Stores []string `json:"stores"`
+	// Paths are the backup paths which exist when backing up. The other backup paths are removed in restore.
+	Paths []string `json:"paths"`
+	// Files are the archive path of each file to its sha256
+	Files map[string]string `json:"files"`
+}
+
+// back up the stores and files into a zip archive while the rules keep running
+func backupHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	tmp, err := os.MkdirTemp("", "kuiper-backup")
+	if err != nil {
+		handleError(w, err, "backup error", logger)
+		return
+	}
+	defer os.RemoveAll(tmp)
+	archive := filepath.Join(tmp, "backup.zip")
+	if err := createBackup(filepath.Join(tmp, "store"), archive); err != nil {
+		handleError(w, err, "backup error", logger)
+		return
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		handleError(w, err, "backup error", logger)
+		return
+	}
+	defer f.Close()
+	name := fmt.Sprintf("kuiper-backup-%s.zip", time.Now().Format("20060102150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+	http.ServeContent(w, r, name, time.Now(), f)
+}
+
+// validate the uploaded archive and stage it. The running instance is not changed, the restore is applied in the next
+// start and the response tells that the restart is required. Restoring again replaces the staged one.
+func restoreHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	tmp, err := os.CreateTemp("", "kuiper-restore-*.zip")
+	if err != nil {
+		handleError(w, err, "restore error", logger)
+		return
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	size, err := io.Copy(tmp, r.Body)
+	if err != nil {
+		handleError(w, err, "Invalid body: Error reading the archive", logger)
+		return
+	}
+	m, err := stageRestore(tmp, size)
+	if err != nil {
+		handleError(w, err, "restore error", logger)
+		return
+	}
+	logger.Warnf("the backup created at %s is staged, restart to apply it", time.UnixMilli(m.CreatedAt).Format(time.RFC3339))
+	jsonResponse(newRestoreStatus(m), w, logger)
+}
+
+// restoreStatus tells whether a staged restore is waiting for the restart to be applied
+type restoreStatus struct {
+	Pending         bool   `json:"pending"`
+	RestartRequired bool   `json:"restartRequired"`
+	CreatedAt       string `json:"createdAt,omitempty"`
+	Message         string `json:"message"`
+}
+
+func newRestoreStatus(m *backupManifest) *restoreStatus {
+	if m == nil {
+		return &restoreStatus{Message: "No restore is pending."}
+	}
+	return &restoreStatus{
+		Pending:         true,
+		RestartRequired: true,
+		CreatedAt:       time.UnixMilli(m.CreatedAt).Format(time.RFC3339),
+		Message:         "The backup is staged and is not applied to the running instance. Restart eKuiper to apply it.",
+	}
+}
+
+// show whether a staged restore is waiting for the restart
+func restoreStatusHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	m, err := stagedRestore()
+	if err != nil {
+		handleError(w, err, "restore status error", logger)
+		return
+	}
+	jsonResponse(newRestoreStatus(m), w, logger)
+}
+
+// discard the staged restore, so the running instance can be changed again
+func restoreDiscardHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	m, err := stagedRestore()
+	if err != nil {
+		handleError(w, err, "discard restore error", logger)
+		return
+	}
+	if m == nil {
+		handleError(w, errorx.NewWithCode(errorx.NOT_FOUND, "no restore is pending"), "discard restore error", logger)
+		return
+	}
+	dataDir, err := conf.GetDataLoc()
+	if err != nil {
+		handleError(w, err, "discard restore error", logger)
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(dataDir, restoreDir)); err != nil {
+		handleError(w, err, "discard restore error", logger)
+		return
+	}
+	logger.Infof("the staged restore of the backup created at %s is discarded", time.UnixMilli(m.CreatedAt).Format(time.RFC3339))
+	jsonResponse(newRestoreStatus(nil), w, logger)
+}
+
+// checkNoPendingRestore returns an error if a staged restore is waiting for the restart. The changes made until the
+// restart are overwritten by the restore, so the operations which change the instance as a whole are refused.
+func checkNoPendingRestore() error {
+	m, err := stagedRestore()
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		return fmt.Errorf("the restore of the backup created at %s is pending, restart eKuiper to apply it or discard it by DELETE /admin/restore", time.UnixMilli(m.CreatedAt).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// blockOnPendingRestore refuses the request with 409 if a staged restore is waiting for the restart
+func blockOnPendingRestore(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkNoPendingRestore(); err != nil {
+			logger.Error(err)
+			http.Error(w, packageInternalErrorCode(err, err.Error()), http.StatusConflict)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// stagedRestore returns the manifest of the staged restore or nil if there is none
+func stagedRestore() (*backupManifest, error) {
+	dataDir, err := conf.GetDataLoc()
+	if err != nil {
+		return nil, err
+	}
+	m := &backupManifest{}
+	if err := filex.ReadJsonUnmarshal(filepath.Join(dataDir, restoreDir, backupManifestFile), m); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read the manifest of the restore error: %v", err)
+	}
+	return m, nil
+}
+
+func backupRoots() (map[string]string, error) {
+	dataDir, err := conf.GetDataLoc()
+	if err != nil {
+		return nil, err
+	}
+	pluginsDir, err := conf.GetPluginsLoc()
+	if err != nil {
+		return nil, err
+	}
+	etcDir, err := conf.GetConfLoc()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"data": dataDir, "plugins": pluginsDir, "etc": etcDir}, nil
+}
+
+func backupPaths() map[string][]string {
+	return map[string][]string{"data": backupDataPaths, "plugins": backupPluginPaths, "etc": backupEtcPaths}
+}
+
+func createBackup(storeDir, archive string) error {
+	roots, err := backupRoots()
+	if err != nil {
+		return err
+	}
+	m := &backupManifest{
+		Version:       backupVersion,
+		KuiperVersion: version,
+		CreatedAt:     time.Now().UnixMilli(),
+		StoreType:     conf.Config.Store.Type,
+		ExtStateType:  conf.Config.Store.ExtStateType,
+		Files:         make(map[string]string),
+	}
+	m.Stores, err = store.Snapshot(storeDir)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, p := range m.Stores {
+		if err := addToArchive(zw, m, path.Join("store", filepath.ToSlash(p)), filepath.Join(storeDir, p)); err != nil {
+			return err
+		}
+	}
+	for _, root := range []string{"data", "plugins", "etc"} {
+		for _, p := range backupPaths()[root] {
+			src := filepath.Join(roots[root], p)
+			if _, err := os.Stat(src); os.IsNotExist(err) {
+				continue
+			}
+			ap := path.Join(root, p)
+			if err := addToArchive(zw, m, ap, src); err != nil {
+				return err
+			}
+			m.Paths = append(m.Paths, ap)
+		}
+	}
+	mw, err := zw.Create(backupManifestFile)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(mw).Encode(m); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addToArchive adds the file or the folder recursively to the archive path
+func addToArchive(zw *zip.Writer, m *backupManifest, archivePath, src string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(archivePath, filepath.ToSlash(rel))
+		if d.IsDir() {
+			_, err = zw.Create(name + "/")
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fh, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		fh.Name = name
+		fh.Method = zip.Deflate
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(w, h), f); err != nil {
+			return err
+		}
+		m.Files[name] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+}
+
+// stageRestore validates the archive and extracts it to the restore dir. The restore dir is renamed from a temp dir
+// after all files are extracted, so a staged restore is always complete.
+func stageRestore(r io.ReaderAt, size int64) (*backupManifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %v", err)
+	}
+	m, err := validateBackup(zr)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := conf.GetDataLoc()
+	if err != nil {
+		return nil, err
+	}
+	staging := filepath.Join(dataDir, restoreDir+".tmp")
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if err := filex.UnzipTo(f, filepath.Join(staging, filepath.FromSlash(f.Name))); err != nil {
+			_ = os.RemoveAll(staging)
+			return nil, err
+		}
+	}
+	target := filepath.Join(dataDir, restoreDir)
+	if err := os.RemoveAll(target); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(staging, target); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func validateBackup(zr *zip.Reader) (*backupManifest, error) {
+	var m *backupManifest
+	for _, f := range zr.File {
+		if f.Name != backupManifestFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		m = &backupManifest{}
+		err = json.NewDecoder(rc).Decode(m)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: %v", err)
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("invalid archive: %s is not found", backupManifestFile)
+	}
+	if m.Version != backupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", m.Version)
+	}
+	if m.StoreType != conf.Config.Store.Type || m.ExtStateType != conf.Config.Store.ExtStateType {
+		return nil, fmt.Errorf("the backup uses store type %s and ext state type %s but the current ones are %s and %s", m.StoreType, m.ExtStateType, conf.Config.Store.Type, conf.Config.Store.ExtStateType)
+	}
+	checked := 0
+	for _, f := range zr.File {
+		if f.Name == backupManifestFile {
+			continue
+		}
+		if !isBackupPath(m, f.Name) {
+			return nil, fmt.Errorf("invalid archive: unexpected file %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		expected, ok := m.Files[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("invalid archive: file %s is not in the manifest", f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: read %s error: %v", f.Name, err)
+		}
+		if hex.EncodeToString(h.Sum(nil)) != expected {
+			return nil, fmt.Errorf("invalid archive: the checksum of %s mismatches", f.Name)
+		}
+		checked++
+	}
+	if checked != len(m.Files) {
+		return nil, fmt.Errorf("invalid archive: %d files are missing", len(m.Files)-checked)
+	}
+	return m, nil
+}
+
+// isBackupPath checks if the archive path is under the store snapshots or the backup paths
+func isBackupPath(m *backupManifest, name string) bool {
+	name = strings.TrimSuffix(name, "/")
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || strings.Contains(name, "\\") {
+		return false
+	}
+	for _, s := range m.Stores {
+		if isUnder(name, path.Join("store", s)) {
+			return true
+		}
+	}
+	for root, paths := range backupPaths() {
+		for _, p := range paths {
+			if isUnder(name, path.Join(root, p)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isUnder(name, p string) bool {
+	return name == p || strings.HasPrefix(name, p+"/")
+}
+
+// applyRestore applies the staged restore before the stores are set up. Each path is moved from the restore dir, so
+// it can be applied again if interrupted.
+func applyRestore(sc *store.StoreConf) error {
+	dataDir, err := conf.GetDataLoc()
+	if err != nil {
+		return err
+	}
+	_ = os.RemoveAll(filepath.Join(dataDir, restoreDir+".tmp"))
+	dir := filepath.Join(dataDir, restoreDir)
+	m, err := stagedRestore()
+	if err != nil || m == nil {
+		return err
+	}
+	conf.Log.Infof("restore the backup created at %s", time.UnixMilli(m.CreatedAt).Format(time.RFC3339))
+	if err := store.RestoreSnapshot(sc, filepath.Join(dir, "store"), m.Stores); err != nil {
+		return err
+	}
+	roots, err := backupRoots()
+	if err != nil {
+		return err
+	}
+	for _, root := range []string{"data", "plugins"} {
+		for _, p := range backupPaths()[root] {
+			src := filepath.Join(dir, root, p)
+			target := filepath.Join(roots[root], p)
+			if _, err := os.Stat(src); err == nil {
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+				if err := filex.Move(src, target); err != nil {
+					return err
+				}
+			} else if !contains(m.Paths, path.Join(root, p)) {
+				// Not exist when backing up
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	etcSrc := filepath.Join(dir, "etc")
+	err = filepath.WalkDir(etcSrc, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(etcSrc, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(roots["etc"], rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, b, 0o644)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/sql"
+)
+
+func TestBackupRestore(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, kv.Set("backupdemo", "create stream backupdemo"))
+	defer func() {
+		_ = kv.Delete("backupdemo")
+	}()
+	// Use a new base folder for the files, the stores are still the ones of the test
+	base := t.TempDir()
+	t.Setenv(conf.KuiperBaseKey, base)
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "data", "test"), os.ModePerm))
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dataDir, "uploads", "a.txt"), "a")
+	writeFile(t, filepath.Join(base, "plugins", "portable", "p1", "p1.json"), "{}")
+	writeFile(t, filepath.Join(base, "etc", "connections", "connection.yaml"), "mqtt: {}")
+	writeFile(t, filepath.Join(base, "etc", "kuiper.yaml"), "basic: {}")
+	writeFile(t, filepath.Join(dataDir, secret.KeyFile), "key")
+
+	tmp := t.TempDir()
+	archive := filepath.Join(tmp, "backup.zip")
+	require.NoError(t, createBackup(filepath.Join(tmp, "store"), archive))
+	b, err := os.ReadFile(archive)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	require.True(t, names["manifest.json"])
+	require.True(t, names["store/sqliteKV.db"])
+	require.True(t, names["data/uploads/a.txt"])
+	require.True(t, names["plugins/portable/p1/p1.json"])
+	require.True(t, names["etc/connections/connection.yaml"])
+	require.False(t, names["etc/kuiper.yaml"])
+	require.False(t, names["data/"+secret.KeyFile])
+
+	// Change after backup
+	require.NoError(t, os.Remove(filepath.Join(dataDir, "uploads", "a.txt")))
+	writeFile(t, filepath.Join(dataDir, "uploads", "b.txt"), "b")
+	writeFile(t, filepath.Join(dataDir, "schemas", "protobuf", "new.proto"), "syntax = \"proto3\";")
+	require.NoError(t, os.RemoveAll(filepath.Join(base, "plugins", "portable", "p1")))
+	writeFile(t, filepath.Join(base, "etc", "connections", "connection.yaml"), "mqtt: {changed: true}")
+
+	m, err := stageRestore(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	require.Equal(t, []string{"sqliteKV.db", "cache.db", "extState.db"}, m.Stores)
+	_, err = os.Stat(filepath.Join(dataDir, restoreDir, backupManifestFile))
+	require.NoError(t, err)
+	// Not applied until the next start
+	_, err = os.Stat(filepath.Join(dataDir, "uploads", "b.txt"))
+	require.NoError(t, err)
+	staged, err := stagedRestore()
+	require.NoError(t, err)
+	require.Equal(t, m.CreatedAt, staged.CreatedAt)
+
+	sc := &store.StoreConf{SqliteConfig: definition.SqliteConfig{Path: dataDir}}
+	require.NoError(t, applyRestore(sc))
+	_, err = os.Stat(filepath.Join(dataDir, restoreDir))
+	require.True(t, os.IsNotExist(err))
+	requireFile(t, filepath.Join(dataDir, "uploads", "a.txt"), "a")
+	_, err = os.Stat(filepath.Join(dataDir, "uploads", "b.txt"))
+	require.True(t, os.IsNotExist(err))
+	// Not exist when backing up
+	_, err = os.Stat(filepath.Join(dataDir, "schemas"))
+	require.True(t, os.IsNotExist(err))
+	requireFile(t, filepath.Join(base, "plugins", "portable", "p1", "p1.json"), "{}")
+	requireFile(t, filepath.Join(base, "etc", "connections", "connection.yaml"), "mqtt: {}")
+	requireFile(t, filepath.Join(base, "etc", "kuiper.yaml"), "basic: {}")
+	// The secret key is kept
+	requireFile(t, filepath.Join(dataDir, secret.KeyFile), "key")
+	kb, _, err := sql.BuildStores(definition.Config{Sqlite: definition.SqliteConfig{Path: dataDir}}, "sqliteKV.db")
+	require.NoError(t, err)
+	restored, err := kb.CreateStore("stream")
+	require.NoError(t, err)
+	var v string
+	ok, err := restored.Get("backupdemo", &v)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "create stream backupdemo", v)
+	// Nothing to apply
+	staged, err = stagedRestore()
+	require.NoError(t, err)
+	require.Nil(t, staged)
+	require.NoError(t, applyRestore(sc))
+}
+
+func TestValidateBackup(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     *backupManifest
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "no manifest",
+			err:  "invalid archive: manifest.json is not found",
+		},
+		{
+			name: "version",
+			m:    &backupManifest{Version: 2},
+			err:  "unsupported backup version 2",
+		},
+		{
+			name: "store type",
+			m:    &backupManifest{Version: 1, StoreType: "redis", ExtStateType: "sqlite"},
+			err:  "the backup uses store type redis and ext state type sqlite but the current ones are sqlite and sqlite",
+		},
+		{
+			name:  "unexpected path",
+			m:     &backupManifest{Version: 1, StoreType: "sqlite", ExtStateType: "sqlite"},
+			files: map[string]string{"data/../../evil": "evil"},
+			err:   "invalid archive: unexpected file data/../../evil",
+		},
+		{
+			name:  "not in manifest",
+			m:     &backupManifest{Version: 1, StoreType: "sqlite", ExtStateType: "sqlite"},
+			files: map[string]string{"data/uploads/a.txt": "a"},
+			err:   "invalid archive: file data/uploads/a.txt is not in the manifest",
+		},
+		{
+			name:  "checksum",
+			m:     &backupManifest{Version: 1, StoreType: "sqlite", ExtStateType: "sqlite", Files: map[string]string{"data/uploads/a.txt": "00"}},
+			files: map[string]string{"data/uploads/a.txt": "a"},
+			err:   "invalid archive: the checksum of data/uploads/a.txt mismatches",
+		},
+		{
+			name: "missing",
+			m:    &backupManifest{Version: 1, StoreType: "sqlite", ExtStateType: "sqlite", Files: map[string]string{"data/uploads/a.txt": "00"}},
+			err:  "invalid archive: 1 files are missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			for name, content := range tt.files {
+				w, err := zw.Create(name)
+				require.NoError(t, err)
+				_, err = w.Write([]byte(content))
+				require.NoError(t, err)
+			}
+			if tt.m != nil {
+				w, err := zw.Create(backupManifestFile)
+				require.NoError(t, err)
+				require.NoError(t, json.NewEncoder(w).Encode(tt.m))
+			}
+			require.NoError(t, zw.Close())
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			require.NoError(t, err)
+			_, err = validateBackup(zr)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func (suite *RestTestSuite) TestBackupHandler() {
+	code, resp := suite.request(http.MethodPost, "/admin/backup", "")
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	zr, err := zip.NewReader(bytes.NewReader([]byte(resp)), int64(len(resp)))
+	require.NoError(suite.T(), err)
+	_, err = validateBackup(zr)
+	require.NoError(suite.T(), err)
+
+	code, _ = suite.request(http.MethodPost, "/admin/restore", "not a zip")
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+
+	code, status := suite.request(http.MethodGet, "/admin/restore", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	require.JSONEq(suite.T(), `{"pending":false,"restartRequired":false,"message":"No restore is pending."}`, status)
+
+	code, status = suite.request(http.MethodPost, "/admin/restore", resp)
+	require.Equal(suite.T(), http.StatusOK, code, status)
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(suite.T(), err)
+	defer os.RemoveAll(filepath.Join(dataDir, restoreDir))
+	s := &restoreStatus{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(status), s))
+	require.True(suite.T(), s.Pending)
+	require.True(suite.T(), s.RestartRequired)
+
+	code, status = suite.request(http.MethodGet, "/admin/restore", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	require.Contains(suite.T(), status, `"restartRequired":true`)
+
+	// The operations which change the whole instance are refused until the restart
+	code, status = suite.request(http.MethodPost, "/admin/backup", "")
+	require.Equal(suite.T(), http.StatusConflict, code)
+	require.Contains(suite.T(), status, "is pending, restart eKuiper to apply it or discard it by DELETE /admin/restore")
+	code, _ = suite.request(http.MethodPost, "/data/import", `{"content":"{}"}`)
+	require.Equal(suite.T(), http.StatusConflict, code)
+	code, _ = suite.request(http.MethodPost, "/ruleset/import", `{"content":"{}"}`)
+	require.Equal(suite.T(), http.StatusConflict, code)
+	// Restore again replaces the staged one
+	code, status = suite.request(http.MethodPost, "/admin/restore", resp)
+	require.Equal(suite.T(), http.StatusOK, code, status)
+
+	code, status = suite.request(http.MethodDelete, "/admin/restore", "")
+	require.Equal(suite.T(), http.StatusOK, code, status)
+	require.JSONEq(suite.T(), `{"pending":false,"restartRequired":false,"message":"No restore is pending."}`, status)
+	_, err = os.Stat(filepath.Join(dataDir, restoreDir))
+	require.True(suite.T(), os.IsNotExist(err))
+	code, _ = suite.request(http.MethodDelete, "/admin/restore", "")
+	require.Equal(suite.T(), http.StatusNotFound, code)
+	code, _ = suite.request(http.MethodPost, "/admin/backup", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+}
+
+func writeFile(t *testing.T, name, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), os.ModePerm))
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+}
+
+func requireFile(t *testing.T, name, content string) {
+	b, err := os.ReadFile(name)
+	require.NoError(t, err)
+	require.Equal(t, content, string(b))
+}
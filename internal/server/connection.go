@@ -20,8 +20,7 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/gorilla/mux"
-
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/pkg/connection"
 )
@@ -46,7 +45,11 @@ func connectionsHandler(w http.ResponseWriter, r *http.Request) {
 			handleError(w, err, "Invalid body", logger)
 			return
 		}
-		_, err = connection.CreateNamedConnection(context.Background(), req.ID, req.Typ, req.Props)
+		if err := namespace.ValidateName(req.ID); err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		_, err = connection.CreateNamedConnection(context.Background(), namespace.Qualify(namespaceOf(r), req.ID), req.Typ, req.Props)
 		if err != nil {
 			handleError(w, err, "create connection failed", logger)
 			return
@@ -55,10 +58,19 @@ func connectionsHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("success"))
 	case http.MethodGet:
 		forceAll, _ := strconv.ParseBool(r.URL.Query().Get("forceAll"))
+		ns := namespaceOf(r)
 		metaList := connection.GetAllConnectionsMeta(forceAll)
 		resp := make([]*ConnectionResponse, 0)
 		for _, meta := range metaList {
-			resp = append(resp, getConnectionRespByMeta(meta))
+			// the anonymous connections are only listed in the default namespace
+			if (meta.Named && namespace.Of(meta.ID) != ns) || (!meta.Named && ns != namespace.Default) {
+				continue
+			}
+			res := getConnectionRespByMeta(meta)
+			if ns != namespace.Default {
+				res.ID = namespace.Name(meta.ID)
+			}
+			resp = append(resp, res)
 		}
 		w.WriteHeader(http.StatusOK)
 		jsonResponse(resp, w, logger)
@@ -77,7 +89,7 @@ type ConnectionResponse struct {
 
 func connectionHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	id := mux.Vars(r)["id"]
+	id := qualifiedVar(r, "id")
 	switch r.Method {
 	case http.MethodGet:
 		meta, err := connection.GetConnectionDetail(context.Background(), id)
@@ -86,6 +98,9 @@ func connectionHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		res := getConnectionRespByMeta(meta)
+		if namespaceOf(r) != namespace.Default {
+			res.ID = namespace.Name(res.ID)
+		}
 		jsonResponse(res, w, logger)
 	case http.MethodDelete:
 		if err := connection.DropNameConnection(context.Background(), id); err != nil {
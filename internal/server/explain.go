@@ -0,0 +1,70 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+// explainAnalyzeResult is the physical topo of a running rule annotated with the runtime statistics of each node
+type explainAnalyzeResult struct {
+	// Plan is the logical plan of sql rules
+	Plan  string                     `json:"plan,omitempty"`
+	Topo  *def.PrintableTopo         `json:"topo"`
+	Nodes map[string]*node.NodeStats `json:"nodes"`
+}
+
+func explainRule(name string) (string, error) {
+	rule, err := ruleProcessor.GetRuleById(name)
+	if err != nil {
+		return "", err
+	}
+	if rule == nil {
+		return "", errorx.NewWithCode(errorx.NOT_FOUND, "rule not found")
+	}
+	if rule.Sql == "" {
+		return "", errors.New("only support explain sql now")
+	}
+	return planner.GetExplainInfoFromLogicalPlan(rule)
+}
+
+func explainAnalyzeRule(name string) (*explainAnalyzeResult, error) {
+	rs, ok := registry.load(name)
+	if !ok {
+		return nil, errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("Rule %s is not found", name))
+	}
+	graph, stats := rs.GetTopoStats()
+	if stats == nil {
+		return nil, fmt.Errorf("rule %s is not running, explain analyze requires a running rule", name)
+	}
+	result := &explainAnalyzeResult{
+		Topo:  graph,
+		Nodes: stats,
+	}
+	if rs.Rule.Sql != "" {
+		plan, err := planner.GetExplainInfoFromLogicalPlan(rs.Rule)
+		if err != nil {
+			return nil, err
+		}
+		result.Plan = plan
+	}
+	return result, nil
+}
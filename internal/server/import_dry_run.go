@@ -0,0 +1,472 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+// The kinds of the import content besides the ones of the reconciler
+const (
+	kindSourceConfig     = "sourceConfig"
+	kindSinkConfig       = "sinkConfig"
+	kindConnectionConfig = "connectionConfig"
+	kindNativePlugin     = "nativePlugin"
+	kindPortablePlugin   = "portablePlugin"
+	kindService          = "service"
+	kindScript           = "script"
+	kindUpload           = "upload"
+)
+
+// importKinds is the order of the import
+var importKinds = []string{
+	kindSourceConfig, kindSinkConfig, kindConnectionConfig, kindUpload, kindService, kindSchema,
+	kindNativePlugin, kindPortablePlugin, kindScript, kindStream, kindTable, kindRule,
+}
+
+// installOnlyKinds are only installed by the partial import if they do not exist
+var installOnlyKinds = map[string]bool{
+	kindNativePlugin:   true,
+	kindPortablePlugin: true,
+	kindService:        true,
+	kindSchema:         true,
+	kindScript:         true,
+}
+
+type importMode int
+
+const (
+	// importReplace resets all the data and then imports, which is the /data/import
+	importReplace importMode = iota
+	// importPartial is the /data/import?partial=1
+	importPartial
+	// importMerge replaces the resources with the same names, which is the /v2/data/import
+	importMerge
+)
+
+type ImportValidationError struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// ImportDryRunResult is what the import would change without applying it
+type ImportDryRunResult struct {
+	Valid bool `json:"valid"`
+	ReconcilePlan
+	// AffectedRules are the running rules which would be updated, deleted, restarted or read the changed streams and tables
+	AffectedRules []string                `json:"affectedRules"`
+	Errors        []ImportValidationError `json:"errors"`
+}
+
+// importItems are the flattened resources of the import content by kind and name
+type importItems map[string]map[string]string
+
+func newImportItems() importItems {
+	items := importItems{}
+	for _, k := range importKinds {
+		items[k] = map[string]string{}
+	}
+	return items
+}
+
+type importDryRun struct {
+	mode     importMode
+	incoming importItems
+	errs     []ImportValidationError
+}
+
+func (d *importDryRun) addError(kind, name string, err error) {
+	d.errs = append(d.errs, ImportValidationError{Kind: kind, Name: name, Error: err.Error()})
+}
+
+// addConfigs flattens the configurations of each plugin to the items named by plugin.confKey
+func addConfigs(items importItems, kind string, plugins map[string]string, onError func(kind, name string, err error)) {
+	for plugin, v := range plugins {
+		confs := map[string]map[string]any{}
+		if err := json.Unmarshal([]byte(v), &confs); err != nil {
+			onError(kind, plugin, err)
+			continue
+		}
+		for key, props := range confs {
+			items[kind][plugin+"."+key] = canonicalJson(props)
+		}
+	}
+}
+
+// canonicalValue returns the canonical json if the value is a json, otherwise the value itself
+func canonicalValue(v string) string {
+	var m any
+	if err := json.Unmarshal([]byte(v), &m); err != nil {
+		return v
+	}
+	return canonicalJson(m)
+}
+
+func itemsFromConfiguration(c *Configuration, onError func(kind, name string, err error)) importItems {
+	items := newImportItems()
+	addConfigs(items, kindSourceConfig, c.SourceConfig, onError)
+	addConfigs(items, kindSinkConfig, c.SinkConfig, onError)
+	addConfigs(items, kindConnectionConfig, c.ConnectionConfig, onError)
+	for kind, m := range map[string]map[string]string{
+		kindUpload:         c.Uploads,
+		kindService:        c.Service,
+		kindSchema:         c.Schema,
+		kindNativePlugin:   c.NativePlugins,
+		kindPortablePlugin: c.PortablePlugins,
+		kindScript:         c.Scripts,
+	} {
+		for k, v := range m {
+			items[kind][k] = canonicalValue(v)
+		}
+	}
+	for k, v := range c.Streams {
+		items[kindStream][k] = v
+	}
+	for k, v := range c.Tables {
+		items[kindTable][k] = v
+	}
+	for k, v := range c.Rules {
+		items[kindRule][k] = v
+	}
+	return items
+}
+
+func itemsFromMeta(m *MetaConfiguration, onError func(kind, name string, err error)) importItems {
+	items := newImportItems()
+	for kind, confs := range map[string]map[string]map[string]any{
+		kindSourceConfig:     m.SourceConfig,
+		kindSinkConfig:       m.SinkConfig,
+		kindConnectionConfig: m.ConnectionConfig,
+	} {
+		for key, props := range confs {
+			_, plugin, name, err := splitConfKey(key)
+			if err != nil {
+				onError(kind, key, err)
+				continue
+			}
+			items[kind][plugin+"."+name] = canonicalJson(replaceConfigurations(key, props))
+		}
+	}
+	for k, v := range m.Uploads {
+		items[kindUpload][k] = canonicalJson(v)
+	}
+	for k, v := range m.Service {
+		items[kindService][k] = canonicalJson(v)
+	}
+	for k, v := range m.Schema {
+		items[kindSchema][k] = canonicalJson(v)
+	}
+	for k, v := range m.NativePlugins {
+		items[kindNativePlugin][k] = canonicalJson(v)
+	}
+	for k, v := range m.PortablePlugins {
+		items[kindPortablePlugin][k] = canonicalJson(v)
+	}
+	for k, v := range m.Streams {
+		items[kindStream][k] = v.SQL
+	}
+	for k, v := range m.Tables {
+		items[kindTable][k] = v.SQL
+	}
+	for k, v := range m.Rules {
+		b, _ := json.Marshal(v)
+		items[kindRule][k] = string(b)
+	}
+	return items
+}
+
+func currentImportItems() (importItems, error) {
+	b, err := configurationExport()
+	if err != nil {
+		return nil, err
+	}
+	c := &Configuration{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return itemsFromConfiguration(c, func(string, string, error) {}), nil
+}
+
+func sameItem(kind, name, a, b string) bool {
+	switch kind {
+	case kindStream, kindTable:
+		return canonicalSql(a) == canonicalSql(b)
+	case kindRule:
+		return canonicalRule(name, a) == canonicalRule(name, b)
+	default:
+		return a == b
+	}
+}
+
+func (d *importDryRun) diff(current importItems) *ReconcilePlan {
+	plan := &ReconcilePlan{Changes: []ResourceChange{}}
+	for _, kind := range importKinds {
+		names := make([]string, 0, len(d.incoming[kind]))
+		for name := range d.incoming[kind] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v, ok := current[kind][name]
+			switch {
+			case !ok:
+				plan.Changes = append(plan.Changes, ResourceChange{Kind: kind, Name: name, Action: ActionCreate})
+			case d.mode == importPartial && installOnlyKinds[kind]:
+				// installed already, ignored
+			case !sameItem(kind, name, v, d.incoming[kind][name]):
+				plan.Changes = append(plan.Changes, ResourceChange{Kind: kind, Name: name, Action: ActionUpdate})
+			}
+		}
+		if d.mode == importReplace {
+			names = names[:0]
+			for name := range current[kind] {
+				if _, ok := d.incoming[kind][name]; !ok {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				plan.Changes = append(plan.Changes, ResourceChange{Kind: kind, Name: name, Action: ActionDelete})
+			}
+		}
+	}
+	return plan
+}
+
+// validate parses the streams and tables, and plans the sql of the rules with the imported streams and tables
+func (d *importDryRun) validate() {
+	var base kv.KeyValue
+	// the replace import drops all the existing streams and tables
+	if d.mode != importReplace {
+		db, err := store.GetKV("stream")
+		if err != nil {
+			d.addError(kindStream, "", err)
+			return
+		}
+		base = db
+	}
+	streams := &overlayKV{base: base, values: map[string]string{}}
+	for _, st := range []ast.StreamType{ast.TypeStream, ast.TypeTable} {
+		kind := ast.StreamTypeMap[st]
+		for name, sql := range d.incoming[kind] {
+			stmt, err := parseStreamStmt(name, sql, st)
+			if err != nil {
+				d.addError(kind, name, err)
+				continue
+			}
+			info, _ := json.Marshal(xsql.StreamInfo{StreamType: st, Statement: sql, StreamKind: stmt.Options.KIND})
+			streams.values[name] = string(info)
+		}
+	}
+	for id, rj := range d.incoming[kindRule] {
+		r, err := ruleProcessor.GetRuleByJson(id, rj)
+		if err != nil {
+			d.addError(kindRule, id, err)
+			continue
+		}
+		if r.Sql != "" {
+			if err := planner.ValidateSql(r, streams); err != nil {
+				d.addError(kindRule, id, err)
+			}
+		}
+	}
+	sort.Slice(d.errs, func(i, j int) bool {
+		if d.errs[i].Kind != d.errs[j].Kind {
+			return d.errs[i].Kind < d.errs[j].Kind
+		}
+		return d.errs[i].Name < d.errs[j].Name
+	})
+}
+
+func parseStreamStmt(name, sql string, st ast.StreamType) (*ast.StreamStmt, error) {
+	parsed, err := xsql.Language.Parse(xsql.NewParser(strings.NewReader(sql)))
+	if err != nil {
+		return nil, err
+	}
+	stmt, ok := parsed.(*ast.StreamStmt)
+	if !ok || stmt.StreamType != st {
+		return nil, fmt.Errorf("invalid %s statement: %s", ast.StreamTypeMap[st], sql)
+	}
+	if string(stmt.Name) != namespace.Name(name) {
+		return nil, fmt.Errorf("the name of the statement %s is not %s", stmt.Name, namespace.Name(name))
+	}
+	return stmt, nil
+}
+
+// affected finds the rules which would be restarted without change, and all the running rules which would be affected
+func (d *importDryRun) affected(plan *ReconcilePlan) []string {
+	running := runningRules()
+	changed := map[string]bool{}
+	affected := map[string]bool{}
+	for _, c := range plan.Changes {
+		if c.Kind == kindRule {
+			changed[c.Name] = true
+			if _, ok := running[c.Name]; ok {
+				affected[c.Name] = true
+			}
+		}
+	}
+	// the imported rules are recreated even if not changed
+	for id := range d.incoming[kindRule] {
+		if _, ok := running[id]; ok && !changed[id] {
+			plan.Restart = append(plan.Restart, id)
+			affected[id] = true
+		}
+	}
+	sort.Strings(plan.Restart)
+	if d.mode == importReplace {
+		// all the rules are stopped by the reset
+		for id := range running {
+			affected[id] = true
+		}
+	} else {
+		// the rules keep running with the old definitions of the changed streams and tables
+		for _, id := range affectedRules(plan.Changes) {
+			affected[id] = true
+		}
+	}
+	result := make([]string, 0, len(affected))
+	for id := range affected {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func runImportDryRun(mode importMode, incoming importItems, errs []ImportValidationError) (*ImportDryRunResult, error) {
+	d := &importDryRun{mode: mode, incoming: incoming, errs: errs}
+	d.validate()
+	current, err := currentImportItems()
+	if err != nil {
+		return nil, err
+	}
+	plan := d.diff(current)
+	affected := d.affected(plan)
+	if d.errs == nil {
+		d.errs = []ImportValidationError{}
+	}
+	return &ImportDryRunResult{
+		Valid:         len(d.errs) == 0,
+		ReconcilePlan: *plan,
+		AffectedRules: affected,
+		Errors:        d.errs,
+	}, nil
+}
+
+// configurationImportDryRun is the dry run of /data/import
+func configurationImportDryRun(content []byte, partial bool) (*ImportDryRunResult, error) {
+	c := &Configuration{}
+	if err := json.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("configuration unmarshal with error %v", err)
+	}
+	mode := importReplace
+	if partial {
+		mode = importPartial
+	}
+	d := &importDryRun{}
+	incoming := itemsFromConfiguration(c, d.addError)
+	return runImportDryRun(mode, incoming, d.errs)
+}
+
+// yamlImportDryRun is the dry run of /v2/data/import
+func yamlImportDryRun(content []byte) (*ImportDryRunResult, error) {
+	m := &MetaConfiguration{}
+	if err := yaml.Unmarshal(content, m); err != nil {
+		return nil, err
+	}
+	d := &importDryRun{}
+	incoming := itemsFromMeta(m, d.addError)
+	return runImportDryRun(importMerge, incoming, d.errs)
+}
+
+// overlayKV is the read only view of the stream store with the imported streams and tables on top
+type overlayKV struct {
+	// base is nil if the existing streams are dropped by the import
+	base   kv.KeyValue
+	values map[string]string
+}
+
+var errReadOnly = errors.New("the store is read only in dry run")
+
+func (o *overlayKV) Get(key string, val interface{}) (bool, error) {
+	if v, ok := o.values[key]; ok {
+		if p, ok := val.(*string); ok {
+			*p = v
+			return true, nil
+		}
+		return true, json.Unmarshal([]byte(v), val)
+	}
+	if o.base == nil {
+		return false, nil
+	}
+	return o.base.Get(key, val)
+}
+
+func (o *overlayKV) All() (map[string]string, error) {
+	result := map[string]string{}
+	if o.base != nil {
+		all, err := o.base.All()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range all {
+			result[k] = v
+		}
+	}
+	for k, v := range o.values {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (o *overlayKV) Keys() ([]string, error) {
+	all, err := o.All()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (o *overlayKV) Setnx(string, interface{}) error { return errReadOnly }
+
+func (o *overlayKV) Set(string, interface{}) error { return errReadOnly }
+
+func (o *overlayKV) GetKeyedState(string) (interface{}, error) { return nil, errReadOnly }
+
+func (o *overlayKV) SetKeyedState(string, interface{}) error { return errReadOnly }
+
+func (o *overlayKV) Delete(string) error { return errReadOnly }
+
+func (o *overlayKV) Clean() error { return errReadOnly }
+
+func (o *overlayKV) Drop() error { return errReadOnly }
@@ -0,0 +1,109 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/meta"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func (suite *RestTestSuite) TestImportDryRun() {
+	meta.InitYamlConfigManager()
+	defer func() {
+		_ = registry.DeleteRule("dryrule1")
+		_, _ = streamProcessor.DropStream("dryexist", ast.TypeStream)
+	}()
+	_, err := streamProcessor.ExecStreamSql(`CREATE STREAM dryexist() WITH (DATASOURCE="dryexist", TYPE="memory")`)
+	require.NoError(suite.T(), err)
+	_, err = registry.CreateRule("dryrule1", `{"id":"dryrule1","sql":"SELECT * FROM dryexist","actions":[{"log":{}}]}`)
+	require.NoError(suite.T(), err)
+	require.Eventually(suite.T(), func() bool {
+		st, _ := getRuleState("dryrule1")
+		return st == rule.Running
+	}, time.Second, 10*time.Millisecond)
+
+	c := &Configuration{
+		Streams: map[string]string{
+			"dryexist": `CREATE STREAM dryexist(a bigint) WITH (DATASOURCE="dryexist", TYPE="memory")`,
+			"drynew":   `CREATE STREAM drynew() WITH (DATASOURCE="drynew", TYPE="memory")`,
+		},
+		Rules: map[string]string{
+			"drynewrule": `{"id":"drynewrule","sql":"SELECT * FROM drynew","actions":[{"log":{}}]}`,
+			"dryinvalid": `{"id":"dryinvalid","sql":"SELECT * FROM drynone","actions":[{"log":{}}]}`,
+		},
+	}
+	b, _ := json.Marshal(c)
+	body, _ := json.Marshal(&configurationInfo{Content: string(b)})
+
+	// partial import
+	code, resp := suite.request(http.MethodPost, "/data/import?partial=1&dryRun=true", string(body))
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	result := &ImportDryRunResult{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(resp), result))
+	require.False(suite.T(), result.Valid)
+	require.Equal(suite.T(), []ResourceChange{
+		{Kind: kindStream, Name: "dryexist", Action: ActionUpdate},
+		{Kind: kindStream, Name: "drynew", Action: ActionCreate},
+		{Kind: kindRule, Name: "dryinvalid", Action: ActionCreate},
+		{Kind: kindRule, Name: "drynewrule", Action: ActionCreate},
+	}, result.Changes)
+	require.Equal(suite.T(), []string{"dryrule1"}, result.AffectedRules)
+	require.Len(suite.T(), result.Errors, 1)
+	require.Equal(suite.T(), kindRule, result.Errors[0].Kind)
+	require.Equal(suite.T(), "dryinvalid", result.Errors[0].Name)
+	// nothing is changed
+	_, err = streamProcessor.GetStream("drynew", ast.TypeStream)
+	require.Error(suite.T(), err)
+	s, err := streamProcessor.GetStream("dryexist", ast.TypeStream)
+	require.NoError(suite.T(), err)
+	require.NotContains(suite.T(), s, "bigint")
+
+	// the replace import deletes the resources which are not imported
+	code, resp = suite.request(http.MethodPost, "/data/import?dryRun=true", string(body))
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	result = &ImportDryRunResult{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(resp), result))
+	require.Contains(suite.T(), result.Changes, ResourceChange{Kind: kindRule, Name: "dryrule1", Action: ActionDelete})
+	require.Contains(suite.T(), result.AffectedRules, "dryrule1")
+
+	// the yaml import recreates the unchanged rules
+	suite.r.HandleFunc("/v2/data/import", yamlConfImportHandler).Methods(http.MethodPost)
+	body, _ = json.Marshal(&configurationInfo{Content: `
+streams:
+  dryexist:
+    sql: CREATE STREAM dryexist() WITH (DATASOURCE="dryexist", TYPE="memory")
+rules:
+  dryrule1:
+    triggered: true
+    sql: SELECT * FROM dryexist
+    actions:
+      - log: {}
+`})
+	code, resp = suite.request(http.MethodPost, "/v2/data/import?dryRun=true", string(body))
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	result = &ImportDryRunResult{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(resp), result))
+	require.True(suite.T(), result.Valid, resp)
+	require.Empty(suite.T(), result.Changes)
+	require.Equal(suite.T(), []string{"dryrule1"}, result.Restart)
+	require.Equal(suite.T(), []string{"dryrule1"}, result.AffectedRules)
+}
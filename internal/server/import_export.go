@@ -29,6 +29,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/meta"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/httpx"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/processor"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
@@ -407,6 +408,21 @@ func configurationImportHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("dryRun") == "true" {
+		content, err := readConfigurationContent(rsi)
+		if err != nil {
+			handleError(w, err, "", logger)
+			return
+		}
+		result, err := configurationImportDryRun(content, partial)
+		if err != nil {
+			handleError(w, err, "", logger)
+			return
+		}
+		jsonResponse(result, w, logger)
+		return
+	}
+
 	result, err := handleConfigurationImport(context.Background(), rsi, partial, stop)
 	if err != nil {
 		if result != nil && err.Error() == ProcessErr {
@@ -420,7 +436,8 @@ func configurationImportHandler(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(result, w, logger)
 }
 
-func handleConfigurationImport(ctx context.Context, rsi *configurationInfo, partial bool, stop bool) (*ImportConfigurationStatus, error) {
+// readConfigurationContent reads the content to import from the body or the file
+func readConfigurationContent(rsi *configurationInfo) ([]byte, error) {
 	if rsi.Content != "" && rsi.FilePath != "" {
 		return nil, errors.New("Invalid body: Cannot specify both content and file")
 	} else if rsi.Content == "" && rsi.FilePath == "" {
@@ -440,6 +457,14 @@ func handleConfigurationImport(ctx context.Context, rsi *configurationInfo, part
 		}
 		content = buf.Bytes()
 	}
+	return content, nil
+}
+
+func handleConfigurationImport(ctx context.Context, rsi *configurationInfo, partial bool, stop bool) (*ImportConfigurationStatus, error) {
+	content, err := readConfigurationContent(rsi)
+	if err != nil {
+		return nil, err
+	}
 	if !partial {
 		configurationReset()
 		result := configurationImport(ctx, content, stop)
@@ -579,7 +604,7 @@ func importRuleSetPartial(all processor.Ruleset) processor.Ruleset {
 	}
 	// replace streams
 	for k, v := range all.Streams {
-		_, e := streamProcessor.ExecReplaceStream(k, v, ast.TypeStream)
+		_, e := streamProcessor.InNamespace(namespace.Of(k)).ExecReplaceStream(namespace.Name(k), v, ast.TypeStream)
 		if e != nil {
 			ruleSetRsp.Streams[k] = e.Error()
 			continue
@@ -587,7 +612,7 @@ func importRuleSetPartial(all processor.Ruleset) processor.Ruleset {
 	}
 	// replace tables
 	for k, v := range all.Tables {
-		_, e := streamProcessor.ExecReplaceStream(k, v, ast.TypeTable)
+		_, e := streamProcessor.InNamespace(namespace.Of(k)).ExecReplaceStream(namespace.Name(k), v, ast.TypeTable)
 		if e != nil {
 			ruleSetRsp.Tables[k] = e.Error()
 			continue
@@ -0,0 +1,491 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lf-edge/ekuiper/v2/internal/meta"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/httpx"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/processor"
+	"github.com/lf-edge/ekuiper/v2/pkg/connection"
+	"github.com/lf-edge/ekuiper/v2/pkg/validate"
+)
+
+// namespaceOf returns the namespace of the request. The paths without the /ns/{ns} prefix are in the default namespace.
+func namespaceOf(r *http.Request) string {
+	if ns, ok := mux.Vars(r)["ns"]; ok && ns != "" {
+		return ns
+	}
+	return namespace.Default
+}
+
+// qualifiedVar returns the global unique id of the resource name in the path
+func qualifiedVar(r *http.Request, key string) string {
+	return namespace.Qualify(namespaceOf(r), mux.Vars(r)[key])
+}
+
+func streamProcessorOf(r *http.Request) *processor.StreamProcessor {
+	return streamProcessor.InNamespace(namespaceOf(r))
+}
+
+// ruleIDInNamespace returns the qualified id of the rule to create in the namespace.
+// The default namespace keeps the id in the rule json.
+func ruleIDInNamespace(ns string, body []byte) (string, error) {
+	if ns == namespace.Default {
+		return "", nil
+	}
+	r := &struct {
+		Id string `json:"id"`
+	}{}
+	if err := json.Unmarshal(body, r); err != nil {
+		return "", fmt.Errorf("Parse rule %s error : %s.", string(body), err)
+	}
+	if r.Id == "" {
+		return "", nil
+	}
+	return namespace.Qualify(ns, r.Id), nil
+}
+
+// filterRulesByNamespace keeps the rules of the namespace and shows their names without namespace
+func filterRulesByNamespace(rules []map[string]any, ns string) []map[string]any {
+	result := make([]map[string]any, 0, len(rules))
+	for _, r := range rules {
+		id, _ := r["id"].(string)
+		if namespace.Of(id) != ns {
+			continue
+		}
+		if ns != namespace.Default {
+			if r["name"] == id {
+				r["name"] = namespace.Name(id)
+			}
+			r["id"] = namespace.Name(id)
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// namespaceUsage is the resources used by a namespace which are limited by the quota
+type namespaceUsage struct {
+	Rules int `json:"rules"`
+	// BufferBytes is the bytes of the messages buffered by the running rules now
+	BufferBytes int64 `json:"bufferBytes"`
+}
+
+// getNamespaceUsage sums the rules of the namespace except the rule of the excluded id
+func getNamespaceUsage(ns string, excluded string) (*namespaceUsage, error) {
+	ids, err := ruleProcessor.GetAllRules()
+	if err != nil {
+		return nil, err
+	}
+	u := &namespaceUsage{}
+	for _, id := range ids {
+		if id == excluded || namespace.Of(id) != ns {
+			continue
+		}
+		u.Rules++
+	}
+	u.BufferBytes = namespace.BufferOf(ns).Used()
+	return u, nil
+}
+
+// checkQuota validates whether the namespace has room for the rule. The buffer quota is enforced when the rules run.
+// The caller must hold the lock of the namespace until the rule is saved.
+func checkQuota(r *def.Rule) error {
+	ns := namespace.Of(r.Id)
+	n, err := namespace.Get(ns)
+	if err != nil {
+		return err
+	}
+	if n.Quota.MaxRules <= 0 {
+		return nil
+	}
+	u, err := getNamespaceUsage(ns, r.Id)
+	if err != nil {
+		return err
+	}
+	if u.Rules+1 > n.Quota.MaxRules {
+		return fmt.Errorf("namespace %s exceeds the quota: the max count of rules is %d", ns, n.Quota.MaxRules)
+	}
+	return nil
+}
+
+// namespaceMiddleware rejects the requests to the namespaces which are not created
+func namespaceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := namespace.Get(namespaceOf(r)); err != nil {
+			handleError(w, err, "", logger)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// registerNamespaceRoutes registers the namespace management and the namespaced paths of the resources
+func registerNamespaceRoutes(r *mux.Router) {
+	r.HandleFunc("/namespaces", namespacesHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/namespaces/{ns}", namespaceHandler).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
+
+	s := r.PathPrefix("/ns/{ns}").Subrouter()
+	s.Use(namespaceMiddleware)
+	s.HandleFunc("/streams", streamsHandler).Methods(http.MethodGet, http.MethodPost)
+	s.HandleFunc("/streamdetails", streamDetailsHandler).Methods(http.MethodGet)
+	s.HandleFunc("/streams/{name}", streamHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
+	s.HandleFunc("/streams/{name}/schema", streamSchemaHandler).Methods(http.MethodGet)
+	s.HandleFunc("/tables", tablesHandler).Methods(http.MethodGet, http.MethodPost)
+	s.HandleFunc("/tabledetails", tableDetailsHandler).Methods(http.MethodGet)
+	s.HandleFunc("/tables/{name}", tableHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
+	s.HandleFunc("/tables/{name}/schema", tableSchemaHandler).Methods(http.MethodGet)
+	s.HandleFunc("/rules", rulesHandler).Methods(http.MethodGet, http.MethodPost)
+	s.HandleFunc("/rules/{name}", ruleHandler).Methods(http.MethodDelete, http.MethodGet, http.MethodPut)
+	s.HandleFunc("/rules/{name}/status", getStatusRuleHandler).Methods(http.MethodGet)
+	s.HandleFunc("/v2/rules/{name}/status", getStatusV2RulHandler).Methods(http.MethodGet)
+	s.HandleFunc("/rules/{name}/start", startRuleHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/stop", stopRuleHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/restart", restartRuleHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/topo", getTopoRuleHandler).Methods(http.MethodGet)
+	s.HandleFunc("/rules/{name}/trace/start", enableRuleTraceHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/trace/stop", disableRuleTraceHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/reset_state", ruleStateHandler).Methods(http.MethodPut)
+	s.HandleFunc("/rules/{name}/savepoint", savepointRuleHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/restore", restoreRuleHandler).Methods(http.MethodPost)
+	s.HandleFunc("/rules/{name}/explain", explainRuleHandler).Methods(http.MethodGet)
+	s.HandleFunc("/rules/{name}/schema", ruleSchemaHandler).Methods(http.MethodGet, http.MethodPost)
+	s.HandleFunc("/connections", connectionsHandler).Methods(http.MethodGet, http.MethodPost)
+	s.HandleFunc("/connections/{id}", connectionHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
+	s.HandleFunc("/config/uploads", fileUploadHandler).Methods(http.MethodPost, http.MethodGet)
+	s.HandleFunc("/config/uploads/{name}", fileDeleteHandler).Methods(http.MethodDelete)
+	s.HandleFunc("/data/export", nsConfigurationExportHandler).Methods(http.MethodGet)
+	s.HandleFunc("/data/import", nsConfigurationImportHandler).Methods(http.MethodPost)
+}
+
+type namespaceResponse struct {
+	*namespace.Namespace
+	Usage *namespaceUsage `json:"usage,omitempty"`
+}
+
+// list or create namespaces
+func namespacesHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	switch r.Method {
+	case http.MethodGet:
+		content, err := namespace.List()
+		if err != nil {
+			handleError(w, err, "List namespaces error", logger)
+			return
+		}
+		jsonResponse(content, w, logger)
+	case http.MethodPost:
+		n := &namespace.Namespace{}
+		if err := json.NewDecoder(r.Body).Decode(n); err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		if err := namespace.Create(n); err != nil {
+			handleError(w, err, "Create namespace error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "Namespace %s was created successfully.", n.Name)
+	}
+}
+
+// describe, update the quota or delete a namespace
+func namespaceHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	ns := mux.Vars(r)["ns"]
+	switch r.Method {
+	case http.MethodGet:
+		n, err := namespace.Get(ns)
+		if err != nil {
+			handleError(w, err, "Describe namespace error", logger)
+			return
+		}
+		u, err := getNamespaceUsage(ns, "")
+		if err != nil {
+			handleError(w, err, "Describe namespace error", logger)
+			return
+		}
+		jsonResponse(&namespaceResponse{Namespace: n, Usage: u}, w, logger)
+	case http.MethodPut:
+		q := &namespace.Quota{}
+		if err := json.NewDecoder(r.Body).Decode(q); err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		if err := namespace.Update(&namespace.Namespace{Name: ns, Quota: *q}); err != nil {
+			handleError(w, err, "Update namespace error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Namespace %s was updated successfully.", ns)
+	case http.MethodDelete:
+		if err := checkNamespaceEmpty(ns); err != nil {
+			handleError(w, err, "Delete namespace error", logger)
+			return
+		}
+		if err := namespace.Delete(ns); err != nil {
+			handleError(w, err, "Delete namespace error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Namespace %s is deleted.", ns)
+	}
+}
+
+// checkNamespaceEmpty makes sure no resource is left in the namespace before deleting it
+func checkNamespaceEmpty(ns string) error {
+	u, err := getNamespaceUsage(ns, "")
+	if err != nil {
+		return err
+	}
+	if u.Rules > 0 {
+		return fmt.Errorf("namespace %s still has %d rules", ns, u.Rules)
+	}
+	all, err := streamProcessor.InNamespace(ns).GetAll()
+	if err != nil {
+		return err
+	}
+	if c := len(all["streams"]) + len(all["tables"]); c > 0 {
+		return fmt.Errorf("namespace %s still has %d streams or tables", ns, c)
+	}
+	for _, m := range connection.GetAllConnectionsMeta(false) {
+		if namespace.Of(m.ID) == ns {
+			return fmt.Errorf("namespace %s still has connection %s", ns, namespace.Name(m.ID))
+		}
+	}
+	uploads, err := uploadsDb.Keys()
+	if err != nil {
+		return err
+	}
+	for _, k := range uploads {
+		if namespace.Of(k) == ns {
+			return fmt.Errorf("namespace %s still has upload %s", ns, namespace.Name(k))
+		}
+	}
+	return nil
+}
+
+// nsConfigurationExport exports the streams, tables, rules, connections and uploads of a namespace by their names.
+// The global resources like plugins and schemas are shared by all namespaces thus not exported.
+func nsConfigurationExport(ns string) ([]byte, error) {
+	c := &Configuration{
+		Streams:          make(map[string]string),
+		Tables:           make(map[string]string),
+		Rules:            make(map[string]string),
+		NativePlugins:    make(map[string]string),
+		PortablePlugins:  make(map[string]string),
+		SourceConfig:     make(map[string]string),
+		SinkConfig:       make(map[string]string),
+		ConnectionConfig: make(map[string]string),
+		Service:          make(map[string]string),
+		Schema:           make(map[string]string),
+		Uploads:          make(map[string]string),
+		Scripts:          make(map[string]string),
+	}
+	allStreams, err := streamProcessor.InNamespace(ns).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	c.Streams = allStreams["streams"]
+	c.Tables = allStreams["tables"]
+	rules, err := ruleProcessor.GetAllRulesJson()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range rules {
+		if namespace.Of(k) == ns {
+			c.Rules[namespace.Name(k)] = v
+		}
+	}
+	for plugin, v := range meta.GetConfigurations().Connections {
+		conns := map[string]any{}
+		if err := json.Unmarshal([]byte(v), &conns); err != nil {
+			return nil, err
+		}
+		result := map[string]any{}
+		for id, props := range conns {
+			if namespace.Of(id) == ns {
+				result[namespace.Name(id)] = props
+			}
+		}
+		if len(result) > 0 {
+			b, _ := json.Marshal(result)
+			c.ConnectionConfig[plugin] = string(b)
+		}
+	}
+	uploads, err := uploadsDb.All()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range uploads {
+		if namespace.Of(k) != ns {
+			continue
+		}
+		fc := &fileContent{}
+		if err := json.Unmarshal([]byte(v), fc); err != nil {
+			return nil, err
+		}
+		fc.Name = namespace.Name(fc.Name)
+		c.Uploads[namespace.Name(k)] = fc.InstallScript()
+	}
+	return json.Marshal(c)
+}
+
+// qualifyConfiguration converts the resource names of the configuration exported from a namespace to the global ids
+func qualifyConfiguration(ns string, c *Configuration) error {
+	qualify := func(m map[string]string) (map[string]string, error) {
+		result := make(map[string]string, len(m))
+		for k, v := range m {
+			if err := validate.ValidateID(k); err != nil {
+				return nil, err
+			}
+			result[namespace.Qualify(ns, k)] = v
+		}
+		return result, nil
+	}
+	var err error
+	if c.Streams, err = qualify(c.Streams); err != nil {
+		return err
+	}
+	if c.Tables, err = qualify(c.Tables); err != nil {
+		return err
+	}
+	if c.Rules, err = qualify(c.Rules); err != nil {
+		return err
+	}
+	uploads := make(map[string]string, len(c.Uploads))
+	for k, v := range c.Uploads {
+		fc := &fileContent{}
+		if err := json.Unmarshal([]byte(v), fc); err != nil {
+			return err
+		}
+		if err := validate.ValidatePath(fc.Name); err != nil {
+			return err
+		}
+		fc.Name = namespace.Qualify(ns, fc.Name)
+		uploads[namespace.Qualify(ns, k)] = fc.InstallScript()
+	}
+	c.Uploads = uploads
+	for plugin, v := range c.ConnectionConfig {
+		conns := map[string]any{}
+		if err := json.Unmarshal([]byte(v), &conns); err != nil {
+			return err
+		}
+		result := make(map[string]any, len(conns))
+		for id, props := range conns {
+			if err := namespace.ValidateName(id); err != nil {
+				return err
+			}
+			result[namespace.Qualify(ns, id)] = props
+		}
+		b, _ := json.Marshal(result)
+		c.ConnectionConfig[plugin] = string(b)
+	}
+	return nil
+}
+
+// nsConfigurationImport imports the configuration exported from a namespace into the namespace.
+// Like the partial import, the existing resources with the same names are replaced and others are kept.
+func nsConfigurationImport(ctx context.Context, ns string, data []byte) (*ImportConfigurationStatus, error) {
+	c := &Configuration{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("configuration unmarshal with error %v", err)
+	}
+	if len(c.NativePlugins) > 0 || len(c.PortablePlugins) > 0 || len(c.Service) > 0 || len(c.Schema) > 0 || len(c.Scripts) > 0 || len(c.SourceConfig) > 0 || len(c.SinkConfig) > 0 {
+		return nil, errors.New("only streams, tables, rules, connections and uploads can be imported into a namespace")
+	}
+	if err := qualifyConfiguration(ns, c); err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	result := configurationPartialImport(ctx, content)
+	if result.ErrorMsg != "" {
+		return &result, errors.New(result.ErrorMsg)
+	}
+	return &result, nil
+}
+
+func nsConfigurationExportHandler(w http.ResponseWriter, r *http.Request) {
+	const name = "ekuiper_export.json"
+	jsonBytes, err := nsConfigurationExport(namespaceOf(r))
+	if err != nil {
+		handleError(w, err, "Export error", logger)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Add("Content-Disposition", "Attachment")
+	http.ServeContent(w, r, name, time.Now(), bytes.NewReader(jsonBytes))
+}
+
+func nsConfigurationImportHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	rsi := &configurationInfo{}
+	if err := json.NewDecoder(r.Body).Decode(rsi); err != nil {
+		handleError(w, err, "Invalid body: Error decoding json", logger)
+		return
+	}
+	if err := validate.ValidatePath(rsi.FilePath); err != nil {
+		handleError(w, err, "", logger)
+		return
+	}
+	if rsi.Content != "" && rsi.FilePath != "" {
+		handleError(w, errors.New("Invalid body: Cannot specify both content and file"), "", logger)
+		return
+	} else if rsi.Content == "" && rsi.FilePath == "" {
+		handleError(w, errors.New("Invalid body: must specify content or file"), "", logger)
+		return
+	}
+	content := []byte(rsi.Content)
+	if rsi.FilePath != "" {
+		reader, err := httpx.ReadFile(rsi.FilePath)
+		if err != nil {
+			handleError(w, err, "", logger)
+			return
+		}
+		defer reader.Close()
+		content, err = io.ReadAll(reader)
+		if err != nil {
+			handleError(w, err, "", logger)
+			return
+		}
+	}
+	result, err := nsConfigurationImport(context.Background(), namespaceOf(r), content)
+	if err != nil {
+		if result != nil && err.Error() == ProcessErr {
+			errStr, _ := json.Marshal(result.ConfigResponse)
+			err = errors.New(string(errStr))
+		}
+		handleError(w, err, "", logger)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	jsonResponse(result, w, logger)
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/meta"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func (suite *RestTestSuite) request(method, path, body string) (int, string) {
+	req, _ := http.NewRequest(method, "http://localhost:8080"+path, bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	result, _ := io.ReadAll(w.Result().Body)
+	return w.Code, string(result)
+}
+
+func (suite *RestTestSuite) TestNamespace() {
+	meta.InitYamlConfigManager()
+	defer func() {
+		_ = registry.DeleteRule("nst1/r1")
+		_ = registry.DeleteRule("nst2/r1")
+		_, _ = streamProcessor.DropStream("nst1/nsdemo", ast.TypeStream)
+		_, _ = streamProcessor.DropStream("nst2/nsdemo", ast.TypeStream)
+		_ = namespace.Delete("nst1")
+		_ = namespace.Delete("nst2")
+	}()
+	code, body := suite.request(http.MethodPost, "/namespaces", `{"name":"nst1","quota":{"maxRules":1}}`)
+	require.Equal(suite.T(), http.StatusCreated, code, body)
+	code, _ = suite.request(http.MethodGet, "/ns/nsnone/streams", "")
+	require.Equal(suite.T(), http.StatusNotFound, code)
+
+	// streams are isolated
+	code, body = suite.request(http.MethodPost, "/ns/nst1/streams", `{"sql":"CREATE stream nsdemo() WITH (DATASOURCE=\"nsdemo\", TYPE=\"memory\")"}`)
+	require.Equal(suite.T(), http.StatusCreated, code, body)
+	_, body = suite.request(http.MethodGet, "/ns/nst1/streams", "")
+	require.Equal(suite.T(), `["nsdemo"]`, body)
+	// the names cannot contain the separator of the namespace
+	code, body = suite.request(http.MethodPost, "/ns/nst1/streams", "{\"sql\":\"CREATE stream `a/b`() WITH (DATASOURCE=\\\"nsdemo\\\", TYPE=\\\"memory\\\")\"}")
+	require.Equal(suite.T(), http.StatusBadRequest, code, body)
+	require.Contains(suite.T(), body, "should not contain /")
+	code, body = suite.request(http.MethodPost, "/ns/nst1/connections", `{"id":"a/b","typ":"mqtt","props":{}}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code, body)
+	code, body = suite.request(http.MethodPost, "/ns/nst1/config/uploads", `{"name":"../nst2/a.txt","content":"hello"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code, body)
+	code, body = suite.request(http.MethodPost, "/ns/nst1/config/uploads", `{"name":"nst2/a.txt","content":"hello"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code, body)
+	_, body = suite.request(http.MethodGet, "/streams", "")
+	require.NotContains(suite.T(), body, "nsdemo")
+	code, _ = suite.request(http.MethodGet, "/streams/nsdemo", "")
+	require.NotEqual(suite.T(), http.StatusOK, code)
+
+	// rules are isolated and limited by the quota
+	ruleJson := `{"id":"r1","triggered":false,"sql":"select * from nsdemo","actions":[{"log":{}}]}`
+	code, body = suite.request(http.MethodPost, "/rules", ruleJson)
+	require.Equal(suite.T(), http.StatusBadRequest, code, body)
+	code, body = suite.request(http.MethodPost, "/ns/nst1/rules", ruleJson)
+	require.Equal(suite.T(), http.StatusCreated, code, body)
+	_, body = suite.request(http.MethodGet, "/ns/nst1/rules", "")
+	var rules []map[string]any
+	require.NoError(suite.T(), json.Unmarshal([]byte(body), &rules))
+	require.Len(suite.T(), rules, 1)
+	require.Equal(suite.T(), "r1", rules[0]["id"])
+	_, body = suite.request(http.MethodGet, "/rules", "")
+	require.NotContains(suite.T(), body, "nst1/r1")
+	code, _ = suite.request(http.MethodGet, "/rules/r1", "")
+	require.NotEqual(suite.T(), http.StatusOK, code)
+	code, _ = suite.request(http.MethodGet, "/ns/nst1/rules/r1", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	code, body = suite.request(http.MethodPost, "/ns/nst1/rules", `{"id":"r2","triggered":false,"sql":"select * from nsdemo","actions":[{"log":{}}]}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+	require.Contains(suite.T(), body, "namespace nst1 exceeds the quota")
+	_, body = suite.request(http.MethodGet, "/namespaces/nst1", "")
+	require.Contains(suite.T(), body, `"usage":{"rules":1`)
+
+	// export and import to another namespace
+	code, exported := suite.request(http.MethodGet, "/ns/nst1/data/export", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	c := &Configuration{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(exported), c))
+	require.Contains(suite.T(), c.Streams, "nsdemo")
+	require.Contains(suite.T(), c.Rules, "r1")
+	code, _ = suite.request(http.MethodPost, "/namespaces", `{"name":"nst2"}`)
+	require.Equal(suite.T(), http.StatusCreated, code)
+	content, _ := json.Marshal(&configurationInfo{Content: exported})
+	code, body = suite.request(http.MethodPost, "/ns/nst2/data/import", string(content))
+	require.Equal(suite.T(), http.StatusOK, code, body)
+	code, _ = suite.request(http.MethodGet, "/ns/nst2/rules/r1", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	code, _ = suite.request(http.MethodGet, "/ns/nst2/streams/nsdemo", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+
+	// only the empty namespace can be deleted
+	code, body = suite.request(http.MethodDelete, "/namespaces/nst1", "")
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+	require.Contains(suite.T(), body, "still has 1 rules")
+	code, _ = suite.request(http.MethodDelete, "/ns/nst1/rules/r1", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	code, _ = suite.request(http.MethodDelete, "/ns/nst1/streams/nsdemo", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	code, body = suite.request(http.MethodDelete, "/namespaces/nst1", "")
+	require.Equal(suite.T(), http.StatusOK, code, body)
+}
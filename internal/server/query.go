@@ -0,0 +1,97 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+// AdhocQueryPrefix is the prefix of the ids of the ad-hoc queries. Each query has its own id.
+const AdhocQueryPrefix = "$$adhoc_query_"
+
+type queryRequest struct {
+	Sql string `json:"sql"`
+}
+
+// runTableQuery runs the bounded select statement against a table synchronously
+func runTableQuery(stmt *ast.SelectStatement) ([]map[string]any, error) {
+	db, err := store.GetKV("stream")
+	if err != nil {
+		return nil, err
+	}
+	q, err := planner.PlanQuery(AdhocQueryPrefix+uuid.New().String(), stmt, db)
+	if err != nil {
+		return nil, err
+	}
+	return q.Run(kctx.Background())
+}
+
+// execTableQuery runs the select of a single table for the query cli.
+// It returns false if the sql is not a select of a table.
+func execTableQuery(sql string) (string, bool, error) {
+	stmt, err := xsql.GetStatementFromSql(sql)
+	if err != nil || len(stmt.Sources) != 1 {
+		return "", false, nil
+	}
+	t, ok := stmt.Sources[0].(*ast.Table)
+	if !ok {
+		return "", false, nil
+	}
+	ss, err := streamProcessor.DescStream(t.Name, ast.TypeTable)
+	if err != nil || ss == nil {
+		return "", false, nil
+	}
+	rows, err := runTableQuery(stmt)
+	if err != nil {
+		return "", true, err
+	}
+	b, err := json.Marshal(rows)
+	if err != nil {
+		return "", true, err
+	}
+	return string(b), true, nil
+}
+
+// run an ad-hoc query against a table and return the rows
+func queryHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	req := &queryRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, fmt.Errorf("decode body error: %v", err), "Invalid body", logger)
+		return
+	}
+	stmt, err := xsql.GetStatementFromSql(strings.TrimSpace(req.Sql))
+	if err != nil {
+		handleError(w, err, "Invalid query", logger)
+		return
+	}
+	rows, err := runTableQuery(stmt)
+	if err != nil {
+		handleError(w, err, "Query error", logger)
+		return
+	}
+	jsonResponse(rows, w, logger)
+}
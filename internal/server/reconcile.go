@@ -0,0 +1,563 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/schema"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/context"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/connection"
+)
+
+const (
+	kindConnection = "connection"
+	kindSchema     = "schema"
+	kindStream     = "stream"
+	kindTable      = "table"
+	kindRule       = "rule"
+)
+
+// reconcileKinds is the order to create and update the resources. The deletion runs in the reverse order.
+var reconcileKinds = []string{kindConnection, kindSchema, kindStream, kindTable, kindRule}
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+type ResourceChange struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+// ReconcilePlan is the difference between the desired definitions and the current resources
+type ReconcilePlan struct {
+	Changes []ResourceChange `json:"changes"`
+	// Restart is the running rules which are not changed but use the changed resources.
+	// They are stopped before the change and started again after it.
+	Restart []string `json:"restart,omitempty"`
+}
+
+func (p *ReconcilePlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *ReconcilePlan) String() string {
+	if p.Empty() {
+		return "No changes. The resources are up to date."
+	}
+	b := &strings.Builder{}
+	counts := map[string]int{}
+	for _, c := range p.Changes {
+		var sign string
+		switch c.Action {
+		case ActionCreate:
+			sign = "+"
+		case ActionUpdate:
+			sign = "~"
+		case ActionDelete:
+			sign = "-"
+		}
+		fmt.Fprintf(b, "%s %s %s\n", sign, c.Kind, c.Name)
+		counts[c.Action]++
+	}
+	for _, id := range p.Restart {
+		fmt.Fprintf(b, "* restart rule %s\n", id)
+	}
+	fmt.Fprintf(b, "Plan: %d to create, %d to update, %d to delete.", counts[ActionCreate], counts[ActionUpdate], counts[ActionDelete])
+	return b.String()
+}
+
+// definitionFile is the format of the yaml files to reconcile. It is the subset of the yaml export.
+type definitionFile struct {
+	ConnectionConfig map[string]map[string]any    `yaml:"connectionConfig"`
+	Schema           map[string]*schema.Info      `yaml:"schema"`
+	Streams          map[string]*DatasourceExport `yaml:"streams"`
+	Tables           map[string]*DatasourceExport `yaml:"tables"`
+	Rules            map[string]map[string]any    `yaml:"rules"`
+}
+
+// definitions are the resources to reconcile. The key of each map is the id of the resource.
+type definitions struct {
+	connections map[string]*ConnectionRequest
+	schemas     map[string]*schema.Info
+	streams     map[string]string
+	tables      map[string]string
+	rules       map[string]string
+}
+
+func newDefinitions() *definitions {
+	return &definitions{
+		connections: map[string]*ConnectionRequest{},
+		schemas:     map[string]*schema.Info{},
+		streams:     map[string]string{},
+		tables:      map[string]string{},
+		rules:       map[string]string{},
+	}
+}
+
+// loadDefinitions reads all the yaml files in the path, or the file itself if the path is a file.
+// A resource can only be defined once among all the files.
+func loadDefinitions(path string) (*definitions, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if fi.IsDir() {
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// skip the hidden folders such as .git
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := filepath.Ext(p); ext == ".yaml" || ext == ".yml" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	} else {
+		files = []string{path}
+	}
+	defs := newDefinitions()
+	for _, f := range files {
+		if err := defs.loadFile(f); err != nil {
+			return nil, fmt.Errorf("load %s error: %v", f, err)
+		}
+	}
+	return defs, nil
+}
+
+func (d *definitions) loadFile(f string) error {
+	file, err := os.Open(f)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	// a file can have multiple yaml documents
+	for {
+		doc := &definitionFile{}
+		err := decoder.Decode(doc)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.add(doc); err != nil {
+			return err
+		}
+	}
+}
+
+func (d *definitions) add(doc *definitionFile) error {
+	for key, props := range doc.ConnectionConfig {
+		typ, plugin, id, err := splitConfKey(key)
+		if err != nil || typ != "connections" {
+			return fmt.Errorf("%s isn't valid connection key, it should be like connections.mqtt.conn1", key)
+		}
+		if err := checkDefined(d.connections, kindConnection, id); err != nil {
+			return err
+		}
+		d.connections[id] = &ConnectionRequest{ID: id, Typ: plugin, Props: replaceConfigurations(key, props)}
+	}
+	for _, info := range doc.Schema {
+		key := string(info.Type) + "_" + info.Name
+		if err := checkDefined(d.schemas, kindSchema, key); err != nil {
+			return err
+		}
+		d.schemas[key] = info
+	}
+	for name, v := range doc.Streams {
+		if err := checkDefined(d.streams, kindStream, name); err != nil {
+			return err
+		}
+		d.streams[name] = v.SQL
+	}
+	for name, v := range doc.Tables {
+		if err := checkDefined(d.tables, kindTable, name); err != nil {
+			return err
+		}
+		d.tables[name] = v.SQL
+	}
+	for id, v := range doc.Rules {
+		if err := checkDefined(d.rules, kindRule, id); err != nil {
+			return err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("invalid rule %s: %v", id, err)
+		}
+		d.rules[id] = string(b)
+	}
+	return nil
+}
+
+func checkDefined[T any](m map[string]T, kind, name string) error {
+	if _, ok := m[name]; ok {
+		return fmt.Errorf("%s %s is defined more than once", kind, name)
+	}
+	if ns := namespace.Of(name); ns != namespace.Default {
+		if _, err := namespace.Get(ns); err != nil {
+			return fmt.Errorf("the namespace of %s %s: %v", kind, name, err)
+		}
+	}
+	return nil
+}
+
+// currentDefinitions reads the resources in the system
+func currentDefinitions() (*definitions, error) {
+	defs := newDefinitions()
+	conns, err := conf.GetCfgFromKVStorage("connections", "", "")
+	if err != nil {
+		return nil, err
+	}
+	for key, props := range conns {
+		_, plugin, id, err := splitConfKey(key)
+		if err != nil {
+			continue
+		}
+		defs.connections[id] = &ConnectionRequest{ID: id, Typ: plugin, Props: props}
+	}
+	if sm, ok := managers["schema"]; ok {
+		for key, v := range sm.Export() {
+			info := &schema.Info{}
+			if err := json.Unmarshal([]byte(v), info); err != nil {
+				return nil, fmt.Errorf("invalid schema %s: %v", key, err)
+			}
+			defs.schemas[key] = info
+		}
+	}
+	rset := rulesetProcessor.ExportRuleSet()
+	if rset == nil {
+		return nil, errors.New("fail to read the streams, tables and rules")
+	}
+	defs.streams = rset.Streams
+	defs.tables = rset.Tables
+	defs.rules = rset.Rules
+	return defs, nil
+}
+
+// canonical returns the comparable form of each resource by kind and id
+func (d *definitions) canonical() map[string]map[string]string {
+	result := map[string]map[string]string{
+		kindConnection: {},
+		kindSchema:     {},
+		kindStream:     {},
+		kindTable:      {},
+		kindRule:       {},
+	}
+	for id, c := range d.connections {
+		result[kindConnection][id] = canonicalJson(c)
+	}
+	for key, info := range d.schemas {
+		result[kindSchema][key] = canonicalJson(info)
+	}
+	for name, sql := range d.streams {
+		result[kindStream][name] = canonicalSql(sql)
+	}
+	for name, sql := range d.tables {
+		result[kindTable][name] = canonicalSql(sql)
+	}
+	for id, rj := range d.rules {
+		result[kindRule][id] = canonicalRule(id, rj)
+	}
+	return result
+}
+
+// canonicalRule fills in the default values so that the omitted options equal to the default ones
+func canonicalRule(id, ruleJson string) string {
+	r, err := ruleProcessor.GetRuleByJsonValidated(id, ruleJson)
+	if err != nil {
+		return ruleJson
+	}
+	return canonicalJson(r)
+}
+
+// canonicalJson marshals the value twice so that the numbers decoded from yaml and json are the same
+func canonicalJson(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	var m any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return string(b)
+	}
+	b, _ = json.Marshal(m)
+	return string(b)
+}
+
+func canonicalSql(sql string) string {
+	return strings.TrimSuffix(strings.Join(strings.Fields(sql), " "), ";")
+}
+
+// planReconcile computes the changes to make the current resources the same as the desired ones.
+// The resources which are not desired are only deleted when prune is set.
+func planReconcile(desired, current *definitions, prune bool) *ReconcilePlan {
+	want := desired.canonical()
+	got := current.canonical()
+	plan := &ReconcilePlan{Changes: []ResourceChange{}}
+	for _, kind := range reconcileKinds {
+		names := make([]string, 0, len(want[kind]))
+		for name := range want[kind] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v, ok := got[kind][name]
+			if !ok {
+				plan.Changes = append(plan.Changes, ResourceChange{Kind: kind, Name: name, Action: ActionCreate})
+			} else if v != want[kind][name] {
+				plan.Changes = append(plan.Changes, ResourceChange{Kind: kind, Name: name, Action: ActionUpdate})
+			}
+		}
+		if prune {
+			names = names[:0]
+			for name := range got[kind] {
+				if _, ok := want[kind][name]; !ok {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				plan.Changes = append(plan.Changes, ResourceChange{Kind: kind, Name: name, Action: ActionDelete})
+			}
+		}
+	}
+	plan.Restart = affectedRules(plan.Changes)
+	return plan
+}
+
+// affectedRules finds the running rules which read the changed streams and tables or use the changed connections.
+// The rules which are changed themselves are excluded.
+func affectedRules(changes []ResourceChange) []string {
+	if registry == nil {
+		return nil
+	}
+	sources := map[string]struct{}{}
+	conns := map[string]struct{}{}
+	changedRules := map[string]struct{}{}
+	for _, c := range changes {
+		switch c.Kind {
+		case kindStream, kindTable:
+			if c.Action != ActionCreate {
+				sources[c.Name] = struct{}{}
+			}
+		case kindConnection:
+			if c.Action != ActionCreate {
+				conns[c.Name] = struct{}{}
+			}
+		case kindRule:
+			changedRules[c.Name] = struct{}{}
+		}
+	}
+	if len(sources) == 0 && len(conns) == 0 {
+		return nil
+	}
+	// the reference ids of a connection are prefixed with the rule id
+	var refs []string
+	for id := range conns {
+		if meta, err := connection.GetConnectionDetail(context.Background(), id); err == nil {
+			refs = append(refs, meta.GetRefNames()...)
+		}
+	}
+	var result []string
+	for id, rs := range runningRules() {
+		if _, ok := changedRules[id]; ok {
+			continue
+		}
+		if ruleUsesSources(id, rs.Rule, sources) || ruleUsesConnection(id, refs) {
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// runningRules returns the rules which are running or starting by id
+func runningRules() map[string]*rule.State {
+	result := map[string]*rule.State{}
+	if registry == nil {
+		return result
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	for id, rs := range registry.internal {
+		if s := rs.GetState(); s == rule.Running || s == rule.Starting {
+			result[id] = rs
+		}
+	}
+	return result
+}
+
+func ruleUsesSources(id string, r *def.Rule, sources map[string]struct{}) bool {
+	if len(sources) == 0 || r == nil || r.Sql == "" {
+		return false
+	}
+	stmt, err := xsql.GetStatementFromSql(r.Sql)
+	if err != nil {
+		return false
+	}
+	ns := namespace.Of(id)
+	for _, s := range xsql.GetStreams(stmt) {
+		if _, ok := sources[namespace.Qualify(ns, s)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleUsesConnection(id string, refs []string) bool {
+	for _, ref := range refs {
+		if strings.HasPrefix(ref, id+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPlan makes the changes of the plan in the dependency order. It continues with the other changes
+// if a change fails, and returns all the errors.
+func applyPlan(desired *definitions, plan *ReconcilePlan) error {
+	var stopped []*rule.State
+	for _, id := range plan.Restart {
+		if rs, ok := registry.load(id); ok {
+			rs.Stop()
+			stopped = append(stopped, rs)
+		}
+	}
+	var errs []error
+	for i := len(reconcileKinds) - 1; i >= 0; i-- {
+		for _, c := range plan.Changes {
+			if c.Kind == reconcileKinds[i] && c.Action == ActionDelete {
+				if err := deleteResource(c.Kind, c.Name); err != nil {
+					errs = append(errs, fmt.Errorf("delete %s %s error: %v", c.Kind, c.Name, err))
+				}
+			}
+		}
+	}
+	for _, kind := range reconcileKinds {
+		for _, c := range plan.Changes {
+			if c.Kind == kind && c.Action != ActionDelete {
+				if err := saveResource(desired, c); err != nil {
+					errs = append(errs, fmt.Errorf("%s %s %s error: %v", c.Action, c.Kind, c.Name, err))
+				}
+			}
+		}
+	}
+	for _, rs := range stopped {
+		if err := rs.Start(); err != nil {
+			errs = append(errs, fmt.Errorf("restart rule %s error: %v", rs.Rule.Id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func saveResource(desired *definitions, c ResourceChange) error {
+	var err error
+	switch c.Kind {
+	case kindConnection:
+		conn := desired.connections[c.Name]
+		if c.Action == ActionCreate {
+			_, err = connection.CreateNamedConnection(context.Background(), conn.ID, conn.Typ, conn.Props)
+		} else {
+			_, err = connection.UpdateConnection(context.Background(), conn.ID, conn.Typ, conn.Props)
+		}
+	case kindSchema:
+		info := desired.schemas[c.Name]
+		if c.Action == ActionCreate {
+			err = schema.Register(info)
+		} else {
+			err = schema.UpdateSchema(info)
+		}
+	case kindStream:
+		_, err = streamProcessor.InNamespace(namespace.Of(c.Name)).ExecReplaceStream(namespace.Name(c.Name), desired.streams[c.Name], ast.TypeStream)
+	case kindTable:
+		_, err = streamProcessor.InNamespace(namespace.Of(c.Name)).ExecReplaceStream(namespace.Name(c.Name), desired.tables[c.Name], ast.TypeTable)
+	case kindRule:
+		if c.Action == ActionCreate {
+			_, err = registry.CreateRule(c.Name, desired.rules[c.Name])
+		} else {
+			err = registry.UpdateRule(c.Name, desired.rules[c.Name])
+		}
+	}
+	return err
+}
+
+func deleteResource(kind, name string) error {
+	var err error
+	switch kind {
+	case kindConnection:
+		err = connection.DropNameConnection(context.Background(), name)
+	case kindSchema:
+		typ, n, _ := strings.Cut(name, "_")
+		err = schema.DeleteSchema(def.SchemaType(typ), n)
+	case kindStream:
+		_, err = streamProcessor.InNamespace(namespace.Of(name)).DropStream(namespace.Name(name), ast.TypeStream)
+	case kindTable:
+		_, err = streamProcessor.InNamespace(namespace.Of(name)).DropStream(namespace.Name(name), ast.TypeTable)
+	case kindRule:
+		err = registry.DeleteRule(name)
+	}
+	return err
+}
+
+// reconcile makes the resources the same as the definitions in the path. It returns the applied plan.
+func reconcile(path string, prune bool, dryRun bool) (*ReconcilePlan, error) {
+	desired, err := loadDefinitions(path)
+	if err != nil {
+		return nil, err
+	}
+	current, err := currentDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	plan := planReconcile(desired, current, prune)
+	if dryRun || plan.Empty() {
+		return plan, nil
+	}
+	return plan, applyPlan(desired, plan)
+}
@@ -27,6 +27,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/handlers"
@@ -38,11 +39,11 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/httpx"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/processor"
 	"github.com/lf-edge/ekuiper/v2/internal/server/middleware"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
-	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
 	"github.com/lf-edge/ekuiper/v2/internal/trial"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
@@ -181,6 +182,9 @@ func createRestServer(ip string, port int, needToken bool) *http.Server {
 	r.HandleFunc("/tabledetails", tableDetailsHandler).Methods(http.MethodGet)
 	r.HandleFunc("/tables/{name}", tableHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
 	r.HandleFunc("/tables/{name}/schema", tableSchemaHandler).Methods(http.MethodGet)
+	r.HandleFunc("/views", viewsHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/views/{name}", viewHandler).Methods(http.MethodGet, http.MethodDelete)
+	r.HandleFunc("/views/{name}/detail", viewDetailHandler).Methods(http.MethodGet)
 	r.HandleFunc("/rules", rulesHandler).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/rules/{name}", ruleHandler).Methods(http.MethodDelete, http.MethodGet, http.MethodPut)
 	r.HandleFunc("/rules/status/all", getAllRuleStatusHandler).Methods(http.MethodGet)
@@ -195,25 +199,31 @@ func createRestServer(ip string, port int, needToken bool) *http.Server {
 	r.HandleFunc("/rules/usage/cpu", rulesTopCpuUsageHandler).Methods(http.MethodGet)
 	r.HandleFunc("/rules/validate", validateRuleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/rules/{name}/reset_state", ruleStateHandler).Methods(http.MethodPut)
+	r.HandleFunc("/rules/{name}/savepoint", savepointRuleHandler).Methods(http.MethodPost)
+	r.HandleFunc("/rules/{name}/restore", restoreRuleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/rules/{name}/explain", explainRuleHandler).Methods(http.MethodGet)
+	r.HandleFunc("/rules/{name}/schema", ruleSchemaHandler).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/ruleset/export", exportHandler).Methods(http.MethodPost)
-	r.HandleFunc("/ruleset/import", importHandler).Methods(http.MethodPost)
+	r.HandleFunc("/ruleset/import", blockOnPendingRestore(importHandler)).Methods(http.MethodPost)
 	r.HandleFunc("/configs", configurationUpdateHandler).Methods(http.MethodPatch)
 	r.HandleFunc("/config/uploads", fileUploadHandler).Methods(http.MethodPost, http.MethodGet)
 	r.HandleFunc("/config/uploads/{name}", fileDeleteHandler).Methods(http.MethodDelete)
 	r.HandleFunc("/data/export", configurationExportHandler).Methods(http.MethodGet, http.MethodPost)
-	r.HandleFunc("/data/import", configurationImportHandler).Methods(http.MethodPost)
+	r.HandleFunc("/data/import", blockOnPendingRestore(configurationImportHandler)).Methods(http.MethodPost)
 	r.HandleFunc("/data/import/status", configurationStatusHandler).Methods(http.MethodGet)
 	r.HandleFunc("/connections", connectionsHandler).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/connections/{id}", connectionHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
+	r.HandleFunc("/secrets", secretsHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/secrets/{name}", secretHandler).Methods(http.MethodDelete, http.MethodPut)
+	r.HandleFunc("/query", queryHandler).Methods(http.MethodPost)
 	r.HandleFunc("/ruletest", testRuleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/ruletest/{name}/start", testRuleStartHandler).Methods(http.MethodPost)
 	r.HandleFunc("/ruletest/{name}", testRuleStopHandler).Methods(http.MethodDelete)
 	r.HandleFunc("/v2/data/export", yamlConfigurationExportHandler).Methods(http.MethodGet)
-	r.HandleFunc("/v2/data/import", yamlConfImportHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v2/data/import", blockOnPendingRestore(yamlConfImportHandler)).Methods(http.MethodPost)
 
 	// r.HandleFunc("/connection/websocket", connectionHandler).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
-	r.HandleFunc("/async/data/import", registerDataImportTask).Methods(http.MethodPost)
+	r.HandleFunc("/async/data/import", blockOnPendingRestore(registerDataImportTask)).Methods(http.MethodPost)
 	r.HandleFunc("/async/task/{id}", queryAsyncTaskStatus).Methods(http.MethodGet)
 	r.HandleFunc("/async/task/{id}/cancel", asyncTaskCancelHandler).Methods(http.MethodPost)
 	r.HandleFunc("/trace/{id}", getTraceByID).Methods(http.MethodGet)
@@ -222,6 +232,12 @@ func createRestServer(ip string, port int, needToken bool) *http.Server {
 
 	// dump metrics
 	r.HandleFunc("/metrics/dump", dumpMetricsHandler).Methods(http.MethodGet)
+	// back up and restore the whole instance
+	r.HandleFunc("/admin/backup", blockOnPendingRestore(backupHandler)).Methods(http.MethodPost)
+	r.HandleFunc("/admin/restore", restoreHandler).Methods(http.MethodPost)
+	r.HandleFunc("/admin/restore", restoreStatusHandler).Methods(http.MethodGet)
+	r.HandleFunc("/admin/restore", restoreDiscardHandler).Methods(http.MethodDelete)
+	registerNamespaceRoutes(r)
 	// Register extended routes
 	for k, v := range components {
 		logger.Infof("register rest endpoint for component %s", k)
@@ -280,6 +296,10 @@ func upload(file *fileContent) error {
 
 func getFile(file *fileContent) error {
 	filePath := filepath.Join(uploadDir, file.Name)
+	// the files of a namespace are in its sub folder
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
 	dst, err := os.Create(filePath)
 	if err != nil {
 		return err
@@ -302,33 +322,37 @@ func getFile(file *fileContent) error {
 
 func explainRuleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
-	// fetch the rule which will be explained
-	rule, err := ruleProcessor.GetRuleById(name)
-	if err != nil {
-		handleError(w, err, "explain rules error", logger)
-		return
-	}
-	if rule == nil {
-		handleError(w, errorx.NewWithCode(errorx.NOT_FOUND, "rule not found"), "", logger)
-		return
-	}
-	if rule.Sql == "" {
-		handleError(w, errors.New("only support explain sql now"), "explain rules error", logger)
+	// explain analyze annotates the running topo with the runtime statistics
+	if analyze, _ := strconv.ParseBool(r.URL.Query().Get("analyze")); analyze {
+		result, err := explainAnalyzeRule(name)
+		if err != nil {
+			handleError(w, err, "explain analyze rules error", logger)
+			return
+		}
+		jsonResponse(result, w, logger)
 		return
 	}
-	var explainInfo string
-	explainInfo, err = planner.GetExplainInfoFromLogicalPlan(rule)
+	explainInfo, err := explainRule(name)
 	if err != nil {
 		handleError(w, err, "explain rules error", logger)
 		return
 	}
-	// resp := planner.BuildExplainResultFromLp(lp, 0)
 	w.Write([]byte(explainInfo))
 }
 
+// validateFileName checks the name of the uploaded file which must be a plain file name without directories
+func validateFileName(name string) error {
+	if err := validate.ValidatePath(name); err != nil {
+		return err
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("file name %s should not contain path separators", name)
+	}
+	return nil
+}
+
 func fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	// Upload or overwrite a file
@@ -351,6 +375,11 @@ func fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 				handleError(w, err, "", logger)
 				return
 			}
+			if err := validateFileName(fc.Name); err != nil {
+				handleError(w, err, "", logger)
+				return
+			}
+			fc.Name = namespace.Qualify(namespaceOf(r), fc.Name)
 			filePath := filepath.Join(uploadDir, fc.Name)
 			err = upload(fc)
 			if err != nil {
@@ -376,9 +405,17 @@ func fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 			}
 
 			defer file.Close()
+			if err := validateFileName(handler.Filename); err != nil {
+				handleError(w, err, "", logger)
+				return
+			}
 
 			// Create file
-			filePath := filepath.Join(uploadDir, handler.Filename)
+			filePath := filepath.Join(uploadDir, namespace.Qualify(namespaceOf(r), handler.Filename))
+			if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+				handleError(w, err, "Error creating the file", logger)
+				return
+			}
 			dst, err := os.Create(filePath)
 			defer dst.Close()
 			if err != nil {
@@ -398,23 +435,27 @@ func fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 	case http.MethodGet:
-		// Get the list of files in the upload directory
-		files, err := os.ReadDir(uploadDir)
-		if err != nil {
+		// Get the list of files in the upload directory of the namespace
+		dir := filepath.Join(uploadDir, namespace.Qualify(namespaceOf(r), ""))
+		files, err := os.ReadDir(dir)
+		if err != nil && !(os.IsNotExist(err) && dir != uploadDir) {
 			handleError(w, err, "Error reading the file upload dir", logger)
 			return
 		}
-		fileNames := make([]string, len(files))
-		for i, f := range files {
-			fileNames[i] = filepath.Join(uploadDir, f.Name())
+		fileNames := make([]string, 0, len(files))
+		for _, f := range files {
+			// the sub folders are for the namespaces
+			if f.IsDir() {
+				continue
+			}
+			fileNames = append(fileNames, filepath.Join(dir, f.Name()))
 		}
 		jsonResponse(fileNames, w, logger)
 	}
 }
 
 func fileDeleteHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 	filePath := filepath.Join(uploadDir, name)
 	if err := validate.ValidatePath(filePath); err != nil {
 		handleError(w, err, "", logger)
@@ -493,7 +534,7 @@ func sourceDetailsManageHandler(w http.ResponseWriter, r *http.Request, st ast.S
 			kind = ""
 		}
 	}
-	content, err = streamProcessor.ShowStreamOrTableDetails(kind, st)
+	content, err = streamProcessorOf(r).ShowStreamOrTableDetails(kind, st)
 	if err != nil {
 		handleError(w, err, fmt.Sprintf("%s command error", cases.Title(language.Und).String(ast.StreamTypeMap[st])), logger)
 		return
@@ -521,9 +562,9 @@ func sourcesManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamT
 			}
 		}
 		if kind != "" {
-			content, err = streamProcessor.ShowTable(kind)
+			content, err = streamProcessorOf(r).ShowTable(kind)
 		} else {
-			content, err = streamProcessor.ShowStream(st)
+			content, err = streamProcessorOf(r).ShowStream(st)
 		}
 		if err != nil {
 			handleError(w, err, fmt.Sprintf("%s command error", cases.Title(language.Und).String(ast.StreamTypeMap[st])), logger)
@@ -536,7 +577,7 @@ func sourcesManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamT
 			handleError(w, err, "Invalid body", logger)
 			return
 		}
-		content, err := streamProcessor.ExecStreamSql(v.Sql)
+		content, err := streamProcessorOf(r).ExecStreamSql(v.Sql)
 		if err != nil {
 			handleError(w, err, fmt.Sprintf("%s command error", cases.Title(language.Und).String(ast.StreamTypeMap[st])), logger)
 			return
@@ -547,12 +588,16 @@ func sourcesManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamT
 	}
 }
 
-func checkStreamBeforeDrop(name string) (bool, error) {
+func checkStreamBeforeDrop(ns, name string) (bool, error) {
 	rules, err := ruleProcessor.GetAllRules()
 	if err != nil {
 		return false, err
 	}
 	for _, r := range rules {
+		// the rules only refer to the streams in the same namespace
+		if namespace.Of(r) != ns {
+			continue
+		}
 		rs, ok := registry.load(r)
 		if !ok {
 			continue
@@ -574,7 +619,7 @@ func sourceManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamTy
 
 	switch r.Method {
 	case http.MethodGet:
-		content, err := streamProcessor.DescStream(name, st)
+		content, err := streamProcessorOf(r).DescStream(name, st)
 		if err != nil {
 			handleError(w, err, fmt.Sprintf("describe %s error", ast.StreamTypeMap[st]), logger)
 			return
@@ -584,7 +629,7 @@ func sourceManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamTy
 		forceRaw := r.URL.Query().Get("force")
 		force, err := strconv.ParseBool(forceRaw)
 		if err != nil || !force {
-			referenced, err := checkStreamBeforeDrop(name)
+			referenced, err := checkStreamBeforeDrop(namespaceOf(r), name)
 			if err != nil {
 				handleError(w, err, fmt.Sprintf("delete %s error", ast.StreamTypeMap[st]), logger)
 				return
@@ -594,7 +639,7 @@ func sourceManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamTy
 				return
 			}
 		}
-		content, err := streamProcessor.DropStream(name, st)
+		content, err := streamProcessorOf(r).DropStream(name, st)
 		if err != nil {
 			handleError(w, err, fmt.Sprintf("delete %s error", ast.StreamTypeMap[st]), logger)
 			return
@@ -607,7 +652,7 @@ func sourceManageHandler(w http.ResponseWriter, r *http.Request, st ast.StreamTy
 			handleError(w, err, "Invalid body", logger)
 			return
 		}
-		content, err := streamProcessor.ExecReplaceStream(name, v.Sql, st)
+		content, err := streamProcessorOf(r).ExecReplaceStream(name, v.Sql, st)
 		if err != nil {
 			handleError(w, err, fmt.Sprintf("%s command error", cases.Title(language.Und).String(ast.StreamTypeMap[st])), logger)
 			return
@@ -657,7 +702,7 @@ func tableSchemaHandler(w http.ResponseWriter, r *http.Request) {
 func sourceSchemaHandler(w http.ResponseWriter, r *http.Request, st ast.StreamType) {
 	vars := mux.Vars(r)
 	name := vars["name"]
-	content, err := streamProcessor.GetInferredJsonSchema(name, st)
+	content, err := streamProcessorOf(r).GetInferredJsonSchema(name, st)
 	if err != nil {
 		handleError(w, err, fmt.Sprintf("get schema of %s error", ast.StreamTypeMap[st]), logger)
 		return
@@ -675,7 +720,12 @@ func rulesHandler(w http.ResponseWriter, r *http.Request) {
 			handleError(w, err, "Invalid body", logger)
 			return
 		}
-		id, err := registry.CreateRule("", string(body))
+		name, err := ruleIDInNamespace(namespaceOf(r), body)
+		if err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		id, err := registry.CreateRule(name, string(body))
 		if err != nil {
 			handleError(w, err, "", logger)
 			return
@@ -688,15 +738,14 @@ func rulesHandler(w http.ResponseWriter, r *http.Request) {
 			handleError(w, err, "Show rules error", logger)
 			return
 		}
-		jsonResponse(content, w, logger)
+		jsonResponse(filterRulesByNamespace(content, namespaceOf(r)), w, logger)
 	}
 }
 
 // describe or delete a rule
 func ruleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	switch r.Method {
 	case http.MethodGet:
@@ -708,6 +757,10 @@ func ruleHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add(ContentType, ContentTypeJSON)
 		w.Write([]byte(rule))
 	case http.MethodDelete:
+		if err := checkViewRule(name); err != nil {
+			handleError(w, err, "Delete rule error", logger)
+			return
+		}
 		// delete rule will wait until rule close
 		err := registry.DeleteRule(name)
 		if err != nil {
@@ -729,12 +782,29 @@ func ruleHandler(w http.ResponseWriter, r *http.Request) {
 			handleError(w, err, "Invalid body", logger)
 			return
 		}
-		err = registry.UpdateRule(name, string(body))
+		force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+		dropped, err := registry.updateRule(name, string(body), force)
 		if err != nil {
+			var se *stateIncompatibleError
+			if errors.As(err, &se) {
+				logger.Error(err)
+				w.Header().Set(ContentType, ContentTypeJSON)
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(se)
+				return
+			}
 			handleError(w, err, "Update rule error", logger)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
+		if len(dropped) > 0 {
+			ops := make([]string, 0, len(dropped))
+			for _, op := range dropped {
+				ops = append(ops, op.OpId)
+			}
+			_, _ = fmt.Fprintf(w, "Rule %s was updated successfully. The state of the incompatible operators %s is dropped.", name, strings.Join(ops, ","))
+			return
+		}
 		_, _ = fmt.Fprintf(w, "Rule %s was updated successfully.", name)
 	}
 }
@@ -754,8 +824,7 @@ func getAllRuleStatusHandler(w http.ResponseWriter, r *http.Request) {
 // get status of a rule
 func getStatusV2RulHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	content, err := registry.GetRuleStatusV2(name)
 	if err != nil {
@@ -769,8 +838,7 @@ func getStatusV2RulHandler(w http.ResponseWriter, r *http.Request) {
 // get status of a rule
 func getStatusRuleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	content, err := registry.GetRuleStatus(name)
 	if err != nil {
@@ -785,8 +853,7 @@ func getStatusRuleHandler(w http.ResponseWriter, r *http.Request) {
 // start a rule
 func startRuleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	err := registry.StartRule(name)
 	if err != nil {
@@ -800,8 +867,7 @@ func startRuleHandler(w http.ResponseWriter, r *http.Request) {
 // stop a rule
 func stopRuleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	err := registry.StopRule(name)
 	if err != nil {
@@ -815,8 +881,7 @@ func stopRuleHandler(w http.ResponseWriter, r *http.Request) {
 // restart a rule
 func restartRuleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	err := registry.RestartRule(name)
 	if err != nil {
@@ -833,8 +898,7 @@ type EnableRuleTraceRequest struct {
 
 func enableRuleTraceHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 	req := &EnableRuleTraceRequest{}
 	err := json.NewDecoder(r.Body).Decode(req)
 	if err != nil {
@@ -851,8 +915,7 @@ func enableRuleTraceHandler(w http.ResponseWriter, r *http.Request) {
 
 func disableRuleTraceHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	err := setIsRuleTraceEnabledHandler(name, false, kctx.AlwaysTraceStrategy)
 	if err != nil {
@@ -873,8 +936,7 @@ func setIsRuleTraceEnabledHandler(name string, isEnabled bool, stra kctx.TraceSt
 // get topo of a rule
 func getTopoRuleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	vars := mux.Vars(r)
-	name := vars["name"]
+	name := qualifiedVar(r, "name")
 
 	content, err := registry.GetRuleTopo(name)
 	if err != nil {
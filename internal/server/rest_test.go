@@ -21,6 +21,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -34,11 +35,15 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/io/http/httpserver"
+	"github.com/lf-edge/ekuiper/v2/internal/io/memory/pubsub"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/processor"
 	"github.com/lf-edge/ekuiper/v2/internal/testx"
+	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/connection"
 	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
@@ -47,6 +52,7 @@ import (
 func init() {
 	testx.InitEnv("server")
 	streamProcessor = processor.NewStreamProcessor()
+	viewProcessor = processor.NewViewProcessor()
 	ruleProcessor = processor.NewRuleProcessor()
 	rulesetProcessor = processor.NewRulesetProcessor(ruleProcessor, streamProcessor)
 	registry = &RuleRegistry{internal: make(map[string]*rule.State)}
@@ -78,6 +84,9 @@ func (suite *RestTestSuite) SetupTest() {
 	r.HandleFunc("/tabledetails", tableDetailsHandler).Methods(http.MethodGet)
 	r.HandleFunc("/tables/{name}", tableHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
 	r.HandleFunc("/tables/{name}/schema", tableSchemaHandler).Methods(http.MethodGet)
+	r.HandleFunc("/views", viewsHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/views/{name}", viewHandler).Methods(http.MethodGet, http.MethodDelete)
+	r.HandleFunc("/views/{name}/detail", viewDetailHandler).Methods(http.MethodGet)
 	r.HandleFunc("/rules", rulesHandler).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/rules/{name}", ruleHandler).Methods(http.MethodDelete, http.MethodGet, http.MethodPut)
 	r.HandleFunc("/rules/{name}/status", getStatusRuleHandler).Methods(http.MethodGet)
@@ -87,26 +96,37 @@ func (suite *RestTestSuite) SetupTest() {
 	r.HandleFunc("/rules/{name}/restart", restartRuleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/rules/{name}/topo", getTopoRuleHandler).Methods(http.MethodGet)
 	r.HandleFunc("/rules/{name}/reset_state", ruleStateHandler).Methods(http.MethodPut)
+	r.HandleFunc("/rules/{name}/savepoint", savepointRuleHandler).Methods(http.MethodPost)
+	r.HandleFunc("/rules/{name}/restore", restoreRuleHandler).Methods(http.MethodPost)
+	r.HandleFunc("/admin/backup", blockOnPendingRestore(backupHandler)).Methods(http.MethodPost)
+	r.HandleFunc("/admin/restore", restoreHandler).Methods(http.MethodPost)
+	r.HandleFunc("/admin/restore", restoreStatusHandler).Methods(http.MethodGet)
+	r.HandleFunc("/admin/restore", restoreDiscardHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/secrets", secretsHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/secrets/{name}", secretHandler).Methods(http.MethodDelete, http.MethodPut)
 	r.HandleFunc("/rules/{name}/explain", explainRuleHandler).Methods(http.MethodGet)
+	r.HandleFunc("/rules/{name}/schema", ruleSchemaHandler).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/rules/{name}/trace/start", enableRuleTraceHandler).Methods(http.MethodPost)
 	r.HandleFunc("/rules/{name}/trace/stop", disableRuleTraceHandler).Methods(http.MethodPost)
 	r.HandleFunc("/rules/validate", validateRuleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/rules/status/all", getAllRuleStatusHandler).Methods(http.MethodGet)
 	r.HandleFunc("/ruleset/export", exportHandler).Methods(http.MethodPost)
-	r.HandleFunc("/ruleset/import", importHandler).Methods(http.MethodPost)
+	r.HandleFunc("/ruleset/import", blockOnPendingRestore(importHandler)).Methods(http.MethodPost)
 	r.HandleFunc("/configs", configurationUpdateHandler).Methods(http.MethodPatch)
 	r.HandleFunc("/config/uploads", fileUploadHandler).Methods(http.MethodPost, http.MethodGet)
 	r.HandleFunc("/config/uploads/{name}", fileDeleteHandler).Methods(http.MethodDelete)
 	r.HandleFunc("/data/export", configurationExportHandler).Methods(http.MethodGet, http.MethodPost)
-	r.HandleFunc("/data/import", configurationImportHandler).Methods(http.MethodPost)
+	r.HandleFunc("/data/import", blockOnPendingRestore(configurationImportHandler)).Methods(http.MethodPost)
 	r.HandleFunc("/data/import/status", configurationStatusHandler).Methods(http.MethodGet)
 	r.HandleFunc("/connections", connectionsHandler).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/connections/{id}", connectionHandler).Methods(http.MethodGet, http.MethodDelete, http.MethodPut)
+	r.HandleFunc("/query", queryHandler).Methods(http.MethodPost)
 	r.HandleFunc("/ruletest", testRuleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/ruletest/{name}/start", testRuleStartHandler).Methods(http.MethodPost)
 	r.HandleFunc("/ruletest/{name}", testRuleStopHandler).Methods(http.MethodDelete)
 	// r.HandleFunc("/connection/websocket", connectionHandler).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
 	r.HandleFunc("/metadata/sinks/{name}/confKeys/{confKey}", sinkConfKeyHandler).Methods(http.MethodDelete, http.MethodPut)
+	registerNamespaceRoutes(r)
 	suite.r = r
 }
 
@@ -215,6 +235,204 @@ func (suite *RestTestSuite) Test_sourcesManageHandler() {
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 }
 
+func (suite *RestTestSuite) Test_viewsHandler() {
+	_, _ = streamProcessor.DropStream("viewDemo", ast.TypeStream)
+	buf := bytes.NewBuffer([]byte(`{"sql":"CREATE stream viewDemo() WITH (DATASOURCE=\"viewDemo\", TYPE=\"memory\")"}`))
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/streams", buf)
+	w := httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	// create view
+	buf = bytes.NewBuffer([]byte(`{"sql":"CREATE VIEW latest_by_device AS SELECT deviceId, last_value(temp, true) AS temp FROM viewDemo GROUP BY deviceId, TUMBLINGWINDOW(ss, 10)"}`))
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/views", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	returnVal, _ := io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, string(returnVal))
+	require.Equal(suite.T(), "View latest_by_device is created.", string(returnVal))
+	_, ok := registry.load("view_latest_by_device")
+	require.True(suite.T(), ok)
+
+	// the rule ids of the existing views are reserved
+	buf = bytes.NewBuffer([]byte(`{"id":"view_latest_by_device","sql":"SELECT * FROM viewDemo","actions":[{"log":{}}]}`))
+	req, _ = http.NewRequest(http.MethodPut, "http://localhost:8080/rules/view_latest_by_device", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Contains(suite.T(), string(returnVal), "rule id view_latest_by_device is reserved for view latest_by_device")
+	// the user rules with the prefix but without a view are managed as usual
+	buf = bytes.NewBuffer([]byte(`{"id":"view_my","triggered":false,"sql":"SELECT * FROM viewDemo","actions":[{"log":{}}]}`))
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/rules", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, string(returnVal))
+	require.Contains(suite.T(), rulesetProcessor.ExportRuleSet().Rules, "view_my")
+	buf = bytes.NewBuffer([]byte(`{"id":"view_my","triggered":false,"sql":"SELECT deviceId FROM viewDemo","actions":[{"log":{}}]}`))
+	req, _ = http.NewRequest(http.MethodPut, "http://localhost:8080/rules/view_my", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), http.StatusOK, w.Code, string(returnVal))
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost:8080/rules/view_my", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	_, ok = registry.load("view_my")
+	require.False(suite.T(), ok)
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost:8080/rules/view_latest_by_device", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	_, ok = registry.load("view_latest_by_device")
+	require.True(suite.T(), ok)
+	require.NotContains(suite.T(), rulesetProcessor.ExportRuleSet().Rules, "view_latest_by_device")
+
+	// list views
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/views", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), `["latest_by_device"]`, string(returnVal))
+
+	// describe view
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/views/latest_by_device/detail", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Contains(suite.T(), string(returnVal), `"keys":["deviceId"]`)
+
+	// query view
+	vs, err := state.GetViewStore("latest_by_device")
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), vs.Upsert(`["d1"]`, map[string]any{"deviceId": "d1", "temp": 20.5}))
+	require.NoError(suite.T(), vs.Upsert(`["d2"]`, map[string]any{"deviceId": "d2", "temp": 25.0}))
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/views/latest_by_device?where="+url.QueryEscape(`temp > 21`), bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), `[{"deviceId":"d2","temp":25}]`, string(returnVal))
+
+	// query view by sql
+	r, ok, err := execViewQuery(`SELECT deviceId FROM latest_by_device WHERE deviceId = "d1"`)
+	require.NoError(suite.T(), err)
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), `[{"deviceId":"d1"}]`, r)
+	_, ok, _ = execViewQuery(`SELECT deviceId FROM viewDemo`)
+	require.False(suite.T(), ok)
+
+	// drop view
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost:8080/views/latest_by_device", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	_, ok = registry.load("view_latest_by_device")
+	require.False(suite.T(), ok)
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/views/latest_by_device", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost:8080/streams/viewDemo", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *RestTestSuite) Test_queryHandler() {
+	_, _ = streamProcessor.DropStream("queryDemo", ast.TypeTable)
+	buf := bytes.NewBuffer([]byte(`{"sql":"CREATE TABLE queryDemo() WITH (DATASOURCE=\"queryDemo\", TYPE=\"memory\", KIND=\"lookup\", KEY=\"id\")"}`))
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/tables", buf)
+	w := httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	defer func() {
+		_, _ = streamProcessor.DropStream("queryDemo", ast.TypeTable)
+	}()
+	// wait for the table to subscribe
+	time.Sleep(100 * time.Millisecond)
+	ctx := kctx.Background()
+	pubsub.Produce(ctx, "queryDemo", &xsql.Tuple{Message: map[string]any{"id": 1, "name": "a", "value": 10}})
+	pubsub.Produce(ctx, "queryDemo", &xsql.Tuple{Message: map[string]any{"id": 2, "name": "b", "value": 20}})
+	time.Sleep(100 * time.Millisecond)
+
+	buf = bytes.NewBuffer([]byte(`{"sql":"SELECT name FROM queryDemo WHERE value > 15"}`))
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/query", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	returnVal, _ := io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), http.StatusOK, w.Code, string(returnVal))
+	require.Equal(suite.T(), `[{"name":"b"}]`, string(returnVal))
+
+	// query cli
+	r, ok, err := execTableQuery("SELECT count(*) AS c FROM queryDemo")
+	require.True(suite.T(), ok)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), `[{"c":2}]`, r)
+
+	buf = bytes.NewBuffer([]byte(`{"sql":"SELECT * FROM queryNone"}`))
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/query", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *RestTestSuite) Test_ruleSchemaHandler() {
+	_, _ = streamProcessor.DropStream("schemaDemo", ast.TypeStream)
+	_, _ = streamProcessor.DropStream("schemaOut", ast.TypeStream)
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost:8080/rules/schemaRule", bytes.NewBufferString("any"))
+	w := httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	defer func() {
+		req, _ := http.NewRequest(http.MethodDelete, "http://localhost:8080/rules/schemaRule", bytes.NewBufferString("any"))
+		suite.r.ServeHTTP(httptest.NewRecorder(), req)
+		_, _ = streamProcessor.DropStream("schemaDemo", ast.TypeStream)
+		_, _ = streamProcessor.DropStream("schemaOut", ast.TypeStream)
+	}()
+
+	buf := bytes.NewBuffer([]byte(`{"sql":"CREATE stream schemaDemo(deviceId STRING, temp FLOAT) WITH (DATASOURCE=\"schemaDemo\", TYPE=\"memory\")"}`))
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/streams", buf)
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	ruleJson := `{"id": "schemaRule","triggered": false,"sql": "SELECT deviceId, avg(temp) AS t, count(*) AS c FROM schemaDemo GROUP BY deviceId, TUMBLINGWINDOW(ss, 10)","actions": [{"memory": {"topic": "schema/out"}}]}`
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/rules", bytes.NewBufferString(ruleJson))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/schemaRule/schema", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	returnVal, _ := io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), http.StatusOK, w.Code, string(returnVal))
+	require.JSONEq(suite.T(), `[{"name":"deviceId","type":"string","nullable":true},{"name":"t","type":"float","nullable":true},{"name":"c","type":"bigint","nullable":false}]`, string(returnVal))
+
+	// register the output as a stream
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/rules/schemaRule/schema", bytes.NewBufferString(`{"stream":"schemaOut"}`))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	returnVal, _ = io.ReadAll(w.Result().Body)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, string(returnVal))
+	stmt, err := streamProcessor.DescStream("schemaOut", ast.TypeStream)
+	require.NoError(suite.T(), err)
+	ss := stmt.(*ast.StreamStmt)
+	require.Equal(suite.T(), "schema/out", ss.Options.DATASOURCE)
+	require.Equal(suite.T(), "memory", ss.Options.TYPE)
+	require.Len(suite.T(), ss.StreamFields, 3)
+	require.Equal(suite.T(), "c", ss.StreamFields[2].Name)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/schemaNone/schema", bytes.NewBufferString("any"))
+	w = httptest.NewRecorder()
+	suite.r.ServeHTTP(w, req)
+	require.NotEqual(suite.T(), http.StatusOK, w.Code)
+}
+
 func (suite *RestTestSuite) TestRecoverRule() {
 	// drop stream
 	req, _ := http.NewRequest(http.MethodDelete, "http://localhost:8080/streams/recoverTest", bytes.NewBufferString("any"))
@@ -430,6 +648,11 @@ func (suite *RestTestSuite) Test_rulesManageHandler() {
 	expect = "{\"error\":1002,\"message\":\"explain rules error: Rule rule32211 is not found.\"}\n"
 	assert.Equal(suite.T(), expect, returnStr)
 
+	req1, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/rule32211/explain?analyze=true", bytes.NewBufferString("any"))
+	w1 = httptest.NewRecorder()
+	suite.r.ServeHTTP(w1, req1)
+	assert.Equal(suite.T(), http.StatusNotFound, w1.Code)
+
 	// get rule topo
 	req1, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/rule321/topo", bytes.NewBufferString("any"))
 	w1 = httptest.NewRecorder()
@@ -446,6 +669,20 @@ func (suite *RestTestSuite) Test_rulesManageHandler() {
 	expect = `Rule rule321 was started`
 	assert.Equal(suite.T(), expect, string(returnVal))
 
+	// explain analyze the running rule
+	req1, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/rule321/explain?analyze=true", bytes.NewBufferString("any"))
+	w1 = httptest.NewRecorder()
+	suite.r.ServeHTTP(w1, req1)
+	require.Equal(suite.T(), http.StatusOK, w1.Code)
+	analyzed := &explainAnalyzeResult{}
+	require.NoError(suite.T(), json.NewDecoder(w1.Result().Body).Decode(analyzed))
+	require.NotEmpty(suite.T(), analyzed.Plan)
+	require.Equal(suite.T(), []string{"source_alert"}, analyzed.Topo.Sources)
+	for _, name := range []string{"source_alert", "op_2_decoder", "op_3_project", "sink_nop_0"} {
+		require.Contains(suite.T(), analyzed.Nodes, name)
+	}
+	require.Equal(suite.T(), 1024, analyzed.Nodes["op_3_project"].BufferCapacity)
+
 	// start non-existence rule
 	req1, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/rules/non-existence-rule/start", bytes.NewBufferString("any"))
 	w1 = httptest.NewRecorder()
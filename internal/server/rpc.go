@@ -87,6 +87,20 @@ func (r *rpcComp) close() {
 type Server int
 
 func (t *Server) CreateQuery(sql string, reply *string) error {
+	if r, ok, err := execViewQuery(sql); ok {
+		if err != nil {
+			return err
+		}
+		*reply = r
+		return nil
+	}
+	if r, ok, err := execTableQuery(sql); ok {
+		if err != nil {
+			return err
+		}
+		*reply = r
+		return nil
+	}
 	if _, ok := registry.load(QueryRuleId); ok {
 		stopQuery()
 	}
@@ -180,6 +194,28 @@ func (t *Server) GetTopoRule(name string, reply *string) error {
 	return nil
 }
 
+func (t *Server) ExplainRule(name string, reply *string) error {
+	r, err := explainRule(name)
+	if err != nil {
+		return err
+	}
+	*reply = r
+	return nil
+}
+
+func (t *Server) ExplainAnalyzeRule(name string, reply *string) error {
+	r, err := explainAnalyzeRule(name)
+	if err != nil {
+		return err
+	}
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	*reply = string(bs)
+	return nil
+}
+
 func (t *Server) StartRule(name string, reply *string) error {
 	if err := registry.StartRule(name); err != nil {
 		return err
@@ -239,6 +275,9 @@ func (t *Server) ShowRules(_ int, reply *string) error {
 }
 
 func (t *Server) DropRule(name string, reply *string) error {
+	if err := checkViewRule(name); err != nil {
+		return fmt.Errorf("Drop rule error : %s.", err)
+	}
 	err := registry.DeleteRule(name)
 	if err != nil {
 		return fmt.Errorf("Drop rule error : %s.", err)
@@ -258,6 +297,9 @@ func (t *Server) ValidateRule(rule *model.RPCArgDesc, reply *string) error {
 }
 
 func (t *Server) Import(file string, reply *string) error {
+	if err := checkNoPendingRestore(); err != nil {
+		return err
+	}
 	f, err := os.Open(file)
 	if err != nil {
 		return fmt.Errorf("fail to read file %s: %v", file, err)
@@ -310,6 +352,9 @@ func (t *Server) Export(file string, reply *string) error {
 }
 
 func (t *Server) ImportConfiguration(arg *model.ImportDataDesc, reply *string) error {
+	if err := checkNoPendingRestore(); err != nil {
+		return err
+	}
 	file := arg.FileName
 	f, err := os.Open(file)
 	if err != nil {
@@ -342,6 +387,21 @@ func (t *Server) ImportConfiguration(arg *model.ImportDataDesc, reply *string) e
 	return nil
 }
 
+func (t *Server) Reconcile(arg *model.ReconcileDesc, reply *string) error {
+	if err := checkNoPendingRestore(); err != nil {
+		return err
+	}
+	plan, err := reconcile(arg.Path, arg.Prune, arg.DryRun)
+	if plan == nil {
+		return err
+	}
+	*reply = plan.String()
+	if err != nil {
+		*reply += "\n" + err.Error()
+	}
+	return nil
+}
+
 func (t *Server) GetStatusImport(_ int, reply *string) error {
 	jsonRsp := configurationStatusExport()
 	result, err := json.Marshal(jsonRsp)
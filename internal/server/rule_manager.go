@@ -20,12 +20,15 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
 	"github.com/lf-edge/ekuiper/v2/internal/xsql"
 	"github.com/lf-edge/ekuiper/v2/metrics"
 	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
@@ -39,6 +42,9 @@ import (
 // Here registry is the in memory registry
 var registry *RuleRegistry
 
+// ruleStopTimeout is the timeout to wait for the old topo to close when updating a rule
+const ruleStopTimeout = time.Minute
+
 type RuleRegistry struct {
 	sync.RWMutex
 	internal map[string]*rule.State
@@ -101,14 +107,30 @@ func (rr *RuleRegistry) delete(key string) (*rule.State, error) {
 //// APIs for REST service
 
 func (rr *RuleRegistry) CreateRule(name, ruleJson string) (id string, err error) {
+	return rr.createRule(name, ruleJson, false)
+}
+
+// createRule creates the rule. The rules with the reserved ids of the views can only be created if managed is true.
+func (rr *RuleRegistry) createRule(name, ruleJson string, managed bool) (id string, err error) {
 	// Validate the rule json
 	r, err := ruleProcessor.GetRuleByJson(name, ruleJson)
 	if err != nil {
 		return "", fmt.Errorf("invalid rule json: %v", err)
 	}
+	if !managed {
+		if err := checkViewRule(r.Id); err != nil {
+			return r.Id, err
+		}
+	}
+	// Hold the namespace from the quota check until the rule is saved
+	unlock := namespace.Lock(namespace.Of(r.Id))
+	defer unlock()
 	if _, ok := rr.load(r.Id); ok {
 		return name, fmt.Errorf("rule %s already exists", r.Id)
 	}
+	if err := checkQuota(r); err != nil {
+		return r.Id, err
+	}
 	ruleJson = replace.ReplaceRuleJson(ruleJson, conf.IsTesting)
 	// create state and save
 	rs := rule.NewState(r)
@@ -161,17 +183,34 @@ func (rr *RuleRegistry) RecoverRule(r *def.Rule) string {
 }
 
 // UpdateRule validates the new rule, then update the db, then restart the rule
+// The state of the compatible operators is migrated and the others are dropped
 func (rr *RuleRegistry) UpdateRule(ruleId, ruleJson string) error {
+	_, err := rr.updateRule(ruleId, ruleJson, true)
+	return err
+}
+
+// updateRule updates the rule and migrates the state of the last checkpoint to the new operators.
+// If force is false, the update is rejected when the state of any operator cannot be migrated.
+// It returns the operators whose state is dropped.
+func (rr *RuleRegistry) updateRule(ruleId, ruleJson string, force bool) ([]state.IncompatibleOp, error) {
 	ruleJson = replace.ReplaceRuleJson(ruleJson, conf.IsTesting)
 	// Validate the rule json
 	r, err := ruleProcessor.GetRuleByJson(ruleId, ruleJson)
 	if err != nil {
-		return fmt.Errorf("Invalid rule json: %v", err)
+		return nil, fmt.Errorf("Invalid rule json: %v", err)
+	}
+	if err := checkViewRule(r.Id); err != nil {
+		return nil, err
 	}
 
 	rs, ok := registry.load(ruleId)
 	if !ok {
-		return errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("Rule %s is not found in registry, please check if it is created", ruleId))
+		return nil, errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("Rule %s is not found in registry, please check if it is created", ruleId))
+	}
+	unlock := namespace.Lock(namespace.Of(r.Id))
+	defer unlock()
+	if err := checkQuota(r); err != nil {
+		return nil, err
 	}
 	// Try plan with the new json. If err, revert to old rule
 	oldRule := rs.Rule
@@ -180,22 +219,65 @@ func (rr *RuleRegistry) UpdateRule(ruleId, ruleJson string) error {
 	newTopo, err := rs.Validate()
 	if err != nil {
 		rs.Rule = oldRule
-		return err
+		return nil, err
+	}
+	// Only the rules with checkpoint have state to migrate
+	migrate := newTopo != nil && oldRule.Options.Qos >= def.AtLeastOnce && r.Options.Qos >= def.AtLeastOnce
+	if migrate && !force {
+		incompatible, err := state.MigrateState(ruleId, newTopo.GetStateLayout(), true)
+		if err == nil && len(incompatible) > 0 {
+			err = &stateIncompatibleError{RuleId: ruleId, Operators: incompatible}
+		}
+		if err != nil {
+			rs.Rule = oldRule
+			newTopo.Cancel()
+			return incompatible, err
+		}
 	}
 	// Validate successful, save to db
 	err1 := rr.update(r.Id, ruleJson)
 	// ReRun the rule
 	rs.Stop()
+	var incompatible []state.IncompatibleOp
+	// The old topo may still write the checkpoint until it is closed
+	if migrate {
+		if err := rs.WaitStop(ruleStopTimeout); err != nil {
+			conf.Log.Warnf("skip migrating the state of rule %s: %v", ruleId, err)
+			migrate = false
+		}
+	}
+	if migrate {
+		incompatible, err = state.MigrateState(ruleId, newTopo.GetStateLayout(), false)
+		if err != nil {
+			conf.Log.Warnf("migrate state of rule %s error: %v", ruleId, err)
+		} else if len(incompatible) > 0 {
+			conf.Log.Infof("drop the state of the incompatible operators %v of rule %s", incompatible, ruleId)
+		}
+	}
 	rs.WithTopo(newTopo)
 	if r.Triggered {
 		err2 := rs.Start()
 		if err2 != nil {
-			return err2
+			return incompatible, err2
 		}
 	} else if newTopo != nil {
 		newTopo.Cancel()
 	}
-	return err1
+	return incompatible, err1
+}
+
+// stateIncompatibleError reports the operators whose state cannot be migrated to the updated rule
+type stateIncompatibleError struct {
+	RuleId    string                 `json:"ruleId"`
+	Operators []state.IncompatibleOp `json:"incompatibleOperators"`
+}
+
+func (e *stateIncompatibleError) Error() string {
+	ops := make([]string, 0, len(e.Operators))
+	for _, op := range e.Operators {
+		ops = append(ops, op.OpId)
+	}
+	return fmt.Sprintf("the state of operators %s of rule %s cannot be migrated, update with force to drop the state", strings.Join(ops, ","), e.RuleId)
 }
 
 func (rr *RuleRegistry) DeleteRule(name string) error {
@@ -15,12 +15,20 @@
 package server
 
 import (
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/lf-edge/ekuiper/v2/internal/io/memory/pubsub"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
 func TestErrors(t *testing.T) {
@@ -53,3 +61,53 @@ func TestErrors(t *testing.T) {
 	err = registry.StartRule("test")
 	assert.EqualError(t, err, "fail to get stream demo, please check if stream is created")
 }
+
+func (suite *RestTestSuite) TestUpdateRuleStateMigration() {
+	defer func() {
+		_ = registry.DeleteRule("migraterule")
+		_, _ = streamProcessor.DropStream("migratedemo", ast.TypeStream)
+	}()
+	_, err := streamProcessor.ExecStreamSql(`CREATE STREAM migratedemo() WITH (DATASOURCE="migratedemo", TYPE="memory", FORMAT="json")`)
+	require.NoError(suite.T(), err)
+	ruleJson := func(sql string) string {
+		return `{"id":"migraterule","sql":"` + sql + `","actions":[{"log":{}}],"options":{"qos":1,"checkpointInterval":"1h"}}`
+	}
+	waitRunning := func() {
+		require.Eventually(suite.T(), func() bool {
+			st, _ := getRuleState("migraterule")
+			return st == rule.Running
+		}, time.Second, 10*time.Millisecond)
+	}
+	_, err = registry.CreateRule("migraterule", ruleJson("SELECT count(*) FROM migratedemo GROUP BY TumblingWindow(ss, 10)"))
+	require.NoError(suite.T(), err)
+	waitRunning()
+	// wait for the source to subscribe
+	time.Sleep(100 * time.Millisecond)
+	pubsub.Produce(kctx.Background(), "migratedemo", &xsql.Tuple{Message: map[string]any{"a": 1}})
+	time.Sleep(100 * time.Millisecond)
+	code, resp := suite.request(http.MethodPost, "/rules/migraterule/savepoint", "")
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+
+	// compatible change
+	code, resp = suite.request(http.MethodPut, "/rules/migraterule", ruleJson("SELECT count(*) AS c FROM migratedemo GROUP BY TumblingWindow(ss, 10)"))
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	require.Equal(suite.T(), "Rule migraterule was updated successfully.", resp)
+	waitRunning()
+	code, resp = suite.request(http.MethodPost, "/rules/migraterule/savepoint", "")
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+
+	// incompatible window is rejected
+	changed := ruleJson("SELECT count(*) AS c FROM migratedemo GROUP BY TumblingWindow(ss, 20)")
+	code, resp = suite.request(http.MethodPut, "/rules/migraterule", changed)
+	require.Equal(suite.T(), http.StatusConflict, code, resp)
+	se := &stateIncompatibleError{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(resp), se))
+	require.Len(suite.T(), se.Operators, 1)
+	r, err := ruleProcessor.GetRuleById("migraterule")
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), r.Sql, "TumblingWindow(ss, 10)")
+
+	code, resp = suite.request(http.MethodPut, "/rules/migraterule?force=true", changed)
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	require.Contains(suite.T(), resp, "The state of the incompatible operators "+se.Operators[0].OpId+" is dropped.")
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+type outputStreamRequest struct {
+	// Stream is the name of the stream to register
+	Stream string `json:"stream"`
+	// Topic is the memory topic of the rule output. Defaults to the topic of the memory action of the rule.
+	Topic string `json:"topic"`
+}
+
+func getSqlRule(name string) (*def.Rule, error) {
+	rule, err := ruleProcessor.GetRuleById(name)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, errorx.NewWithCode(errorx.NOT_FOUND, "rule not found")
+	}
+	if rule.Sql == "" {
+		return nil, errors.New("only support inferring the schema of sql rules")
+	}
+	return rule, nil
+}
+
+// inferRuleSchema infers the output schema of the rule statically
+func inferRuleSchema(rule *def.Rule) ([]planner.OutputField, error) {
+	stmt, err := xsql.GetStatementFromSql(rule.Sql)
+	if err != nil {
+		return nil, err
+	}
+	db, err := store.GetKV("stream")
+	if err != nil {
+		return nil, err
+	}
+	return planner.InferOutputSchema(stmt, namespace.KV(db, namespace.Of(rule.Id)))
+}
+
+// memoryTopic finds the topic of the first memory action of the rule
+func memoryTopic(rule *def.Rule) string {
+	for _, action := range rule.Actions {
+		props, ok := action["memory"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if topic, ok := props["topic"].(string); ok && topic != "" && !strings.Contains(topic, "{{") {
+			return topic
+		}
+	}
+	return ""
+}
+
+// registerOutputStream creates a typed memory stream to consume the output of the rule
+func registerOutputStream(rule *def.Rule, req *outputStreamRequest) (string, error) {
+	if req.Stream == "" {
+		return "", errors.New("stream name is required")
+	}
+	topic := req.Topic
+	if topic == "" {
+		topic = memoryTopic(rule)
+		if topic == "" {
+			return "", fmt.Errorf("rule %s has no memory action, please specify the topic", rule.Id)
+		}
+	}
+	fields, err := inferRuleSchema(rule)
+	if err != nil {
+		return "", err
+	}
+	defs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.FieldType == nil {
+			return "", fmt.Errorf("cannot register stream %s: the type of field %s cannot be inferred", req.Stream, f.Name)
+		}
+		defs = append(defs, fmt.Sprintf("`%s` %s", f.Name, f.Type))
+	}
+	sql := fmt.Sprintf(`CREATE STREAM %s (%s) WITH (DATASOURCE="%s", TYPE="memory")`, req.Stream, strings.Join(defs, ", "), topic)
+	return streamProcessor.InNamespace(namespace.Of(rule.Id)).ExecStreamSql(sql)
+}
+
+// infer the output schema of a rule or register it as a stream
+func ruleSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	name := qualifiedVar(r, "name")
+
+	rule, err := getSqlRule(name)
+	if err != nil {
+		handleError(w, err, "infer rule schema error", logger)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		fields, err := inferRuleSchema(rule)
+		if err != nil {
+			handleError(w, err, "infer rule schema error", logger)
+			return
+		}
+		jsonResponse(fields, w, logger)
+	case http.MethodPost:
+		req := &outputStreamRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			handleError(w, fmt.Errorf("decode body error: %v", err), "Invalid body", logger)
+			return
+		}
+		content, err := registerOutputStream(rule, req)
+		if err != nil {
+			handleError(w, err, "register rule output stream error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(content))
+	}
+}
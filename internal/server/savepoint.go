@@ -0,0 +1,96 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+)
+
+const savepointTimeout = time.Minute
+
+// create a savepoint of the running rule and download it
+func savepointRuleHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	name := qualifiedVar(r, "name")
+	sp, err := createSavepoint(name)
+	if err != nil {
+		handleError(w, err, "create savepoint error", logger)
+		return
+	}
+	b, err := json.Marshal(sp)
+	if err != nil {
+		handleError(w, err, "create savepoint error", logger)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.savepoint.json\"", sp.RuleId))
+	http.ServeContent(w, r, sp.RuleId+".savepoint.json", time.Now(), bytes.NewReader(b))
+}
+
+// restore the rule state from the uploaded savepoint and start the rule
+func restoreRuleHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	name := qualifiedVar(r, "name")
+	sp := &state.Savepoint{}
+	if err := json.NewDecoder(r.Body).Decode(sp); err != nil {
+		handleError(w, err, "Invalid body: Error decoding the savepoint", logger)
+		return
+	}
+	if err := restoreSavepoint(name, sp); err != nil {
+		handleError(w, err, "restore savepoint error", logger)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "Rule %s was restored from savepoint %d of rule %s", name, sp.CheckpointId, sp.RuleId)
+}
+
+func createSavepoint(name string) (*state.Savepoint, error) {
+	rs, ok := registry.load(name)
+	if !ok {
+		return nil, errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("Rule %s is not found in registry, please check if it is created", name))
+	}
+	checkpointId, err := rs.Savepoint(savepointTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return state.CreateSavepoint(name, checkpointId)
+}
+
+func restoreSavepoint(name string, sp *state.Savepoint) error {
+	rs, ok := registry.load(name)
+	if !ok {
+		return errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("Rule %s is not found in registry, please check if it is created", name))
+	}
+	if rs.Rule.Options.Qos < def.AtLeastOnce {
+		return fmt.Errorf("rule %s does not enable checkpoint, set qos to at least once", name)
+	}
+	rs.Stop()
+	// The state must not be written by the running topo during the restore
+	if err := rs.WaitStop(savepointTimeout); err != nil {
+		return err
+	}
+	if err := state.RestoreSavepoint(name, sp); err != nil {
+		return err
+	}
+	return registry.StartRule(name)
+}
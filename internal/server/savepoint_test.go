@@ -0,0 +1,73 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func (suite *RestTestSuite) TestSavepoint() {
+	defer func() {
+		_ = registry.DeleteRule("sprule1")
+		_ = registry.DeleteRule("sprule2")
+		_ = registry.DeleteRule("sprule3")
+		_, _ = streamProcessor.DropStream("spdemo", ast.TypeStream)
+	}()
+	_, err := streamProcessor.ExecStreamSql(`CREATE STREAM spdemo() WITH (DATASOURCE="spdemo", TYPE="memory", FORMAT="json")`)
+	require.NoError(suite.T(), err)
+	ruleSql := `"sql":"SELECT count(*) FROM spdemo GROUP BY CountWindow(10)","actions":[{"log":{}}]`
+	_, err = registry.CreateRule("sprule1", `{"id":"sprule1",`+ruleSql+`,"options":{"qos":1,"checkpointInterval":"1h"}}`)
+	require.NoError(suite.T(), err)
+	_, err = registry.CreateRule("sprule2", `{"id":"sprule2",`+ruleSql+`,"options":{"qos":1,"checkpointInterval":"1h"}}`)
+	require.NoError(suite.T(), err)
+	_, err = registry.CreateRule("sprule3", `{"id":"sprule3",`+ruleSql+`}`)
+	require.NoError(suite.T(), err)
+	require.Eventually(suite.T(), func() bool {
+		st1, _ := getRuleState("sprule1")
+		st3, _ := getRuleState("sprule3")
+		return st1 == rule.Running && st3 == rule.Running
+	}, time.Second, 10*time.Millisecond)
+
+	code, resp := suite.request(http.MethodPost, "/rules/sprule1/savepoint", "")
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	sp := &state.Savepoint{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(resp), sp))
+	require.Equal(suite.T(), "sprule1", sp.RuleId)
+	require.NotEmpty(suite.T(), sp.Operators)
+
+	// restore to another rule
+	code, resp = suite.request(http.MethodPost, "/rules/sprule2/restore", resp)
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	require.Eventually(suite.T(), func() bool {
+		st, _ := getRuleState("sprule2")
+		return st == rule.Running
+	}, time.Second, 10*time.Millisecond)
+
+	// the rule without checkpoint
+	code, _ = suite.request(http.MethodPost, "/rules/sprule3/savepoint", "")
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+	code, _ = suite.request(http.MethodPost, "/rules/sprule3/restore", `{"version":1}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+	code, _ = suite.request(http.MethodPost, "/rules/sprulenone/savepoint", "")
+	require.Equal(suite.T(), http.StatusNotFound, code)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
+)
+
+// SecretRequest is the body to create or update a secret. The value is write only.
+type SecretRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func secretsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	switch r.Method {
+	case http.MethodGet:
+		names, err := secret.List()
+		if err != nil {
+			handleError(w, err, "list secrets error", logger)
+			return
+		}
+		jsonResponse(names, w, logger)
+	case http.MethodPost:
+		req := &SecretRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		if err := secret.Create(req.Name, req.Value); err != nil {
+			handleError(w, err, "create secret error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "Secret %s is created.", req.Name)
+	}
+}
+
+func secretHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	name := mux.Vars(r)["name"]
+	switch r.Method {
+	case http.MethodPut:
+		req := &SecretRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		if req.Name != "" && req.Name != name {
+			handleError(w, fmt.Errorf("the name %s in the body mismatches %s in the path", req.Name, name), "Invalid body", logger)
+			return
+		}
+		if err := secret.Set(name, req.Value); err != nil {
+			handleError(w, err, "update secret error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Secret %s is updated.", name)
+	case http.MethodDelete:
+		if err := secret.Delete(name); err != nil {
+			handleError(w, err, "delete secret error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Secret %s is deleted.", name)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
+)
+
+func (suite *RestTestSuite) TestSecretsHandler() {
+	defer func() {
+		_ = secret.Delete("rest_pwd")
+	}()
+	code, resp := suite.request(http.MethodPost, "/secrets", `{"name":"rest_pwd","value":"p1"}`)
+	require.Equal(suite.T(), http.StatusCreated, code, resp)
+	code, _ = suite.request(http.MethodPost, "/secrets", `{"name":"rest_pwd","value":"p2"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+	code, _ = suite.request(http.MethodPost, "/secrets", `{"name":"rest:pwd","value":"p2"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+
+	// The values are never returned
+	code, resp = suite.request(http.MethodGet, "/secrets", "")
+	require.Equal(suite.T(), http.StatusOK, code)
+	require.Contains(suite.T(), resp, `"rest_pwd"`)
+	require.NotContains(suite.T(), resp, "p1")
+	code, _ = suite.request(http.MethodGet, "/secrets/rest_pwd", "")
+	require.Equal(suite.T(), http.StatusMethodNotAllowed, code)
+
+	code, resp = suite.request(http.MethodPut, "/secrets/rest_pwd", `{"value":"p2"}`)
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	r, err := secret.Resolve(map[string]any{"password": `{{secret "rest_pwd"}}`})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "p2", r["password"])
+	code, _ = suite.request(http.MethodPut, "/secrets/rest_pwd", `{"name":"other","value":"p2"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, code)
+
+	code, resp = suite.request(http.MethodDelete, "/secrets/rest_pwd", "")
+	require.Equal(suite.T(), http.StatusOK, code, resp)
+	code, _ = suite.request(http.MethodDelete, "/secrets/rest_pwd", "")
+	require.Equal(suite.T(), http.StatusNotFound, code)
+}
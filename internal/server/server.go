@@ -59,6 +59,7 @@ var (
 	sysMetrics             *Metrics
 	ruleProcessor          *processor.RuleProcessor
 	streamProcessor        *processor.StreamProcessor
+	viewProcessor          *processor.ViewProcessor
 	rulesetProcessor       *processor.RulesetProcessor
 	ruleMigrationProcessor *RuleMigrationProcessor
 	stopSignal             chan struct{}
@@ -130,10 +131,41 @@ func getStoreConfigByKuiperConfig(c *conf.KuiperConf) (*store.StoreConf, error)
 		FdbConfig: definition.FdbConfig{
 			Path: c.Store.Fdb.Path,
 		},
+		PebbleConfig: definition.PebbleConfig{
+			Path:                     c.Store.Pebble.Path,
+			MemTableSize:             c.Store.Pebble.MemTableSize,
+			CacheSize:                c.Store.Pebble.CacheSize,
+			L0CompactionThreshold:    c.Store.Pebble.L0CompactionThreshold,
+			L0StopWritesThreshold:    c.Store.Pebble.L0StopWritesThreshold,
+			MaxConcurrentCompactions: c.Store.Pebble.MaxConcurrentCompactions,
+			Sync:                     c.Store.Pebble.Sync,
+		},
+		EncryptionConfig: definition.EncryptionConfig{
+			Enable:           c.Store.Encryption.Enable,
+			Path:             dataDir,
+			KeyFile:          c.Store.Encryption.KeyFile,
+			PreviousKeyFile:  c.Store.Encryption.PreviousKeyFile,
+			RotationInterval: time.Duration(c.Store.Encryption.RotationInterval),
+		},
+	}
+	if sc.PebbleConfig.Path == "" {
+		sc.PebbleConfig.Path = dataDir
 	}
 	return sc, nil
 }
 
+// MigrateStore copies the data of the sqlite store to the store type configured in kuiper.yaml. It must run when the
+// server is stopped.
+func MigrateStore() error {
+	conf.SetupEnv()
+	conf.InitConf()
+	sc, err := getStoreConfigByKuiperConfig(conf.Config)
+	if err != nil {
+		return err
+	}
+	return store.MigrateFromSqlite(sc)
+}
+
 func StartUp(Version string) {
 	version = Version
 	startTimeStamp = time.Now().Unix()
@@ -167,6 +199,9 @@ func StartUp(Version string) {
 	if err != nil {
 		panic(err)
 	}
+	if err := applyRestore(sc); err != nil {
+		panic(err)
+	}
 	err = store.SetupWithConfig(sc)
 	if err != nil {
 		panic(err)
@@ -190,6 +225,7 @@ func StartUp(Version string) {
 	httpserver.InitGlobalServerManager(conf.Config.Source.HttpServerIp, conf.Config.Source.HttpServerPort, conf.Config.Source.HttpServerTls)
 	ruleProcessor = processor.NewRuleProcessor()
 	streamProcessor = processor.NewStreamProcessor()
+	viewProcessor = processor.NewViewProcessor()
 	rulesetProcessor = processor.NewRulesetProcessor(ruleProcessor, streamProcessor)
 	ruleMigrationProcessor = NewRuleMigrationProcessor(ruleProcessor, streamProcessor)
 	sysMetrics = NewMetrics()
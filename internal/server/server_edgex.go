@@ -23,10 +23,12 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	edgex_vault "github.com/lf-edge/ekuiper/v2/internal/edgex"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
 )
 
 func init() {
 	newNetListener = newZitifiedNetListener
+	secret.RegisterProvider("vault", &edgex_vault.VaultProvider{Logger: conf.Log})
 }
 
 func newZitifiedNetListener(addr string, logger *logrus.Logger) (net.Listener, error) {
@@ -0,0 +1,188 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+// createView saves the view and creates the rule to maintain it continuously
+func createView(sql string) (string, error) {
+	vi, err := viewProcessor.ExecCreate(sql)
+	if err != nil {
+		return "", err
+	}
+	ruleJson, err := vi.RuleJson()
+	if err == nil {
+		_, err = registry.createRule(vi.RuleId(), ruleJson, true)
+	}
+	if err != nil {
+		_ = viewProcessor.DropView(vi.Name)
+		return "", fmt.Errorf("create view %s error: %v", vi.Name, err)
+	}
+	return fmt.Sprintf("View %s is created.", vi.Name), nil
+}
+
+// checkViewRule rejects the changes of the rules which maintain the existing views by the rule APIs
+func checkViewRule(ruleId string) error {
+	if name, ok := viewProcessor.ViewOfRule(ruleId); ok {
+		return fmt.Errorf("rule id %s is reserved for view %s, please use the view statements to manage it", ruleId, name)
+	}
+	return nil
+}
+
+// dropView deletes the maintaining rule and then the view with all its rows
+func dropView(name string) (string, error) {
+	vi, err := viewProcessor.GetView(name)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := registry.load(vi.RuleId()); ok {
+		if err := registry.DeleteRule(vi.RuleId()); err != nil {
+			return "", fmt.Errorf("drop view %s error: %v", name, err)
+		}
+	}
+	if err := viewProcessor.DropView(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("View %s is dropped.", name), nil
+}
+
+// execViewQuery runs the view statements and the point-in-time select of a view.
+// It returns false if the sql is not about views.
+func execViewQuery(sql string) (string, bool, error) {
+	stmt, err := xsql.Language.Parse(xsql.NewParser(strings.NewReader(sql)))
+	if err != nil {
+		return "", false, nil
+	}
+	switch s := stmt.(type) {
+	case *ast.ViewStmt:
+		r, err := createView(sql)
+		return r, true, err
+	case *ast.ShowViewsStatement:
+		views, err := viewProcessor.ShowViews()
+		if err != nil {
+			return "", true, err
+		}
+		if len(views) == 0 {
+			return "No view definitions are found.", true, nil
+		}
+		return strings.Join(views, "\n"), true, nil
+	case *ast.DescribeViewStatement:
+		vi, err := viewProcessor.GetView(s.Name)
+		if err != nil {
+			return "", true, err
+		}
+		return vi.Statement, true, nil
+	case *ast.DropViewStatement:
+		r, err := dropView(s.Name)
+		return r, true, err
+	case *ast.SelectStatement:
+		if len(s.Sources) != 1 || len(s.Joins) > 0 || len(s.Dimensions) > 0 || len(s.Unions) > 0 {
+			return "", false, nil
+		}
+		t, ok := s.Sources[0].(*ast.Table)
+		if !ok {
+			return "", false, nil
+		}
+		if _, err := viewProcessor.GetView(t.Name); err != nil {
+			return "", false, nil
+		}
+		rows, err := viewProcessor.Query(t.Name, s.Fields, s.Condition)
+		if err != nil {
+			return "", true, err
+		}
+		b, err := json.Marshal(rows)
+		if err != nil {
+			return "", true, err
+		}
+		return string(b), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// list or create views
+func viewsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	switch r.Method {
+	case http.MethodGet:
+		content, err := viewProcessor.ShowViews()
+		if err != nil {
+			handleError(w, err, "View command error", logger)
+			return
+		}
+		jsonResponse(content, w, logger)
+	case http.MethodPost:
+		v, err := decodeStatementDescriptor(r.Body)
+		if err != nil {
+			handleError(w, err, "Invalid body", logger)
+			return
+		}
+		content, err := createView(v.Sql)
+		if err != nil {
+			handleError(w, err, "View command error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(content))
+	}
+}
+
+// query or delete a view
+func viewHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	switch r.Method {
+	case http.MethodGet:
+		content, err := viewProcessor.QueryWhere(name, r.URL.Query().Get("where"))
+		if err != nil {
+			handleError(w, err, "query view error", logger)
+			return
+		}
+		jsonResponse(content, w, logger)
+	case http.MethodDelete:
+		content, err := dropView(name)
+		if err != nil {
+			handleError(w, err, "delete view error", logger)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(content))
+	}
+}
+
+// describe a view
+func viewDetailHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	vars := mux.Vars(r)
+	name := vars["name"]
+	content, err := viewProcessor.GetView(name)
+	if err != nil {
+		handleError(w, err, "describe view error", logger)
+		return
+	}
+	jsonResponse(content, w, logger)
+}
@@ -323,6 +323,15 @@ func yamlConfImportHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		content = buf.Bytes()
 	}
+	if r.URL.Query().Get("dryRun") == "true" {
+		result, err := yamlImportDryRun(content)
+		if err != nil {
+			handleError(w, err, "", logger)
+			return
+		}
+		jsonResponse(result, w, logger)
+		return
+	}
 	err = importFromByte(content)
 	if err != nil {
 		handleError(w, err, "import failed", logger)
@@ -15,6 +15,7 @@
 package checkpoint
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -103,6 +104,14 @@ type Coordinator struct {
 	store                   api.Store
 	ctx                     api.StreamContext
 	activated               bool
+	savepoint               chan chan *savepointResult
+	// The checkpoint id to the result channel of the savepoint waiting for it
+	savepointWaiters *sync.Map
+}
+
+type savepointResult struct {
+	checkpointId int64
+	err          error
 }
 
 func NewCoordinator(ruleId string, sources []StreamTask, operators []NonSourceTask, sinks []SinkTask, qos def.Qos, store api.Store, interval time.Duration, ctx api.StreamContext) *Coordinator {
@@ -142,12 +151,14 @@ func NewCoordinator(ruleId string, sources []StreamTask, operators []NonSourceTa
 		completedCheckpoints: &checkpointStore{
 			maxNum: 3,
 		},
-		ruleId:         ruleId,
-		signal:         signal,
-		baseInterval:   interval,
-		store:          store,
-		ctx:            ctx,
-		cleanThreshold: 100,
+		ruleId:           ruleId,
+		signal:           signal,
+		baseInterval:     interval,
+		store:            store,
+		ctx:              ctx,
+		cleanThreshold:   100,
+		savepoint:        make(chan chan *savepointResult),
+		savepointWaiters: new(sync.Map),
 	}
 }
 
@@ -173,6 +184,7 @@ func (c *Coordinator) Activate() error {
 		err := infra.SafeRun(func() error {
 			c.activated = true
 			toBeClean := 0
+			var lastId int64
 			for {
 				select {
 				case n := <-tc:
@@ -181,25 +193,16 @@ func (c *Coordinator) Activate() error {
 
 					// TODO Check if all tasks are running
 
-					// Create a pending checkpoint
-					checkpointId := cast.TimeToUnixMilli(n)
-					checkpoint := newPendingCheckpoint(checkpointId, c.tasksToWaitFor)
-					logger.Debugf("Create checkpoint %d", checkpointId)
-					c.pendingCheckpoints.Store(checkpointId, checkpoint)
-					// Let the sources send out a barrier
-					for _, r := range c.tasksToTrigger {
-						go func(t Responder) {
-							if err := t.TriggerCheckpoint(checkpointId); err != nil {
-								logger.Infof("Fail to trigger checkpoint for source %s with error %v, cancel it", t.GetName(), err)
-								c.cancel(checkpointId)
-							}
-						}(r)
-					}
+					lastId = c.trigger(nextCheckpointId(cast.TimeToUnixMilli(n), lastId))
 					toBeClean++
 					if toBeClean >= c.cleanThreshold {
 						c.store.Clean()
 						toBeClean = 0
 					}
+				case result := <-c.savepoint:
+					checkpointId := nextCheckpointId(timex.GetNowInMilli(), lastId)
+					c.savepointWaiters.Store(checkpointId, result)
+					lastId = c.trigger(checkpointId)
 				case s := <-c.signal:
 					switch s.Message {
 					case STOP:
@@ -238,6 +241,64 @@ func (c *Coordinator) Activate() error {
 	return nil
 }
 
+// nextCheckpointId makes sure the checkpoint ids are unique and increasing even if triggered in the same millisecond
+func nextCheckpointId(n int64, lastId int64) int64 {
+	if n <= lastId {
+		return lastId + 1
+	}
+	return n
+}
+
+// trigger creates a pending checkpoint and lets the sources send out a barrier
+func (c *Coordinator) trigger(checkpointId int64) int64 {
+	logger := c.ctx.GetLogger()
+	checkpoint := newPendingCheckpoint(checkpointId, c.tasksToWaitFor)
+	logger.Debugf("Create checkpoint %d", checkpointId)
+	c.pendingCheckpoints.Store(checkpointId, checkpoint)
+	for _, r := range c.tasksToTrigger {
+		go func(t Responder) {
+			if err := t.TriggerCheckpoint(checkpointId); err != nil {
+				logger.Infof("Fail to trigger checkpoint for source %s with error %v, cancel it", t.GetName(), err)
+				c.cancel(checkpointId)
+			}
+		}(r)
+	}
+	return checkpointId
+}
+
+// Savepoint triggers a checkpoint immediately and waits until it is completed and saved in the store.
+// It returns the id of the completed checkpoint.
+func (c *Coordinator) Savepoint(timeout time.Duration) (int64, error) {
+	if !c.activated {
+		return 0, fmt.Errorf("checkpoint coordinator of rule %s is not activated", c.ruleId)
+	}
+	result := make(chan *savepointResult, 1)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.savepoint <- result:
+	case <-c.ctx.Done():
+		return 0, fmt.Errorf("rule %s is stopped", c.ruleId)
+	case <-timer.C:
+		return 0, fmt.Errorf("trigger savepoint for rule %s timeout", c.ruleId)
+	}
+	select {
+	case r := <-result:
+		return r.checkpointId, r.err
+	case <-c.ctx.Done():
+		return 0, fmt.Errorf("rule %s is stopped", c.ruleId)
+	case <-timer.C:
+		return 0, fmt.Errorf("savepoint for rule %s is not completed in %v", c.ruleId, timeout)
+	}
+}
+
+// notifySavepoint sends the result to the savepoint waiting for the checkpoint if any
+func (c *Coordinator) notifySavepoint(checkpointId int64, err error) {
+	if r, ok := c.savepointWaiters.LoadAndDelete(checkpointId); ok {
+		r.(chan *savepointResult) <- &savepointResult{checkpointId: checkpointId, err: err}
+	}
+}
+
 func (c *Coordinator) Deactivate() error {
 	if c.ticker != nil {
 		c.ticker.Stop()
@@ -251,6 +312,7 @@ func (c *Coordinator) cancel(checkpointId int64) {
 	if checkpoint, ok := c.pendingCheckpoints.Load(checkpointId); ok {
 		c.pendingCheckpoints.Delete(checkpointId)
 		checkpoint.(*pendingCheckpoint).dispose(true)
+		c.notifySavepoint(checkpointId, fmt.Errorf("checkpoint %d is cancelled", checkpointId))
 	} else {
 		logger.Debugf("Cancel for non existing checkpoint %d. Just ignored", checkpointId)
 	}
@@ -264,6 +326,7 @@ func (c *Coordinator) complete(checkpointId int64) {
 		if err != nil {
 			logger.Infof("Cannot save checkpoint %d due to storage error: %v", checkpointId, err)
 			// TODO handle checkpoint error
+			c.notifySavepoint(checkpointId, err)
 			return
 		}
 		c.completedCheckpoints.add(ccp.(*pendingCheckpoint).finalize())
@@ -276,10 +339,12 @@ func (c *Coordinator) complete(checkpointId int64) {
 				// TODO revisit how to abort a checkpoint, discard callback
 				cp.isDiscarded = true
 				c.pendingCheckpoints.Delete(cid)
+				c.notifySavepoint(cid, fmt.Errorf("checkpoint %d is discarded", cid))
 			}
 			return true
 		})
 		logger.Debugf("Totally complete checkpoint %d", checkpointId)
+		c.notifySavepoint(checkpointId, nil)
 	} else {
 		logger.Infof("Cannot find checkpoint %d to complete", checkpointId)
 	}
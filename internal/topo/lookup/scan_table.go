@@ -0,0 +1,69 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lookup
+
+import (
+	"fmt"
+	"sync"
+)
+
+// The rows of the scan tables are kept by the join nodes of the running rules. They are published here so that the
+// ad-hoc queries can read the current rows without reading the source again.
+var (
+	scanTables = make(map[string]map[string][]map[string]any)
+	scanLock   sync.RWMutex
+)
+
+// SetScanTable saves the current rows of the scan table retained by the rule
+func SetScanTable(name string, ruleId string, rows []map[string]any) {
+	scanLock.Lock()
+	defer scanLock.Unlock()
+	t, ok := scanTables[name]
+	if !ok {
+		t = make(map[string][]map[string]any)
+		scanTables[name] = t
+	}
+	t[ruleId] = rows
+}
+
+// RemoveScanTable removes the rows of the scan table retained by the rule when the rule stops
+func RemoveScanTable(name string, ruleId string) {
+	scanLock.Lock()
+	defer scanLock.Unlock()
+	if t, ok := scanTables[name]; ok {
+		delete(t, ruleId)
+		if len(t) == 0 {
+			delete(scanTables, name)
+		}
+	}
+}
+
+// ScanTable returns the rows of the scan table retained by the running rules. The rules which join the same table
+// have the same rows unless their retain size differs, so the rule retaining the most rows is read.
+func ScanTable(name string) ([]map[string]any, error) {
+	scanLock.RLock()
+	defer scanLock.RUnlock()
+	t, ok := scanTables[name]
+	if !ok {
+		return nil, fmt.Errorf("scan table %s has no rows in memory, it is only kept by the running rules which join it", name)
+	}
+	var result []map[string]any
+	for _, rows := range t {
+		if result == nil || len(rows) > len(result) {
+			result = rows
+		}
+	}
+	return result, nil
+}
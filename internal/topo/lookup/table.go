@@ -23,6 +23,8 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/io"
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	nodeConf "github.com/lf-edge/ekuiper/v2/internal/topo/node/conf"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
@@ -63,13 +65,35 @@ func Detach(name string) error {
 	return fmt.Errorf("lookup table %s is not found", name)
 }
 
+// Scanner is implemented by the lookup sources which can read all their rows
+type Scanner interface {
+	Scan(ctx api.StreamContext) ([]map[string]any, error)
+}
+
+// Scan reads all the rows of the lookup table for the ad-hoc query
+func Scan(ctx api.StreamContext, name string) ([]map[string]any, error) {
+	ls, err := Attach(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = Detach(name)
+	}()
+	s, ok := ls.(Scanner)
+	if !ok {
+		return nil, fmt.Errorf("lookup table %s does not support scan", name)
+	}
+	return s.Scan(ctx)
+}
+
 // CreateInstance called when create a lookup table
 func CreateInstance(name string, sourceType string, options *ast.Options) error {
 	lock.Lock()
 	defer lock.Unlock()
 	contextLogger := conf.Log.WithField("table", name)
 	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
-	props := nodeConf.GetSourceConf(sourceType, options)
+	// the name of the table in a namespace is qualified
+	props := nodeConf.GetSourceConfInNamespace(namespace.Of(name), sourceType, options)
 	ctx.GetLogger().Infof("open lookup table with props %v", conf.Printable(props))
 	// Create the lookup source according to the source options
 	ns, err := io.LookupSource(sourceType)
@@ -78,7 +102,11 @@ func CreateInstance(name string, sourceType string, options *ast.Options) error
 		return err
 	}
 	ctx.GetLogger().Debugf("lookup source %s is created", sourceType)
-	err = ns.Provision(ctx, props)
+	resolved, err := secret.Resolve(props)
+	if err != nil {
+		return err
+	}
+	err = ns.Provision(ctx, resolved)
 	if err != nil {
 		return err
 	}
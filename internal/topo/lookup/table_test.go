@@ -18,6 +18,9 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
+	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
@@ -99,3 +102,40 @@ func TestTable(t *testing.T) {
 		return
 	}
 }
+
+func TestScan(t *testing.T) {
+	err := CreateInstance("scan1", "memory", &ast.Options{
+		DATASOURCE: "scan1",
+		TYPE:       "memory",
+		KIND:       "lookup",
+		KEY:        "id",
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = DropInstance("scan1")
+	}()
+	ctx := kctx.Background()
+	rows, err := Scan(ctx, "scan1")
+	require.NoError(t, err)
+	require.Empty(t, rows)
+	require.Equal(t, int32(0), instances["scan1"].count)
+	_, err = Scan(ctx, "nonexist")
+	require.EqualError(t, err, "lookup table nonexist is not found")
+}
+
+func TestScanTable(t *testing.T) {
+	_, err := ScanTable("scanTable1")
+	require.EqualError(t, err, "scan table scanTable1 has no rows in memory, it is only kept by the running rules which join it")
+	SetScanTable("scanTable1", "rule1", []map[string]any{{"id": 1}})
+	SetScanTable("scanTable1", "rule2", []map[string]any{{"id": 1}, {"id": 2}})
+	rows, err := ScanTable("scanTable1")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"id": 1}, {"id": 2}}, rows)
+	RemoveScanTable("scanTable1", "rule2")
+	rows, err = ScanTable("scanTable1")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"id": 1}}, rows)
+	RemoveScanTable("scanTable1", "rule1")
+	_, err = ScanTable("scanTable1")
+	require.Error(t, err)
+}
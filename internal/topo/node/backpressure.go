@@ -0,0 +1,455 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/checkpoint"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/node/cache"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/metrics"
+	"github.com/lf-edge/ekuiper/v2/pkg/infra"
+)
+
+const defaultMaxSpill = 1024000
+
+func init() {
+	gob.Register(&spilledMessage{})
+}
+
+// EdgeStats is the backpressure statistics of an output of a node
+type EdgeStats struct {
+	Policy    string `json:"policy"`
+	Dropped   int64  `json:"dropped"`
+	BlockedUs int64  `json:"blockedUs"`
+	Spilled   int64  `json:"spilled"`
+	// SpillLength is the count of the spilled messages which are not sent yet
+	SpillLength int64 `json:"spillLength"`
+}
+
+// outputEdge is the output channel to a downstream node with its backpressure state
+type outputEdge struct {
+	name   string
+	out    chan any
+	policy string
+	// closed is closed when the output is removed to unblock the sending
+	closed    chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Int64
+	blockedUs atomic.Int64
+	spilled   atomic.Int64
+	spillOnce sync.Once
+	spill     atomic.Pointer[spillQueue]
+	// the buffer quota of the namespace
+	bufOnce  sync.Once
+	buf      *namespace.Buffer
+	bufMu    sync.Mutex
+	bufQueue *namespace.BufferQueue
+}
+
+func (e *outputEdge) close() {
+	e.closeOnce.Do(func() {
+		close(e.closed)
+	})
+	e.releaseBuffer()
+}
+
+func (e *outputEdge) stats() *EdgeStats {
+	s := &EdgeStats{
+		Policy:    e.policy,
+		Dropped:   e.dropped.Load(),
+		BlockedUs: e.blockedUs.Load(),
+		Spilled:   e.spilled.Load(),
+	}
+	if q := e.spill.Load(); q != nil {
+		s.SpillLength = q.length()
+	}
+	return s
+}
+
+// newEdge creates the edge state of the output. The edges are only created when adding the outputs.
+func (o *defaultNode) newEdge(name string, out chan any) *outputEdge {
+	return &outputEdge{
+		name:   name,
+		out:    out,
+		policy: o.backpressure.GetPolicy(o.name),
+		closed: make(chan struct{}),
+	}
+}
+
+// edgesOf returns the edges of the current outputs, so the sending does not hold the locks
+func (o *defaultNode) edgesOf() []*outputEdge {
+	o.outputMu.RLock()
+	defer o.outputMu.RUnlock()
+	o.edgeMu.Lock()
+	defer o.edgeMu.Unlock()
+	result := make([]*outputEdge, 0, len(o.outputs))
+	for name := range o.outputs {
+		if e, ok := o.edges[name]; ok {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// send sends the value to the edge by the backpressure policy
+func (o *defaultNode) send(e *outputEdge, val any) {
+	ctx := o.ctx
+	// The output may be removed after the edges are copied
+	select {
+	case <-e.closed:
+		return
+	default:
+	}
+	// The buffer quota of the namespace is full like the channel is full
+	size, full := o.bufferFull(e, val)
+	// Try to send without waiting first
+	if e.spill.Load() == nil && !full {
+		select {
+		case e.out <- val:
+			o.buffered(e, size)
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+	switch e.policy {
+	case def.BackpressureDropNewest:
+		o.drop(e, val)
+	case def.BackpressureBlock:
+		o.block(e, val, size)
+	case def.BackpressureSpillToDisk:
+		o.startSpill(e)
+		if q := e.spill.Load(); q == nil || !q.offer(ctx, val, size, full) {
+			o.block(e, val, size)
+		}
+	default:
+		for {
+			if full = size > 0 && o.buffer(e).Full(size); !full {
+				select {
+				case e.out <- val:
+					o.buffered(e, size)
+					return
+				case <-ctx.Done():
+					return
+				case <-e.closed:
+					return
+				default:
+				}
+			}
+			// read the oldest to drop.
+			select {
+			case oldest := <-e.out:
+				o.drop(e, oldest)
+			default:
+				// The quota is used by the other edges
+				if full {
+					o.drop(e, val)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (o *defaultNode) drop(e *outputEdge, val any) {
+	e.dropped.Add(1)
+	metrics.BackpressureDroppedCounter.WithLabelValues(o.ctx.GetRuleId(), o.name, e.name).Inc()
+	// record the error and stop propagating to avoid infinite loop
+	// TODO get a unique id for the message
+	o.onErrorOpt(o.ctx, fmt.Errorf("buffer full, drop message %v from %s to %s", val, o.name, e.name), false)
+}
+
+// block waits until the downstream and the buffer quota have room. As the sources send in their ingest goroutine, the pulling or subscription is paused too.
+func (o *defaultNode) block(e *outputEdge, val any, size int64) {
+	start := time.Now()
+	if o.waitBuffer(e, size) {
+		select {
+		case e.out <- val:
+			o.buffered(e, size)
+		case <-e.closed:
+		case <-o.ctx.Done():
+		}
+	}
+	d := time.Since(start).Microseconds()
+	e.blockedUs.Add(d)
+	metrics.BackpressureBlockedCounter.WithLabelValues(o.ctx.GetRuleId(), o.name, e.name).Add(float64(d))
+}
+
+func (o *defaultNode) cleanEdges() {
+	o.edgeMu.Lock()
+	defer o.edgeMu.Unlock()
+	for name := range o.edges {
+		metrics.RemoveBackpressure(o.ctx.GetRuleId(), o.name, name)
+	}
+}
+
+func (o *defaultNode) edgeStats() map[string]*EdgeStats {
+	o.edgeMu.Lock()
+	defer o.edgeMu.Unlock()
+	if len(o.edges) == 0 {
+		return nil
+	}
+	result := make(map[string]*EdgeStats, len(o.edges))
+	for name, e := range o.edges {
+		result[name] = e.stats()
+	}
+	return result
+}
+
+// spilledMessage is a message saved in the spill store. The checkpoint wrapper is kept to replay with qos.
+type spilledMessage struct {
+	Row        []byte
+	Channel    string
+	Checkpoint bool
+}
+
+func encodeSpill(val any) (*spilledMessage, error) {
+	m := &spilledMessage{}
+	if boe, ok := val.(*checkpoint.BufferOrEvent); ok {
+		m.Checkpoint = true
+		m.Channel = boe.Channel
+		val = boe.Data
+	}
+	b, err := xsql.EncodeRow(val)
+	if err != nil {
+		return nil, err
+	}
+	m.Row = b
+	return m, nil
+}
+
+func (m *spilledMessage) decode() (any, error) {
+	v, err := xsql.DecodeRow(m.Row)
+	if err != nil {
+		return nil, err
+	}
+	if m.Checkpoint {
+		return &checkpoint.BufferOrEvent{Data: v, Channel: m.Channel}, nil
+	}
+	return v, nil
+}
+
+// spillQueue saves the messages to the sync cache when the downstream is full and sends them in order by a goroutine.
+// Only the rows are spilled. The other messages like the watermarks and barriers wait until the spilled messages
+// are sent to keep the order.
+type spillQueue struct {
+	node  *defaultNode
+	edge  *outputEdge
+	max   int
+	mu    sync.Mutex
+	cache *cache.SyncCache
+	// the count of the spilled messages including the one being sent
+	pending int
+	// drained is signaled when all spilled messages are sent or the queue stops
+	drained *sync.Cond
+	stopped bool
+	notify  chan struct{}
+}
+
+// startSpill creates the spill queue of the edge once
+func (o *defaultNode) startSpill(e *outputEdge) {
+	e.spillOnce.Do(func() {
+		if q := o.newSpillQueue(e); q != nil {
+			e.spill.Store(q)
+		}
+	})
+}
+
+// restoreSpill replays the messages spilled before the last stop when qos is at least once
+func (o *defaultNode) restoreSpill() {
+	if o.qos < def.AtLeastOnce {
+		return
+	}
+	o.edgeMu.Lock()
+	edges := make([]*outputEdge, 0, len(o.edges))
+	for _, e := range o.edges {
+		edges = append(edges, e)
+	}
+	o.edgeMu.Unlock()
+	for _, e := range edges {
+		if e.policy == def.BackpressureSpillToDisk {
+			o.startSpill(e)
+		}
+	}
+}
+
+// newSpillQueue returns nil if the store cannot be created, then the edge falls back to block
+func (o *defaultNode) newSpillQueue(e *outputEdge) *spillQueue {
+	ctx := o.ctx
+	maxSpill := defaultMaxSpill
+	if o.backpressure != nil && o.backpressure.MaxSpill > 0 {
+		maxSpill = o.backpressure.MaxSpill
+	}
+	pageSize := 256
+	if conf.Config != nil && conf.Config.Sink != nil && conf.Config.Sink.BufferPageSize > 0 {
+		pageSize = conf.Config.Sink.BufferPageSize
+	}
+	// With qos, the spilled messages are kept when the rule stops and replayed when it restarts
+	sc := &conf.SinkConf{
+		MaxDiskCache:     maxSpill + 2*pageSize,
+		BufferPageSize:   pageSize,
+		CleanCacheAtStop: o.qos < def.AtLeastOnce,
+	}
+	c, err := cache.NewSyncCache(ctx, sc)
+	if err == nil {
+		c.SetupMeta(ctx)
+		err = c.InitStoreWithTable(ctx, path.Join("sink", ctx.GetRuleId()+o.name+"_spill_"+e.name))
+	}
+	if err != nil {
+		ctx.GetLogger().Errorf("fail to create spill store for %s, fall back to block: %v", e.name, err)
+		return nil
+	}
+	q := &spillQueue{
+		node:    o,
+		edge:    e,
+		max:     maxSpill,
+		cache:   c,
+		pending: c.CacheLength,
+		notify:  make(chan struct{}, 1),
+	}
+	q.drained = sync.NewCond(&q.mu)
+	if q.pending > 0 {
+		ctx.GetLogger().Infof("replay %d spilled messages to %s", q.pending, e.name)
+		q.notify <- struct{}{}
+	}
+	go func() {
+		_ = infra.SafeRun(func() error {
+			q.run(ctx)
+			return nil
+		})
+	}()
+	return q
+}
+
+// offer sends the value directly if nothing is spilled and the channel and the buffer quota have room, otherwise spill it.
+// Return false if the value cannot be spilled, then it must be sent after the spilled messages.
+func (q *spillQueue) offer(ctx api.StreamContext, val any, size int64, full bool) bool {
+	item, err := encodeSpill(val)
+	q.mu.Lock()
+	if q.pending == 0 && !full {
+		select {
+		case q.edge.out <- val:
+			q.node.buffered(q.edge, size)
+			q.mu.Unlock()
+			return true
+		default:
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, xsql.ErrUnsupportedRow) {
+			ctx.GetLogger().Errorf("fail to encode message to spill to %s, send it after the spilled ones: %v", q.edge.name, err)
+		}
+		for q.pending > 0 && !q.stopped {
+			q.drained.Wait()
+		}
+		q.mu.Unlock()
+		return false
+	}
+	if q.pending >= q.max {
+		q.mu.Unlock()
+		q.node.drop(q.edge, val)
+		return true
+	}
+	err = q.cache.AddCache(ctx, item)
+	if err == nil {
+		q.pending++
+	}
+	q.mu.Unlock()
+	if err != nil {
+		ctx.GetLogger().Errorf("fail to spill message to disk: %v", err)
+		return false
+	}
+	q.edge.spilled.Add(1)
+	metrics.BackpressureSpilledCounter.WithLabelValues(ctx.GetRuleId(), q.node.name, q.edge.name).Inc()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *spillQueue) run(ctx api.StreamContext) {
+	defer func() {
+		q.mu.Lock()
+		q.stopped = true
+		q.cache.Flush(ctx)
+		q.drained.Broadcast()
+		q.mu.Unlock()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.edge.closed:
+			return
+		case <-q.notify:
+		}
+		for {
+			q.mu.Lock()
+			if q.pending == 0 {
+				q.drained.Broadcast()
+				q.mu.Unlock()
+				break
+			}
+			// Only remove the message after it is sent, so it is kept in the store if the rule stops
+			item, _ := q.cache.PeekCache(ctx)
+			q.mu.Unlock()
+			if m, ok := item.(*spilledMessage); ok {
+				val, err := m.decode()
+				if err != nil {
+					q.edge.dropped.Add(1)
+					ctx.GetLogger().Errorf("fail to decode spilled message to %s, drop it: %v", q.edge.name, err)
+				} else {
+					size, _ := q.node.bufferFull(q.edge, val)
+					if !q.node.waitBuffer(q.edge, size) {
+						return
+					}
+					select {
+					case q.edge.out <- val:
+						q.node.buffered(q.edge, size)
+					case <-ctx.Done():
+						return
+					case <-q.edge.closed:
+						return
+					}
+				}
+			}
+			q.mu.Lock()
+			q.cache.PopCache(ctx)
+			q.pending--
+			q.mu.Unlock()
+		}
+	}
+}
+
+func (q *spillQueue) length() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(q.pending)
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/checkpoint"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+)
+
+const subTopoRulePrefix = "$$subtopo_"
+
+// The edges from a shared sub topo to a rule are named by the rule id and the run id, see Topo.AddOperator
+var ruleEdgeRegex = regexp.MustCompile(`^.+\.\d+_`)
+
+// edgeNamespace returns the namespace whose buffer quota the edge uses. The edges inside a shared sub topo are not
+// counted because they are not owned by one rule, while the edges from the sub topo to each rule are counted to the rule.
+func edgeNamespace(ruleId, edgeName string) (string, bool) {
+	if !strings.HasPrefix(ruleId, subTopoRulePrefix) {
+		return namespace.Of(ruleId), true
+	}
+	if ruleEdgeRegex.MatchString(edgeName) {
+		return namespace.Of(edgeName), true
+	}
+	return "", false
+}
+
+// buffer returns the buffer account of the namespace of the edge or nil if it is not counted
+func (o *defaultNode) buffer(e *outputEdge) *namespace.Buffer {
+	e.bufOnce.Do(func() {
+		if ns, ok := edgeNamespace(o.ctx.GetRuleId(), e.name); ok {
+			e.buf = namespace.BufferOf(ns)
+		}
+	})
+	return e.buf
+}
+
+// bufferFull estimates the size of the message if the namespace has a buffer quota, and checks if the quota is full.
+// The size is 0 if the quota is not set, then the message is not counted.
+func (o *defaultNode) bufferFull(e *outputEdge, val any) (int64, bool) {
+	b := o.buffer(e)
+	if b == nil || b.Limit() <= 0 {
+		return 0, false
+	}
+	size := estimateSize(val)
+	return size, size > 0 && b.Full(size)
+}
+
+// buffered records the size of the message which is just sent to the edge
+func (o *defaultNode) buffered(e *outputEdge, size int64) {
+	b := o.buffer(e)
+	if size <= 0 || b == nil {
+		return
+	}
+	e.bufMu.Lock()
+	defer e.bufMu.Unlock()
+	if e.bufQueue == nil {
+		e.bufQueue = b.NewQueue(func() int { return len(e.out) })
+	}
+	e.bufQueue.Add(size)
+}
+
+// releaseBuffer releases the messages of the edge from the buffer quota when the edge is discarded
+func (e *outputEdge) releaseBuffer() {
+	e.bufMu.Lock()
+	defer e.bufMu.Unlock()
+	if e.bufQueue != nil {
+		e.bufQueue.Close()
+		e.bufQueue = nil
+	}
+}
+
+// releaseBuffers releases the messages of all edges when the node closes as the channels are discarded
+func (o *defaultNode) releaseBuffers() {
+	o.edgeMu.Lock()
+	defer o.edgeMu.Unlock()
+	for _, e := range o.edges {
+		e.releaseBuffer()
+	}
+}
+
+// waitBuffer waits until the buffer quota has room for the message. Return false if the edge or the rule is closed.
+func (o *defaultNode) waitBuffer(e *outputEdge, size int64) bool {
+	b := o.buffer(e)
+	if size <= 0 || b == nil || !b.Full(size) {
+		return true
+	}
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for b.Full(size) {
+		select {
+		case <-ticker.C:
+		case <-e.closed:
+			return false
+		case <-o.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// estimateSize estimates the bytes of a message by the sizes of its values. It is not the exact memory usage but is
+// proportional to it. The control messages like the watermarks are not counted.
+func estimateSize(val any) int64 {
+	switch v := val.(type) {
+	case *checkpoint.BufferOrEvent:
+		return estimateSize(v.Data)
+	case api.RawTuple:
+		return int64(len(v.Raw()))
+	case xsql.Collection:
+		var n int64
+		_ = v.Range(func(_ int, r xsql.ReadonlyRow) (bool, error) {
+			if rr, ok := r.(xsql.RawRow); ok {
+				n += sizeOfValue(rr.ToMap())
+			}
+			return true, nil
+		})
+		return n
+	case xsql.RawRow:
+		return sizeOfValue(v.ToMap())
+	default:
+		return 0
+	}
+}
+
+func sizeOfValue(v any) int64 {
+	switch vt := v.(type) {
+	case string:
+		return int64(len(vt))
+	case []byte:
+		return int64(len(vt))
+	case map[string]any:
+		var n int64
+		for k, e := range vt {
+			n += int64(len(k)) + sizeOfValue(e)
+		}
+		return n
+	case []map[string]any:
+		var n int64
+		for _, e := range vt {
+			n += sizeOfValue(e)
+		}
+		return n
+	case []any:
+		var n int64
+		for _, e := range vt {
+			n += sizeOfValue(e)
+		}
+		return n
+	case nil:
+		return 0
+	default:
+		return 8
+	}
+}
@@ -118,7 +118,8 @@ type SyncCache struct {
 	diskPageTail int // init from the database
 	diskPageHead int
 	// serialize
-	store kv.KeyValue
+	store   kv.KeyValue
+	kvTable string
 }
 
 func NewSyncCache(ctx api.StreamContext, cacheConf *conf.SinkConf) (*SyncCache, error) {
@@ -140,7 +141,12 @@ func NewSyncCache(ctx api.StreamContext, cacheConf *conf.SinkConf) (*SyncCache,
 }
 
 func (c *SyncCache) InitStore(ctx api.StreamContext) error {
-	return c.initStore(ctx)
+	return c.initStore(ctx, path.Join("sink", ctx.GetRuleId()+ctx.GetOpId()+strconv.Itoa(ctx.GetInstanceId())))
+}
+
+// InitStoreWithTable inits the store with the specified table instead of the one of the sink instance
+func (c *SyncCache) InitStoreWithTable(ctx api.StreamContext, table string) error {
+	return c.initStore(ctx, table)
 }
 
 func (c *SyncCache) SetupMeta(ctx api.StreamContext) {
@@ -234,6 +240,26 @@ func (c *SyncCache) insertReadCache(ctx api.StreamContext) error {
 // PopCache not thread safe!
 func (c *SyncCache) PopCache(ctx api.StreamContext) (any, bool) {
 	ctx.GetLogger().Debugf("poping cache. CacheLength: %d, diskSize: %d", c.CacheLength, c.diskSize)
+	c.prepareRead(ctx)
+	result, _ := c.readBufferPage.peak()
+	isNotEmpty := c.readBufferPage.delete()
+	if isNotEmpty {
+		c.CacheLength--
+		ctx.GetLogger().Debugf("deleted cache: %d", c.CacheLength)
+	}
+	ctx.GetLogger().Debugf("deleted cache. CacheLength: %d, diskSize: %d, readPage: %v", c.CacheLength, c.diskSize, c.readBufferPage)
+	metrics.SyncCacheCounter.WithLabelValues(syncCachePop, c.RuleID, c.OpID).Inc()
+	return result, true
+}
+
+// PeekCache returns the oldest item without removing it, so it is kept if the sending is interrupted. Not thread safe!
+func (c *SyncCache) PeekCache(ctx api.StreamContext) (any, bool) {
+	c.prepareRead(ctx)
+	return c.readBufferPage.peak()
+}
+
+// prepareRead fills the read buffer page from the disk or the write buffer page if it is empty
+func (c *SyncCache) prepareRead(ctx api.StreamContext) {
 	if c.readBufferPage.isEmpty() {
 		// read from disk or cool list
 		if c.diskSize > 0 {
@@ -247,15 +273,6 @@ func (c *SyncCache) PopCache(ctx api.StreamContext) (any, bool) {
 			c.writeBufferPage = newPage(c.cacheConf.BufferPageSize)
 		}
 	}
-	result, _ := c.readBufferPage.peak()
-	isNotEmpty := c.readBufferPage.delete()
-	if isNotEmpty {
-		c.CacheLength--
-		ctx.GetLogger().Debugf("deleted cache: %d", c.CacheLength)
-	}
-	ctx.GetLogger().Debugf("deleted cache. CacheLength: %d, diskSize: %d, readPage: %v", c.CacheLength, c.diskSize, c.readBufferPage)
-	metrics.SyncCacheCounter.WithLabelValues(syncCachePop, c.RuleID, c.OpID).Inc()
-	return result, true
 }
 
 // loaded means whether load the page to memory or just drop
@@ -309,8 +326,8 @@ func (c *SyncCache) loadFromDisk(ctx api.StreamContext) error {
 	return nil
 }
 
-func (c *SyncCache) initStore(ctx api.StreamContext) error {
-	kvTable := path.Join("sink", ctx.GetRuleId()+ctx.GetOpId()+strconv.Itoa(ctx.GetInstanceId()))
+func (c *SyncCache) initStore(ctx api.StreamContext, kvTable string) error {
+	c.kvTable = kvTable
 	if c.cacheConf.CleanCacheAtStop {
 		ctx.GetLogger().Infof("creating cache store %s", kvTable)
 		_ = store.DropCacheKV(kvTable)
@@ -368,9 +385,8 @@ func (c *SyncCache) initStore(ctx api.StreamContext) error {
 func (c *SyncCache) Flush(ctx api.StreamContext) {
 	ctx.GetLogger().Infof("sink node %s instance cache %d closing", ctx.GetOpId(), ctx.GetInstanceId())
 	if c.cacheConf.CleanCacheAtStop {
-		kvTable := path.Join("sink", ctx.GetRuleId()+ctx.GetOpId()+strconv.Itoa(ctx.GetInstanceId()))
-		ctx.GetLogger().Infof("cleaning cache store %s", kvTable)
-		_ = store.DropCacheKV(kvTable)
+		ctx.GetLogger().Infof("cleaning cache store %s", c.kvTable)
+		_ = store.DropCacheKV(c.kvTable)
 	} else {
 		var err error
 		if !c.readBufferPage.isEmpty() {
@@ -18,12 +18,18 @@ import (
 	"strings"
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/connection"
 )
 
 // GetSourceConf unifies all properties set in different locations
 func GetSourceConf(sourceType string, options *ast.Options) map[string]interface{} {
+	return GetSourceConfInNamespace(namespace.Default, sourceType, options)
+}
+
+// GetSourceConfInNamespace is the same as GetSourceConf but the connection selector refers to the connection in the namespace
+func GetSourceConfInNamespace(ns string, sourceType string, options *ast.Options) map[string]interface{} {
 	confkey := options.CONF_KEY
 
 	yamlOps, err := conf.NewConfigOperatorFromSourceStorage(sourceType)
@@ -61,6 +67,8 @@ func GetSourceConf(sourceType string, options *ast.Options) map[string]interface
 	if ok {
 		selectorID, ok := connectionSelector.(string)
 		if ok {
+			selectorID = namespace.Qualify(ns, selectorID)
+			props["connectionSelector"] = selectorID
 			meta, err := connection.GetConnectionDetail(nil, selectorID)
 			if err != nil {
 				conf.Log.Warnf("load connection meta %s failed, err:%v", selectorID, err)
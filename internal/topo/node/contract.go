@@ -47,6 +47,11 @@ type MetricNode interface {
 	RemoveMetrics(ruleId string)
 }
 
+// AnalyzableNode provides the runtime statistics for explain analyze
+type AnalyzableNode interface {
+	GetStats() *NodeStats
+}
+
 type OperatorNode interface {
 	DataSinkNode
 	Emitter
@@ -84,6 +89,8 @@ type MergeableTopo interface {
 	LinkTopo(parentTopo *def.PrintableTopo, parentJointName string)
 	// SubMetrics return the metrics of the sub nodes
 	SubMetrics() ([]string, []any)
+	// SubStats return the runtime statistics of the sub nodes keyed by the node name in the printable topo
+	SubStats() map[string]*NodeStats
 	// Close notifies subtopo to deref
 	Close(ctx api.StreamContext, ruleId string, runId int)
 }
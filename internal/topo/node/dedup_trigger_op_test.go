@@ -204,7 +204,7 @@ func TestExec(t *testing.T) {
 	ctx := context.NewMockContext("test", "test")
 	resultChan := make(chan any, 100)
 	errChan := make(chan error)
-	node.outputs["output"] = resultChan
+	_ = node.AddOutput(resultChan, "output")
 	node.Exec(ctx, errChan)
 	expResults := []any{
 		map[string]any{"begin": int64(90), "finish": int64(180), "ts": int64(180), "ruleId": "new", "ranges": []map[string]any{{"start_key": "90", "end_key": "180"}}},
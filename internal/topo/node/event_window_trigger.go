@@ -189,7 +189,7 @@ func (o *WindowOperator) execEventWindow(ctx api.StreamContext, inputs []*xsql.T
 				inputs = append(inputs, d)
 				o.span = nil
 				o.onProcessEnd(ctx)
-				_ = ctx.PutState(WindowInputsKey, inputs)
+				o.saveInputs(ctx, inputs)
 			default:
 				o.onError(ctx, fmt.Errorf("run Window error: expect xsql.Event type but got %[1]T(%[1]v)", d))
 			}
@@ -20,6 +20,8 @@ import (
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
 	"github.com/lf-edge/ekuiper/v2/internal/xsql"
 	"github.com/lf-edge/ekuiper/v2/pkg/infra"
 )
@@ -59,6 +61,9 @@ func (n *JoinAlignNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 	log := ctx.GetLogger()
 	go func() {
 		defer func() {
+			for e := range n.size {
+				lookup.RemoveScanTable(n.tableName(ctx, e), ctx.GetRuleId())
+			}
 			n.Close()
 		}()
 		err := infra.SafeRun(func() error {
@@ -79,6 +84,10 @@ func (n *JoinAlignNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 			if n.batch == nil {
 				n.batch = make(map[string][]*xsql.Tuple)
 			}
+			n.setStateSize(n.batchSize())
+			for e := range n.size {
+				n.publish(ctx, e)
+			}
 
 			for {
 				log.Debugf("JoinAlignNode %s is looping", n.name)
@@ -101,6 +110,8 @@ func (n *JoinAlignNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 							b = append(b, d)
 							n.batch[d.Emitter] = b
 							_ = ctx.PutState(BatchKey, n.batch)
+							n.setStateSize(n.batchSize())
+							n.publish(ctx, d.Emitter)
 						} else {
 							n.alignBatch(ctx, d)
 						}
@@ -145,3 +156,26 @@ func (n *JoinAlignNode) alignBatch(ctx api.StreamContext, input any) {
 	n.onSend(ctx, w)
 	n.statManager.SetBufferLength(int64(len(n.input)))
 }
+
+func (n *JoinAlignNode) batchSize() int {
+	size := 0
+	for _, b := range n.batch {
+		size += len(b)
+	}
+	return size
+}
+
+// tableName returns the qualified name of the table in the namespace of the rule
+func (n *JoinAlignNode) tableName(ctx api.StreamContext, emitter string) string {
+	return namespace.Qualify(namespace.Of(ctx.GetRuleId()), emitter)
+}
+
+// publish exposes the current rows of the table to the ad-hoc queries
+func (n *JoinAlignNode) publish(ctx api.StreamContext, emitter string) {
+	b := n.batch[emitter]
+	rows := make([]map[string]any, 0, len(b))
+	for _, t := range b {
+		rows = append(rows, t.ToMap())
+	}
+	lookup.SetScanTable(n.tableName(ctx, emitter), ctx.GetRuleId(), rows)
+}
@@ -17,10 +17,13 @@ package node
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
 	"github.com/lf-edge/ekuiper/v2/internal/xsql"
 	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
 )
@@ -186,3 +189,24 @@ func TestAlignTable(t *testing.T) {
 		})
 	}
 }
+
+func TestAlignTablePublish(t *testing.T) {
+	n, err := NewJoinAlignNode("join", []string{"alignTable1"}, []int{2}, &def.RuleOption{BufferLength: 10})
+	require.NoError(t, err)
+	_ = n.AddOutput(make(chan any, 10), "out")
+	ctx, cancel := mockContext.NewMockContext("alignRule", "join").WithCancel()
+	n.Exec(ctx, make(chan error, 10))
+	for i := 1; i <= 3; i++ {
+		n.input <- &xsql.Tuple{Emitter: "alignTable1", Message: map[string]any{"id": i}}
+	}
+	// the latest rows within the retain size are published
+	require.Eventually(t, func() bool {
+		rows, err := lookup.ScanTable("alignTable1")
+		return err == nil && assert.ObjectsAreEqual([]map[string]any{{"id": 2}, {"id": 3}}, rows)
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	require.Eventually(t, func() bool {
+		_, err := lookup.ScanTable("alignTable1")
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
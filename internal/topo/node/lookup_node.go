@@ -23,6 +23,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/converter"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup/cache"
 	"github.com/lf-edge/ekuiper/v2/internal/xsql"
@@ -53,10 +54,14 @@ type LookupNode struct {
 	conf *LookupConf
 	c    *srcConf
 
+	// table is the name of the lookup table to attach
+	table    string
 	joinType ast.JoinType
-	vals     []ast.Expr
-	fields   []string
-	keys     []string
+	// semi is set when the lookup is for the IN or EXISTS subquery, which only tests the existence
+	semi   *SemiJoin
+	vals   []ast.Expr
+	fields []string
+	keys   []string
 	// If lookupByteSource, the decoders are needed
 	isBytesLookup  bool
 	formatDecoder  message.Converter
@@ -72,6 +77,7 @@ func NewLookupNode(ctx api.StreamContext, name string, isBytesLookup bool, field
 		}
 	}
 	n := &LookupNode{
+		table:         name,
 		fields:        fields,
 		keys:          keys,
 		conf:          lookupConf,
@@ -121,6 +127,18 @@ func NewLookupNode(ctx api.StreamContext, name string, isBytesLookup bool, field
 	return n, nil
 }
 
+// NewLookupSemiJoinNode creates the lookup node for the IN or EXISTS subquery of the lookup table.
+// Instead of joining, it saves whether there are matched rows into the input rows.
+func NewLookupSemiJoinNode(ctx api.StreamContext, name string, isBytesLookup bool, fields []string, keys []string, vals []ast.Expr, semi *SemiJoin, srcOptions *ast.Options, options *def.RuleOption, props map[string]any) (*LookupNode, error) {
+	n, err := NewLookupNode(ctx, name, isBytesLookup, fields, keys, ast.INNER_JOIN, vals, srcOptions, options, props)
+	if err != nil {
+		return nil, err
+	}
+	n.table = semi.Table
+	n.semi = semi
+	return n, nil
+}
+
 func (n *LookupNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 	log := ctx.GetLogger()
 	n.prepareExec(ctx, errCh, "op")
@@ -129,11 +147,13 @@ func (n *LookupNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 			n.Close()
 		}()
 		err := infra.SafeRun(func() error {
-			ns, err := lookup.Attach(n.name)
+			// The lookup table instances are isolated by the namespace of the rule
+			table := namespace.Qualify(namespace.Of(ctx.GetRuleId()), n.table)
+			ns, err := lookup.Attach(table)
 			if err != nil {
 				return err
 			}
-			defer lookup.Detach(n.name)
+			defer lookup.Detach(table)
 			fv, _ := xsql.NewFunctionValuersForOp(ctx)
 			var c *cache.Cache
 			if n.conf.Cache {
@@ -151,6 +171,24 @@ func (n *LookupNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 						break
 					}
 					n.onProcessStart(ctx, data)
+					if n.semi != nil {
+						result, err := rangeSemiJoinRows(data, func(row xsql.Row) error {
+							r, err := n.lookupRows(ctx, row, fv, ns, c)
+							if err != nil {
+								return err
+							}
+							return n.semi.apply(row, r, fv)
+						})
+						if err != nil {
+							n.onError(ctx, err)
+						} else {
+							n.Broadcast(result)
+							n.onSend(ctx, result)
+						}
+						n.onProcessEnd(ctx)
+						n.statManager.SetBufferLength(int64(len(n.input)))
+						break
+					}
 					switch d := data.(type) {
 					case xsql.Row:
 						log.Debugf("Lookup Node receive tuple input %s", d)
@@ -205,35 +243,7 @@ func (n *LookupNode) Exec(ctx api.StreamContext, errCh chan<- error) {
 
 // lookup will lookup the cache firstly, if expires, read the external source
 func (n *LookupNode) lookup(ctx api.StreamContext, d xsql.Row, fv *xsql.FunctionValuer, ns api.Source, tuples *xsql.JoinTuples, c *cache.Cache) error {
-	ve := &xsql.ValuerEval{Valuer: xsql.MultiValuer(d, fv)}
-	cvs := make([]interface{}, len(n.vals))
-	hasNil := false
-	for i, val := range n.vals {
-		cvs[i] = ve.Eval(val)
-		if cvs[i] == nil {
-			hasNil = true
-		}
-	}
-	var (
-		r  []map[string]any
-		e  error
-		ok bool
-	)
-	if !hasNil { // if any of the value is nil, the lookup will always return empty result
-		if c != nil {
-			k := fmt.Sprintf("%v", cvs)
-			r, ok = c.Get(k)
-			if !ok {
-				r, e = n.doLookup(ctx, ns, cvs)
-				if e != nil {
-					return e
-				}
-				c.Set(k, r)
-			}
-		} else {
-			r, e = n.doLookup(ctx, ns, cvs)
-		}
-	}
+	r, e := n.lookupRows(ctx, d, fv, ns, c)
 	if e != nil {
 		return e
 	} else {
@@ -264,6 +274,33 @@ func (n *LookupNode) lookup(ctx api.StreamContext, d xsql.Row, fv *xsql.Function
 	}
 }
 
+// lookupRows evaluates the lookup values of the row and reads the matched rows from the cache or the external source
+func (n *LookupNode) lookupRows(ctx api.StreamContext, d xsql.Row, fv *xsql.FunctionValuer, ns api.Source, c *cache.Cache) ([]map[string]any, error) {
+	ve := &xsql.ValuerEval{Valuer: xsql.MultiValuer(d, fv)}
+	cvs := make([]interface{}, len(n.vals))
+	for i, val := range n.vals {
+		cvs[i] = ve.Eval(val)
+		// if any of the value is nil, the lookup will always return empty result
+		if cvs[i] == nil {
+			return nil, nil
+		}
+	}
+	if c == nil {
+		return n.doLookup(ctx, ns, cvs)
+	}
+	k := fmt.Sprintf("%v", cvs)
+	r, ok := c.Get(k)
+	if !ok {
+		var e error
+		r, e = n.doLookup(ctx, ns, cvs)
+		if e != nil {
+			return nil, e
+		}
+		c.Set(k, r)
+	}
+	return r, nil
+}
+
 func (n *LookupNode) doLookup(ctx api.StreamContext, ns api.Source, cvs []any) ([]map[string]any, error) {
 	if n.isBytesLookup {
 		rawRows, err := ns.(api.LookupBytesSource).Lookup(ctx, n.fields, n.keys, cvs)
@@ -0,0 +1,74 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// processLatencyBuckets are the buckets of the process_latency_us_hist histogram, 10us ~ 5s
+var processLatencyBuckets = prometheus.ExponentialBuckets(10, 2, 20)
+
+// LatencyStatManager provides the percentiles of the process latency in microseconds
+type LatencyStatManager interface {
+	GetLatencyPercentiles(ps ...float64) []int64
+}
+
+// newProcessLatencyHist creates the process latency histogram for the stat manager without prometheus.
+// It is not registered, so it is only read by the stat manager itself.
+func newProcessLatencyHist() prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    ProcessLatencyUsHist,
+		Buckets: processLatencyBuckets,
+	})
+}
+
+// histogramPercentiles estimates the percentiles from the buckets of the histogram like histogram_quantile of prometheus.
+// The value is interpolated linearly in the bucket which the rank falls into. The percentile p is in the range of (0, 1].
+func histogramPercentiles(h prometheus.Histogram, ps ...float64) []int64 {
+	result := make([]int64, len(ps))
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil || m.Histogram == nil || m.Histogram.GetSampleCount() == 0 {
+		return result
+	}
+	buckets := m.Histogram.GetBucket()
+	count := float64(m.Histogram.GetSampleCount())
+	for i, p := range ps {
+		rank := p * count
+		lower, lowerCount := 0.0, 0.0
+		v := math.NaN()
+		for _, b := range buckets {
+			upper, upperCount := b.GetUpperBound(), float64(b.GetCumulativeCount())
+			if upperCount >= rank {
+				if upperCount == lowerCount {
+					v = upper
+				} else {
+					v = lower + (upper-lower)*(rank-lowerCount)/(upperCount-lowerCount)
+				}
+				break
+			}
+			lower, lowerCount = upper, upperCount
+		}
+		// The rank is in the +Inf bucket, return the upper bound of the highest bucket
+		if math.IsNaN(v) {
+			v = lower
+		}
+		result[i] = int64(math.Round(v))
+	}
+	return result
+}
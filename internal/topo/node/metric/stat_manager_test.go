@@ -36,3 +36,33 @@ func TestGetMetrics(t *testing.T) {
 	assert.NotEqual(t, "", a[5])
 	assert.Equal(t, e[6:], a[6:])
 }
+
+func TestMetricIndexes(t *testing.T) {
+	assert.Equal(t, RecordsInTotal, MetricNames[RecordsInTotalIndex])
+	assert.Equal(t, BufferLength, MetricNames[BufferLengthIndex])
+	assert.Equal(t, ExceptionsTotal, MetricNames[ExceptionsTotalIndex])
+	assert.Equal(t, ConnectionLastTryTime, MetricNames[ConnectionLastTryTimeIndex])
+	ctx := mockContext.NewMockContext("rule1", "op1")
+	assert.Len(t, NewStatManager(ctx, "source").GetMetrics(), len(MetricNames))
+	assert.Len(t, NewStatManager(ctx, "op").GetMetrics(), ConnectionStatusIndex)
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	ctx := mockContext.NewMockContext("rule1", "op1")
+	sm := NewStatManager(ctx, "op")
+	lsm, ok := sm.(LatencyStatManager)
+	assert.True(t, ok)
+	assert.Equal(t, []int64{0, 0}, lsm.GetLatencyPercentiles(0.5, 0.99))
+
+	h := newProcessLatencyHist()
+	// 50 samples in the bucket (10, 20] and 50 samples in the bucket (40, 80]
+	for i := 0; i < 50; i++ {
+		h.Observe(15)
+		h.Observe(60)
+	}
+	assert.Equal(t, []int64{20, 79, 80}, histogramPercentiles(h, 0.5, 0.99, 1))
+	// The samples over the highest bucket are reported as its upper bound
+	h = newProcessLatencyHist()
+	h.Observe(1e9)
+	assert.Equal(t, []int64{int64(processLatencyBuckets[len(processLatencyBuckets)-1])}, histogramPercentiles(h, 0.5))
+}
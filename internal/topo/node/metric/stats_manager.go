@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
@@ -38,7 +39,40 @@ const (
 	ConnectionLastTryTime             = "connection_last_try_time"
 )
 
-var MetricNames = []string{RecordsInTotal, RecordsOutTotal, MessagesProcessedTotal, ProcessLatencyUs, BufferLength, LastInvocation, ExceptionsTotal, LastException, LastExceptionTime, ConnectionStatus, ConnectionLastConnectedTime, ConnectionLastDisconnectedTime, ConnectionLastDisconnectedMessage, ConnectionLastTryTime}
+// The indexes of the metrics in MetricNames and in the result of GetMetrics
+const (
+	RecordsInTotalIndex = iota
+	RecordsOutTotalIndex
+	MessagesProcessedTotalIndex
+	ProcessLatencyUsIndex
+	BufferLengthIndex
+	LastInvocationIndex
+	ExceptionsTotalIndex
+	LastExceptionIndex
+	LastExceptionTimeIndex
+	ConnectionStatusIndex
+	ConnectionLastConnectedTimeIndex
+	ConnectionLastDisconnectedTimeIndex
+	ConnectionLastDisconnectedMessageIndex
+	ConnectionLastTryTimeIndex
+)
+
+var MetricNames = []string{
+	RecordsInTotalIndex:                    RecordsInTotal,
+	RecordsOutTotalIndex:                   RecordsOutTotal,
+	MessagesProcessedTotalIndex:            MessagesProcessedTotal,
+	ProcessLatencyUsIndex:                  ProcessLatencyUs,
+	BufferLengthIndex:                      BufferLength,
+	LastInvocationIndex:                    LastInvocation,
+	ExceptionsTotalIndex:                   ExceptionsTotal,
+	LastExceptionIndex:                     LastException,
+	LastExceptionTimeIndex:                 LastExceptionTime,
+	ConnectionStatusIndex:                  ConnectionStatus,
+	ConnectionLastConnectedTimeIndex:       ConnectionLastConnectedTime,
+	ConnectionLastDisconnectedTimeIndex:    ConnectionLastDisconnectedTime,
+	ConnectionLastDisconnectedMessageIndex: ConnectionLastDisconnectedMessage,
+	ConnectionLastTryTimeIndex:             ConnectionLastTryTime,
+}
 
 type StatManager interface {
 	IncTotalRecordsIn()
@@ -71,6 +105,8 @@ type DefaultStatManager struct {
 	lastExceptionTime time.Time
 
 	connectionState *ConnectionStatManager
+	// processLatencyHist is the process_latency_us_hist histogram to calculate the latency percentiles
+	processLatencyHist prometheus.Histogram
 	// configs
 	opType           string //"source", "op", "sink"
 	prefix           string
@@ -84,26 +120,29 @@ func NewStatManager(ctx api.StreamContext, opType string) StatManager {
 	switch opType {
 	case "source":
 		ds = DefaultStatManager{
-			opType:          opType,
-			prefix:          "source_",
-			opId:            ctx.GetOpId(),
-			instanceId:      ctx.GetInstanceId(),
-			connectionState: &ConnectionStatManager{},
+			opType:             opType,
+			prefix:             "source_",
+			opId:               ctx.GetOpId(),
+			instanceId:         ctx.GetInstanceId(),
+			connectionState:    &ConnectionStatManager{},
+			processLatencyHist: newProcessLatencyHist(),
 		}
 	case "op":
 		ds = DefaultStatManager{
-			opType:     opType,
-			prefix:     "op_",
-			opId:       ctx.GetOpId(),
-			instanceId: ctx.GetInstanceId(),
+			opType:             opType,
+			prefix:             "op_",
+			opId:               ctx.GetOpId(),
+			instanceId:         ctx.GetInstanceId(),
+			processLatencyHist: newProcessLatencyHist(),
 		}
 	case "sink":
 		ds = DefaultStatManager{
-			opType:          opType,
-			prefix:          "sink_",
-			opId:            ctx.GetOpId(),
-			instanceId:      ctx.GetInstanceId(),
-			connectionState: &ConnectionStatManager{},
+			opType:             opType,
+			prefix:             "sink_",
+			opId:               ctx.GetOpId(),
+			instanceId:         ctx.GetInstanceId(),
+			connectionState:    &ConnectionStatManager{},
+			processLatencyHist: newProcessLatencyHist(),
 		}
 	}
 	sm, err := getStatManager(ctx, ds)
@@ -147,7 +186,23 @@ func (sm *DefaultStatManager) ProcessTimeStart() {
 func (sm *DefaultStatManager) ProcessTimeEnd() {
 	if !sm.processTimeStart.IsZero() {
 		sm.processLatency = int64(time.Since(sm.processTimeStart) / time.Microsecond)
+		sm.observeLatency()
+	}
+}
+
+func (sm *DefaultStatManager) observeLatency() {
+	if sm.processLatencyHist != nil {
+		sm.processLatencyHist.Observe(float64(sm.processLatency))
+	}
+}
+
+// GetLatencyPercentiles returns the percentiles of the process latency such as 0.5 for p50.
+// They are estimated from the buckets of the process_latency_us_hist histogram.
+func (sm *DefaultStatManager) GetLatencyPercentiles(ps ...float64) []int64 {
+	if sm.processLatencyHist == nil {
+		return make([]int64, len(ps))
 	}
+	return histogramPercentiles(sm.processLatencyHist, ps...)
 }
 
 func (sm *DefaultStatManager) SetBufferLength(l int64) {
@@ -162,45 +217,43 @@ func (sm *DefaultStatManager) SetProcessTimeStart(t time.Time) {
 func (sm *DefaultStatManager) GetMetrics() []any {
 	var result []any
 	if sm.connectionState != nil {
-		result = make([]any, 14)
+		result = make([]any, len(MetricNames))
 	} else {
-		result = make([]any, 9)
+		result = make([]any, ConnectionStatusIndex)
 	}
-	copy(result, []any{
-		sm.totalRecordsIn,
-		sm.totalRecordsOut,
-		sm.totalMessagesProcessed,
-		sm.processLatency,
-		sm.bufferLength,
-		int64(0),
-		sm.totalExceptions,
-		sm.lastException,
-		int64(0),
-	})
+	result[RecordsInTotalIndex] = sm.totalRecordsIn
+	result[RecordsOutTotalIndex] = sm.totalRecordsOut
+	result[MessagesProcessedTotalIndex] = sm.totalMessagesProcessed
+	result[ProcessLatencyUsIndex] = sm.processLatency
+	result[BufferLengthIndex] = sm.bufferLength
+	result[LastInvocationIndex] = int64(0)
+	result[ExceptionsTotalIndex] = sm.totalExceptions
+	result[LastExceptionIndex] = sm.lastException
+	result[LastExceptionTimeIndex] = int64(0)
 
 	if !sm.lastInvocation.IsZero() {
-		result[5] = sm.lastInvocation.UnixMilli()
+		result[LastInvocationIndex] = sm.lastInvocation.UnixMilli()
 	}
 	if !sm.lastExceptionTime.IsZero() {
-		result[8] = sm.lastExceptionTime.UnixMilli()
+		result[LastExceptionTimeIndex] = sm.lastExceptionTime.UnixMilli()
 	}
 	if sm.connectionState != nil {
-		result[9] = sm.connectionState.connStatus
+		result[ConnectionStatusIndex] = sm.connectionState.connStatus
 		if !sm.connectionState.lastConnectedTime.IsZero() {
-			result[10] = sm.connectionState.lastConnectedTime.UnixMilli()
+			result[ConnectionLastConnectedTimeIndex] = sm.connectionState.lastConnectedTime.UnixMilli()
 		} else {
-			result[10] = int64(0)
+			result[ConnectionLastConnectedTimeIndex] = int64(0)
 		}
 		if !sm.connectionState.lastDisconnectTime.IsZero() {
-			result[11] = sm.connectionState.lastDisconnectTime.UnixMilli()
+			result[ConnectionLastDisconnectedTimeIndex] = sm.connectionState.lastDisconnectTime.UnixMilli()
 		} else {
-			result[11] = int64(0)
+			result[ConnectionLastDisconnectedTimeIndex] = int64(0)
 		}
-		result[12] = sm.connectionState.lastDisconnect
+		result[ConnectionLastDisconnectedMessageIndex] = sm.connectionState.lastDisconnect
 		if !sm.connectionState.lastTryTime.IsZero() {
-			result[13] = sm.connectionState.lastTryTime.UnixMilli()
+			result[ConnectionLastTryTimeIndex] = sm.connectionState.lastTryTime.UnixMilli()
 		} else {
-			result[13] = int64(0)
+			result[ConnectionLastTryTimeIndex] = int64(0)
 		}
 	}
 	return result
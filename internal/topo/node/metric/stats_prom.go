@@ -50,7 +50,8 @@ func getStatManager(ctx api.StreamContext, dsm DefaultStatManager) (StatManager,
 		psm.pTotalRecordsOut = mg.TotalRecordsOut.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId)
 		psm.pTotalExceptions = mg.TotalExceptions.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId)
 		psm.pProcessLatency = mg.ProcessLatency.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId)
-		psm.pProcessLatencyHist = mg.ProcessLatencyHist.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId)
+		// The registered histogram is also read for the latency percentiles
+		psm.processLatencyHist = mg.ProcessLatencyHist.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId).(prometheus.Histogram)
 		psm.pBufferLength = mg.BufferLength.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId)
 		if dsm.opType != "op" {
 			psm.pConnectionStatus = mg.ConnectionStatus.WithLabelValues(ctx.GetRuleId(), dsm.opType, dsm.opId, strInId)
@@ -70,7 +71,6 @@ type PrometheusStatManager struct {
 	pTotalRecordsOut        prometheus.Counter
 	pTotalExceptions        prometheus.Counter
 	pProcessLatency         prometheus.Gauge
-	pProcessLatencyHist     prometheus.Observer
 	pBufferLength           prometheus.Gauge
 	pConnectionStatus       prometheus.Gauge
 }
@@ -99,7 +99,7 @@ func (sm *PrometheusStatManager) ProcessTimeEnd() {
 	if !sm.processTimeStart.IsZero() {
 		sm.processLatency = int64(time.Since(sm.processTimeStart) / time.Microsecond)
 		sm.pProcessLatency.Set(float64(sm.processLatency))
-		sm.pProcessLatencyHist.Observe(float64(sm.processLatency))
+		sm.observeLatency()
 	}
 }
 
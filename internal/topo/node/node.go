@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 	"go.opentelemetry.io/otel/codes"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/io"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/util"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/checkpoint"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/context"
@@ -46,9 +48,15 @@ type defaultNode struct {
 	outputMu    sync.RWMutex
 	outputs     map[string]chan any
 	opsWg       *sync.WaitGroup
+	// backpressure state of each output
+	backpressure *def.BackpressureOption
+	edgeMu       sync.Mutex
+	edges        map[string]*outputEdge
 	// tracing state
 	span    trace.Span
 	spanCtx api.StreamContext
+	// the size of the state for explain analyze
+	stateSize atomic.Int64
 }
 
 func newDefaultNode(name string, options *def.RuleOption) *defaultNode {
@@ -57,10 +65,11 @@ func newDefaultNode(name string, options *def.RuleOption) *defaultNode {
 		c = 1
 	}
 	return &defaultNode{
-		name:        name,
-		outputs:     make(map[string]chan any),
-		concurrency: c,
-		sendError:   options.SendError,
+		name:         name,
+		outputs:      make(map[string]chan any),
+		concurrency:  c,
+		sendError:    options.SendError,
+		backpressure: options.Backpressure,
 	}
 }
 
@@ -68,13 +77,31 @@ func (o *defaultNode) AddOutput(output chan any, name string) error {
 	o.outputMu.Lock()
 	defer o.outputMu.Unlock()
 	o.outputs[name] = output
+	o.edgeMu.Lock()
+	defer o.edgeMu.Unlock()
+	if o.edges == nil {
+		o.edges = make(map[string]*outputEdge)
+	}
+	if e, ok := o.edges[name]; ok {
+		e.close()
+	}
+	o.edges[name] = o.newEdge(name, output)
 	return nil
 }
 
 func (o *defaultNode) RemoveOutput(name string) error {
+	namePre := name + "_"
+	// unblock the sending to the removed outputs before acquiring the lock
+	o.edgeMu.Lock()
+	for n, e := range o.edges {
+		if strings.HasPrefix(n, namePre) {
+			e.close()
+			delete(o.edges, n)
+		}
+	}
+	o.edgeMu.Unlock()
 	o.outputMu.Lock()
 	defer o.outputMu.Unlock()
-	namePre := name + "_"
 	for n := range o.outputs {
 		if strings.HasPrefix(n, namePre) {
 			delete(o.outputs, n)
@@ -105,6 +132,9 @@ func (o *defaultNode) RemoveMetrics(ruleId string) {
 	if o.statManager != nil {
 		o.statManager.Clean(ruleId)
 	}
+	if o.ctx != nil {
+		o.cleanEdges()
+	}
 }
 
 func (o *defaultNode) Broadcast(val any) {
@@ -128,10 +158,9 @@ func (o *defaultNode) BroadcastCustomized(val any, broadcastFunc func(val any))
 }
 
 func (o *defaultNode) doBroadcast(val any) {
-	o.outputMu.RLock()
-	defer o.outputMu.RUnlock()
+	// Copy the edges to send without the lock, so a blocked output does not block adding or removing outputs
 	first := true
-	for name, out := range o.outputs {
+	for _, e := range o.edgesOf() {
 		// Only copy when there are many outputs to save one copy time
 		if !first {
 			switch vt := val.(type) {
@@ -148,22 +177,7 @@ func (o *defaultNode) doBroadcast(val any) {
 		if vt, ok := val.(xsql.HasTracerCtx); ok && vt.GetTracerCtx() == nil {
 			vt.SetTracerCtx(o.spanCtx)
 		}
-		// Try to send the latest one. If full, read the oldest one and retry
-	forlabel:
-		for {
-			select {
-			case out <- val:
-				break forlabel
-			case <-o.ctx.Done():
-				return
-			default:
-				// read the oldest to drop.
-				oldest := <-out
-				// record the error and stop propagating to avoid infinite loop
-				// TODO get a unique id for the message
-				o.onErrorOpt(o.ctx, fmt.Errorf("buffer full, drop message %v from %s to %s", oldest, o.name, name), false)
-			}
-		}
+		o.send(e, val)
 	}
 }
 
@@ -213,6 +227,7 @@ func (o *defaultNode) prepareExec(ctx api.StreamContext, errCh chan<- error, opT
 		o.opsWg.Add(1)
 	}
 	o.ctrlCh = errCh
+	o.restoreSpill()
 }
 
 func (o *defaultNode) finishExec() {
@@ -220,6 +235,7 @@ func (o *defaultNode) finishExec() {
 }
 
 func (o *defaultNode) Close() {
+	o.releaseBuffers()
 	if o.opsWg != nil {
 		o.ctx.GetLogger().Infof("node %s is closing", o.name)
 		o.opsWg.Done()
@@ -330,7 +346,7 @@ func SourcePing(sourceType string, config map[string]any) error {
 		config["datasource"] = "/$$TEST_CONNECTION$$"
 	}
 	if pingAble, ok := source.(util.PingableConn); ok {
-		return pingAble.Ping(context.Background(), config)
+		return ping(pingAble, config)
 	}
 	return fmt.Errorf("source %v doesn't support ping connection", sourceType)
 }
@@ -341,7 +357,7 @@ func SinkPing(sinkType string, config map[string]any) error {
 		return err
 	}
 	if pingAble, ok := sink.(util.PingableConn); ok {
-		return pingAble.Ping(context.Background(), config)
+		return ping(pingAble, config)
 	}
 	return fmt.Errorf("sink %v doesn't support ping connection", sinkType)
 }
@@ -355,7 +371,15 @@ func LookupPing(lookupType string, config map[string]any) error {
 		config["datasource"] = "/$$TEST_CONNECTION$$"
 	}
 	if pingAble, ok := lookup.(util.PingableConn); ok {
-		return pingAble.Ping(context.Background(), config)
+		return ping(pingAble, config)
 	}
 	return fmt.Errorf("lookup source %v doesn't support ping connection", lookupType)
 }
+
+func ping(pingAble util.PingableConn, config map[string]any) error {
+	resolved, err := secret.Resolve(config)
+	if err != nil {
+		return err
+	}
+	return pingAble.Ping(context.Background(), resolved)
+}
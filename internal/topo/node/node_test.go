@@ -15,11 +15,22 @@
 package node
 
 import (
+	"fmt"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/testx"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/checkpoint"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
 )
 
 func TestOutputs(t *testing.T) {
@@ -34,3 +45,263 @@ func TestOutputs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(n.outputs))
 }
+
+func TestBackpressure(t *testing.T) {
+	testx.InitEnv("backpressure")
+	tests := []struct {
+		name    string
+		policy  string
+		result  []any
+		dropped int64
+		spilled int64
+	}{
+		{
+			name:    "dropOldest",
+			policy:  def.BackpressureDropOldest,
+			result:  []any{4, 5},
+			dropped: 3,
+		},
+		{
+			name:    "dropNewest",
+			policy:  def.BackpressureDropNewest,
+			result:  []any{1, 2},
+			dropped: 3,
+		},
+		{
+			name:    "spillToDisk",
+			policy:  def.BackpressureSpillToDisk,
+			result:  []any{1, 2, 3, 4},
+			dropped: 1,
+			spilled: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newDefaultNode("test", &def.RuleOption{Backpressure: &def.BackpressureOption{
+				Nodes:    map[string]string{"test": tt.policy},
+				MaxSpill: 2,
+			}})
+			ctx := mockContext.NewMockContext("bp_"+tt.name, "test")
+			n.prepareExec(ctx, make(chan error, 1), "op")
+			out := make(chan any, 2)
+			require.NoError(t, n.AddOutput(out, "down"))
+			for i := 1; i <= 5; i++ {
+				n.Broadcast(&xsql.Tuple{Emitter: "demo", Message: map[string]any{"a": i}})
+			}
+			result := make([]any, 0, len(tt.result))
+			for len(result) < len(tt.result) {
+				select {
+				case v := <-out:
+					result = append(result, v.(*xsql.Tuple).Message["a"])
+				case <-time.After(time.Second):
+					t.Fatalf("timeout, received %v", result)
+				}
+			}
+			assert.Equal(t, tt.result, result)
+			stats := n.GetStats().Edges["down"]
+			assert.Equal(t, tt.policy, stats.Policy)
+			assert.Equal(t, tt.dropped, stats.Dropped)
+			assert.Equal(t, tt.spilled, stats.Spilled)
+		})
+	}
+}
+
+func TestBackpressureBlock(t *testing.T) {
+	n := newDefaultNode("test", &def.RuleOption{Backpressure: &def.BackpressureOption{Policy: def.BackpressureBlock}})
+	ctx := mockContext.NewMockContext("bp_block", "test")
+	n.prepareExec(ctx, make(chan error, 1), "op")
+	out := make(chan any, 1)
+	require.NoError(t, n.AddOutput(out, "down_0"))
+	n.Broadcast(1)
+	done := make(chan struct{})
+	go func() {
+		n.Broadcast(2)
+		n.Broadcast(3)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, <-out)
+	// 3 is blocked as 2 fills the buffer
+	time.Sleep(20 * time.Millisecond)
+	stats := n.GetStats().Edges["down_0"]
+	assert.Equal(t, int64(0), stats.Dropped)
+	assert.True(t, stats.BlockedUs > 0)
+	// rewiring is not blocked by the blocked sending
+	added := make(chan struct{})
+	go func() {
+		_ = n.AddOutput(make(chan any, 1), "other_0")
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("adding output is blocked by the blocked sending")
+	}
+	// removing the output unblocks the sending
+	require.NoError(t, n.RemoveOutput("down"))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sending is not unblocked after removing the output")
+	}
+	assert.Equal(t, 2, <-out)
+}
+
+func TestBackpressureSpillRestart(t *testing.T) {
+	testx.InitEnv("backpressure")
+	pageSize := conf.Config.Sink.BufferPageSize
+	conf.Config.Sink.BufferPageSize = 2
+	defer func() {
+		conf.Config.Sink.BufferPageSize = pageSize
+	}()
+	// drop the spilled messages of the previous runs
+	table := path.Join("sink", "bp_restarttest_spill_down")
+	_, err := store.GetCacheKV(table)
+	require.NoError(t, err)
+	require.NoError(t, store.DropCacheKV(table))
+	opt := &def.RuleOption{Backpressure: &def.BackpressureOption{Policy: def.BackpressureSpillToDisk, MaxSpill: 10}}
+	windowOf := func(i int) *xsql.WindowTuples {
+		w := &xsql.WindowTuples{
+			Content:     []xsql.Row{&xsql.Tuple{Emitter: "demo", Message: map[string]any{"a": int64(i)}}},
+			WindowRange: xsql.NewWindowRange(int64(i*10), int64(i*10+10)),
+		}
+		if i%2 == 0 {
+			w.SetIsAgg(true)
+		}
+		return w
+	}
+
+	n := newDefaultNode("test", opt)
+	n.SetQos(def.AtLeastOnce)
+	out := make(chan any, 1)
+	require.NoError(t, n.AddOutput(out, "down"))
+	ctx, cancel := mockContext.NewMockContext("bp_restart", "test").WithCancel()
+	n.prepareExec(ctx, make(chan error, 1), "op")
+	for i := 1; i <= 6; i++ {
+		n.Broadcast(windowOf(i))
+	}
+	// 1 is in the channel and 2-6 are spilled with pages flushed to disk
+	assert.Equal(t, int64(5), n.GetStats().Edges["down"].Spilled)
+	cancel()
+	// wait for the spill queue to stop before reading so that 2 is not sent
+	q := n.edgesOf()[0].spill.Load()
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.stopped
+	}, time.Second, 10*time.Millisecond)
+	b := <-out
+	assert.Equal(t, int64(1), b.(*checkpoint.BufferOrEvent).Data.(*xsql.WindowTuples).Content[0].(*xsql.Tuple).Message["a"])
+
+	// restart and replay in order
+	n = newDefaultNode("test", opt)
+	n.SetQos(def.AtLeastOnce)
+	out = make(chan any, 1)
+	require.NoError(t, n.AddOutput(out, "down"))
+	ctx, cancel = mockContext.NewMockContext("bp_restart", "test").WithCancel()
+	defer cancel()
+	n.prepareExec(ctx, make(chan error, 1), "op")
+	for i := 2; i <= 6; i++ {
+		select {
+		case v := <-out:
+			boe, ok := v.(*checkpoint.BufferOrEvent)
+			require.True(t, ok)
+			assert.Equal(t, "test", boe.Channel)
+			w := boe.Data.(*xsql.WindowTuples)
+			assert.Equal(t, windowOf(i).ToMaps(), w.ToMaps())
+			start, _ := w.FuncValue("window_start")
+			assert.Equal(t, int64(i*10), start)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %d", i)
+		}
+	}
+}
+
+func TestBufferQuota(t *testing.T) {
+	testx.InitEnv("backpressure")
+	// Each message is 11 bytes, so 2 messages fit in the quota
+	require.NoError(t, namespace.Create(&namespace.Namespace{Name: "nsbq", Quota: namespace.Quota{MaxBufferBytes: 25}}))
+	defer func() {
+		_ = namespace.Delete("nsbq")
+	}()
+	msg := func(i int) *xsql.Tuple {
+		return &xsql.Tuple{Emitter: "demo", Message: map[string]any{"a": fmt.Sprintf("message%03d", i)}}
+	}
+	assert.Equal(t, int64(11), estimateSize(msg(1)))
+
+	n := newDefaultNode("test", &def.RuleOption{Backpressure: &def.BackpressureOption{Policy: def.BackpressureDropNewest}})
+	ctx := mockContext.NewMockContext("nsbq/r1", "test")
+	n.prepareExec(ctx, make(chan error, 1), "op")
+	out := make(chan any, 10)
+	require.NoError(t, n.AddOutput(out, "down"))
+	for i := 1; i <= 4; i++ {
+		n.Broadcast(msg(i))
+	}
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, int64(2), n.GetStats().Edges["down"].Dropped)
+	assert.Equal(t, int64(22), namespace.BufferOf("nsbq").Used())
+	<-out
+	n.Broadcast(msg(5))
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, int64(2), n.GetStats().Edges["down"].Dropped)
+
+	// The quota is shared by the rules of the namespace
+	n2 := newDefaultNode("test", &def.RuleOption{Backpressure: &def.BackpressureOption{Policy: def.BackpressureBlock}})
+	ctx2 := mockContext.NewMockContext("nsbq/r2", "test")
+	n2.prepareExec(ctx2, make(chan error, 1), "op")
+	out2 := make(chan any, 10)
+	require.NoError(t, n2.AddOutput(out2, "down"))
+	done := make(chan struct{})
+	go func() {
+		n2.Broadcast(msg(6))
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("sending is not blocked by the full quota")
+	case <-time.After(50 * time.Millisecond):
+	}
+	<-out
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sending is not unblocked after the quota has room")
+	}
+	assert.Equal(t, 1, len(out2))
+	assert.True(t, n2.GetStats().Edges["down"].BlockedUs > 0)
+
+	// Other namespaces are not limited
+	n3 := newDefaultNode("test", &def.RuleOption{Backpressure: &def.BackpressureOption{Policy: def.BackpressureDropNewest}})
+	n3.prepareExec(mockContext.NewMockContext("r3", "test"), make(chan error, 1), "op")
+	out3 := make(chan any, 10)
+	require.NoError(t, n3.AddOutput(out3, "down"))
+	for i := 1; i <= 4; i++ {
+		n3.Broadcast(msg(i))
+	}
+	assert.Equal(t, 4, len(out3))
+
+	// The buffered messages are released when the rules close
+	n.Close()
+	n2.Close()
+	assert.Equal(t, int64(0), namespace.BufferOf("nsbq").Used())
+}
+
+func TestEdgeNamespace(t *testing.T) {
+	tests := []struct {
+		ruleId string
+		edge   string
+		ns     string
+		ok     bool
+	}{
+		{ruleId: "r1", edge: "r1.0_op", ns: namespace.Default, ok: true},
+		{ruleId: "t1/r1", edge: "sink", ns: "t1", ok: true},
+		{ruleId: "$$subtopo_t1/s1", edge: "t1/r1.2_op", ns: "t1", ok: true},
+		{ruleId: "$$subtopo_s1", edge: "r1.2_op", ns: namespace.Default, ok: true},
+		{ruleId: "$$subtopo_s1", edge: "decoder", ok: false},
+	}
+	for _, tt := range tests {
+		ns, ok := edgeNamespace(tt.ruleId, tt.edge)
+		assert.Equal(t, tt.ok, ok, tt.edge)
+		assert.Equal(t, tt.ns, ns, tt.edge)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/infra"
+)
+
+// SemiJoin tests whether a row has any matched table row for the IN or EXISTS subquery.
+// The result is saved to the internal field of the row, so the output rows keep their shape.
+type SemiJoin struct {
+	// Field is the internal field to save the result
+	Field string
+	Table string
+	// Condition filters the table rows. The table fields are referred by the table name.
+	Condition ast.Expr
+	Not       bool
+}
+
+func (s *SemiJoin) apply(row xsql.Row, candidates []map[string]any, fv *xsql.FunctionValuer) error {
+	matched := false
+	for _, c := range candidates {
+		if s.Condition == nil {
+			matched = true
+			break
+		}
+		ve := &xsql.ValuerEval{Valuer: xsql.MultiValuer(&semiJoinValuer{row: row, table: s.Table, candidate: c}, fv)}
+		switch r := ve.Eval(s.Condition).(type) {
+		case error:
+			return fmt.Errorf("run subquery of table %s error: %s", s.Table, r)
+		case bool:
+			matched = r
+		}
+		if matched {
+			break
+		}
+	}
+	row.Set(s.Field, matched != s.Not)
+	return nil
+}
+
+// rangeSemiJoinRows applies the semi join to the single row or each row of the collection
+func rangeSemiJoinRows(data any, f func(row xsql.Row) error) (any, error) {
+	switch d := data.(type) {
+	case xsql.Row:
+		r := d.Clone()
+		return r, f(r)
+	case xsql.Collection:
+		return d, d.RangeSet(func(_ int, r xsql.Row) (bool, error) {
+			if err := f(r); err != nil {
+				return false, err
+			}
+			return true, nil
+		})
+	default:
+		return nil, fmt.Errorf("run subquery error: invalid input type but got %[1]T(%[1]v)", d)
+	}
+}
+
+// semiJoinValuer reads the table fields from the candidate table row and the others from the row
+type semiJoinValuer struct {
+	row       xsql.Row
+	table     string
+	candidate map[string]any
+}
+
+func (v *semiJoinValuer) Value(key, table string) (any, bool) {
+	if table == v.table {
+		r, ok := v.candidate[key]
+		return r, ok
+	}
+	return v.row.Value(key, table)
+}
+
+func (v *semiJoinValuer) Meta(key, table string) (any, bool) {
+	if table == v.table {
+		return nil, false
+	}
+	return v.row.Meta(key, table)
+}
+
+// SemiJoinNode buffers the rows of a scan table and tests the other inputs against them for the IN or EXISTS subquery.
+// Lookup tables are handled by the LookupNode instead.
+type SemiJoinNode struct {
+	*defaultSinkNode
+	semi *SemiJoin
+	size int
+	// table state
+	rows []map[string]any
+}
+
+const SemiJoinKey = "$$semiJoinRows"
+
+func NewSemiJoinNode(name string, semi *SemiJoin, size int, options *def.RuleOption) (*SemiJoinNode, error) {
+	n := &SemiJoinNode{
+		semi: semi,
+		size: size,
+	}
+	n.defaultSinkNode = newDefaultSinkNode(name, options)
+	return n, nil
+}
+
+func (n *SemiJoinNode) Exec(ctx api.StreamContext, errCh chan<- error) {
+	n.prepareExec(ctx, errCh, "op")
+	log := ctx.GetLogger()
+	go func() {
+		defer func() {
+			n.Close()
+		}()
+		err := infra.SafeRun(func() error {
+			// restore table state
+			if s, err := ctx.GetState(SemiJoinKey); err == nil {
+				switch st := s.(type) {
+				case []map[string]any:
+					n.rows = st
+					log.Infof("Restore semi join state %+v", st)
+				case nil:
+					log.Debugf("Restore semi join state, nothing")
+				default:
+					infra.DrainError(ctx, fmt.Errorf("restore semi join state %v error, invalid type", st), errCh)
+				}
+			} else {
+				log.Warnf("Restore semi join state fails: %s", err)
+			}
+			fv, _ := xsql.NewFunctionValuersForOp(ctx)
+			for {
+				log.Debugf("SemiJoinNode %s is looping", n.name)
+				select {
+				case item := <-n.input:
+					data, processed := n.commonIngest(ctx, item)
+					if processed {
+						break
+					}
+					n.onProcessStart(ctx, data)
+					if t, ok := data.(*xsql.Tuple); ok && t.Emitter == n.semi.Table {
+						log.Debugf("SemiJoinNode receive table input %v", t)
+						if len(n.rows) >= n.size {
+							n.rows = n.rows[len(n.rows)-n.size+1:]
+						}
+						n.rows = append(n.rows, t.ToMap())
+						_ = ctx.PutState(SemiJoinKey, n.rows)
+						n.setStateSize(len(n.rows))
+					} else {
+						result, err := rangeSemiJoinRows(data, func(row xsql.Row) error {
+							return n.semi.apply(row, n.rows, fv)
+						})
+						if err != nil {
+							n.onError(ctx, err)
+						} else {
+							n.Broadcast(result)
+							n.onSend(ctx, result)
+						}
+					}
+					n.onProcessEnd(ctx)
+					n.statManager.SetBufferLength(int64(len(n.input)))
+				case <-ctx.Done():
+					log.Info("Cancelling semi join node....")
+					return nil
+				}
+			}
+		})
+		if err != nil {
+			infra.DrainError(ctx, err, errCh)
+		}
+	}()
+}
@@ -0,0 +1,222 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
+	"github.com/lf-edge/ekuiper/v2/pkg/modules"
+)
+
+// semiJoinResults converts the output to the semi join results of each row to compare
+func semiJoinResults(field string, r any) any {
+	switch rt := r.(type) {
+	case xsql.Row:
+		v, _ := rt.Value(field, "")
+		return v
+	case xsql.Collection:
+		var result []any
+		_ = rt.Range(func(_ int, row xsql.ReadonlyRow) (bool, error) {
+			v, _ := row.Value(field, "")
+			result = append(result, v)
+			return true, nil
+		})
+		return result
+	default:
+		return r
+	}
+}
+
+func TestLookupSemiJoin(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  any
+		result any
+	}{
+		{
+			name: "matched",
+			input: &xsql.Tuple{
+				Emitter: "stream1",
+				Message: map[string]any{"a": 2, "b": 3},
+			},
+			result: true,
+		},
+		{
+			name: "condition not matched",
+			input: &xsql.Tuple{
+				Emitter: "stream1",
+				Message: map[string]any{"a": 2, "b": 5},
+			},
+			result: false,
+		},
+		{
+			name: "empty",
+			input: &xsql.Tuple{
+				Emitter: "stream1",
+				Message: map[string]any{"a": "empty", "b": 0},
+			},
+			result: false,
+		},
+		{
+			name: "lookup error",
+			input: &xsql.Tuple{
+				Emitter: "stream1",
+				Message: map[string]any{"a": "wrong"},
+			},
+			result: errors.New("mock lookup error"),
+		},
+		{
+			name: "window",
+			input: &xsql.WindowTuples{
+				Content: []xsql.Row{
+					&xsql.Tuple{
+						Emitter: "stream1",
+						Message: map[string]any{"a": 2, "b": 1},
+					},
+					&xsql.Tuple{
+						Emitter: "stream1",
+						Message: map[string]any{"a": "empty", "b": 1},
+					},
+				},
+			},
+			result: []any{true, false},
+		},
+	}
+	modules.RegisterLookupSource("mock", func() api.Source {
+		return &MockLookupBytes{}
+	})
+
+	ctx, cancel := mockContext.NewMockContext("testRule", "test").WithCancel()
+	defer cancel()
+	semi := &SemiJoin{
+		Field: "$$subquery_0",
+		Table: "testSemi",
+		// testSemi.lb > stream1.b
+		Condition: &ast.BinaryExpr{
+			OP:  ast.GT,
+			LHS: &ast.FieldRef{StreamName: "testSemi", Name: "lb"},
+			RHS: &ast.FieldRef{StreamName: "stream1", Name: "b"},
+		},
+	}
+	op, err := NewLookupSemiJoinNode(ctx, "1_semijoin", true, []string{"la", "lb"}, []string{"id"}, []ast.Expr{&ast.FieldRef{
+		StreamName: "stream1",
+		Name:       "a",
+	}}, semi, &ast.Options{TYPE: "mock", FORMAT: "json"}, &def.RuleOption{BufferLength: 10, SendError: true}, map[string]any{})
+	require.NoError(t, err)
+	out := make(chan any, 100)
+	require.NoError(t, op.AddOutput(out, "test"))
+	err = lookup.CreateInstance("testSemi", "mock", &ast.Options{
+		DATASOURCE: "testSemi",
+		TYPE:       "mock",
+		KIND:       "lookup",
+		KEY:        "id",
+	})
+	require.NoError(t, err)
+	errCh := make(chan error)
+	op.Exec(ctx, errCh)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op.input <- tt.input
+			r := <-out
+			require.Equal(t, tt.result, semiJoinResults(semi.Field, r))
+		})
+	}
+}
+
+func TestScanSemiJoin(t *testing.T) {
+	semi := &SemiJoin{
+		Field: "$$subquery_0",
+		Table: "table1",
+		Not:   true,
+		// table1.id = stream1.id
+		Condition: &ast.BinaryExpr{
+			OP:  ast.EQ,
+			LHS: &ast.FieldRef{StreamName: "table1", Name: "id"},
+			RHS: &ast.FieldRef{StreamName: "stream1", Name: "id"},
+		},
+	}
+	in := []any{
+		&xsql.Tuple{
+			Emitter: "stream1",
+			Message: map[string]any{"id": 1, "a": 1},
+		},
+		&xsql.Tuple{
+			Emitter: "table1",
+			Message: map[string]any{"id": 1},
+		},
+		&xsql.Tuple{
+			Emitter: "table1",
+			Message: map[string]any{"id": 2},
+		},
+		&xsql.Tuple{
+			Emitter: "table1",
+			Message: map[string]any{"id": 3},
+		},
+		&xsql.Tuple{
+			Emitter: "stream1",
+			Message: map[string]any{"id": 1, "a": 2},
+		},
+		&xsql.WindowTuples{
+			Content: []xsql.Row{
+				&xsql.Tuple{
+					Emitter: "stream1",
+					Message: map[string]any{"id": 2, "a": 3},
+				},
+				&xsql.Tuple{
+					Emitter: "stream1",
+					Message: map[string]any{"id": 4, "a": 4},
+				},
+			},
+		},
+		"unknown",
+	}
+	// The retain size is 2, so id 1 is dropped when id 3 comes
+	out := []any{
+		true,
+		true,
+		[]any{false, true},
+		errors.New("run subquery error: invalid input type but got string(unknown)"),
+	}
+	ctx, cancel := mockContext.NewMockContext("testScanSemiJoin", "test").WithCancel()
+	defer cancel()
+	op, err := NewSemiJoinNode("1_semijoin", semi, 2, &def.RuleOption{BufferLength: 10, SendError: true})
+	require.NoError(t, err)
+	output := make(chan any, 10)
+	require.NoError(t, op.AddOutput(output, "output"))
+	errCh := make(chan error)
+	op.Exec(ctx, errCh)
+	for _, item := range in {
+		op.input <- item
+	}
+	for i, exp := range out {
+		r := <-output
+		require.Equal(t, exp, semiJoinResults(semi.Field, r), "case %d", i)
+	}
+	stats := op.GetStats()
+	require.Equal(t, int64(2), stats.StateSize)
+	require.Equal(t, 10, stats.BufferCapacity)
+	require.Equal(t, int64(len(in)), stats.RecordsIn)
+	require.Equal(t, int64(3), stats.RecordsOut)
+	require.Equal(t, float64(3)/float64(len(in)), stats.Selectivity)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/io/memory/pubsub"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/sig"
 	topoContext "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/node/tracenode"
@@ -51,7 +52,12 @@ type sourceConf struct {
 
 // NewSourceNode creates a SourceConnectorNode
 func NewSourceNode(ctx api.StreamContext, name string, ss api.Source, props map[string]any, rOpt *def.RuleOption) (*SourceNode, error) {
-	err := ss.Provision(ctx, props)
+	// Only the source gets the secret values. The props keep the references to be printed.
+	resolved, err := secret.Resolve(props)
+	if err != nil {
+		return nil, err
+	}
+	err = ss.Provision(ctx, resolved)
 	if err != nil {
 		return nil, err
 	}
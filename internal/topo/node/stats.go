@@ -0,0 +1,76 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"github.com/lf-edge/ekuiper/v2/internal/topo/node/metric"
+)
+
+// NodeStats is the runtime statistics of a node for explain analyze
+type NodeStats struct {
+	RecordsIn  int64 `json:"recordsIn"`
+	RecordsOut int64 `json:"recordsOut"`
+	// Selectivity is the ratio of records out to records in
+	Selectivity  float64 `json:"selectivity"`
+	LatencyP50Us int64   `json:"latencyP50Us"`
+	LatencyP99Us int64   `json:"latencyP99Us"`
+	Exceptions   int64   `json:"exceptions"`
+	BufferLength int64   `json:"bufferLength"`
+	// BufferCapacity is 0 for the nodes without input buffer such as the sources
+	BufferCapacity int     `json:"bufferCapacity"`
+	BufferFill     float64 `json:"bufferFill"`
+	// StateSize is the count of the buffered tuples or aggregation groups of windows and joins
+	StateSize int64 `json:"stateSize"`
+	// Edges is the backpressure statistics of each output
+	Edges map[string]*EdgeStats `json:"edges,omitempty"`
+}
+
+func (o *defaultNode) GetStats() *NodeStats {
+	stats := &NodeStats{
+		StateSize: o.stateSize.Load(),
+		Edges:     o.edgeStats(),
+	}
+	if o.statManager == nil {
+		return stats
+	}
+	m := o.statManager.GetMetrics()
+	stats.RecordsIn, _ = m[metric.RecordsInTotalIndex].(int64)
+	stats.RecordsOut, _ = m[metric.RecordsOutTotalIndex].(int64)
+	stats.BufferLength, _ = m[metric.BufferLengthIndex].(int64)
+	stats.Exceptions, _ = m[metric.ExceptionsTotalIndex].(int64)
+	if stats.RecordsIn > 0 {
+		stats.Selectivity = float64(stats.RecordsOut) / float64(stats.RecordsIn)
+	}
+	if lsm, ok := o.statManager.(metric.LatencyStatManager); ok {
+		ps := lsm.GetLatencyPercentiles(0.5, 0.99)
+		stats.LatencyP50Us, stats.LatencyP99Us = ps[0], ps[1]
+	}
+	return stats
+}
+
+func (o *defaultSinkNode) GetStats() *NodeStats {
+	stats := o.defaultNode.GetStats()
+	stats.BufferLength = int64(len(o.input))
+	stats.BufferCapacity = cap(o.input)
+	if stats.BufferCapacity > 0 {
+		stats.BufferFill = float64(stats.BufferLength) / float64(stats.BufferCapacity)
+	}
+	return stats
+}
+
+// setStateSize is called by the stateful nodes when their state changes
+func (o *defaultNode) setStateSize(n int) {
+	o.stateSize.Store(int64(n))
+}
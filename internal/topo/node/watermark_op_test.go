@@ -212,7 +212,7 @@ func TestSingleStreamWatermark(t *testing.T) {
 			})
 			errCh := make(chan error)
 			outputCh := make(chan interface{}, 50)
-			w.outputs["mock"] = outputCh
+			_ = w.AddOutput(outputCh, "mock")
 			w.Exec(nctx, errCh)
 
 			in := 0
@@ -464,7 +464,7 @@ func TestMultiStreamWatermark(t *testing.T) {
 			})
 			errCh := make(chan error)
 			outputCh := make(chan interface{}, 50)
-			w.outputs["mock"] = outputCh
+			_ = w.AddOutput(outputCh, "mock")
 			w.Exec(nctx, errCh)
 
 			in := 0
@@ -159,6 +159,17 @@ func (w *IncAggWindow) restoreState(ctx api.StreamContext) {
 	}
 }
 
+// incAggStateSize counts the aggregation groups of the windows
+func incAggStateSize(windows ...*IncAggWindow) int {
+	size := 0
+	for _, w := range windows {
+		if w != nil {
+			size += len(w.DimensionsIncAggRange)
+		}
+	}
+	return size
+}
+
 type IncAggRange struct {
 	fv   *xsql.FunctionValuer
 	fctx *topoContext.DefaultContext
@@ -217,6 +228,7 @@ type CountWindowIncAggOpState struct {
 func (co *CountWindowIncAggOp) PutState(ctx api.StreamContext) {
 	co.CountWindowIncAggOpState.CurrWindow.GenerateAllFunctionState()
 	ctx.PutState(buildStateKey(ctx), co.CountWindowIncAggOpState)
+	co.setStateSize(incAggStateSize(co.CurrWindow))
 }
 
 func (co *CountWindowIncAggOp) RestoreFromState(ctx api.StreamContext) error {
@@ -335,6 +347,7 @@ func NewTumblingWindowIncAggOp(o *WindowIncAggOperator) *TumblingWindowIncAggOp
 func (to *TumblingWindowIncAggOp) PutState(ctx api.StreamContext) {
 	to.CurrWindow.GenerateAllFunctionState()
 	ctx.PutState(buildStateKey(ctx), to.TumblingWindowIncAggOpState)
+	to.setStateSize(incAggStateSize(to.CurrWindow))
 }
 
 func (to *TumblingWindowIncAggOp) RestoreFromState(ctx api.StreamContext) error {
@@ -475,6 +488,7 @@ func (so *SlidingWindowIncAggOp) PutState(ctx api.StreamContext) {
 		so.CurrWindowList[index] = window
 	}
 	ctx.PutState(buildStateKey(ctx), so.SlidingWindowIncAggOpState)
+	so.setStateSize(incAggStateSize(so.CurrWindowList...))
 }
 
 func (so *SlidingWindowIncAggOp) RestoreFromState(ctx api.StreamContext) error {
@@ -625,6 +639,7 @@ func (ho *HoppingWindowIncAggOp) PutState(ctx api.StreamContext) {
 		ho.CurrWindowList[index] = window
 	}
 	ctx.PutState(buildStateKey(ctx), ho.HoppingWindowIncAggOpState)
+	ho.setStateSize(incAggStateSize(ho.CurrWindowList...))
 }
 
 func (ho *HoppingWindowIncAggOp) RestoreFromState(ctx api.StreamContext) error {
@@ -221,6 +221,60 @@ func TestIncAggWindow(t *testing.T) {
 	op.Close()
 }
 
+func TestIncAggDistinctCountWindow(t *testing.T) {
+	o := &def.RuleOption{
+		BufferLength: 10,
+	}
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+	sql := "select count(distinct a) from stream group by countwindow(2)"
+	stmt, err := xsql.NewParser(strings.NewReader(sql)).Parse()
+	require.NoError(t, err)
+	p, err := planner.CreateLogicalPlan(stmt, &def.RuleOption{
+		PlanOptimizeStrategy: &def.PlanOptimizeStrategy{
+			EnableIncrementalWindow: true,
+		},
+		Qos: 0,
+	}, kv)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	incPlan := extractIncWindowPlan(p)
+	require.NotNil(t, incPlan)
+	op, err := node.NewWindowIncAggOp("1", &node.WindowConfig{
+		Type:        incPlan.WType,
+		CountLength: incPlan.Length,
+	}, incPlan.Dimensions, incPlan.IncAggFuncs, o)
+	require.NoError(t, err)
+	require.NotNil(t, op)
+	input, _ := op.GetInput()
+	output := make(chan any, 10)
+	op.AddOutput(output, "output")
+	errCh := make(chan error, 10)
+	ctx, cancel := mockContext.NewMockContext("1", "2").WithCancel()
+	op.Exec(ctx, errCh)
+	time.Sleep(10 * time.Millisecond)
+	// The seen values are cleared with the window, so the value 1 is counted again in the second window
+	for _, tc := range []struct {
+		values []int64
+		count  int64
+	}{
+		{values: []int64{1, 1}, count: 1},
+		{values: []int64{1, 2}, count: 2},
+	} {
+		for _, v := range tc.values {
+			input <- &xsql.Tuple{Message: map[string]any{"a": v}}
+		}
+		got := <-output
+		wt, ok := got.(*xsql.WindowTuples)
+		require.True(t, ok)
+		require.Equal(t, tc.count, wt.ToMaps()[0]["inc_agg_col_1"])
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	op.Close()
+}
+
 func TestIncAggAlignTumblingWindow(t *testing.T) {
 	conf.IsTesting = true
 	node.EnableAlignWindow = true
@@ -131,6 +131,7 @@ func (o *WindowOperator) Exec(ctx api.StreamContext, errCh chan<- error) {
 		case []*xsql.Tuple:
 			inputs = st
 			log.Infof("Restore window state %+v", inputs)
+			o.setStateSize(len(inputs))
 		case nil:
 			log.Debugf("Restore window state, nothing")
 		default:
@@ -274,7 +275,7 @@ func (o *WindowOperator) execProcessingWindow(ctx api.StreamContext, inputs []*x
 					}
 					log.Debugf("triggered by restore inputs")
 					inputs = o.scan(inputs, next, ctx)
-					_ = ctx.PutState(WindowInputsKey, inputs)
+					o.saveInputs(ctx, inputs)
 					_ = ctx.PutState(TriggerTimeKey, o.triggerTime)
 				}
 			case ast.SESSION_WINDOW:
@@ -311,7 +312,7 @@ func (o *WindowOperator) execProcessingWindow(ctx api.StreamContext, inputs []*x
 					}
 					log.Debugf("triggered by restore inputs")
 					inputs = o.scan(inputs, next, ctx)
-					_ = ctx.PutState(WindowInputsKey, inputs)
+					o.saveInputs(ctx, inputs)
 					_ = ctx.PutState(TriggerTimeKey, o.triggerTime)
 				}
 			}
@@ -324,7 +325,7 @@ func (o *WindowOperator) execProcessingWindow(ctx api.StreamContext, inputs []*x
 			o.statManager.ProcessTimeStart()
 			inputs = o.scan(inputs, delayTS, ctx)
 			o.statManager.ProcessTimeEnd()
-			_ = ctx.PutState(WindowInputsKey, inputs)
+			o.saveInputs(ctx, inputs)
 			_ = ctx.PutState(MsgCountKey, o.msgCount)
 		// process incoming item
 		case item := <-o.input:
@@ -401,7 +402,7 @@ func (o *WindowOperator) execProcessingWindow(ctx api.StreamContext, inputs []*x
 						inputs = tl.getRestTuples()
 					}
 				}
-				_ = ctx.PutState(WindowInputsKey, inputs)
+				o.saveInputs(ctx, inputs)
 				_ = ctx.PutState(MsgCountKey, o.msgCount)
 			default:
 				o.onError(ctx, fmt.Errorf("run Window error: expect xsql.Tuple type but got %[1]T(%[1]v)", d))
@@ -438,7 +439,7 @@ func (o *WindowOperator) execProcessingWindow(ctx api.StreamContext, inputs []*x
 				// expire all inputs, so that when timer scans there is no item
 				inputs = make([]*xsql.Tuple, 0)
 				o.statManager.ProcessTimeEnd()
-				_ = ctx.PutState(WindowInputsKey, inputs)
+				o.saveInputs(ctx, inputs)
 				_ = ctx.PutState(TriggerTimeKey, o.triggerTime)
 				timeoutTicker = nil
 			}
@@ -465,6 +466,12 @@ func (o *WindowOperator) setupTicker() {
 	}
 }
 
+// saveInputs saves the window inputs to the state
+func (o *WindowOperator) saveInputs(ctx api.StreamContext, inputs []*xsql.Tuple) {
+	_ = ctx.PutState(WindowInputsKey, inputs)
+	o.setStateSize(len(inputs))
+}
+
 func (o *WindowOperator) tick(ctx api.StreamContext, inputs []*xsql.Tuple, n time.Time, log api.Logger) []*xsql.Tuple {
 	if o.window.Type == ast.SESSION_WINDOW {
 		log.Debugf("session window update trigger time %d with %d inputs", n.UnixMilli(), len(inputs))
@@ -479,7 +486,7 @@ func (o *WindowOperator) tick(ctx api.StreamContext, inputs []*xsql.Tuple, n tim
 	log.Debugf("triggered by ticker at %d", n.UnixMilli())
 	inputs = o.scan(inputs, n, ctx)
 	o.statManager.ProcessTimeEnd()
-	_ = ctx.PutState(WindowInputsKey, inputs)
+	o.saveInputs(ctx, inputs)
 	_ = ctx.PutState(TriggerTimeKey, o.triggerTime)
 	return inputs
 }
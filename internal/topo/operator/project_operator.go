@@ -16,6 +16,11 @@ package operator
 
 import (
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
@@ -37,6 +42,8 @@ type ProjectOp struct {
 	IsAggregate      bool // Whether the project is used in an aggregate context. This is set by planner by analyzing the SQL query
 	EnableLimit      bool
 	LimitCount       int
+	// Distinct removes the duplicate result rows of a collection for SELECT DISTINCT. The limit is applied after it.
+	Distinct bool
 
 	SendMeta bool
 	SendNil  bool
@@ -78,7 +85,7 @@ func (pp *ProjectOp) Apply(ctx api.StreamContext, data interface{}, fv *xsql.Fun
 		if pp.IsAggregate {
 			input.SetIsAgg(true)
 			err = input.GroupRange(func(i int, aggRow xsql.CollectionRow) (bool, error) {
-				if pp.EnableLimit && pp.LimitCount > 0 && i >= pp.LimitCount {
+				if !pp.Distinct && pp.EnableLimit && pp.LimitCount > 0 && i >= pp.LimitCount {
 					return false, nil
 				}
 				ve := pp.getVE(aggRow, aggRow, input.GetWindowRange(), fv, afv)
@@ -89,7 +96,7 @@ func (pp *ProjectOp) Apply(ctx api.StreamContext, data interface{}, fv *xsql.Fun
 			})
 		} else {
 			err = input.RangeSet(func(i int, row xsql.Row) (bool, error) {
-				if pp.EnableLimit && pp.LimitCount > 0 && i >= pp.LimitCount {
+				if !pp.Distinct && pp.EnableLimit && pp.LimitCount > 0 && i >= pp.LimitCount {
 					return false, nil
 				}
 				aggData, ok := input.(xsql.AggregateData)
@@ -106,12 +113,104 @@ func (pp *ProjectOp) Apply(ctx api.StreamContext, data interface{}, fv *xsql.Fun
 		if err != nil {
 			return err
 		}
+		if pp.Distinct {
+			return pp.distinct(input)
+		}
 	default:
 		return fmt.Errorf("run Select error: invalid input %[1]T(%[1]v)", input)
 	}
 	return data
 }
 
+// distinct keeps the first one of the duplicate result rows and then applies the limit
+func (pp *ProjectOp) distinct(input xsql.Collection) xsql.Collection {
+	seen := make(map[string]struct{})
+	var indexes []int
+	var b strings.Builder
+	_ = input.Range(func(i int, row xsql.ReadonlyRow) (bool, error) {
+		if pp.EnableLimit && pp.LimitCount > 0 && len(indexes) >= pp.LimitCount {
+			return false, nil
+		}
+		r, ok := row.(xsql.Row)
+		if !ok {
+			indexes = append(indexes, i)
+			return true, nil
+		}
+		b.Reset()
+		writeDistinctKey(&b, r.ToMap())
+		k := b.String()
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			indexes = append(indexes, i)
+		}
+		return true, nil
+	})
+	return input.Filter(indexes)
+}
+
+// writeDistinctKey writes the canonical key of a projected value. Each value is prefixed by its kind and
+// strings are quoted, so that different values like "1" and 1 never share a key. Numbers of different types
+// with the same value like 1 and 1.0 are the same. Map keys are sorted.
+func writeDistinctKey(b *strings.Builder, v interface{}) {
+	switch vt := v.(type) {
+	case nil:
+		b.WriteString("nil")
+	case bool:
+		b.WriteString("b:")
+		b.WriteString(strconv.FormatBool(vt))
+	case string:
+		b.WriteString("s:")
+		b.WriteString(strconv.Quote(vt))
+	case []byte:
+		b.WriteString("x:")
+		b.WriteString(strconv.Quote(string(vt)))
+	case int, int8, int16, int32, int64:
+		b.WriteString("n:")
+		b.WriteString(strconv.FormatInt(reflect.ValueOf(vt).Int(), 10))
+	case uint, uint8, uint16, uint32, uint64:
+		b.WriteString("n:")
+		b.WriteString(strconv.FormatUint(reflect.ValueOf(vt).Uint(), 10))
+	case float32, float64:
+		f := reflect.ValueOf(vt).Float()
+		b.WriteString("n:")
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			b.WriteString(strconv.FormatInt(int64(f), 10))
+		} else {
+			b.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vt))
+		for k := range vt {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("m{")
+		for _, k := range keys {
+			b.WriteString(strconv.Quote(k))
+			b.WriteByte(':')
+			writeDistinctKey(b, vt[k])
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteString("a[")
+		for _, e := range vt {
+			writeDistinctKey(b, e)
+			b.WriteByte(',')
+		}
+		b.WriteByte(']')
+	case []map[string]interface{}:
+		b.WriteString("a[")
+		for _, e := range vt {
+			writeDistinctKey(b, e)
+			b.WriteByte(',')
+		}
+		b.WriteByte(']')
+	default:
+		b.WriteString(fmt.Sprintf("%T:%#v", vt, vt))
+	}
+}
+
 func (pp *ProjectOp) getVE(tuple xsql.RawRow, agg xsql.AggregateData, wr *xsql.WindowRange, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer) *xsql.ValuerEval {
 	afv.SetData(agg)
 	if pp.IsAggregate {
@@ -3139,3 +3139,73 @@ func TestProjectPlan_SendNil(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectPlan_Distinct(t *testing.T) {
+	data := &xsql.WindowTuples{
+		Content: []xsql.Row{
+			&xsql.Tuple{Emitter: "test", Message: xsql.Message{"color": "red", "size": 1, "code": "1", "tags": map[string]interface{}{"a": 1, "b": "x"}}},
+			&xsql.Tuple{Emitter: "test", Message: xsql.Message{"color": "blue", "size": 2, "code": 1, "tags": map[string]interface{}{"b": "x", "a": 1.0}}},
+			&xsql.Tuple{Emitter: "test", Message: xsql.Message{"color": "red", "size": 1.0, "code": 1.0, "tags": map[string]interface{}{"a": "1", "b": "x"}}},
+			&xsql.Tuple{Emitter: "test", Message: xsql.Message{"color": "yellow", "size": 2, "code": "1", "tags": map[string]interface{}{"a": 1, "b": "x"}}},
+		},
+	}
+	tests := []struct {
+		sql    string
+		result []map[string]interface{}
+	}{
+		{
+			sql: `SELECT DISTINCT color FROM test`,
+			result: []map[string]interface{}{
+				{"color": "red"},
+				{"color": "blue"},
+				{"color": "yellow"},
+			},
+		},
+		{
+			sql: `SELECT DISTINCT color, size FROM test LIMIT 2`,
+			result: []map[string]interface{}{
+				{"color": "red", "size": 1},
+				{"color": "blue", "size": 2},
+			},
+		},
+		{
+			sql: `SELECT DISTINCT code FROM test`,
+			result: []map[string]interface{}{
+				{"code": "1"},
+				{"code": 1},
+			},
+		},
+		{
+			sql: `SELECT DISTINCT tags FROM test`,
+			result: []map[string]interface{}{
+				{"tags": map[string]interface{}{"a": 1, "b": "x"}},
+				{"tags": map[string]interface{}{"a": "1", "b": "x"}},
+			},
+		},
+		{
+			sql: `SELECT count(DISTINCT color) AS c, sum(DISTINCT size) AS s, count(size) AS total FROM test`,
+			result: []map[string]interface{}{
+				{"c": 3, "s": int64(3), "total": 4},
+			},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_Distinct")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			pp := &ProjectOp{IsAggregate: xsql.WithAggFields(stmt), Distinct: stmt.Distinct}
+			if stmt.Limit != nil {
+				pp.EnableLimit = true
+				pp.LimitCount = int(stmt.Limit.(*ast.LimitExpr).LimitCount.Val)
+			}
+			parseStmt(pp, stmt.Fields)
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			opResult := pp.Apply(ctx, data.Clone(), fv, afv)
+			result, err := parseResult(opResult, pp.IsAggregate)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+)
+
+// UnionOp merges the results of all UNION ALL branches. The branches are connected to the same union node.
+// The schemas are reconciled by column name. If SendNil is set, the columns missing in a branch are filled with nil.
+type UnionOp struct {
+	// ColNames is the union of the output columns of all branches. Nil for wildcard.
+	ColNames []string
+	SendNil  bool
+}
+
+func (p *UnionOp) Apply(ctx api.StreamContext, data interface{}, _ *xsql.FunctionValuer, _ *xsql.AggregateFunctionValuer) interface{} {
+	ctx.GetLogger().Debugf("union plan receive %v", data)
+	if !p.SendNil || len(p.ColNames) == 0 {
+		return data
+	}
+	switch input := data.(type) {
+	case xsql.Row:
+		p.fill(input)
+	case xsql.Collection:
+		_ = input.RangeSet(func(_ int, r xsql.Row) (bool, error) {
+			p.fill(r)
+			return true, nil
+		})
+	}
+	return data
+}
+
+func (p *UnionOp) fill(row xsql.Row) {
+	for _, name := range p.ColNames {
+		if _, ok := row.Value(name, ""); !ok {
+			row.Set(name, nil)
+		}
+	}
+}
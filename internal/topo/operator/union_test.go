@@ -0,0 +1,73 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/context"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+)
+
+func TestUnionOp_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     *UnionOp
+		data   any
+		result any
+	}{
+		{
+			name:   "pass through",
+			op:     &UnionOp{ColNames: []string{"a", "b"}},
+			data:   &xsql.Tuple{Emitter: "s1", Message: xsql.Message{"a": 1}},
+			result: &xsql.Tuple{Emitter: "s1", Message: xsql.Message{"a": 1}},
+		},
+		{
+			name:   "fill nil",
+			op:     &UnionOp{ColNames: []string{"a", "b"}, SendNil: true},
+			data:   &xsql.Tuple{Emitter: "s1", Message: xsql.Message{"a": 1}},
+			result: &xsql.Tuple{Emitter: "s1", Message: xsql.Message{"a": 1}, AffiliateRow: xsql.AffiliateRow{CalCols: map[string]any{"b": nil}}},
+		},
+		{
+			name: "fill nil in collection",
+			op:   &UnionOp{ColNames: []string{"a", "b"}, SendNil: true},
+			data: &xsql.WindowTuples{Content: []xsql.Row{
+				&xsql.Tuple{Emitter: "s2", Message: xsql.Message{"b": 2}},
+			}},
+			result: &xsql.WindowTuples{Content: []xsql.Row{
+				&xsql.Tuple{Emitter: "s2", Message: xsql.Message{"b": 2}, AffiliateRow: xsql.AffiliateRow{CalCols: map[string]any{"a": nil}}},
+			}},
+		},
+		{
+			name:   "error",
+			op:     &UnionOp{ColNames: []string{"a"}, SendNil: true},
+			data:   errors.New("an error"),
+			result: errors.New("an error"),
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestUnionOp_Apply")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			result := tt.op.Apply(ctx, tt.data, fv, afv)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
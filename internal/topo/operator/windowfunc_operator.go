@@ -28,28 +28,134 @@ type WindowFuncOperator struct {
 	WindowFuncField *ast.Field
 }
 
+// windowFuncHandle calculates the window function result of each row in a sorted partition
 type windowFuncHandle interface {
-	handleTuple(input xsql.Row) xsql.Row
-	handleCollection(input xsql.Collection) xsql.Collection
+	values(rows []xsql.Row) ([]interface{}, error)
 }
 
-type rowNumberFuncHandle struct {
-	name string
+// windowFuncEval evaluates the expression like the function arguments or sort fields against a row
+type windowFuncEval func(row xsql.Row, expr ast.Expr) (interface{}, error)
+
+type rowNumberFuncHandle struct{}
+
+func (rh *rowNumberFuncHandle) values(rows []xsql.Row) ([]interface{}, error) {
+	result := make([]interface{}, len(rows))
+	for i := range rows {
+		result[i] = i + 1
+	}
+	return result, nil
 }
 
-func (rh *rowNumberFuncHandle) handleTuple(input xsql.Row) xsql.Row {
-	input.Set(rh.name, 1)
-	return input
+// rankFuncHandle calculates rank, dense_rank and percent_rank. The rows with the same sort values are peers with the same rank.
+type rankFuncHandle struct {
+	funcName   string
+	sortFields ast.SortFields
+	eval       windowFuncEval
 }
 
-func (rh *rowNumberFuncHandle) handleCollection(input xsql.Collection) xsql.Collection {
-	index := 1
-	input.RangeSet(func(i int, r xsql.Row) (bool, error) {
-		r.Set(rh.name, index)
-		index++
-		return true, nil
-	})
-	return input
+func (rh *rankFuncHandle) values(rows []xsql.Row) ([]interface{}, error) {
+	result := make([]interface{}, len(rows))
+	rank, denseRank := 0, 0
+	prevKey := ""
+	for i, row := range rows {
+		key := ""
+		for _, sf := range rh.sortFields {
+			v, err := rh.eval(row, sf.FieldExpr)
+			if err != nil {
+				return nil, err
+			}
+			key += fmt.Sprintf("%v,", v)
+		}
+		if i == 0 || key != prevKey {
+			rank = i + 1
+			denseRank++
+			prevKey = key
+		}
+		switch rh.funcName {
+		case "dense_rank":
+			result[i] = denseRank
+		case "percent_rank":
+			if len(rows) == 1 {
+				result[i] = float64(0)
+			} else {
+				result[i] = float64(rank-1) / float64(len(rows)-1)
+			}
+		default:
+			result[i] = rank
+		}
+	}
+	return result, nil
+}
+
+// ntileFuncHandle divides the rows into n buckets as even as possible. The first buckets have one more row if not divisible.
+type ntileFuncHandle struct {
+	n int
+}
+
+func (nh *ntileFuncHandle) values(rows []xsql.Row) ([]interface{}, error) {
+	result := make([]interface{}, len(rows))
+	size, extra := len(rows)/nh.n, len(rows)%nh.n
+	bucket, count := 1, 0
+	for i := range rows {
+		limit := size
+		if bucket <= extra {
+			limit++
+		}
+		if count >= limit {
+			bucket++
+			count = 0
+		}
+		result[i] = bucket
+		count++
+	}
+	return result, nil
+}
+
+// leadFuncHandle gets the value of the row after the current row by the offset in the partition
+type leadFuncHandle struct {
+	expr       ast.Expr
+	offset     int
+	defaultVal ast.Expr
+	eval       windowFuncEval
+}
+
+func (lh *leadFuncHandle) values(rows []xsql.Row) ([]interface{}, error) {
+	result := make([]interface{}, len(rows))
+	for i, row := range rows {
+		var err error
+		if i+lh.offset < len(rows) {
+			result[i], err = lh.eval(rows[i+lh.offset], lh.expr)
+		} else if lh.defaultVal != nil {
+			result[i], err = lh.eval(row, lh.defaultVal)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// nthValueFuncHandle gets the value of the nth row in the partition for first_value and nth_value.
+// The frame is always the whole partition.
+type nthValueFuncHandle struct {
+	expr ast.Expr
+	n    int
+	eval windowFuncEval
+}
+
+func (nh *nthValueFuncHandle) values(rows []xsql.Row) ([]interface{}, error) {
+	result := make([]interface{}, len(rows))
+	if nh.n > len(rows) {
+		return result, nil
+	}
+	v, err := nh.eval(rows[nh.n-1], nh.expr)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		result[i] = v
+	}
+	return result, nil
 }
 
 func (wf *WindowFuncOperator) Apply(ctx api.StreamContext, data interface{}, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer) interface{} {
@@ -58,33 +164,44 @@ func (wf *WindowFuncOperator) Apply(ctx api.StreamContext, data interface{}, fv
 	if windowFuncField.AName != "" {
 		name = windowFuncField.AName
 	}
-	var funcName string
-	var pr *ast.PartitionExpr
-	var sortFields ast.SortFields
+	var call *ast.Call
 	switch c := windowFuncField.Expr.(type) {
 	case *ast.Call:
-		funcName = c.Name
-		pr = c.Partition
-		sortFields = c.SortFields
+		call = c
 	case *ast.FieldRef:
-		call := c.AliasRef.Expression.(*ast.Call)
-		funcName = call.Name
-		pr = call.Partition
-		sortFields = call.SortFields
+		call = c.AliasRef.Expression.(*ast.Call)
 	}
-	wh, err := getWindowFuncHandle(funcName, name)
+	pr := call.Partition
+	sortFields := call.SortFields
+	var wr *xsql.WindowRange
+	if input, ok := data.(xsql.Collection); ok {
+		wr = input.GetWindowRange()
+	}
+	eval := func(row xsql.Row, expr ast.Expr) (interface{}, error) {
+		ve := &xsql.ValuerEval{Valuer: xsql.MultiValuer(row, &xsql.WindowRangeValuer{WindowRange: wr}, fv, &xsql.WildcardValuer{Data: row})}
+		r := ve.Eval(expr)
+		if err, ok := r.(error); ok {
+			return nil, fmt.Errorf("run window function %s error: %v", call.Name, err)
+		}
+		return r, nil
+	}
+	wh, err := getWindowFuncHandle(call, eval)
 	if err != nil {
 		return err
 	}
 	switch input := data.(type) {
 	case xsql.Row:
-		wh.handleTuple(input)
+		vals, err := wh.values([]xsql.Row{input})
+		if err != nil {
+			return err
+		}
+		input.Set(name, vals[0])
 	case xsql.Collection:
 		if pr != nil {
 			// handle the following case:
 			// 1: row_number() over (partition by a)
 			// 2: row_number() over (partition by a order by b)
-			input, err = partitionCollection(ctx, input, fv, afv, pr, sortFields, wh)
+			input, err = partitionCollection(ctx, input, fv, afv, pr, sortFields, wh, name)
 			if err != nil {
 				return err
 			}
@@ -93,23 +210,65 @@ func (wf *WindowFuncOperator) Apply(ctx api.StreamContext, data interface{}, fv
 			// handle the following case:
 			// 1: row_number() over (order by a)
 			input = sortCollection(ctx, input, fv, afv, sortFields)
-			input = wh.handleCollection(input)
-			return input
 		}
 		// handle the following case:
 		// 1: row_number() without over clause
-		input = wh.handleCollection(input)
+		input, err = handleCollection(input, wh, name)
+		if err != nil {
+			return err
+		}
 		return input
 	}
 	return data
 }
 
-func getWindowFuncHandle(funcName, colName string) (windowFuncHandle, error) {
-	switch funcName {
+func getWindowFuncHandle(call *ast.Call, eval windowFuncEval) (windowFuncHandle, error) {
+	switch call.Name {
 	case "row_number":
-		return &rowNumberFuncHandle{name: colName}, nil
+		return &rowNumberFuncHandle{}, nil
+	case "rank", "dense_rank", "percent_rank":
+		return &rankFuncHandle{funcName: call.Name, sortFields: call.SortFields, eval: eval}, nil
+	case "ntile":
+		return &ntileFuncHandle{n: intLiteralArg(call.Args, 0, 1)}, nil
+	case "lead":
+		h := &leadFuncHandle{expr: call.Args[0], offset: intLiteralArg(call.Args, 1, 1), eval: eval}
+		if len(call.Args) > 2 {
+			h.defaultVal = call.Args[2]
+		}
+		return h, nil
+	case "first_value":
+		return &nthValueFuncHandle{expr: call.Args[0], n: 1, eval: eval}, nil
+	case "nth_value":
+		return &nthValueFuncHandle{expr: call.Args[0], n: intLiteralArg(call.Args, 1, 1), eval: eval}, nil
 	}
-	return nil, fmt.Errorf("")
+	return nil, fmt.Errorf("unknown window function %s", call.Name)
+}
+
+// intLiteralArg returns the integer literal argument at the index which is validated by the function validator
+func intLiteralArg(args []ast.Expr, index int, defaultVal int) int {
+	if len(args) > index {
+		if il, ok := args[index].(*ast.IntegerLiteral); ok {
+			return int(il.Val)
+		}
+	}
+	return defaultVal
+}
+
+func handleCollection(input xsql.Collection, wh windowFuncHandle, name string) (xsql.Collection, error) {
+	rows := make([]xsql.Row, 0)
+	_ = input.Range(func(i int, r xsql.ReadonlyRow) (bool, error) {
+		rows = append(rows, r.(xsql.Row))
+		return true, nil
+	})
+	vals, err := wh.values(rows)
+	if err != nil {
+		return nil, err
+	}
+	_ = input.RangeSet(func(i int, r xsql.Row) (bool, error) {
+		r.Set(name, vals[i])
+		return true, nil
+	})
+	return input, nil
 }
 
 func sortCollection(ctx api.StreamContext, data xsql.Collection, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer, sortFields ast.SortFields) xsql.Collection {
@@ -118,7 +277,7 @@ func sortCollection(ctx api.StreamContext, data xsql.Collection, fv *xsql.Functi
 	return output.(xsql.Collection)
 }
 
-func partitionCollection(ctx api.StreamContext, input xsql.Collection, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer, prs *ast.PartitionExpr, sortFields ast.SortFields, wh windowFuncHandle) (xsql.Collection, error) {
+func partitionCollection(ctx api.StreamContext, input xsql.Collection, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer, prs *ast.PartitionExpr, sortFields ast.SortFields, wh windowFuncHandle, name string) (xsql.Collection, error) {
 	result := make(map[string]*xsql.WindowTuples)
 	keys := make([]string, 0)
 	err := input.Range(func(i int, ir xsql.ReadonlyRow) (bool, error) {
@@ -148,8 +307,10 @@ func partitionCollection(ctx api.StreamContext, input xsql.Collection, fv *xsql.
 	// visit result by order
 	sort.Strings(keys)
 	for _, key := range keys {
-		subOutput := sortCollection(ctx, result[key], fv, afv, sortFields)
-		subOutput = wh.handleCollection(subOutput)
+		subOutput, err := handleCollection(sortCollection(ctx, result[key], fv, afv, sortFields), wh, name)
+		if err != nil {
+			return nil, err
+		}
 		subOutput.Range(func(i int, r xsql.ReadonlyRow) (bool, error) {
 			t := r.(xsql.Row)
 			output.AddTuple(t)
@@ -169,3 +169,90 @@ func TestWindowFuncApplyCollection(t *testing.T) {
 		require.Equal(t, tc.expect, output.ToMaps())
 	}
 }
+
+func TestWindowFuncRanking(t *testing.T) {
+	data := &xsql.WindowTuples{
+		Content: []xsql.Row{
+			&xsql.Tuple{Emitter: "demo", Message: map[string]interface{}{"a": 1, "b": 2}},
+			&xsql.Tuple{Emitter: "demo", Message: map[string]interface{}{"a": 2, "b": 1}},
+			&xsql.Tuple{Emitter: "demo", Message: map[string]interface{}{"a": 3, "b": 1}},
+			&xsql.Tuple{Emitter: "demo", Message: map[string]interface{}{"a": 4, "b": 3}},
+			&xsql.Tuple{Emitter: "demo", Message: map[string]interface{}{"a": 5, "b": 3}},
+		},
+	}
+	// order by b, the rows with the same b are peers
+	sortFields := ast.SortFields{
+		{
+			Name:      "b",
+			Uname:     "b",
+			Ascending: true,
+			FieldExpr: &ast.FieldRef{StreamName: "demo", Name: "b"},
+		},
+	}
+	fieldA := &ast.FieldRef{StreamName: "demo", Name: "a"}
+	testcases := []struct {
+		call   *ast.Call
+		expect []interface{}
+	}{
+		{
+			call:   &ast.Call{Name: "rank", SortFields: sortFields},
+			expect: []interface{}{1, 1, 3, 4, 4},
+		},
+		{
+			call:   &ast.Call{Name: "dense_rank", SortFields: sortFields},
+			expect: []interface{}{1, 1, 2, 3, 3},
+		},
+		{
+			call:   &ast.Call{Name: "percent_rank", SortFields: sortFields},
+			expect: []interface{}{float64(0), float64(0), 0.5, 0.75, 0.75},
+		},
+		{
+			call:   &ast.Call{Name: "ntile", Args: []ast.Expr{&ast.IntegerLiteral{Val: 3}}, SortFields: sortFields},
+			expect: []interface{}{1, 1, 2, 2, 3},
+		},
+		{
+			call:   &ast.Call{Name: "ntile", Args: []ast.Expr{&ast.IntegerLiteral{Val: 8}}, SortFields: sortFields},
+			expect: []interface{}{1, 2, 3, 4, 5},
+		},
+		{
+			call:   &ast.Call{Name: "lead", Args: []ast.Expr{fieldA}, SortFields: sortFields},
+			expect: []interface{}{3, 1, 4, 5, nil},
+		},
+		{
+			call:   &ast.Call{Name: "lead", Args: []ast.Expr{fieldA, &ast.IntegerLiteral{Val: 2}, &ast.IntegerLiteral{Val: -1}}, SortFields: sortFields},
+			expect: []interface{}{1, 4, 5, int64(-1), int64(-1)},
+		},
+		{
+			call:   &ast.Call{Name: "first_value", Args: []ast.Expr{fieldA}, SortFields: sortFields},
+			expect: []interface{}{2, 2, 2, 2, 2},
+		},
+		{
+			call:   &ast.Call{Name: "nth_value", Args: []ast.Expr{fieldA, &ast.IntegerLiteral{Val: 2}}, SortFields: sortFields},
+			expect: []interface{}{3, 3, 3, 3, 3},
+		},
+		{
+			call:   &ast.Call{Name: "nth_value", Args: []ast.Expr{fieldA, &ast.IntegerLiteral{Val: 6}}, SortFields: sortFields},
+			expect: []interface{}{nil, nil, nil, nil, nil},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestWindowFuncRanking")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tc := range testcases {
+		t.Run(tc.call.Name, func(t *testing.T) {
+			op := &WindowFuncOperator{WindowFuncField: &ast.Field{Name: "r", Expr: tc.call}}
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			output := op.Apply(ctx, data.Clone(), fv, afv).(xsql.Collection)
+			var result []interface{}
+			for _, m := range output.ToMaps() {
+				result = append(result, m["r"])
+			}
+			require.Equal(t, tc.expect, result)
+		})
+	}
+	// A single row is a partition of itself
+	op := &WindowFuncOperator{WindowFuncField: &ast.Field{Name: "r", Expr: &ast.Call{Name: "percent_rank"}}}
+	fv, afv := xsql.NewFunctionValuersForOp(nil)
+	output := op.Apply(ctx, &xsql.Tuple{Emitter: "demo", Message: map[string]interface{}{"a": 1}}, fv, afv)
+	r, _ := output.(xsql.Row).Value("r", "")
+	require.Equal(t, float64(0), r)
+}
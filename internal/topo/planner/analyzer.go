@@ -34,22 +34,38 @@ type streamInfo struct {
 
 // Analyze the select statement by decorating the info from stream statement.
 // Typically, set the correct stream name for fieldRefs
-func decorateStmt(s *ast.SelectStatement, store kv.KeyValue, opt *def.RuleOption) ([]*streamInfo, []*ast.Call, []*ast.Call, error) {
-	streamsFromStmt := xsql.GetStreams(s)
-	streamStmts := make([]*streamInfo, len(streamsFromStmt))
-	isSchemaless := false
-	for i, s := range streamsFromStmt {
-		streamStmt, err := xsql.GetDataSource(store, s)
-		if err != nil {
-			return nil, nil, nil, fmt.Errorf("fail to get stream %s, please check if stream is created", s)
-		}
-		si, err := convertStreamInfo(streamStmt)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		streamStmts[i] = si
-		if si.schema == nil {
-			isSchemaless = true
+func decorateStmt(s *ast.SelectStatement, store kv.KeyValue, opt *def.RuleOption, checkers []validateOptStmt) ([]*streamInfo, []*ast.Call, []*ast.Call, error) {
+	var (
+		streamsFromStmt []string
+		streamStmts     []*streamInfo
+		isSchemaless    bool
+	)
+	if sq := getSubQuery(s); sq != nil {
+		// The outer query reads the output rows of the subquery which have no schema.
+		// Bind all the fields to the default stream so that they can also refer to the alias in the subquery.
+		isSchemaless = true
+		ast.WalkFunc(s, func(n ast.Node) bool {
+			if f, ok := n.(*ast.FieldRef); ok && (string(f.StreamName) == sq.Name || (sq.Alias != "" && string(f.StreamName) == sq.Alias)) {
+				f.StreamName = ast.DefaultStream
+			}
+			return true
+		})
+	} else {
+		streamsFromStmt = xsql.GetSourceStreams(s)
+		streamStmts = make([]*streamInfo, len(streamsFromStmt))
+		for i, s := range streamsFromStmt {
+			streamStmt, err := xsql.GetDataSource(store, s)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("fail to get stream %s, please check if stream is created", s)
+			}
+			si, err := convertStreamInfo(streamStmt)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			streamStmts[i] = si
+			if si.schema == nil {
+				isSchemaless = true
+			}
 		}
 	}
 	if checkAliasReferenceCycle(s) {
@@ -167,7 +183,7 @@ func decorateStmt(s *ast.SelectStatement, store kv.KeyValue, opt *def.RuleOption
 	if walkErr != nil {
 		return nil, nil, nil, walkErr
 	}
-	walkErr = validate(s)
+	walkErr = validateWith(s, checkers)
 	// Collect all analytic function calls so that we can let them run firstly
 	ast.WalkFunc(s, func(n ast.Node) bool {
 		switch f := n.(type) {
@@ -405,7 +421,11 @@ type validateOptStmt interface {
 }
 
 func validate(stmt *ast.SelectStatement) error {
-	for _, checker := range stmtCheckers {
+	return validateWith(stmt, stmtCheckers)
+}
+
+func validateWith(stmt *ast.SelectStatement, checkers []validateOptStmt) error {
+	for _, checker := range checkers {
 		if err := checker.validate(stmt); err != nil {
 			return err
 		}
@@ -418,6 +438,11 @@ var stmtCheckers = []validateOptStmt{
 	&groupChecker{},
 }
 
+// queryCheckers validates the ad-hoc queries. The query reads a bounded table, so group by is allowed without window.
+var queryCheckers = []validateOptStmt{
+	&aggFuncChecker{},
+}
+
 type aggFuncChecker struct{}
 
 func (c *aggFuncChecker) validate(s *ast.SelectStatement) (err error) {
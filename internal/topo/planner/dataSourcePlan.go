@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/lf-edge/ekuiper/v2/internal/converter/merge"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/message"
 )
@@ -48,6 +49,11 @@ type DataSourcePlan struct {
 	fields      map[string]*ast.JsonStreamField
 	metaMap     map[string]string
 	pruneFields []string
+	// accepted by the source to filter and project by itself
+	pushedConditions []ast.Expr
+	pushedColumns    []string
+	// the built node which is reused if the plan is shared by many branches of UNION ALL
+	built node.Emitter
 }
 
 func (p DataSourcePlan) Init() *DataSourcePlan {
@@ -134,6 +140,19 @@ func (p *DataSourcePlan) BuildExplainInfo() {
 		}
 		info += " ]"
 	}
+	if len(p.pushedConditions) > 0 {
+		info += ", PushedConditions:[ "
+		for i, c := range p.pushedConditions {
+			info += c.String()
+			if i != len(p.pushedConditions)-1 {
+				info += ", "
+			}
+		}
+		info += " ]"
+	}
+	if len(p.pushedColumns) > 0 {
+		info += ", PushedColumns:[ " + strings.Join(p.pushedColumns, ", ") + " ]"
+	}
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
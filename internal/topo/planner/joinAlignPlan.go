@@ -21,6 +21,21 @@ const (
 	MaxRetainSize     = 9999
 )
 
+func getRetainSize(options *ast.Options) int {
+	tableSize := options.RETAIN_SIZE
+	if tableSize == 0 {
+		switch options.TYPE {
+		// If retainSize is not set, file table will try to read all the content in it
+		case "", "file":
+			// TODO use interface to determine if the table is batch like file
+			tableSize = MaxRetainSize
+		default:
+			tableSize = DefaultRetainSize
+		}
+	}
+	return tableSize
+}
+
 type JoinAlignPlan struct {
 	baseLogicalPlan
 	Emitters []string
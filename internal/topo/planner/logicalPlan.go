@@ -157,4 +157,7 @@ const (
 	WINDOWFUNC    PlanType = "WindowFuncPlan"
 	WATERMARK     PlanType = "WatermarkPlan"
 	IncAggWindow  PlanType = "IncAggWindowPlan"
+	UNION         PlanType = "UnionPlan"
+	SUBQUERY      PlanType = "SubQueryPlan"
+	SEMIJOIN      PlanType = "SemiJoinPlan"
 )
@@ -21,6 +21,8 @@ var optRuleList = []logicalOptRule{
 	&predicatePushDown{},
 	&pushProjectionPlan{},
 	&pushAliasDecode{},
+	&pushDownSource{},
+	&shareSource{},
 }
 
 func optimize(p LogicalPlan, options *def.RuleOption) (LogicalPlan, error) {
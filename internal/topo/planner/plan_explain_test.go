@@ -123,6 +123,161 @@ func TestExplainPlan(t *testing.T) {
 	}
 }
 
+func TestExplainUnion(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+
+	stmt, err := xsql.NewParser(strings.NewReader(`select a from stream where a > 1 union all select b as a, b as c from sharedStream`)).Parse()
+	require.NoError(t, err)
+	p, err := createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err := ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"op":"UnionPlan_0","info":"Fields:[ a, c ]"}
+	{"op":"ProjectPlan_1","info":"Fields:[ stream.a ]"}
+			{"op":"FilterPlan_2","info":"Condition:{ binaryExpr:{ stream.a > 1 } }, "}
+					{"op":"DataSourcePlan_3","info":"StreamName: stream, StreamFields:[ a ]"}
+
+
+	{"op":"ProjectPlan_2","info":"Fields:[ $$alias.a,aliasRef:sharedStream.b, $$alias.c,aliasRef:sharedStream.b ]"}
+			{"op":"DataSourcePlan_3","info":"StreamName: sharedStream, StreamFields:[ b ]"}`, explain)
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select a from stream where a > 1 union all select b as a from stream where b > 2`)).Parse()
+	require.NoError(t, err)
+	p, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err = ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	// The branches share the source of the same stream which reads the fields of both
+	require.Equal(t, `{"op":"UnionPlan_0","info":"Fields:[ a ]"}
+	{"op":"ProjectPlan_1","info":"Fields:[ stream.a ]"}
+			{"op":"FilterPlan_2","info":"Condition:{ binaryExpr:{ stream.a > 1 } }, "}
+					{"op":"DataSourcePlan_4","info":"StreamName: stream, StreamFields:[ a, b ]"}
+
+
+	{"op":"ProjectPlan_2","info":"Fields:[ $$alias.a,aliasRef:stream.b ]"}
+			{"op":"FilterPlan_3","info":"Condition:{ binaryExpr:{ stream.b > 2 } }, "}
+					{"op":"DataSourcePlan_4","info":"StreamName: stream, StreamFields:[ a, b ]"}`, explain)
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select count(*) as c from (select a from stream union all select b as a from sharedStream) as u group by countwindow(2)`)).Parse()
+	require.NoError(t, err)
+	p, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err = ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	// The branches are merged before the window of the outer query
+	require.Equal(t, `{"op":"ProjectPlan_0","info":"Fields:[ $$alias.c,aliasRef:Call:{ name:count, args:[*] } ]"}
+	{"op":"WindowPlan_1","info":"{ length:2, windowType:COUNT_WINDOW, limit: 0 }"}
+			{"op":"SubQueryPlan_2","info":"Name: u"}
+					{"op":"UnionPlan_3","info":"Fields:[ a ]"}
+							{"op":"ProjectPlan_4","info":"Fields:[ stream.a ]"}
+									{"op":"DataSourcePlan_5","info":"StreamName: stream, StreamFields:[ a ]"}
+
+							{"op":"ProjectPlan_5","info":"Fields:[ $$alias.a,aliasRef:sharedStream.b ]"}
+									{"op":"ProjectPlan_6","info":"Fields:[ sharedStream.b ]"}
+											{"op":"DataSourcePlan_7","info":"StreamName: sharedStream, StreamFields:[ b ]"}`, explain)
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select a from stream where a > 1 union all select b as a from sharedStream group by countwindow(2)`)).Parse()
+	require.NoError(t, err)
+	p, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err = ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	// The window after the last branch is shared by all branches
+	require.Equal(t, `{"op":"ProjectPlan_0","info":"Fields:[ $$default.a ]"}
+	{"op":"WindowPlan_1","info":"{ length:2, windowType:COUNT_WINDOW, limit: 0 }"}
+			{"op":"UnionPlan_2","info":"Fields:[ a ]"}
+					{"op":"ProjectPlan_3","info":"Fields:[ stream.a ]"}
+							{"op":"FilterPlan_4","info":"Condition:{ binaryExpr:{ stream.a > 1 } }, "}
+									{"op":"DataSourcePlan_5","info":"StreamName: stream, StreamFields:[ a ]"}
+
+
+					{"op":"ProjectPlan_4","info":"Fields:[ $$alias.a,aliasRef:sharedStream.b ]"}
+							{"op":"ProjectPlan_5","info":"Fields:[ sharedStream.b ]"}
+									{"op":"DataSourcePlan_6","info":"StreamName: sharedStream, StreamFields:[ b ]"}`, explain)
+
+	_, err = createLogicalPlan(stmt, &def.RuleOption{IsEventTime: true}, kv)
+	require.EqualError(t, err, "event time window is not supported after UNION ALL")
+
+	for _, sql := range []string{
+		`select count(*) from stream group by countwindow(2) union all select b from sharedStream`,
+		`select a from stream union all select count(*) as a from sharedStream group by countwindow(2)`,
+		`select a from stream union all select b as a from sharedStream group by b, countwindow(2)`,
+	} {
+		stmt, err = xsql.NewParser(strings.NewReader(sql)).Parse()
+		require.NoError(t, err)
+		_, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+		require.EqualError(t, err, "group by and aggregate are not supported in the branches of UNION ALL. Put the window after the last branch to apply it to all branches such as SELECT ... UNION ALL SELECT ... GROUP BY TumblingWindow(ss, 10), or aggregate in a subquery such as SELECT count(*) FROM (SELECT ... UNION ALL SELECT ...) AS u GROUP BY TumblingWindow(ss, 10)", sql)
+	}
+}
+
+func TestExplainSubQuery(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+
+	stmt, err := xsql.NewParser(strings.NewReader(`with cleaned as (select a * 2 as d from stream where b > 1) select count(*) as c from cleaned where d > 2 group by countwindow(2)`)).Parse()
+	require.NoError(t, err)
+	p, err := createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err := ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"op":"ProjectPlan_0","info":"Fields:[ $$alias.c,aliasRef:Call:{ name:count, args:[*] } ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ binaryExpr:{ $$default.d > 2 } }, "}
+			{"op":"WindowPlan_2","info":"{ length:2, windowType:COUNT_WINDOW, limit: 0 }"}
+					{"op":"SubQueryPlan_3","info":"Name: cleaned"}
+							{"op":"ProjectPlan_4","info":"Fields:[ $$alias.d,aliasRef:binaryExpr:{ stream.a * 2 } ]"}
+									{"op":"FilterPlan_5","info":"Condition:{ binaryExpr:{ stream.b > 1 } }, "}
+											{"op":"DataSourcePlan_6","info":"StreamName: stream, StreamFields:[ a, b ]"}`, explain)
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select d from (select a as d from stream) as t inner join sharedStream on t.d = sharedStream.a group by countwindow(2)`)).Parse()
+	require.NoError(t, err)
+	_, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.EqualError(t, err, "subquery t cannot be joined with other sources")
+}
+
+func TestExplainSemiJoin(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+
+	stmt, err := xsql.NewParser(strings.NewReader(`select a from stream where b > 1 and a in (select id from allowTable where level > 2)`)).Parse()
+	require.NoError(t, err)
+	p, err := createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err := ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"op":"ProjectPlan_0","info":"Fields:[ stream.a ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ $$default.$$subquery_0 }, "}
+			{"op":"SemiJoinPlan_2","info":"Table:allowTable, Field:$$subquery_0, Condition:{ binaryExpr:{ binaryExpr:{ allowTable.id = stream.a } AND binaryExpr:{ allowTable.level > 2 } } }"}
+					{"op":"FilterPlan_3","info":"Condition:{ binaryExpr:{ stream.b > 1 } }, "}
+							{"op":"DataSourcePlan_4","info":"StreamName: stream, StreamFields:[ a, b ]"}
+
+					{"op":"DataSourcePlan_4","info":"StreamName: allowTable, StreamFields:[ id, level ]"}`, explain)
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select a from stream where not exists (select * from allowLookup where id = stream.a and level > stream.b)`)).Parse()
+	require.NoError(t, err)
+	p, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	explain, err = ExplainFromLogicalPlan(p, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"op":"ProjectPlan_0","info":"Fields:[ stream.a ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ $$default.$$subquery_0 }, "}
+			{"op":"SemiJoinPlan_2","info":"Table:allowLookup, Field:$$subquery_0, Not:true, Condition:{ binaryExpr:{ binaryExpr:{ allowLookup.id = stream.a } AND binaryExpr:{ allowLookup.level > stream.b } } }"}
+					{"op":"DataSourcePlan_3","info":"StreamName: stream, StreamFields:[ a, b ]"}`, explain)
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select a from stream where exists (select * from allowLookup where level > stream.b)`)).Parse()
+	require.NoError(t, err)
+	_, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.EqualError(t, err, "the subquery of lookup table allowLookup requires at least one equi predicate between the table and the outer query")
+
+	stmt, err = xsql.NewParser(strings.NewReader(`select a from stream where a in (select a from sharedStream)`)).Parse()
+	require.NoError(t, err)
+	_, err = createLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.EqualError(t, err, "IN and EXISTS subqueries only support selecting from a table, but sharedStream is a stream")
+}
+
 func prepareStream() error {
 	kv, err := store.GetKV("stream")
 	if err != nil {
@@ -137,11 +292,21 @@ func prepareStream() error {
 					a BIGINT,
 					b BIGINT,
 				) WITH (DATASOURCE="src1");`,
+		"allowTable": `CREATE TABLE allowTable (
+					id BIGINT,
+					level BIGINT,
+				) WITH (DATASOURCE="allow", TYPE="memory");`,
+		"allowLookup": `CREATE TABLE allowLookup (
+					id BIGINT,
+					level BIGINT,
+				) WITH (DATASOURCE="allow", TYPE="memory", KIND="lookup", KEY="id");`,
 	}
 
 	types := map[string]ast.StreamType{
 		"sharedStream": ast.TypeStream,
 		"stream":       ast.TypeStream,
+		"allowTable":   ast.TypeTable,
+		"allowLookup":  ast.TypeTable,
 	}
 	for name, sql := range streamSqls {
 		s, err := json.Marshal(&xsql.StreamInfo{
@@ -245,3 +410,46 @@ func TestExplainPushAlias(t *testing.T) {
 		require.Equal(t, tc.explain, explain, tc.sql)
 	}
 }
+
+func TestExplainDistinct(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+
+	testcases := []struct {
+		sql     string
+		explain string
+	}{
+		{
+			sql: `select distinct a from stream group by countwindow(2)`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ stream.a ], Distinct:true"}
+	{"op":"WindowPlan_1","info":"{ length:2, windowType:COUNT_WINDOW, limit: 0 }"}
+			{"op":"DataSourcePlan_2","info":"StreamName: stream, StreamFields:[ a ]"}`,
+		},
+		{
+			sql: `select count(distinct a), sum(b) from stream group by countwindow(2)`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ Call:{ name:bypass, args:[$$default.inc_agg_col_1] }, Call:{ name:bypass, args:[$$default.inc_agg_col_2] } ]"}
+	{"op":"IncAggWindowPlan_1","info":"wType:COUNT_WINDOW, funcs:[Call:{ name:inc_distinct_count, args:[stream.a] }->inc_agg_col_1,Call:{ name:inc_sum, args:[stream.b] }->inc_agg_col_2]"}
+			{"op":"DataSourcePlan_2","info":"StreamName: stream, StreamFields:[ a, b ]"}`,
+		},
+		{
+			sql: `select last_value(distinct a, true) from stream group by countwindow(2)`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ Call:{ name:last_value, distinct:true, args:[stream.a, true] } ]"}
+	{"op":"WindowPlan_1","info":"{ length:2, windowType:COUNT_WINDOW, limit: 0 }"}
+			{"op":"DataSourcePlan_2","info":"StreamName: stream, StreamFields:[ a ]"}`,
+		},
+	}
+	for _, tc := range testcases {
+		stmt, err := xsql.NewParser(strings.NewReader(tc.sql)).Parse()
+		require.NoError(t, err)
+		p, err := createLogicalPlan(stmt, &def.RuleOption{
+			PlanOptimizeStrategy: &def.PlanOptimizeStrategy{
+				EnableIncrementalWindow: true,
+			},
+		}, kv)
+		require.NoError(t, err)
+		explain, err := ExplainFromLogicalPlan(p, "")
+		require.NoError(t, err)
+		require.Equal(t, tc.explain, explain, tc.sql)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	store2 "github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/topo"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
@@ -72,6 +73,7 @@ func PlanSQLWithSourcesAndSinks(rule *def.Rule, mockSourcesProp map[string]map[s
 	if err != nil {
 		return nil, err
 	}
+	store = namespace.KV(store, namespace.Of(rule.Id))
 	// Create the logical plan and optimize. Logical plans are a linked list
 	lp, err := createLogicalPlan(stmt, rule.Options, store)
 	if err != nil {
@@ -84,6 +86,23 @@ func PlanSQLWithSourcesAndSinks(rule *def.Rule, mockSourcesProp map[string]map[s
 	return tp, nil
 }
 
+// ValidateSql validates the sql rule by creating the logical plan with the streams and tables in the store.
+// Unlike Plan, it does not create the topo, thus no source or sink is initialized.
+func ValidateSql(rule *def.Rule, store kv.KeyValue) error {
+	stmt, err := xsql.GetStatementFromSql(rule.Sql)
+	if err != nil {
+		return err
+	}
+	if err := validateStmt(stmt); err != nil {
+		return err
+	}
+	if rule.Options.SendMetaToSink && (len(xsql.GetStreams(stmt)) > 1 || stmt.Dimensions != nil) {
+		return fmt.Errorf("Invalid option sendMetaToSink, it can not be applied to window")
+	}
+	_, err = createLogicalPlan(stmt, rule.Options, namespace.KV(store, namespace.Of(rule.Id)))
+	return err
+}
+
 func validateStmt(stmt *ast.SelectStatement) error {
 	var vErr error
 	ast.WalkFunc(stmt, func(n ast.Node) bool {
@@ -95,7 +114,20 @@ func validateStmt(stmt *ast.SelectStatement) error {
 		}
 		return true
 	})
-	return vErr
+	if vErr != nil {
+		return vErr
+	}
+	if sq := getSubQuery(stmt); sq != nil {
+		if err := validateStmt(sq.Query); err != nil {
+			return err
+		}
+	}
+	for _, union := range stmt.Unions {
+		if err := validateStmt(union); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func createTopo(rule *def.Rule, lp LogicalPlan, mockSourcesProp map[string]map[string]any, streamsFromStmt []string) (t *topo.Topo, err error) {
@@ -144,6 +176,7 @@ func GetExplainInfoFromLogicalPlan(rule *def.Rule) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	store = namespace.KV(store, namespace.Of(rule.Id))
 	// Create logical plan and optimize. Logical plans are a linked list
 	lp, err := createLogicalPlan(stmt, rule.Options, store)
 	if err != nil {
@@ -190,6 +223,10 @@ func ExplainFromLogicalPlan(lp LogicalPlan, ruleID string) (string, error) {
 }
 
 func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources map[string]map[string]any, streamsFromStmt []string, index int) (node.Emitter, int, error) {
+	// The data source shared by the branches of UNION ALL is built once
+	if ds, ok := lp.(*DataSourcePlan); ok && ds.built != nil {
+		return ds.built, index, nil
+	}
 	var inputs []node.Emitter
 	newIndex := index
 	for _, c := range lp.Children() {
@@ -226,6 +263,7 @@ func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources ma
 		} else {
 			newIndex += indexInc
 		}
+		t.built = op
 	case *WatermarkPlan:
 		op = node.NewWatermarkOp(fmt.Sprintf("%d_watermark", newIndex), t.SendWatermark, t.Emitters, options)
 	case *AnalyticFuncsPlan:
@@ -291,6 +329,15 @@ func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources ma
 		op = node.NewDedupTriggerNode(fmt.Sprintf("%d_dedup_trigger", newIndex), options, t.aliasName, t.startField.Name, t.endField.Name, t.nowField.Name, t.expire)
 	case *LookupPlan:
 		op, err = planLookupSource(tp.GetContext(), t, options)
+	case *SemiJoinPlan:
+		semi := &node.SemiJoin{Field: t.field, Table: t.table, Not: t.not}
+		if t.isLookup {
+			semi.Condition = t.lookupCondition
+			op, err = planLookupSemiJoin(tp.GetContext(), fmt.Sprintf("%d_semijoin", newIndex), t, semi, options)
+		} else {
+			semi.Condition = t.condition
+			op, err = node.NewSemiJoinNode(fmt.Sprintf("%d_semijoin", newIndex), semi, t.size, options)
+		}
 	case *JoinAlignPlan:
 		op, err = node.NewJoinAlignNode(fmt.Sprintf("%d_join_aligner", newIndex), t.Emitters, t.Sizes, options)
 	case *JoinPlan:
@@ -306,11 +353,16 @@ func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources ma
 	case *OrderPlan:
 		op = Transform(&operator.OrderOp{SortFields: t.SortFields}, fmt.Sprintf("%d_order", newIndex), options)
 	case *ProjectPlan:
-		op = Transform(&operator.ProjectOp{ColNames: t.colNames, AliasNames: t.aliasNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, ExprNames: t.exprNames, SendMeta: t.sendMeta, SendNil: t.sendNil, LimitCount: t.limitCount, EnableLimit: t.enableLimit}, fmt.Sprintf("%d_project", newIndex), options)
+		op = Transform(&operator.ProjectOp{ColNames: t.colNames, AliasNames: t.aliasNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, ExprNames: t.exprNames, SendMeta: t.sendMeta, SendNil: t.sendNil, LimitCount: t.limitCount, EnableLimit: t.enableLimit, Distinct: t.distinct}, fmt.Sprintf("%d_project", newIndex), options)
 	case *ProjectSetPlan:
 		op = Transform(&operator.ProjectSetOperator{SrfMapping: t.SrfMapping, LimitCount: t.limitCount, EnableLimit: t.enableLimit}, fmt.Sprintf("%d_projectset", newIndex), options)
 	case *WindowFuncPlan:
 		op = Transform(&operator.WindowFuncOperator{WindowFuncField: t.windowFuncField}, fmt.Sprintf("%d_windowFunc", newIndex), options)
+	case *SubQueryPlan:
+		// The output of the subquery is the input of the outer query
+		return inputs[0], newIndex, nil
+	case *UnionPlan:
+		op = Transform(&operator.UnionOp{ColNames: t.colNames, SendNil: t.sendNil}, fmt.Sprintf("%d_union", newIndex), options)
 	default:
 		err = fmt.Errorf("unknown logical plan %v", t)
 	}
@@ -319,6 +371,9 @@ func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources ma
 	}
 	if onode, ok := op.(node.OperatorNode); ok {
 		tp.AddOperator(inputs, onode)
+		if l := stateLayoutOf(lp); l != nil {
+			tp.SetStateLayout(onode.GetName(), l)
+		}
 	}
 	return op, newIndex, nil
 }
@@ -350,6 +405,22 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 			err = errorx.NewWithCode(errorx.PlanError, err.Error())
 		}
 	}()
+	p, err := buildLogicalPlan(stmt, opt, store, stmtCheckers)
+	if err != nil {
+		return nil, err
+	}
+	return optimize(p, opt)
+}
+
+// buildLogicalPlan builds the plan tree of the statement without optimization.
+// The subqueries and union branches are built recursively into the same tree.
+func buildLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.KeyValue, checkers []validateOptStmt) (LogicalPlan, error) {
+	if len(stmt.Unions) > 0 {
+		return createUnionPlan(stmt, opt, store, checkers)
+	}
+	if sq := getSubQuery(stmt); sq != nil && stmt.Joins != nil {
+		return nil, fmt.Errorf("subquery %s cannot be joined with other sources", sq.Name)
+	}
 	dimensions := stmt.Dimensions
 	var (
 		p        LogicalPlan
@@ -364,11 +435,15 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 		ds                  ast.Dimensions
 	)
 
-	streamStmts, analyticFuncs, analyticFieldFuncs, err := decorateStmt(stmt, store, opt)
+	streamStmts, analyticFuncs, analyticFieldFuncs, err := decorateStmt(stmt, store, opt, checkers)
 	if err != nil {
 		return nil, err
 	}
 	rewriteRes := rewriteStmt(stmt, opt)
+	semiJoins, err := extractSemiJoins(stmt, store, opt)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, sInfo := range streamStmts {
 		if sInfo.stmt.StreamType == ast.TypeTable && sInfo.stmt.Options.KIND == ast.StreamKindLookup {
@@ -393,23 +468,26 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 			} else {
 				scanTableChildren = append(scanTableChildren, p)
 				scanTableEmitters = append(scanTableEmitters, string(sInfo.stmt.Name))
-				tableSize := sInfo.stmt.Options.RETAIN_SIZE
-				if tableSize == 0 {
-					switch sInfo.stmt.Options.TYPE {
-					// If retainSize is not set, file table will try to read all the content in it
-					case "", "file":
-						// TODO use interface to determine if the table is batch like file
-						tableSize = MaxRetainSize
-					default:
-						tableSize = DefaultRetainSize
-					}
-				}
-				scanTableSizes = append(scanTableSizes, tableSize)
+				scanTableSizes = append(scanTableSizes, getRetainSize(sInfo.stmt.Options))
 			}
 		}
 	}
 	hasWindow := dimensions != nil && dimensions.GetWindow() != nil
-	if opt.IsEventTime {
+	sq := getSubQuery(stmt)
+	if sq != nil {
+		if opt.IsEventTime && hasWindow {
+			return nil, fmt.Errorf("event time window is not supported on subquery %s", sq.Name)
+		}
+		child, err := buildLogicalPlan(sq.Query, opt, store, checkers)
+		if err != nil {
+			return nil, err
+		}
+		p = SubQueryPlan{name: sq.Name}.Init()
+		p.SetChildren([]LogicalPlan{child})
+		children = append(children, p)
+	}
+	// The watermark of the subquery is generated inside it
+	if opt.IsEventTime && sq == nil {
 		p = WatermarkPlan{
 			SendWatermark: hasWindow,
 			Emitters:      streamEmitters,
@@ -457,29 +535,7 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 				children = []LogicalPlan{incWp}
 				p = incWp
 			} else {
-				wp := WindowPlan{
-					wtype:       w.WindowType,
-					length:      int(w.Length.Val),
-					isEventTime: opt.IsEventTime,
-				}.Init()
-				if w.Delay != nil {
-					wp.delay = w.Delay.Val
-				}
-				if w.Interval != nil {
-					wp.interval = int(w.Interval.Val)
-				} else if w.WindowType == ast.COUNT_WINDOW {
-					// if no interval value is set, and it's a count window, then set interval to length value.
-					wp.interval = int(w.Length.Val)
-				}
-				if w.TimeUnit != nil {
-					wp.timeUnit = w.TimeUnit.Val
-				}
-				if w.Filter != nil {
-					wp.condition = w.Filter
-				}
-				if w.TriggerCondition != nil {
-					wp.triggerCondition = w.TriggerCondition
-				}
+				wp := newWindowPlan(w, opt.IsEventTime)
 				// TODO calculate limit
 				// TODO incremental aggregate
 				wp.SetChildren(children)
@@ -534,6 +590,16 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 			children = []LogicalPlan{p}
 		}
 	}
+	// The subqueries are evaluated before the filter so that the filter can refer to their results
+	for _, sj := range semiJoins {
+		if sj.source != nil {
+			sj.SetChildren(append(children, sj.source))
+		} else {
+			sj.SetChildren(children)
+		}
+		children = []LogicalPlan{sj}
+		p = sj
+	}
 	if stmt.Condition != nil {
 		p = FilterPlan{
 			condition: stmt.Condition,
@@ -631,6 +697,7 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 			sendNil:     opt.SendNil,
 			enableLimit: enableLimit,
 			limitCount:  limitCount,
+			distinct:    stmt.Distinct,
 		}.Init()
 		p.SetChildren(children)
 		children = []LogicalPlan{p}
@@ -651,7 +718,103 @@ func createLogicalPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.
 		p.SetChildren(children)
 	}
 
-	return optimize(p, opt)
+	return p, nil
+}
+
+func newWindowPlan(w *ast.Window, isEventTime bool) *WindowPlan {
+	wp := WindowPlan{
+		wtype:       w.WindowType,
+		length:      int(w.Length.Val),
+		isEventTime: isEventTime,
+	}.Init()
+	if w.Delay != nil {
+		wp.delay = w.Delay.Val
+	}
+	if w.Interval != nil {
+		wp.interval = int(w.Interval.Val)
+	} else if w.WindowType == ast.COUNT_WINDOW {
+		// if no interval value is set, and it's a count window, then set interval to length value.
+		wp.interval = int(w.Length.Val)
+	}
+	if w.TimeUnit != nil {
+		wp.timeUnit = w.TimeUnit.Val
+	}
+	if w.Filter != nil {
+		wp.condition = w.Filter
+	}
+	if w.TriggerCondition != nil {
+		wp.triggerCondition = w.TriggerCondition
+	}
+	return wp
+}
+
+// createUnionPlan plans each branch of UNION ALL separately and merges them by a union plan. The branches only filter
+// and project the rows, so that they are merged before the window and the sink which are shared. The same stream can
+// be used in many branches and is read by one source node for all of them.
+// A window after the last branch like `... UNION ALL SELECT ... FROM b GROUP BY TumblingWindow(ss, 10)` applies to
+// the merged rows of all branches, like ORDER BY after the last branch in standard SQL.
+func createUnionPlan(stmt *ast.SelectStatement, opt *def.RuleOption, store kv.KeyValue, checkers []validateOptStmt) (LogicalPlan, error) {
+	first := *stmt
+	first.Unions = nil
+	branches := append([]*ast.SelectStatement{&first}, stmt.Unions...)
+	var w *ast.Window
+	if last := branches[len(branches)-1]; last.Dimensions != nil && len(last.Dimensions.GetGroups()) == 0 && last.Having == nil {
+		if w = last.Dimensions.GetWindow(); w != nil {
+			if opt.IsEventTime {
+				return nil, fmt.Errorf("event time window is not supported after UNION ALL")
+			}
+			b := *last
+			b.Dimensions = nil
+			branches[len(branches)-1] = &b
+		}
+	}
+	streams := make(map[string]struct{})
+	repeated := false
+	for _, branch := range branches {
+		if branch.Dimensions != nil || branch.Having != nil || xsql.WithAggFields(branch) {
+			return nil, fmt.Errorf("group by and aggregate are not supported in the branches of UNION ALL. Put the window after the last branch to apply it to all branches such as SELECT ... UNION ALL SELECT ... GROUP BY TumblingWindow(ss, 10), or aggregate in a subquery such as SELECT count(*) FROM (SELECT ... UNION ALL SELECT ...) AS u GROUP BY TumblingWindow(ss, 10)")
+		}
+		for _, s := range xsql.GetSourceStreams(branch) {
+			if _, ok := streams[s]; ok {
+				repeated = true
+			}
+			streams[s] = struct{}{}
+		}
+	}
+	// The shared source cannot decode the columns as the alias of one branch
+	branchOpt := opt
+	if repeated && opt.PlanOptimizeStrategy != nil && opt.PlanOptimizeStrategy.EnableAliasPushdown {
+		o := *opt
+		strategy := *opt.PlanOptimizeStrategy
+		strategy.EnableAliasPushdown = false
+		o.PlanOptimizeStrategy = &strategy
+		branchOpt = &o
+	}
+	children := make([]LogicalPlan, 0, len(branches))
+	for _, branch := range branches {
+		child, err := buildLogicalPlan(branch, branchOpt, store, checkers)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	var p LogicalPlan = UnionPlan{
+		colNames: unionColNames(branches),
+		sendNil:  opt.SendNil,
+	}.Init()
+	p.SetChildren(children)
+	if w != nil {
+		wp := newWindowPlan(w, false)
+		wp.SetChildren([]LogicalPlan{p})
+		// Send all the merged rows in the window
+		p = ProjectPlan{
+			fields:   unionFields(branches),
+			sendMeta: opt.SendMetaToSink,
+			sendNil:  opt.SendNil,
+		}.Init()
+		p.SetChildren([]LogicalPlan{wp})
+	}
+	return p, nil
 }
 
 // extractSRFMapping extracts the set-returning-function in the field
@@ -688,9 +851,11 @@ func extractWindowFuncFields(stmt *ast.SelectStatement) []*ast.Field {
 		case *ast.Call:
 			if wf.FuncType == ast.FuncTypeWindow {
 				newWf := &ast.Call{
-					Name:     wf.Name,
-					FuncType: wf.FuncType,
-					Args:     wf.Args,
+					Name:       wf.Name,
+					FuncType:   wf.FuncType,
+					Args:       wf.Args,
+					Partition:  wf.Partition,
+					SortFields: wf.SortFields,
 				}
 				windowFunctionCount++
 				newName := fmt.Sprintf("wf_%s_%d", wf.Name, windowFunctionCount)
@@ -764,7 +929,7 @@ func extractNodeIncAgg(node ast.Node, index *int) ([]*ast.Field, bool) {
 		case *ast.Call:
 			if f.FuncType == ast.FuncTypeAgg {
 				hasAgg = true
-				if !function.IsSupportedIncAgg(f.Name) {
+				if !function.IsSupportedIncAgg(f.Name) || (f.Distinct && !function.IsSupportedIncDistinctAgg(f.Name)) {
 					canIncAgg = false
 					return false
 				}
@@ -785,8 +950,12 @@ func extractNodeIncAgg(node ast.Node, index *int) ([]*ast.Field, bool) {
 			if aggFunc.FuncType == ast.FuncTypeAgg {
 				if function.IsSupportedIncAgg(aggFunc.Name) {
 					*index++
+					incName := fmt.Sprintf("inc_%s", aggFunc.Name)
+					if aggFunc.Distinct {
+						incName = fmt.Sprintf("inc_distinct_%s", aggFunc.Name)
+					}
 					newAggFunc := &ast.Call{
-						Name:     fmt.Sprintf("inc_%s", aggFunc.Name),
+						Name:     incName,
 						FuncType: ast.FuncTypeScalar,
 						Args:     aggFunc.Args,
 						FuncId:   *index,
@@ -814,6 +983,7 @@ func rewriteIntoBypass(newFieldRef *ast.FieldRef, f *ast.Call) {
 	f.FuncType = ast.FuncTypeScalar
 	f.Args = []ast.Expr{newFieldRef}
 	f.Name = "bypass"
+	f.Distinct = false
 }
 
 func supportedWindowType(window *ast.Window) bool {
@@ -843,7 +1013,8 @@ func rewriteIfPushdownAlias(stmt *ast.SelectStatement, opt *def.RuleOption) map[
 	if !opt.PlanOptimizeStrategy.EnableAliasPushdown {
 		return nil
 	}
-	if hasWildcard(stmt) {
+	// There is no data source to decode the alias for subquery
+	if hasWildcard(stmt) || getSubQuery(stmt) != nil {
 		return nil
 	}
 	dsColAliasMapping := make(map[ast.StreamName]map[string]string)
@@ -22,6 +22,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	store2 "github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/topo"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/graph"
@@ -109,7 +110,7 @@ func PlanByGraph(rule *def.Rule) (*topo.Topo, error) {
 			if _, ok := ruleGraph.Topo.Edges[nodeName]; ok {
 				return nil, fmt.Errorf("sink %s has edge", nodeName)
 			}
-			cn, err := SinkToComp(tp, gn.NodeType, nodeName, gn.Props, rule, len(sourceNames))
+			cn, err := SinkToComp(tp, gn.NodeType, nodeName, sinkPropsInNamespace(rule.Id, gn.Props), rule, len(sourceNames))
 			if err != nil {
 				return nil, err
 			}
@@ -458,6 +459,7 @@ func parseSource(nodeName string, gn *def.GraphNode, rule *def.Rule, tp *topo.To
 			if err != nil {
 				return nil, ILLEGAL, "", nil, err
 			}
+			store = namespace.KV(store, namespace.Of(rule.Id))
 		}
 		streamStmt, e := xsql.GetDataSource(store, sourceMeta.SourceName)
 		if e != nil {
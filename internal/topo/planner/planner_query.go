@@ -0,0 +1,177 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/operator"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+// Query is an ad-hoc query which runs a bounded select statement against a table synchronously.
+// It reuses the operators of the rule but has no rule lifecycle.
+type Query struct {
+	id    string
+	table *streamInfo
+	ops   []node.UnOperation
+	limit int
+}
+
+// PlanQuery plans the select statement of an ad-hoc query. Only a single scan table or lookup table can be queried.
+func PlanQuery(id string, stmt *ast.SelectStatement, store kv.KeyValue) (*Query, error) {
+	if len(stmt.Unions) > 0 || len(stmt.Joins) > 0 || getSubQuery(stmt) != nil || len(stmt.Sources) != 1 {
+		return nil, errors.New("ad-hoc query only supports selecting from a single table")
+	}
+	if stmt.Dimensions != nil && stmt.Dimensions.GetWindow() != nil {
+		return nil, errors.New("ad-hoc query does not support window")
+	}
+	t, ok := stmt.Sources[0].(*ast.Table)
+	if !ok {
+		return nil, errors.New("ad-hoc query only supports selecting from a single table")
+	}
+	streamStmt, err := xsql.GetDataSource(store, t.Name)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get table %s, please check if table is created", t.Name)
+	}
+	if streamStmt.StreamType != ast.TypeTable {
+		return nil, fmt.Errorf("ad-hoc query only supports tables, %s is a stream", t.Name)
+	}
+	si, err := convertStreamInfo(streamStmt)
+	if err != nil {
+		return nil, err
+	}
+	opt := def.GetDefaultRule(id, "").Options
+	lp, err := buildLogicalPlan(stmt, opt, store, queryCheckers)
+	if err != nil {
+		return nil, errorx.NewWithCode(errorx.PlanError, err.Error())
+	}
+	lp, err = optimize(lp, opt)
+	if err != nil {
+		return nil, errorx.NewWithCode(errorx.PlanError, err.Error())
+	}
+	// The plan is a chain without data source, build the operators from the bottom up
+	var plans []LogicalPlan
+	for p := lp; p != nil; {
+		plans = append(plans, p)
+		switch len(p.Children()) {
+		case 0:
+			p = nil
+		case 1:
+			p = p.Children()[0]
+		default:
+			return nil, errors.New("ad-hoc query only supports selecting from a single table")
+		}
+	}
+	ops := make([]node.UnOperation, 0, len(plans))
+	for i := len(plans) - 1; i >= 0; i-- {
+		op, err := queryOp(plans[i])
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	limit := 0
+	if stmt.Limit != nil {
+		limit = int(stmt.Limit.(*ast.LimitExpr).LimitCount.Val)
+	}
+	return &Query{id: id, table: si, ops: ops, limit: limit}, nil
+}
+
+func queryOp(lp LogicalPlan) (node.UnOperation, error) {
+	switch t := lp.(type) {
+	case *AnalyticFuncsPlan:
+		return &operator.AnalyticFuncsOp{Funcs: t.funcs, FieldFuncs: t.fieldFuncs}, nil
+	case *FilterPlan:
+		t.ExtractStateFunc()
+		return &operator.FilterOp{Condition: t.condition, StateFuncs: t.stateFuncs}, nil
+	case *AggregatePlan:
+		return &operator.AggregateOp{Dimensions: t.dimensions}, nil
+	case *HavingPlan:
+		t.ExtractStateFunc()
+		return &operator.HavingOp{Condition: t.condition, StateFuncs: t.stateFuncs, IsIncAgg: t.IsIncAgg}, nil
+	case *OrderPlan:
+		return &operator.OrderOp{SortFields: t.SortFields}, nil
+	case *ProjectPlan:
+		return &operator.ProjectOp{ColNames: t.colNames, AliasNames: t.aliasNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, ExprNames: t.exprNames, SendMeta: t.sendMeta, SendNil: t.sendNil, LimitCount: t.limitCount, EnableLimit: t.enableLimit, Distinct: t.distinct}, nil
+	case *ProjectSetPlan:
+		return &operator.ProjectSetOperator{SrfMapping: t.SrfMapping, LimitCount: t.limitCount, EnableLimit: t.enableLimit}, nil
+	case *WindowFuncPlan:
+		return &operator.WindowFuncOperator{WindowFuncField: t.windowFuncField}, nil
+	default:
+		return nil, fmt.Errorf("ad-hoc query does not support %T", lp)
+	}
+}
+
+// Run reads all the rows of the table and runs the operators over them as one collection.
+// Lookup tables are read from the table instance. Scan tables are read from the rows kept by the running rules.
+func (q *Query) Run(ctx api.StreamContext) ([]map[string]any, error) {
+	store, err := state.CreateStore(q.id, def.AtMostOnce)
+	if err != nil {
+		return nil, err
+	}
+	ctx = ctx.WithMeta(q.id, "query", store)
+	var rows []map[string]any
+	name := string(q.table.stmt.Name)
+	if q.table.stmt.Options.KIND == ast.StreamKindLookup {
+		rows, err = lookup.Scan(ctx, name)
+	} else {
+		rows, err = lookup.ScanTable(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := timex.GetNow()
+	tuples := make([]xsql.Row, len(rows))
+	for i, r := range rows {
+		tuples[i] = &xsql.Tuple{Emitter: name, Message: r, Timestamp: now}
+	}
+	var data any = &xsql.WindowTuples{Content: tuples}
+	fv, afv := xsql.NewFunctionValuersForOp(ctx)
+	for _, op := range q.ops {
+		data = op.Apply(ctx, data, fv, afv)
+		switch r := data.(type) {
+		case nil:
+			return []map[string]any{}, nil
+		case error:
+			return nil, r
+		}
+	}
+	var result []map[string]any
+	switch r := data.(type) {
+	case xsql.Collection:
+		result = r.ToMaps()
+	case xsql.Row:
+		result = []map[string]any{r.ToMap()}
+	default:
+		return nil, fmt.Errorf("invalid query result %v", data)
+	}
+	// The project operator only stops projecting at the limit, drop the rest rows
+	if q.limit > 0 && len(result) > q.limit {
+		result = result[:q.limit]
+	}
+	return result, nil
+}
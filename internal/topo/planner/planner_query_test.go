@@ -0,0 +1,170 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/io/memory/pubsub"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/lookup"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func TestPlanQuery(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	streamSqls := map[string]string{
+		"queryStream": `CREATE STREAM queryStream () WITH (DATASOURCE="queryStream", TYPE="memory");`,
+		"queryTable":  `CREATE TABLE queryTable () WITH (DATASOURCE="queryTable", TYPE="memory", KIND="lookup", KEY="id");`,
+		"queryTable2": `CREATE TABLE queryTable2 () WITH (DATASOURCE="queryTable2", TYPE="memory", KIND="lookup", KEY="id");`,
+		"queryScan":   `CREATE TABLE queryScan () WITH (DATASOURCE="queryScan", TYPE="memory");`,
+	}
+	types := map[string]ast.StreamType{
+		"queryStream": ast.TypeStream,
+		"queryTable":  ast.TypeTable,
+		"queryTable2": ast.TypeTable,
+		"queryScan":   ast.TypeTable,
+	}
+	for name, sql := range streamSqls {
+		s, err := json.Marshal(&xsql.StreamInfo{
+			StreamType: types[name],
+			Statement:  sql,
+		})
+		require.NoError(t, err)
+		require.NoError(t, kv.Set(name, string(s)))
+	}
+	defer func() {
+		for name := range streamSqls {
+			_ = kv.Delete(name)
+		}
+	}()
+
+	errTests := []struct {
+		name string
+		sql  string
+		err  string
+	}{
+		{
+			name: "stream",
+			sql:  "SELECT * FROM queryStream",
+			err:  "ad-hoc query only supports tables, queryStream is a stream",
+		},
+		{
+			name: "join",
+			sql:  "SELECT * FROM queryTable INNER JOIN queryTable2 ON queryTable.id = queryTable2.id",
+			err:  "ad-hoc query only supports selecting from a single table",
+		},
+		{
+			name: "window",
+			sql:  "SELECT count(*) FROM queryTable GROUP BY TUMBLINGWINDOW(ss, 10)",
+			err:  "ad-hoc query does not support window",
+		},
+		{
+			name: "not exist",
+			sql:  "SELECT * FROM queryNone",
+			err:  "fail to get table queryNone, please check if table is created",
+		},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.GetStatementFromSql(tt.sql)
+			require.NoError(t, err)
+			_, err = PlanQuery("test", stmt, kv)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	require.NoError(t, lookup.CreateInstance("queryTable", "memory", &ast.Options{
+		DATASOURCE: "queryTable",
+		TYPE:       "memory",
+		KIND:       "lookup",
+		KEY:        "id",
+	}))
+	defer func() {
+		_ = lookup.DropInstance("queryTable")
+	}()
+	ctx := kctx.Background()
+	// wait for the table to subscribe
+	time.Sleep(100 * time.Millisecond)
+	pubsub.Produce(ctx, "queryTable", &xsql.Tuple{Message: map[string]any{"id": 1, "kind": "a", "value": 10}})
+	pubsub.Produce(ctx, "queryTable", &xsql.Tuple{Message: map[string]any{"id": 2, "kind": "b", "value": 20}})
+	pubsub.Produce(ctx, "queryTable", &xsql.Tuple{Message: map[string]any{"id": 3, "kind": "a", "value": 30}})
+	pubsub.Produce(ctx, "queryTable", &xsql.Tuple{Message: map[string]any{"id": 4, "kind": "c", "value": 5}})
+	time.Sleep(100 * time.Millisecond)
+
+	tests := []struct {
+		name   string
+		sql    string
+		result []map[string]any
+	}{
+		{
+			name: "filter order limit",
+			sql:  "SELECT id, value FROM queryTable WHERE value > 8 ORDER BY value DESC LIMIT 2",
+			result: []map[string]any{
+				{"id": 3, "value": 30},
+				{"id": 2, "value": 20},
+			},
+		},
+		{
+			name: "aggregate",
+			sql:  "SELECT kind, sum(value) AS total FROM queryTable GROUP BY kind HAVING count(*) > 1",
+			result: []map[string]any{
+				{"kind": "a", "total": int64(40)},
+			},
+		},
+		{
+			name: "aggregate all",
+			sql:  "SELECT count(*) AS c, max(value) AS m FROM queryTable",
+			result: []map[string]any{
+				{"c": 4, "m": int64(30)},
+			},
+		},
+		{
+			name:   "empty",
+			sql:    "SELECT * FROM queryTable WHERE value > 100",
+			result: []map[string]any{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.GetStatementFromSql(tt.sql)
+			require.NoError(t, err)
+			q, err := PlanQuery("test", stmt, kv)
+			require.NoError(t, err)
+			result, err := q.Run(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	// scan tables are read from the rows kept by the running rules
+	stmt, err := xsql.GetStatementFromSql("SELECT count(*) AS c FROM queryScan")
+	require.NoError(t, err)
+	q, err := PlanQuery("test", stmt, kv)
+	require.NoError(t, err)
+	_, err = q.Run(ctx)
+	require.Error(t, err)
+	lookup.SetScanTable("queryScan", "rule1", []map[string]any{{"id": 1}, {"id": 2}})
+	defer lookup.RemoveScanTable("queryScan", "rule1")
+	result, err := q.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"c": 2}}, result)
+}
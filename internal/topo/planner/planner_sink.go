@@ -23,6 +23,8 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/io"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
 	"github.com/lf-edge/ekuiper/v2/internal/topo"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/node/conf"
@@ -39,7 +41,7 @@ func buildActions(tp *topo.Topo, rule *def.Rule, inputs []node.Emitter, streamCo
 			if !ok {
 				return fmt.Errorf("expect map[string]interface{} type for the action properties, but found %v", action)
 			}
-			props, err := conf.OverwriteByConnectionConf(name, props)
+			props, err := conf.OverwriteByConnectionConf(name, sinkPropsInNamespace(rule.Id, props))
 			if err != nil {
 				return err
 			}
@@ -54,6 +56,22 @@ func buildActions(tp *topo.Topo, rule *def.Rule, inputs []node.Emitter, streamCo
 	return nil
 }
 
+// sinkPropsInNamespace refers the connection selector to the connection in the namespace of the rule.
+// The props are copied to keep the rule definition unchanged.
+func sinkPropsInNamespace(ruleId string, props map[string]any) map[string]any {
+	ns := namespace.Of(ruleId)
+	selId, ok := props[conf.ConnectionSelector].(string)
+	if !ok || ns == namespace.Default {
+		return props
+	}
+	result := make(map[string]any, len(props))
+	for k, v := range props {
+		result[k] = v
+	}
+	result[conf.ConnectionSelector] = namespace.Qualify(ns, selId)
+	return result
+}
+
 func PlanSinkOps(tp *topo.Topo, inputs []node.Emitter, cn node.CompNode) {
 	newInputs := inputs
 	var preSink node.DataSinkNode
@@ -80,6 +98,12 @@ func SinkToComp(tp *topo.Topo, sinkType string, sinkName string, props map[strin
 	if s == nil {
 		return nil, fmt.Errorf("sink %s is not defined", sinkType)
 	}
+	// The sink gets the secret values while the logs print the references
+	refProps := props
+	props, err := secret.Resolve(props)
+	if err != nil {
+		return nil, err
+	}
 	commonConf, err := node.ParseConf(tp.GetContext().GetLogger(), props)
 	if err != nil {
 		return nil, fmt.Errorf("fail to parse sink configuration: %v", err)
@@ -93,7 +117,7 @@ func SinkToComp(tp *topo.Topo, sinkType string, sinkName string, props map[strin
 	if err = s.Provision(tp.GetContext(), props); err != nil {
 		return nil, err
 	}
-	tp.GetContext().GetLogger().Infof("provision sink %s with props %+v", sinkName, props)
+	tp.GetContext().GetLogger().Infof("provision sink %s with props %+v", sinkName, refProps)
 
 	result := &SinkCompNode{
 		name:  sinkName,
@@ -123,7 +147,7 @@ func SinkToComp(tp *topo.Topo, sinkType string, sinkName string, props map[strin
 		if err = s.Provision(tp.GetContext(), props); err != nil {
 			return nil, err
 		}
-		tp.GetContext().GetLogger().Infof("provision sink %s with props %+v", sinkName, props)
+		tp.GetContext().GetLogger().Infof("provision sink %s with props %+v", sinkName, refProps)
 
 		cacheOp, err := node.NewCacheOp(tp.GetContext(), fmt.Sprintf("%s_cache", sinkName), rule.Options, &commonConf.SinkConf)
 		if err != nil {
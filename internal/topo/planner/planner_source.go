@@ -21,6 +21,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/io"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/namespace"
 	"github.com/lf-edge/ekuiper/v2/internal/topo"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
 	nodeConf "github.com/lf-edge/ekuiper/v2/internal/topo/node/conf"
@@ -65,7 +66,9 @@ func transformSourceNode(ctx api.StreamContext, t *DataSourcePlan, mockSourcesPr
 
 func splitSource(ctx api.StreamContext, t *DataSourcePlan, ss api.Source, options *def.RuleOption, mockProps map[string]any, index int, ruleId string, pp node.UnOperation) (node.DataSourceNode, []node.OperatorNode, int, error) {
 	// Get all props
-	props := nodeConf.GetSourceConf(t.streamStmt.Options.TYPE, t.streamStmt.Options)
+	// The named connections and shared sources are isolated by the namespace of the rule
+	ns := namespace.Of(ruleId)
+	props := nodeConf.GetSourceConfInNamespace(ns, t.streamStmt.Options.TYPE, t.streamStmt.Options)
 	sp := &SourcePropsForSplit{}
 	if len(mockProps) > 0 {
 		for k, v := range mockProps {
@@ -90,6 +93,9 @@ func splitSource(ctx api.StreamContext, t *DataSourcePlan, ss api.Source, option
 	var ops []node.OperatorNode
 	// If having unique connection id AND unique sub id for each connection, need to share the sub node
 	if conId == "" {
+		if ps, ok := ss.(model.PushDownSource); ok && (len(t.pushedConditions) > 0 || len(t.pushedColumns) > 0) {
+			ps.PushDown(props, t.pushedConditions, t.pushedColumns)
+		}
 		srcConnNode, err = node.NewSourceNode(ctx, string(t.name), ss, props, options)
 		if err != nil {
 			return nil, nil, 0, err
@@ -194,9 +200,10 @@ func splitSource(ctx api.StreamContext, t *DataSourcePlan, ss api.Source, option
 
 	if t.streamStmt.Options.SHARED {
 		// Create subtopo in the end to avoid errors in the middle
-		srcSubtopo, existed := topo.GetOrCreateSubTopo(string(t.name))
+		subName := namespace.Qualify(ns, string(t.name))
+		srcSubtopo, existed := topo.GetOrCreateSubTopo(subName)
 		if !existed {
-			ctx.GetLogger().Infof("Create SubTopo %s", string(t.name))
+			ctx.GetLogger().Infof("Create SubTopo %s", subName)
 			srcSubtopo.AddSrc(srcConnNode)
 			subInputs := []node.Emitter{srcSubtopo}
 			for _, e := range ops {
@@ -319,7 +326,7 @@ func planLookupSource(ctx api.StreamContext, t *LookupPlan, ruleOption *def.Rule
 	if si == nil {
 		return nil, fmt.Errorf("lookup source type %s not found", t.options.TYPE)
 	}
-	props := nodeConf.GetSourceConf(t.options.TYPE, t.options)
+	props := nodeConf.GetSourceConfInNamespace(namespace.Of(ctx.GetRuleId()), t.options.TYPE, t.options)
 	switch si.(type) {
 	case api.LookupSource:
 		return node.NewLookupNode(ctx, t.joinExpr.Name, false, t.fields, t.keys, t.joinExpr.JoinType, t.valvars, t.options, ruleOption, props)
@@ -331,3 +338,24 @@ func planLookupSource(ctx api.StreamContext, t *LookupPlan, ruleOption *def.Rule
 	}
 	return nil, fmt.Errorf("lookup source type %s is found but not a valid lookup source", t.options.TYPE)
 }
+
+func planLookupSemiJoin(ctx api.StreamContext, name string, t *SemiJoinPlan, semi *node.SemiJoin, ruleOption *def.RuleOption) (node.Emitter, error) {
+	si, err := io.LookupSource(t.options.TYPE)
+	if err != nil {
+		return nil, err
+	}
+	if si == nil {
+		return nil, fmt.Errorf("lookup source type %s not found", t.options.TYPE)
+	}
+	props := nodeConf.GetSourceConfInNamespace(namespace.Of(ctx.GetRuleId()), t.options.TYPE, t.options)
+	switch si.(type) {
+	case api.LookupSource:
+		return node.NewLookupSemiJoinNode(ctx, name, false, t.fields, t.keys, t.valvars, semi, t.options, ruleOption, props)
+	case api.LookupBytesSource:
+		if t.options.FORMAT == "" {
+			return nil, fmt.Errorf("lookup source type %s must specify format", t.options.TYPE)
+		}
+		return node.NewLookupSemiJoinNode(ctx, name, true, t.fields, t.keys, t.valvars, semi, t.options, ruleOption, props)
+	}
+	return nil, fmt.Errorf("lookup source type %s is found but not a valid lookup source", t.options.TYPE)
+}
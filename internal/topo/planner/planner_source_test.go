@@ -396,6 +396,43 @@ func TestPlanTopo(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test union of the same stream",
+			sql:  `SELECT a FROM src1 WHERE a > 1 UNION ALL SELECT b AS a FROM src1 WHERE b > 2`,
+			topo: &def.PrintableTopo{
+				Sources: []string{"source_src1"},
+				Edges: map[string][]any{
+					"source_src1": {
+						"op_2_decoder",
+					},
+					"op_2_decoder": {
+						"op_3_filter",
+						"op_5_filter",
+					},
+					"op_3_filter": {
+						"op_4_project",
+					},
+					"op_4_project": {
+						"op_7_union",
+					},
+					"op_5_filter": {
+						"op_6_project",
+					},
+					"op_6_project": {
+						"op_7_union",
+					},
+					"op_7_union": {
+						"op_logToMemory_0_0_transform",
+					},
+					"op_logToMemory_0_0_transform": {
+						"op_logToMemory_0_1_encode",
+					},
+					"op_logToMemory_0_1_encode": {
+						"sink_logToMemory_0",
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -36,6 +36,7 @@ type ProjectPlan struct {
 	exprFields       ast.Fields
 	enableLimit      bool
 	limitCount       int
+	distinct         bool
 }
 
 func (p ProjectPlan) Init() *ProjectPlan {
@@ -85,6 +86,9 @@ func (p *ProjectPlan) BuildExplainInfo() {
 		}
 		info += " ]"
 	}
+	if p.distinct {
+		info += ", Distinct:true"
+	}
 	if p.enableLimit {
 		info += ", Limit:" + strconv.Itoa(p.limitCount)
 	}
@@ -0,0 +1,120 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"sort"
+
+	"github.com/lf-edge/ekuiper/v2/internal/binder/io"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	nodeConf "github.com/lf-edge/ekuiper/v2/internal/topo/node/conf"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/model"
+)
+
+type pushDownSource struct{}
+
+// pushDownSource offers the filter conditions and the read columns to the sources which implement model.PushDownSource.
+// The filter plan is kept, so the conditions are evaluated again in the rule.
+func (r *pushDownSource) optimize(plan LogicalPlan, _ *def.RuleOption) (LogicalPlan, error) {
+	r.search(plan, nil)
+	return plan, nil
+}
+
+func (r *pushDownSource) name() string {
+	return "pushDownSource"
+}
+
+func (r *pushDownSource) search(plan LogicalPlan, father LogicalPlan) {
+	if ds, ok := plan.(*DataSourcePlan); ok {
+		var condition ast.Expr
+		if f, ok := father.(*FilterPlan); ok {
+			condition = f.condition
+		}
+		ds.offerPushDown(condition)
+		return
+	}
+	for _, child := range plan.Children() {
+		r.search(child, plan)
+	}
+}
+
+// offerPushDown saves the conditions and columns accepted by the source so that they are applied in the source node
+func (p *DataSourcePlan) offerPushDown(condition ast.Expr) {
+	// The shared source instance serves multiple rules, so it cannot filter for one rule
+	if p.streamStmt == nil || p.streamStmt.Options == nil || p.streamStmt.Options.SHARED {
+		return
+	}
+	si, err := io.Source(p.streamStmt.Options.TYPE)
+	if err != nil || si == nil {
+		return
+	}
+	ps, ok := si.(model.PushDownSource)
+	if !ok {
+		return
+	}
+	props := nodeConf.GetSourceConf(p.streamStmt.Options.TYPE, p.streamStmt.Options)
+	sp := &SourcePropsForSplit{}
+	_ = cast.MapToStruct(props, sp)
+	if sp.SelId != "" {
+		return
+	}
+	var columns []string
+	if !p.isWildCard && len(p.streamFields) > 0 && len(p.colAliasMapping) == 0 {
+		columns = make([]string, 0, len(p.streamFields))
+		for k := range p.streamFields {
+			columns = append(columns, k)
+		}
+		sort.Strings(columns)
+	}
+	var conditions []ast.Expr
+	for _, c := range splitConjuncts(condition) {
+		if onlyColumns(c) {
+			conditions = append(conditions, c)
+		}
+	}
+	if len(conditions) == 0 && len(columns) == 0 {
+		return
+	}
+	p.pushedConditions, p.pushedColumns = ps.PushDown(props, conditions, columns)
+}
+
+// splitConjuncts splits the condition by AND
+func splitConjuncts(expr ast.Expr) []ast.Expr {
+	switch e := expr.(type) {
+	case nil:
+		return nil
+	case *ast.BinaryExpr:
+		if e.OP == ast.AND {
+			return append(splitConjuncts(e.LHS), splitConjuncts(e.RHS)...)
+		}
+	case *ast.ParenExpr:
+		return splitConjuncts(e.Expr)
+	}
+	return []ast.Expr{expr}
+}
+
+// onlyColumns checks if the expression does not refer to any alias which is calculated in the rule
+func onlyColumns(expr ast.Expr) bool {
+	result := true
+	ast.WalkFunc(expr, func(n ast.Node) bool {
+		if f, ok := n.(*ast.FieldRef); ok && !f.IsColumn() {
+			result = false
+		}
+		return result
+	})
+	return result
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/modules"
+)
+
+// mockPushDownSource accepts the conditions on field a and all the columns
+type mockPushDownSource struct{}
+
+func (m *mockPushDownSource) Provision(_ api.StreamContext, _ map[string]any) error { return nil }
+
+func (m *mockPushDownSource) Close(_ api.StreamContext) error { return nil }
+
+func (m *mockPushDownSource) Connect(_ api.StreamContext, _ api.StatusChangeHandler) error {
+	return nil
+}
+
+func (m *mockPushDownSource) Pull(_ api.StreamContext, _ time.Time, _ api.TupleIngest, _ api.ErrorIngest) {
+}
+
+func (m *mockPushDownSource) PushDown(_ map[string]any, conditions []ast.Expr, columns []string) ([]ast.Expr, []string) {
+	var accepted []ast.Expr
+	for _, c := range conditions {
+		if be, ok := c.(*ast.BinaryExpr); ok {
+			if f, ok := be.LHS.(*ast.FieldRef); ok && f.Name == "a" {
+				accepted = append(accepted, c)
+			}
+		}
+	}
+	return accepted, columns
+}
+
+func TestExplainPushDownSource(t *testing.T) {
+	modules.RegisterSource("pushdownMock", func() api.Source { return &mockPushDownSource{} })
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+	streamSqls := map[string]string{
+		"pushStream":       `CREATE STREAM pushStream (a BIGINT, b BIGINT, c BIGINT) WITH (DATASOURCE="src1", TYPE="pushdownMock");`,
+		"pushSharedStream": `CREATE STREAM pushSharedStream (a BIGINT, b BIGINT) WITH (DATASOURCE="src1", TYPE="pushdownMock", SHARED="true");`,
+	}
+	for name, sql := range streamSqls {
+		s, err := json.Marshal(&xsql.StreamInfo{
+			StreamType: ast.TypeStream,
+			Statement:  sql,
+		})
+		require.NoError(t, err)
+		require.NoError(t, kv.Set(name, string(s)))
+	}
+	defer func() {
+		for name := range streamSqls {
+			_ = kv.Delete(name)
+		}
+	}()
+
+	testcases := []struct {
+		sql     string
+		explain string
+	}{
+		{
+			sql: `select a, b from pushStream where a > 1 and b < 2`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ pushStream.a, pushStream.b ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ binaryExpr:{ binaryExpr:{ pushStream.a > 1 } AND binaryExpr:{ pushStream.b < 2 } } }, "}
+			{"op":"DataSourcePlan_2","info":"StreamName: pushStream, StreamFields:[ a, b ], PushedConditions:[ binaryExpr:{ pushStream.a > 1 } ], PushedColumns:[ a, b ]"}`,
+		},
+		{
+			sql: `select a + 1 as x from pushStream where x > 1`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ $$alias.x,aliasRef:binaryExpr:{ pushStream.a + 1 } ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ binaryExpr:{ $$alias.x,aliasRef:binaryExpr:{ pushStream.a + 1 } > 1 } }, "}
+			{"op":"DataSourcePlan_2","info":"StreamName: pushStream, StreamFields:[ a ], PushedColumns:[ a ]"}`,
+		},
+		{
+			sql: `select * from pushStream where b < 2`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ * ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ binaryExpr:{ pushStream.b < 2 } }, "}
+			{"op":"DataSourcePlan_2","info":"StreamName: pushStream, StreamFields:[ a, b, c ]"}`,
+		},
+		{
+			sql: `select a from pushSharedStream where a > 1`,
+			explain: `{"op":"ProjectPlan_0","info":"Fields:[ pushSharedStream.a ]"}
+	{"op":"FilterPlan_1","info":"Condition:{ binaryExpr:{ pushSharedStream.a > 1 } }, "}
+			{"op":"DataSourcePlan_2","info":"StreamName: pushSharedStream, StreamFields:[ a ]"}`,
+		},
+	}
+	for _, tc := range testcases {
+		stmt, err := xsql.NewParser(strings.NewReader(tc.sql)).Parse()
+		require.NoError(t, err)
+		p, err := createLogicalPlan(stmt, &def.RuleOption{Qos: 0}, kv)
+		require.NoError(t, err)
+		explain, err := ExplainFromLogicalPlan(p, "")
+		require.NoError(t, err)
+		require.Equal(t, tc.explain, explain, tc.sql)
+	}
+}
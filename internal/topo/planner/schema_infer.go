@@ -0,0 +1,290 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+// AnyType is the type of the output field whose type can only be decided at runtime
+const AnyType = "any"
+
+// OutputField is a field of the inferred output schema of a select statement
+type OutputField struct {
+	Name string `json:"name"`
+	// Type is printed in the syntax of the stream definition. It is AnyType if the type cannot be inferred statically.
+	Type string `json:"type"`
+	// Nullable is false only if the field always has a value such as a literal or count
+	Nullable  bool          `json:"nullable"`
+	FieldType ast.FieldType `json:"-"`
+}
+
+// schemaSource is a stream, table or subquery which the output fields are read from
+type schemaSource struct {
+	name  string
+	alias string
+	// fields is nil if the source is schemaless
+	fields []OutputField
+}
+
+type schemaInferrer struct {
+	sources []*schemaSource
+	// outputs are the inferred select fields which can be referred by alias
+	outputs []OutputField
+}
+
+// InferOutputSchema infers the output fields of the select statement statically by the schema of the streams
+// and the return types of the functions
+func InferOutputSchema(stmt *ast.SelectStatement, store kv.KeyValue) ([]OutputField, error) {
+	if len(stmt.Unions) > 0 {
+		return nil, fmt.Errorf("cannot infer the output schema of union")
+	}
+	si := &schemaInferrer{}
+	for _, s := range stmt.Sources {
+		t, ok := s.(*ast.Table)
+		if !ok {
+			continue
+		}
+		src, err := loadSchemaSource(t.Name, t.Alias, t.Query, store)
+		if err != nil {
+			return nil, err
+		}
+		si.sources = append(si.sources, src)
+	}
+	for _, j := range stmt.Joins {
+		src, err := loadSchemaSource(j.Name, j.Alias, nil, store)
+		if err != nil {
+			return nil, err
+		}
+		si.sources = append(si.sources, src)
+	}
+	for _, f := range stmt.Fields {
+		if w, ok := f.Expr.(*ast.Wildcard); ok {
+			fields, err := si.expandWildcard(w)
+			if err != nil {
+				return nil, err
+			}
+			si.outputs = appendOutput(si.outputs, fields...)
+			continue
+		}
+		ft, nullable := si.inferExpr(f.Expr)
+		si.outputs = appendOutput(si.outputs, newOutputField(f.GetName(), ft, nullable))
+	}
+	return si.outputs, nil
+}
+
+func loadSchemaSource(name, alias string, query *ast.SelectStatement, store kv.KeyValue) (*schemaSource, error) {
+	src := &schemaSource{name: name, alias: alias}
+	if query != nil {
+		fields, err := InferOutputSchema(query, store)
+		if err != nil {
+			return nil, err
+		}
+		src.fields = fields
+		return src, nil
+	}
+	streamStmt, err := xsql.GetDataSource(store, name)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get stream %s, please check if stream is created", name)
+	}
+	for _, sf := range streamStmt.StreamFields {
+		src.fields = append(src.fields, newOutputField(sf.Name, sf.FieldType, true))
+	}
+	return src, nil
+}
+
+func newOutputField(name string, ft ast.FieldType, nullable bool) OutputField {
+	t := AnyType
+	if ft != nil {
+		t = ast.PrintFieldType(ft)
+	}
+	return OutputField{Name: name, Type: t, Nullable: nullable, FieldType: ft}
+}
+
+// appendOutput appends the fields and the later one replaces the former one with the same name like the project result
+func appendOutput(outputs []OutputField, fields ...OutputField) []OutputField {
+outer:
+	for _, f := range fields {
+		for i, o := range outputs {
+			if o.Name == f.Name {
+				outputs[i] = f
+				continue outer
+			}
+		}
+		outputs = append(outputs, f)
+	}
+	return outputs
+}
+
+func (si *schemaInferrer) expandWildcard(w *ast.Wildcard) ([]OutputField, error) {
+	except := make(map[string]struct{}, len(w.Except))
+	for _, e := range w.Except {
+		except[e] = struct{}{}
+	}
+	var result []OutputField
+	for _, src := range si.sources {
+		if src.fields == nil {
+			return nil, fmt.Errorf("cannot infer the fields of * for schemaless stream %s", src.name)
+		}
+		for _, f := range src.fields {
+			if _, ok := except[f.Name]; !ok {
+				result = appendOutput(result, f)
+			}
+		}
+	}
+	for _, r := range w.Replace {
+		ft, nullable := si.inferExpr(r.Expr)
+		result = appendOutput(result, newOutputField(r.GetName(), ft, nullable))
+	}
+	return result, nil
+}
+
+// resolveField finds the type of the field reference. The type is nil if the stream is schemaless.
+func (si *schemaInferrer) resolveField(fr *ast.FieldRef) (ast.FieldType, bool) {
+	if fr.StreamName == "" || fr.StreamName == ast.DefaultStream {
+		// The alias of the former select fields
+		for _, o := range si.outputs {
+			if o.Name == fr.Name {
+				return o.FieldType, o.Nullable
+			}
+		}
+	}
+	for _, src := range si.sources {
+		if fr.StreamName != "" && fr.StreamName != ast.DefaultStream && string(fr.StreamName) != src.name && string(fr.StreamName) != src.alias {
+			continue
+		}
+		for _, f := range src.fields {
+			if f.Name == fr.Name {
+				return f.FieldType, true
+			}
+		}
+	}
+	return nil, true
+}
+
+// inferExpr infers the type and the nullability of the expression. The type is nil if it cannot be inferred.
+func (si *schemaInferrer) inferExpr(expr ast.Expr) (ast.FieldType, bool) {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteral:
+		return &ast.BasicType{Type: ast.BIGINT}, false
+	case *ast.NumberLiteral:
+		return &ast.BasicType{Type: ast.FLOAT}, false
+	case *ast.StringLiteral:
+		return &ast.BasicType{Type: ast.STRINGS}, false
+	case *ast.BooleanLiteral:
+		return &ast.BasicType{Type: ast.BOOLEAN}, false
+	case *ast.ParenExpr:
+		return si.inferExpr(e.Expr)
+	case *ast.FieldRef:
+		return si.resolveField(e)
+	case *ast.BinaryExpr:
+		return si.inferBinary(e)
+	case *ast.CaseExpr:
+		return si.inferCase(e)
+	case *ast.Call:
+		argTypes := make([]ast.FieldType, len(e.Args))
+		nullable := false
+		for i, arg := range e.Args {
+			var n bool
+			argTypes[i], n = si.inferExpr(arg)
+			nullable = nullable || n
+		}
+		ft, notNull := function.InferReturnType(e.Name, e.Args, argTypes)
+		return ft, !notNull && nullable
+	default:
+		return nil, true
+	}
+}
+
+func (si *schemaInferrer) inferBinary(e *ast.BinaryExpr) (ast.FieldType, bool) {
+	lt, ln := si.inferExpr(e.LHS)
+	switch e.OP {
+	case ast.ARROW:
+		if rt, ok := lt.(*ast.RecType); ok {
+			if jr, ok := e.RHS.(*ast.JsonFieldRef); ok {
+				for _, f := range rt.StreamFields {
+					if f.Name == jr.Name {
+						return f.FieldType, true
+					}
+				}
+			}
+		}
+		return nil, true
+	case ast.SUBSET:
+		at, ok := lt.(*ast.ArrayType)
+		if !ok {
+			return nil, true
+		}
+		if _, isIndex := e.RHS.(*ast.IndexExpr); isIndex {
+			if at.FieldType != nil {
+				return at.FieldType, true
+			}
+			return &ast.BasicType{Type: at.Type}, true
+		}
+		return at, true
+	}
+	rt, rn := si.inferExpr(e.RHS)
+	nullable := ln || rn
+	switch e.OP {
+	case ast.EQ, ast.NEQ, ast.LT, ast.LTE, ast.GT, ast.GTE, ast.AND, ast.OR, ast.IN, ast.NOTIN, ast.BETWEEN, ast.NOTBETWEEN, ast.LIKE, ast.NOTLIKE:
+		return &ast.BasicType{Type: ast.BOOLEAN}, nullable
+	case ast.BITWISE_AND, ast.BITWISE_OR, ast.BITWISE_XOR:
+		return &ast.BasicType{Type: ast.BIGINT}, nullable
+	case ast.ADD, ast.SUB, ast.MUL, ast.DIV, ast.MOD:
+		l, lok := lt.(*ast.BasicType)
+		r, rok := rt.(*ast.BasicType)
+		if !lok || !rok {
+			return nil, nullable
+		}
+		switch {
+		case l.Type == ast.BIGINT && r.Type == ast.BIGINT:
+			return &ast.BasicType{Type: ast.BIGINT}, nullable
+		case (l.Type == ast.BIGINT || l.Type == ast.FLOAT) && (r.Type == ast.BIGINT || r.Type == ast.FLOAT):
+			return &ast.BasicType{Type: ast.FLOAT}, nullable
+		}
+	}
+	return nil, nullable
+}
+
+// inferCase returns the type of the results if all of them are the same
+func (si *schemaInferrer) inferCase(e *ast.CaseExpr) (ast.FieldType, bool) {
+	results := make([]ast.Expr, 0, len(e.WhenClauses)+1)
+	for _, w := range e.WhenClauses {
+		results = append(results, w.Result)
+	}
+	nullable := e.ElseClause == nil
+	if e.ElseClause != nil {
+		results = append(results, e.ElseClause)
+	}
+	var ft ast.FieldType
+	for i, r := range results {
+		t, n := si.inferExpr(r)
+		nullable = nullable || n
+		if t == nil {
+			return nil, nullable
+		}
+		if i == 0 {
+			ft = t
+		} else if ast.PrintFieldType(t) != ast.PrintFieldType(ft) {
+			return nil, nullable
+		}
+	}
+	return ft, nullable
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func TestInferOutputSchema(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	streamSqls := map[string]string{
+		"inferSrc": `CREATE STREAM inferSrc (
+					id BIGINT,
+					name STRING,
+					temp FLOAT,
+					tags ARRAY(STRING),
+					loc STRUCT(lat FLOAT, lng FLOAT)
+				) WITH (DATASOURCE="inferSrc", FORMAT="json", KEY="id");`,
+		"inferTable": `CREATE TABLE inferTable (
+					id BIGINT,
+					owner STRING
+				) WITH (DATASOURCE="inferTable", TYPE="memory", KIND="lookup", KEY="id");`,
+		"inferSchemaless": `CREATE STREAM inferSchemaless () WITH (DATASOURCE="inferSchemaless", FORMAT="json");`,
+	}
+	types := map[string]ast.StreamType{
+		"inferSrc":        ast.TypeStream,
+		"inferTable":      ast.TypeTable,
+		"inferSchemaless": ast.TypeStream,
+	}
+	for name, sql := range streamSqls {
+		s, err := json.Marshal(&xsql.StreamInfo{
+			StreamType: types[name],
+			Statement:  sql,
+		})
+		require.NoError(t, err)
+		require.NoError(t, kv.Set(name, string(s)))
+	}
+	defer func() {
+		for name := range streamSqls {
+			_ = kv.Delete(name)
+		}
+	}()
+
+	tests := []struct {
+		name   string
+		sql    string
+		result []OutputField
+		err    string
+	}{
+		{
+			name: "fields and functions",
+			sql:  `SELECT id, upper(name) AS uname, temp * 2 AS t2, id + 1 AS next, count(*) AS c, avg(temp), loc->lat AS lat, tags[0] AS tag, cast(id, "string") AS sid, 1 AS one, temp > 20 AS hot FROM inferSrc GROUP BY id, TUMBLINGWINDOW(ss, 10)`,
+			result: []OutputField{
+				{Name: "id", Type: "bigint", Nullable: true},
+				{Name: "uname", Type: "string", Nullable: true},
+				{Name: "t2", Type: "float", Nullable: true},
+				{Name: "next", Type: "bigint", Nullable: true},
+				{Name: "c", Type: "bigint", Nullable: false},
+				{Name: "avg", Type: "float", Nullable: true},
+				{Name: "lat", Type: "float", Nullable: true},
+				{Name: "tag", Type: "string", Nullable: true},
+				{Name: "sid", Type: "string", Nullable: true},
+				{Name: "one", Type: "bigint", Nullable: false},
+				{Name: "hot", Type: "boolean", Nullable: true},
+			},
+		},
+		{
+			name: "wildcard and join",
+			sql:  `SELECT *, inferTable.owner AS o FROM inferSrc INNER JOIN inferTable ON inferSrc.id = inferTable.id`,
+			result: []OutputField{
+				{Name: "id", Type: "bigint", Nullable: true},
+				{Name: "name", Type: "string", Nullable: true},
+				{Name: "temp", Type: "float", Nullable: true},
+				{Name: "tags", Type: "array(string)", Nullable: true},
+				{Name: "loc", Type: "struct(lat float, lng float)", Nullable: true},
+				{Name: "owner", Type: "string", Nullable: true},
+				{Name: "o", Type: "string", Nullable: true},
+			},
+		},
+		{
+			name: "case and alias",
+			sql:  `SELECT temp AS t, CASE WHEN t > 20 THEN "hot" ELSE "cold" END AS level FROM inferSrc`,
+			result: []OutputField{
+				{Name: "t", Type: "float", Nullable: true},
+				{Name: "level", Type: "string", Nullable: false},
+			},
+		},
+		{
+			name: "schemaless",
+			sql:  `SELECT a, abs(b) AS b, count(*) AS c FROM inferSchemaless GROUP BY TUMBLINGWINDOW(ss, 10)`,
+			result: []OutputField{
+				{Name: "a", Type: AnyType, Nullable: true},
+				{Name: "b", Type: AnyType, Nullable: true},
+				{Name: "c", Type: "bigint", Nullable: false},
+			},
+		},
+		{
+			name: "subquery",
+			sql:  `SELECT s.n FROM (SELECT name AS n FROM inferSrc) AS s`,
+			result: []OutputField{
+				{Name: "n", Type: "string", Nullable: true},
+			},
+		},
+		{
+			name: "schemaless wildcard",
+			sql:  `SELECT * FROM inferSchemaless`,
+			err:  "cannot infer the fields of * for schemaless stream inferSchemaless",
+		},
+		{
+			name: "not found",
+			sql:  `SELECT * FROM inferNone`,
+			err:  "fail to get stream inferNone, please check if stream is created",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.GetStatementFromSql(tt.sql)
+			require.NoError(t, err)
+			result, err := InferOutputSchema(stmt, kv)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			for i := range result {
+				result[i].FieldType = nil
+			}
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+// semiJoinFieldPrefix is the prefix of the internal field to save the subquery result.
+// The field is private so that it will not be written out.
+const semiJoinFieldPrefix = xsql.PRIVATE_PREFIX + "subquery_"
+
+// SemiJoinPlan tests whether each row has any matched row in the table for the IN or EXISTS subquery.
+// The result is saved in an internal field of the row which is referred by the filter, so the row is not changed.
+// The lookup table is looked up by the keys while the scan table source is the last child to feed the table state.
+type SemiJoinPlan struct {
+	baseLogicalPlan
+	field     string
+	table     string
+	not       bool
+	isLookup  bool
+	options   *ast.Options
+	condition ast.Expr
+	// lookup table only
+	keys    []string
+	valvars []ast.Expr
+	fields  []string
+	// the remaining conditions to filter the lookup result
+	lookupCondition ast.Expr
+	// scan table only
+	source *DataSourcePlan
+	size   int
+}
+
+func (p SemiJoinPlan) Init() *SemiJoinPlan {
+	p.baseLogicalPlan.self = &p
+	p.baseLogicalPlan.setPlanType(SEMIJOIN)
+	return &p
+}
+
+func (p *SemiJoinPlan) BuildExplainInfo() {
+	info := "Table:" + p.table + ", Field:" + p.field
+	if p.not {
+		info += ", Not:true"
+	}
+	if p.condition != nil {
+		info += ", Condition:{ " + p.condition.String() + " }"
+	}
+	p.baseLogicalPlan.ExplainInfo.Info = info
+}
+
+// PushDownPredicate keeps the conditions referring to the result field above and pushes the others to the rows child
+func (p *SemiJoinPlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
+	if len(p.children) == 0 {
+		return condition, p.self
+	}
+	owned, other := p.extract(condition)
+	rest, child := p.children[0].PushDownPredicate(other)
+	p.children[0] = child
+	return combine(owned, rest), p.self
+}
+
+// extract returns the conditions referring to the result field and the others
+func (p *SemiJoinPlan) extract(expr ast.Expr) (ast.Expr, ast.Expr) {
+	if expr == nil {
+		return nil, nil
+	}
+	if be, ok := expr.(*ast.BinaryExpr); ok && be.OP == ast.AND {
+		ol, pl := p.extract(be.LHS)
+		or, pr := p.extract(be.RHS)
+		return combine(ol, or), combine(pl, pr)
+	}
+	owned := false
+	ast.WalkFunc(expr, func(n ast.Node) bool {
+		if f, ok := n.(*ast.FieldRef); ok && f.StreamName == ast.DefaultStream && f.Name == p.field {
+			owned = true
+		}
+		return !owned
+	})
+	if owned {
+		return expr, nil
+	}
+	return nil, expr
+}
+
+func (p *SemiJoinPlan) PruneColumns(fields []ast.Expr) error {
+	newFields := make([]ast.Expr, 0, len(fields))
+	for _, field := range fields {
+		if f, ok := field.(*ast.FieldRef); ok && f.StreamName == ast.DefaultStream && f.Name == p.field {
+			continue
+		}
+		newFields = append(newFields, field)
+	}
+	var tableFields []ast.Expr
+	for _, f := range getFields(p.condition) {
+		if fr, ok := f.(*ast.FieldRef); ok && string(fr.StreamName) == p.table {
+			tableFields = append(tableFields, fr)
+		} else {
+			newFields = append(newFields, f)
+		}
+	}
+	if p.isLookup {
+		fieldMap := make(map[string]struct{})
+		for _, f := range getFields(p.lookupCondition) {
+			if fr, ok := f.(*ast.FieldRef); ok && string(fr.StreamName) == p.table {
+				fieldMap[fr.Name] = struct{}{}
+			}
+		}
+		// Always read the keys so that the lookup source has something to select
+		for _, k := range p.keys {
+			fieldMap[k] = struct{}{}
+		}
+		p.fields = make([]string, 0, len(fieldMap))
+		for k := range fieldMap {
+			p.fields = append(p.fields, k)
+		}
+		sort.Strings(p.fields)
+	} else if p.source != nil {
+		if err := p.source.PruneColumns(tableFields); err != nil {
+			return err
+		}
+	}
+	if len(p.children) > 0 {
+		return p.children[0].PruneColumns(newFields)
+	}
+	return nil
+}
+
+// semiJoinExtractor replaces the IN and EXISTS subqueries in the WHERE clause with the internal result fields
+// and creates a semi join plan for each of them
+type semiJoinExtractor struct {
+	store kv.KeyValue
+	opt   *def.RuleOption
+	plans []*SemiJoinPlan
+	// the alias of the outer sources
+	aliases map[string]string
+}
+
+func extractSemiJoins(stmt *ast.SelectStatement, store kv.KeyValue, opt *def.RuleOption) ([]*SemiJoinPlan, error) {
+	e := &semiJoinExtractor{store: store, opt: opt, aliases: make(map[string]string)}
+	for _, s := range stmt.Sources {
+		if t, ok := s.(*ast.Table); ok && t.Alias != "" {
+			e.aliases[t.Alias] = t.Name
+		}
+	}
+	for _, j := range stmt.Joins {
+		if j.Alias != "" {
+			e.aliases[j.Alias] = j.Name
+		}
+	}
+	if stmt.Condition != nil {
+		c, err := e.rewrite(stmt.Condition)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Condition = c
+	}
+	var err error
+	ast.WalkFunc(stmt, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.SubQueryExpr, *ast.ExistsExpr:
+			err = fmt.Errorf("IN and EXISTS subqueries are only supported as the predicates of the WHERE clause")
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.plans, nil
+}
+
+func (e *semiJoinExtractor) rewrite(expr ast.Expr) (ast.Expr, error) {
+	var err error
+	switch ex := expr.(type) {
+	case *ast.ParenExpr:
+		ex.Expr, err = e.rewrite(ex.Expr)
+	case *ast.ExistsExpr:
+		return e.create(nil, ex.Query, ex.Not)
+	case *ast.BinaryExpr:
+		if sq, ok := ex.RHS.(*ast.SubQueryExpr); ok && (ex.OP == ast.IN || ex.OP == ast.NOTIN) {
+			return e.create(ex.LHS, sq.Query, ex.OP == ast.NOTIN)
+		}
+		if ex.OP == ast.AND || ex.OP == ast.OR {
+			if ex.LHS, err = e.rewrite(ex.LHS); err != nil {
+				return nil, err
+			}
+			ex.RHS, err = e.rewrite(ex.RHS)
+		}
+	}
+	return expr, err
+}
+
+// create validates the subquery and creates the semi join plan. The lhs is the left side of IN, nil for EXISTS.
+func (e *semiJoinExtractor) create(lhs ast.Expr, q *ast.SelectStatement, not bool) (ast.Expr, error) {
+	if len(q.Sources) != 1 || q.Joins != nil || q.Dimensions != nil || q.Having != nil || q.SortFields != nil || q.Limit != nil || len(q.Unions) > 0 {
+		return nil, fmt.Errorf("IN and EXISTS subqueries only support selecting from one table without join, group by, order by or limit")
+	}
+	t, ok := q.Sources[0].(*ast.Table)
+	if !ok || t.Query != nil {
+		return nil, fmt.Errorf("IN and EXISTS subqueries only support selecting from a table")
+	}
+	streamStmt, err := xsql.GetDataSource(e.store, t.Name)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get table %s, please check if table is created", t.Name)
+	}
+	if streamStmt.StreamType != ast.TypeTable {
+		return nil, fmt.Errorf("IN and EXISTS subqueries only support selecting from a table, but %s is a stream", t.Name)
+	}
+	var nested bool
+	ast.WalkFunc(q, func(n ast.Node) bool {
+		switch f := n.(type) {
+		case *ast.SubQueryExpr, *ast.ExistsExpr:
+			nested = true
+		case *ast.FieldRef:
+			// The fields without stream name are always bound to the table
+			if f.StreamName == ast.DefaultStream || string(f.StreamName) == t.Name || (t.Alias != "" && string(f.StreamName) == t.Alias) {
+				f.StreamName = ast.StreamName(t.Name)
+			} else if name, ok := e.aliases[string(f.StreamName)]; ok {
+				f.StreamName = ast.StreamName(name)
+			}
+		}
+		return true
+	})
+	if nested {
+		return nil, fmt.Errorf("nested subquery in the subquery of table %s is not supported", t.Name)
+	}
+	condition := q.Condition
+	if lhs != nil {
+		var key *ast.FieldRef
+		if len(q.Fields) == 1 {
+			key, _ = q.Fields[0].Expr.(*ast.FieldRef)
+		}
+		if key == nil || !key.IsColumn() {
+			return nil, fmt.Errorf("the subquery of IN must select exactly one column of table %s", t.Name)
+		}
+		condition = combine(&ast.BinaryExpr{OP: ast.EQ, LHS: &ast.FieldRef{StreamName: key.StreamName, Name: key.Name}, RHS: lhs}, condition)
+	}
+	p := SemiJoinPlan{
+		field:     semiJoinFieldPrefix + strconv.Itoa(len(e.plans)),
+		table:     t.Name,
+		not:       not,
+		options:   streamStmt.Options,
+		condition: condition,
+		isLookup:  streamStmt.Options.KIND == ast.StreamKindLookup,
+	}.Init()
+	if p.isLookup {
+		lp := &LookupPlan{joinExpr: ast.Join{Name: t.Name, Expr: condition}}
+		if condition == nil || !lp.validateAndExtractCondition() {
+			return nil, fmt.Errorf("the subquery of lookup table %s requires at least one equi predicate between the table and the outer query", t.Name)
+		}
+		p.keys, p.valvars, p.lookupCondition = lp.keys, lp.valvars, lp.conditions
+	} else {
+		si, err := convertStreamInfo(streamStmt)
+		if err != nil {
+			return nil, err
+		}
+		p.source = DataSourcePlan{
+			name:         streamStmt.Name,
+			streamStmt:   streamStmt,
+			streamFields: si.schema.ToJsonSchema(),
+			isSchemaless: si.schema == nil,
+			iet:          e.opt.IsEventTime,
+			allMeta:      e.opt.SendMetaToSink,
+		}.Init()
+		p.size = getRetainSize(streamStmt.Options)
+	}
+	e.plans = append(e.plans, p)
+	return &ast.FieldRef{StreamName: ast.DefaultStream, Name: p.field}, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+// stateLayoutOf returns the stable identity of the state of the operator planned by the logical plan.
+// It returns nil for the stateless operators and the sources whose op id is already stable.
+func stateLayoutOf(lp LogicalPlan) *state.OpLayout {
+	switch t := lp.(type) {
+	case *WatermarkPlan:
+		return &state.OpLayout{Identity: "watermark:" + strings.Join(t.Emitters, ",")}
+	case *AnalyticFuncsPlan:
+		// Each function has its own state, so add or remove a function does not affect the others
+		return &state.OpLayout{Identity: "analytic", Funcs: funcLayout(append(append([]*ast.Call{}, t.funcs...), t.fieldFuncs...))}
+	case *WindowPlan:
+		return &state.OpLayout{
+			Identity: "window:" + windowIdentity(t.wtype, t.length, t.interval, t.timeUnit, t.delay, t.triggerCondition) + fmt.Sprintf(",eventTime:%v", t.isEventTime),
+			Funcs:    funcLayout(t.stateFuncs),
+		}
+	case *IncWindowPlan:
+		// The state is the partial result of the aggregate functions, so they must be the same
+		var b strings.Builder
+		b.WriteString("inc_agg_window:")
+		b.WriteString(windowIdentity(t.WType, t.Length, t.Interval, t.TimeUnit, t.Delay, t.TriggerCondition))
+		b.WriteString(",dimensions:[")
+		for i, d := range t.Dimensions {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(d.Expr.String())
+		}
+		b.WriteString("],aggs:[")
+		for i, f := range t.IncAggFuncs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(f.Expr.String())
+		}
+		b.WriteString("]")
+		return &state.OpLayout{Identity: b.String()}
+	case *DedupTriggerPlan:
+		return &state.OpLayout{Identity: fmt.Sprintf("dedup_trigger:%s,%s,%s,%s,%d", t.aliasName, t.startField.Name, t.endField.Name, t.nowField.Name, t.expire)}
+	case *SemiJoinPlan:
+		return &state.OpLayout{Identity: fmt.Sprintf("semijoin:%s,%s,%v,%d", t.field, t.table, t.not, t.size)}
+	case *JoinAlignPlan:
+		return &state.OpLayout{Identity: fmt.Sprintf("join_aligner:%v,%v", t.Emitters, t.Sizes)}
+	case *FilterPlan:
+		if len(t.stateFuncs) > 0 {
+			return &state.OpLayout{Identity: "filter", Funcs: funcLayout(t.stateFuncs)}
+		}
+	case *HavingPlan:
+		if len(t.stateFuncs) > 0 {
+			return &state.OpLayout{Identity: "having", Funcs: funcLayout(t.stateFuncs)}
+		}
+	}
+	return nil
+}
+
+func windowIdentity(wtype ast.WindowType, length, interval int, timeUnit ast.Token, delay int64, trigger ast.Expr) string {
+	identity := fmt.Sprintf("type:%s,length:%d,interval:%d,timeUnit:%s,delay:%d", wtype, length, interval, timeUnit, delay)
+	if trigger != nil {
+		identity += ",trigger:" + trigger.String()
+	}
+	return identity
+}
+
+// funcLayout maps the identity of each function call to its function id. The function id is the order of the function
+// in the sql which changes once a function is added before it. The same calls are numbered by their order.
+func funcLayout(calls []*ast.Call) map[string]int {
+	if len(calls) == 0 {
+		return nil
+	}
+	result := make(map[string]int, len(calls))
+	for _, c := range calls {
+		identity := c.String()
+		if c.Partition != nil {
+			identity += c.Partition.String()
+		}
+		key := identity
+		for i := 1; ; i++ {
+			if _, ok := result[key]; !ok {
+				break
+			}
+			key = fmt.Sprintf("%s#%d", identity, i)
+		}
+		result[key] = c.FuncId
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/internal/xsql"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+func TestStateLayout(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	s, err := json.Marshal(&xsql.StreamInfo{
+		StreamType: ast.TypeStream,
+		Statement:  `CREATE STREAM layoutsrc () WITH (DATASOURCE="layoutsrc", FORMAT="json", TYPE="memory");`,
+	})
+	require.NoError(t, err)
+	require.NoError(t, kv.Set("layoutsrc", string(s)))
+	defer func() {
+		_ = kv.Delete("layoutsrc")
+	}()
+
+	layoutOf := func(sql string) map[string]*state.OpLayout {
+		tp, err := Plan(&def.Rule{
+			Id:      "layoutRule",
+			Sql:     sql,
+			Actions: []map[string]any{{"log": map[string]any{}}},
+			Options: &def.RuleOption{Qos: def.AtLeastOnce, BufferLength: 1024},
+		})
+		require.NoError(t, err)
+		defer tp.Cancel()
+		return tp.GetStateLayout()
+	}
+
+	// The function id changes but the identity of the analytic function does not
+	l1 := layoutOf("SELECT acc_sum(a) AS total FROM layoutsrc")
+	l2 := layoutOf("SELECT abs(b) AS b, acc_sum(a) AS sum_total FROM layoutsrc")
+	require.Len(t, l1, 1)
+	require.Len(t, l2, 1)
+	for opId, ol := range l1 {
+		nl, ok := l2[opId]
+		require.True(t, ok)
+		require.Equal(t, ol.Identity, nl.Identity)
+		require.Len(t, ol.Funcs, 1)
+		for f, oldId := range ol.Funcs {
+			require.Contains(t, nl.Funcs, f)
+			require.NotEqual(t, oldId, nl.Funcs[f])
+		}
+	}
+
+	// The window is compatible if the window definition is the same
+	w1 := layoutOf("SELECT count(*) FROM layoutsrc GROUP BY TumblingWindow(ss, 10)")
+	w2 := layoutOf("SELECT count(*) AS c, avg(a) FROM layoutsrc WHERE a > 1 GROUP BY TumblingWindow(ss, 10)")
+	w3 := layoutOf("SELECT count(*) FROM layoutsrc GROUP BY TumblingWindow(ss, 20)")
+	identities := func(l map[string]*state.OpLayout) []string {
+		var result []string
+		for _, ol := range l {
+			result = append(result, ol.Identity)
+		}
+		return result
+	}
+	require.Equal(t, identities(w1), identities(w2))
+	require.NotEqual(t, identities(w1), identities(w3))
+}
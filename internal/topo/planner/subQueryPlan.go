@@ -0,0 +1,58 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+// SubQueryPlan is the boundary between the outer query and the plan of a subquery or common table expression.
+// It does not create any operator. The output of the subquery is fed into the outer query directly.
+type SubQueryPlan struct {
+	baseLogicalPlan
+	name string
+}
+
+func (p SubQueryPlan) Init() *SubQueryPlan {
+	p.baseLogicalPlan.self = &p
+	p.baseLogicalPlan.setPlanType(SUBQUERY)
+	return &p
+}
+
+func (p *SubQueryPlan) BuildExplainInfo() {
+	p.baseLogicalPlan.ExplainInfo.Info = "Name: " + p.name
+}
+
+// PushDownPredicate The outer condition refers to the output columns of the subquery, so it cannot be pushed down.
+// The subquery is optimized from its own root.
+func (p *SubQueryPlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
+	_, p.children[0] = p.children[0].PushDownPredicate(nil)
+	return condition, p.self
+}
+
+// PruneColumns The subquery only needs the columns selected by itself
+func (p *SubQueryPlan) PruneColumns(_ []ast.Expr) error {
+	return p.children[0].PruneColumns(nil)
+}
+
+// getSubQuery returns the source which is a subquery or refers to a common table expression
+func getSubQuery(stmt *ast.SelectStatement) *ast.Table {
+	for _, source := range stmt.Sources {
+		if t, ok := source.(*ast.Table); ok && t.Query != nil {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+)
+
+// UnionPlan merges the output of all branches of UNION ALL. Each child is the complete plan of a branch.
+type UnionPlan struct {
+	baseLogicalPlan
+	// colNames is the union of the output columns of all branches. It is nil if any branch selects wildcard.
+	colNames []string
+	sendNil  bool
+}
+
+func (p UnionPlan) Init() *UnionPlan {
+	p.baseLogicalPlan.self = &p
+	p.baseLogicalPlan.setPlanType(UNION)
+	return &p
+}
+
+func (p *UnionPlan) BuildExplainInfo() {
+	info := ""
+	if len(p.colNames) > 0 {
+		info += "Fields:[ " + strings.Join(p.colNames, ", ") + " ]"
+	}
+	p.baseLogicalPlan.ExplainInfo.Info = info
+}
+
+// PushDownPredicate Each branch has its own condition and column names, so do not push down through the union.
+// Instead, optimize each branch from its own root.
+func (p *UnionPlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
+	for i, child := range p.children {
+		_, p.children[i] = child.PushDownPredicate(nil)
+	}
+	return condition, p.self
+}
+
+// PruneColumns The branches are pruned by their own fields
+func (p *UnionPlan) PruneColumns(_ []ast.Expr) error {
+	for _, child := range p.children {
+		if err := child.PruneColumns(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unionColNames returns the output column names of all branches in order. Return nil if any branch has wildcard.
+func unionColNames(branches []*ast.SelectStatement) []string {
+	var result []string
+	exists := make(map[string]struct{})
+	for _, branch := range branches {
+		for _, field := range branch.Fields {
+			if _, ok := field.Expr.(*ast.Wildcard); ok {
+				return nil
+			}
+			name := field.AName
+			if name == "" {
+				name = field.Name
+			}
+			if _, ok := exists[name]; ok {
+				continue
+			}
+			exists[name] = struct{}{}
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// unionFields returns the fields to send the merged rows of all branches. The columns are referred by name to keep the
+// values of the alias and the expressions of the branches which a bare wildcard drops.
+func unionFields(branches []*ast.SelectStatement) ast.Fields {
+	var result ast.Fields
+	exists := make(map[string]struct{})
+	wildcard := false
+	for _, branch := range branches {
+		for _, field := range branch.Fields {
+			switch ft := field.Expr.(type) {
+			case *ast.Wildcard:
+				wildcard = true
+				continue
+			case *ast.FieldRef:
+				if ft.Name == "*" {
+					wildcard = true
+					continue
+				}
+			}
+			name := field.AName
+			if name == "" {
+				name = field.Name
+			}
+			if _, ok := exists[name]; ok {
+				continue
+			}
+			exists[name] = struct{}{}
+			result = append(result, ast.Field{Name: name, Expr: &ast.FieldRef{StreamName: ast.DefaultStream, Name: name}})
+		}
+	}
+	if wildcard {
+		result = append(ast.Fields{{Name: "*", Expr: &ast.Wildcard{Token: ast.ASTERISK}}}, result...)
+	}
+	return result
+}
+
+type shareSource struct{}
+
+// shareSource replaces the data sources of the same stream in the branches of UNION ALL with the first one, so that
+// the stream is read once and sent to all the branches. It runs last because the other rules optimize the data source
+// of each branch by its own fields and conditions.
+func (r *shareSource) optimize(plan LogicalPlan, _ *def.RuleOption) (LogicalPlan, error) {
+	r.search(plan, make(map[ast.StreamName]*DataSourcePlan))
+	return plan, nil
+}
+
+func (r *shareSource) name() string {
+	return "shareSource"
+}
+
+func (r *shareSource) search(plan LogicalPlan, sources map[ast.StreamName]*DataSourcePlan) {
+	for i, child := range plan.Children() {
+		ds, ok := child.(*DataSourcePlan)
+		if !ok {
+			r.search(child, sources)
+			continue
+		}
+		if first, ok := sources[ds.name]; ok {
+			if first != ds {
+				first.share(ds)
+				plan.Children()[i] = first
+			}
+		} else {
+			sources[ds.name] = ds
+		}
+	}
+}
+
+// share makes the data source read the fields of another branch too. The conditions and columns pushed to the source
+// are dropped since they only fit one branch. The branches still evaluate their own conditions.
+func (p *DataSourcePlan) share(other *DataSourcePlan) {
+	p.isWildCard = p.isWildCard || other.isWildCard
+	if other.streamFields != nil {
+		if p.streamFields == nil {
+			p.streamFields = make(map[string]*ast.JsonStreamField, len(other.streamFields))
+		}
+		for k, v := range other.streamFields {
+			p.streamFields[k] = v
+		}
+	}
+	p.allMeta = p.allMeta || other.allMeta
+	for _, m := range other.metaFields {
+		exist := false
+		for _, e := range p.metaFields {
+			if e == m {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			p.metaFields = append(p.metaFields, m)
+		}
+	}
+	sort.Strings(p.metaFields)
+	p.pushedConditions = nil
+	p.pushedColumns = nil
+}
@@ -38,3 +38,8 @@ func (p *WindowFuncPlan) BuildExplainInfo() {
 	info += "}"
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
+
+func (p *WindowFuncPlan) PruneColumns(fields []ast.Expr) error {
+	f := getFields(p.windowFuncField.Expr)
+	return p.baseLogicalPlan.PruneColumns(append(fields, f...))
+}
@@ -33,7 +33,9 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/schedule"
 	"github.com/lf-edge/ekuiper/v2/internal/topo"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/checkpoint"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/node"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
@@ -388,6 +390,24 @@ func (s *State) Stop() {
 	return
 }
 
+// WaitStop waits until the rule is stopped and its topo is closed. The stop action is deferred if the rule is
+// starting or stopping, so Stop may return before the topo is closed.
+func (s *State) WaitStop(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		s.RLock()
+		stopped := len(s.actionQ) == 0 && (s.currentState == Stopped || s.currentState == StoppedByErr || s.currentState == ScheduledStop)
+		s.RUnlock()
+		if stopped {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wait for rule %s to stop timeout", s.Rule.Id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func (s *State) ScheduleStop() {
 	defer s.nextAction()
 	s.logger.Debug("scheduled stop RunState")
@@ -554,6 +574,16 @@ func (s *State) GetTopoGraph() *def.PrintableTopo {
 	}
 }
 
+// GetTopoStats returns the topo graph and the runtime statistics of its nodes. The statistics are nil if the rule is not running.
+func (s *State) GetTopoStats() (*def.PrintableTopo, map[string]*node.NodeStats) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.topology != nil {
+		return s.topology.GetTopo(), s.topology.GetStats()
+	}
+	return s.topoGraph, nil
+}
+
 func (s *State) SetIsTraceEnabled(isEnabled bool, stra kctx.TraceStrategy) error {
 	s.Lock()
 	defer s.Unlock()
@@ -623,3 +653,21 @@ func (s *State) ResetStreamOffset(name string, input map[string]any) error {
 	}
 	return fmt.Errorf("topo is not initialized, check rule status")
 }
+
+// Savepoint triggers a checkpoint of the running rule and waits until it is saved. It returns the checkpoint id.
+func (s *State) Savepoint(timeout time.Duration) (int64, error) {
+	s.RLock()
+	var c *checkpoint.Coordinator
+	if s.topology != nil {
+		c = s.topology.GetCoordinator()
+	}
+	isRunning := s.topology != nil
+	s.RUnlock()
+	if !isRunning {
+		return 0, fmt.Errorf("topo is not initialized, check rule status")
+	}
+	if c == nil {
+		return 0, fmt.Errorf("rule %s does not enable checkpoint, set qos to at least once", s.Rule.Id)
+	}
+	return c.Savepoint(timeout)
+}
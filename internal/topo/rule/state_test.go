@@ -269,3 +269,17 @@ func sendAction(st *State, a ActionSignal) {
 func TestRuleRestart(t *testing.T) {
 	// TODO added later
 }
+
+func TestWaitStop(t *testing.T) {
+	st := NewState(def.GetDefaultRule("testWaitStop", "select * from demo"))
+	assert.NoError(t, st.WaitStop(time.Second))
+	// The topo is still closing
+	st.transit(Stopping, nil)
+	assert.EqualError(t, st.WaitStop(50*time.Millisecond), "wait for rule testWaitStop to stop timeout")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		st.transit(Stopped, nil)
+	}()
+	assert.NoError(t, st.WaitStop(5*time.Second))
+	assert.Equal(t, Stopped, st.GetState())
+}
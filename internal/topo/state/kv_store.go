@@ -44,6 +44,8 @@ type KVStore struct {
 	checkpoints []int64
 	max         int
 	ruleId      string
+	// The layout of the current topo which is saved along with each checkpoint
+	layout map[string]*OpLayout
 }
 
 // Store in path ./data/checkpoint/$ruleId
@@ -107,7 +109,11 @@ func (s *KVStore) SaveCheckpoint(checkpointId int64) error {
 				s.checkpoints = s.checkpoints[1:]
 				s.mapStore.Delete(cp)
 			}
-			_, err := s.db.Set(checkpointId, cast.SyncMapToMap(m))
+			snapshot := cast.SyncMapToMap(m)
+			if s.layout != nil {
+				snapshot[LayoutKey] = s.layout
+			}
+			_, err := s.db.Set(checkpointId, snapshot)
 			if err != nil {
 				return fmt.Errorf("save checkpoint err: %v", err)
 			}
@@ -116,6 +122,11 @@ func (s *KVStore) SaveCheckpoint(checkpointId int64) error {
 	return nil
 }
 
+// SetLayout sets the state layout of the topo to save with the checkpoints
+func (s *KVStore) SetLayout(layout map[string]*OpLayout) {
+	s.layout = layout
+}
+
 // GetOpState Only run in the initialization
 func (s *KVStore) GetOpState(opId string) (*sync.Map, error) {
 	if len(s.checkpoints) > 0 {
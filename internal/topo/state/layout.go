@@ -0,0 +1,177 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"encoding/gob"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	ts "github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+// LayoutKey is the key of the state layout in the checkpoint snapshot. It never conflicts with an op id.
+const LayoutKey = "$$layout"
+
+const funcKeyPrefix = "$$func"
+
+func init() {
+	gob.Register(map[string]*OpLayout{})
+}
+
+// OpLayout describes the state of an operator by the identities derived from the plan. The op id is an index in the
+// plan which changes once the sql changes, but the identity only changes when the state is incompatible.
+type OpLayout struct {
+	// Identity is the definition of the operator which decides the state, such as the window definition
+	Identity string
+	// Funcs is the identity of the stateful functions to the function id which is used in the state key
+	Funcs map[string]int
+}
+
+// IncompatibleOp is an operator of the old rule whose state cannot be migrated to the new rule
+type IncompatibleOp struct {
+	OpId     string `json:"opId"`
+	Identity string `json:"identity"`
+}
+
+// MigrateState moves the state of the last checkpoint of the rule from the old op ids to the new op ids by the
+// identities, so that the compatible operators of the updated rule restore their state. The state of the operators
+// without identity are kept by op id. It returns the operators whose state will be dropped.
+// If dryRun is true, the checkpoint is not changed.
+func MigrateState(ruleId string, layout map[string]*OpLayout, dryRun bool) ([]IncompatibleOp, error) {
+	db, err := ts.GetTS(ruleId)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot map[string]interface{}
+	last, err := db.Last(&snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("read the last checkpoint of rule %s error: %v", ruleId, err)
+	}
+	if last <= 0 {
+		return nil, nil
+	}
+	oldLayout, ok := snapshot[LayoutKey].(map[string]*OpLayout)
+	if !ok {
+		// Saved by the old version, restore by op id as before
+		return nil, nil
+	}
+	result, incompatible := migrate(ruleId, snapshot, oldLayout, layout)
+	if dryRun {
+		return incompatible, nil
+	}
+	checkpointId := timex.GetNowInMilli()
+	if checkpointId <= last {
+		checkpointId = last + 1
+	}
+	if _, err := db.Set(checkpointId, result); err != nil {
+		return nil, fmt.Errorf("save the migrated state of rule %s error: %v", ruleId, err)
+	}
+	return incompatible, nil
+}
+
+func migrate(ruleId string, snapshot map[string]interface{}, oldLayout, newLayout map[string]*OpLayout) (map[string]interface{}, []IncompatibleOp) {
+	result := map[string]interface{}{LayoutKey: newLayout}
+	// The state of the operators without identity are kept by op id
+	for opId, st := range snapshot {
+		if opId == LayoutKey {
+			continue
+		}
+		if _, ok := oldLayout[opId]; ok {
+			continue
+		}
+		if _, ok := newLayout[opId]; ok {
+			continue
+		}
+		result[opId] = st
+	}
+	used := make(map[string]bool)
+	newIds := sortedKeys(newLayout)
+	oldIds := sortedKeys(oldLayout)
+	for _, newId := range newIds {
+		nl := newLayout[newId]
+		for _, oldId := range oldIds {
+			ol := oldLayout[oldId]
+			if used[oldId] || ol.Identity != nl.Identity {
+				continue
+			}
+			used[oldId] = true
+			if st, ok := snapshot[oldId].(map[string]interface{}); ok {
+				result[newId] = migrateOpState(ruleId, oldId, newId, st, ol, nl)
+			}
+			break
+		}
+	}
+	var incompatible []IncompatibleOp
+	for _, oldId := range oldIds {
+		if used[oldId] {
+			continue
+		}
+		if st, ok := snapshot[oldId].(map[string]interface{}); ok && len(st) > 0 {
+			incompatible = append(incompatible, IncompatibleOp{OpId: oldId, Identity: oldLayout[oldId].Identity})
+		}
+	}
+	return result, incompatible
+}
+
+// migrateOpState renames the state keys which include the op id or the function id
+func migrateOpState(ruleId, oldId, newId string, st map[string]interface{}, ol, nl *OpLayout) map[string]interface{} {
+	funcIds := make(map[int]int, len(ol.Funcs))
+	for identity, oldFuncId := range ol.Funcs {
+		if newFuncId, ok := nl.Funcs[identity]; ok {
+			funcIds[oldFuncId] = newFuncId
+		}
+	}
+	oldPrefix := ruleId + "_" + oldId + "_"
+	newPrefix := ruleId + "_" + newId + "_"
+	result := make(map[string]interface{}, len(st))
+	for k, v := range st {
+		switch {
+		case strings.HasPrefix(k, funcKeyPrefix):
+			// The key is $$func{funcId}_{key}
+			idStr, key, found := strings.Cut(strings.TrimPrefix(k, funcKeyPrefix), "_")
+			if !found {
+				result[k] = v
+				continue
+			}
+			oldFuncId, err := strconv.Atoi(idStr)
+			if err != nil {
+				result[k] = v
+				continue
+			}
+			// The function is removed
+			if newFuncId, ok := funcIds[oldFuncId]; ok {
+				result[fmt.Sprintf("%s%d_%s", funcKeyPrefix, newFuncId, key)] = v
+			}
+		case strings.HasPrefix(k, oldPrefix):
+			result[newPrefix+strings.TrimPrefix(k, oldPrefix)] = v
+		default:
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]*OpLayout) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
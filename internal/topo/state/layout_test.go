@@ -0,0 +1,94 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+)
+
+func TestMigrateState(t *testing.T) {
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(t, err)
+	require.NoError(t, store.SetupDefault(dataDir))
+	defer func() {
+		_ = store.DropTS("migrate_rule")
+	}()
+
+	oldLayout := map[string]*OpLayout{
+		"2_analytic":       {Identity: "analytic", Funcs: map[string]int{"acc_sum(a)": 1, "acc_max(a)": 2}},
+		"3_inc_agg_window": {Identity: "inc_agg_window:10s"},
+		"4_window":         {Identity: "window:20s"},
+	}
+	s, err := getKVStore("migrate_rule")
+	require.NoError(t, err)
+	s.SetLayout(oldLayout)
+	require.NoError(t, s.SaveState(1, "src", map[string]any{"offset": 10}))
+	require.NoError(t, s.SaveState(1, "2_analytic", map[string]any{"$$func1_self": 3, "$$func2_self": 5}))
+	require.NoError(t, s.SaveState(1, "3_inc_agg_window", map[string]any{"migrate_rule_3_inc_agg_window_0/state": "partial"}))
+	require.NoError(t, s.SaveState(1, "4_window", map[string]any{"inputs": "buffered"}))
+	require.NoError(t, s.SaveCheckpoint(1))
+
+	newLayout := map[string]*OpLayout{
+		"3_analytic":       {Identity: "analytic", Funcs: map[string]int{"acc_sum(a)": 2, "acc_min(a)": 1}},
+		"4_inc_agg_window": {Identity: "inc_agg_window:10s"},
+		"5_window":         {Identity: "window:30s"},
+	}
+	exp := []IncompatibleOp{{OpId: "4_window", Identity: "window:20s"}}
+	incompatible, err := MigrateState("migrate_rule", newLayout, true)
+	require.NoError(t, err)
+	require.Equal(t, exp, incompatible)
+	// dry run does not change the state
+	s, err = getKVStore("migrate_rule")
+	require.NoError(t, err)
+	m, err := s.GetOpState("3_analytic")
+	require.NoError(t, err)
+	_, ok := m.Load("$$func2_self")
+	require.False(t, ok)
+
+	incompatible, err = MigrateState("migrate_rule", newLayout, false)
+	require.NoError(t, err)
+	require.Equal(t, exp, incompatible)
+	s, err = getKVStore("migrate_rule")
+	require.NoError(t, err)
+	m, err = s.GetOpState("src")
+	require.NoError(t, err)
+	v, _ := m.Load("offset")
+	require.Equal(t, 10, v)
+	m, err = s.GetOpState("3_analytic")
+	require.NoError(t, err)
+	v, _ = m.Load("$$func2_self")
+	require.Equal(t, 3, v)
+	// the removed function
+	_, ok = m.Load("$$func1_self")
+	require.False(t, ok)
+	m, err = s.GetOpState("4_inc_agg_window")
+	require.NoError(t, err)
+	v, _ = m.Load("migrate_rule_4_inc_agg_window_0/state")
+	require.Equal(t, "partial", v)
+	m, err = s.GetOpState("5_window")
+	require.NoError(t, err)
+	_, ok = m.Load("inputs")
+	require.False(t, ok)
+
+	// migrate again with the saved layout
+	incompatible, err = MigrateState("migrate_rule", newLayout, true)
+	require.NoError(t, err)
+	require.Empty(t, incompatible)
+}
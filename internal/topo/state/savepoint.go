@@ -0,0 +1,105 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+
+	ts "github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+const SavepointVersion = 1
+
+// Savepoint is the portable snapshot of a rule state, such as the window buffers, the analytic function states and
+// the source offsets. It can be restored to a rule with the same topo in another instance.
+type Savepoint struct {
+	Version      int    `json:"version"`
+	RuleId       string `json:"ruleId"`
+	CheckpointId int64  `json:"checkpointId"`
+	// The op ids which have state in the snapshot, just for information
+	Operators []string `json:"operators"`
+	// The gob encoded snapshot of op id to op state
+	State []byte `json:"state"`
+}
+
+// CreateSavepoint reads the completed checkpoint of the rule from the checkpoint storage
+func CreateSavepoint(ruleId string, checkpointId int64) (*Savepoint, error) {
+	db, err := ts.GetTS(ruleId)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	found, err := db.Get(checkpointId, &m)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint %d of rule %s error: %v", checkpointId, ruleId, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("checkpoint %d of rule %s is not found", checkpointId, ruleId)
+	}
+	var buff bytes.Buffer
+	if err := gob.NewEncoder(&buff).Encode(m); err != nil {
+		return nil, fmt.Errorf("encode checkpoint %d of rule %s error: %v", checkpointId, ruleId, err)
+	}
+	ops := make([]string, 0, len(m))
+	for k := range m {
+		if k != LayoutKey {
+			ops = append(ops, k)
+		}
+	}
+	sort.Strings(ops)
+	return &Savepoint{
+		Version:      SavepointVersion,
+		RuleId:       ruleId,
+		CheckpointId: checkpointId,
+		Operators:    ops,
+		State:        buff.Bytes(),
+	}, nil
+}
+
+// RestoreSavepoint replaces the checkpoints of the rule with the savepoint. The rule must not be running and
+// will restore the state from it in the next start.
+func RestoreSavepoint(ruleId string, sp *Savepoint) error {
+	if sp.Version != SavepointVersion {
+		return fmt.Errorf("unsupported savepoint version %d", sp.Version)
+	}
+	var m map[string]interface{}
+	if err := gob.NewDecoder(bytes.NewReader(sp.State)).Decode(&m); err != nil {
+		return fmt.Errorf("decode savepoint state error: %v", err)
+	}
+	// Drop the existing checkpoints so that the savepoint is the latest one.
+	// Use the current time as the id so that the new checkpoints after restore are still increasing.
+	if _, err := ts.GetTS(ruleId); err != nil {
+		return err
+	}
+	if err := ts.DropTS(ruleId); err != nil {
+		return err
+	}
+	db, err := ts.GetTS(ruleId)
+	if err != nil {
+		return err
+	}
+	checkpointId := timex.GetNowInMilli()
+	if checkpointId <= 0 {
+		checkpointId = 1
+	}
+	if _, err := db.Set(checkpointId, m); err != nil {
+		return fmt.Errorf("save savepoint for rule %s error: %v", ruleId, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+)
+
+func TestSavepoint(t *testing.T) {
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(t, err)
+	require.NoError(t, store.SetupDefault(dataDir))
+	defer func() {
+		_ = store.DropTS("sp_src")
+		_ = store.DropTS("sp_dest")
+	}()
+
+	src, err := getKVStore("sp_src")
+	require.NoError(t, err)
+	require.NoError(t, src.SaveState(100, "op1", map[string]any{"offset": int64(10)}))
+	require.NoError(t, src.SaveState(100, "op2", map[string]any{"count": 3}))
+	require.NoError(t, src.SaveCheckpoint(100))
+
+	_, err = CreateSavepoint("sp_src", 99)
+	require.EqualError(t, err, "checkpoint 99 of rule sp_src is not found")
+	sp, err := CreateSavepoint("sp_src", 100)
+	require.NoError(t, err)
+	require.Equal(t, []string{"op1", "op2"}, sp.Operators)
+
+	// The savepoint file is transferred as json
+	b, err := json.Marshal(sp)
+	require.NoError(t, err)
+	nsp := &Savepoint{}
+	require.NoError(t, json.Unmarshal(b, nsp))
+
+	// The existing checkpoints of the destination are replaced
+	dest, err := getKVStore("sp_dest")
+	require.NoError(t, err)
+	require.NoError(t, dest.SaveState(200, "op1", map[string]any{"offset": int64(20)}))
+	require.NoError(t, dest.SaveCheckpoint(200))
+	require.NoError(t, RestoreSavepoint("sp_dest", nsp))
+	dest, err = getKVStore("sp_dest")
+	require.NoError(t, err)
+	m, err := dest.GetOpState("op1")
+	require.NoError(t, err)
+	v, _ := m.Load("offset")
+	require.Equal(t, int64(10), v)
+	m, err = dest.GetOpState("op2")
+	require.NoError(t, err)
+	v, _ = m.Load("count")
+	require.Equal(t, 3, v)
+
+	nsp.Version = 2
+	require.EqualError(t, RestoreSavepoint("sp_dest", nsp), "unsupported savepoint version 2")
+}
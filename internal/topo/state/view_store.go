@@ -0,0 +1,147 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"encoding/gob"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	ts "github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+func init() {
+	// The rows are gob encoded to keep the value types such as int64 and time
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register([]map[string]any{})
+	gob.Register(time.Time{})
+}
+
+// ViewStore keeps the latest rows of a materialized view keyed by the view key.
+// The rows are cached in memory for query and written through to the kv store so that they survive restarts.
+type ViewStore struct {
+	name string
+	db   kv.KeyValue
+	sync.RWMutex
+	rows map[string]map[string]any
+}
+
+var (
+	viewStores   = make(map[string]*ViewStore)
+	viewStoresMu sync.Mutex
+)
+
+func viewTable(name string) string {
+	return "view_" + name
+}
+
+// GetViewStore returns the store of the view. The first call loads the persisted rows.
+func GetViewStore(name string) (*ViewStore, error) {
+	viewStoresMu.Lock()
+	defer viewStoresMu.Unlock()
+	if s, ok := viewStores[name]; ok {
+		return s, nil
+	}
+	db, err := ts.GetKV(viewTable(name))
+	if err != nil {
+		return nil, err
+	}
+	s := &ViewStore{name: name, db: db, rows: make(map[string]map[string]any)}
+	if err := s.restore(); err != nil {
+		return nil, err
+	}
+	viewStores[name] = s
+	return s, nil
+}
+
+// DropViewStore removes all the rows of the view including the persisted ones.
+func DropViewStore(name string) error {
+	viewStoresMu.Lock()
+	defer viewStoresMu.Unlock()
+	delete(viewStores, name)
+	return ts.DropKV(viewTable(name))
+}
+
+func (s *ViewStore) restore() error {
+	keys, err := s.db.Keys()
+	if err != nil {
+		return fmt.Errorf("load view %s error: %v", s.name, err)
+	}
+	for _, k := range keys {
+		row := make(map[string]any)
+		if _, err := s.db.Get(k, &row); err != nil {
+			return fmt.Errorf("load view %s row %s error: %v", s.name, k, err)
+		}
+		s.rows[k] = row
+	}
+	return nil
+}
+
+// Upsert inserts or replaces the row of the key.
+func (s *ViewStore) Upsert(key string, row map[string]any) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.db.Set(key, row); err != nil {
+		return fmt.Errorf("save view %s row error: %v", s.name, err)
+	}
+	s.rows[key] = row
+	return nil
+}
+
+// Replace replaces all the existing rows with the new rows. Only the rows which are not in the new rows are deleted.
+func (s *ViewStore) Replace(rows map[string]map[string]any) error {
+	s.Lock()
+	defer s.Unlock()
+	for k := range s.rows {
+		if _, ok := rows[k]; ok {
+			continue
+		}
+		if err := s.db.Delete(k); err != nil {
+			return fmt.Errorf("delete view %s row error: %v", s.name, err)
+		}
+		delete(s.rows, k)
+	}
+	for k, row := range rows {
+		if err := s.db.Set(k, row); err != nil {
+			return fmt.Errorf("save view %s row error: %v", s.name, err)
+		}
+		s.rows[k] = row
+	}
+	return nil
+}
+
+// Rows returns the snapshot of all rows ordered by the key.
+func (s *ViewStore) Rows() []map[string]any {
+	s.RLock()
+	defer s.RUnlock()
+	keys := make([]string, 0, len(s.rows))
+	for k := range s.rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		row := make(map[string]any, len(s.rows[k]))
+		for f, v := range s.rows[k] {
+			row[f] = v
+		}
+		result = append(result, row)
+	}
+	return result
+}
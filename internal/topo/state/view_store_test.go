@@ -0,0 +1,68 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+)
+
+func TestViewStore(t *testing.T) {
+	dataDir, err := conf.GetDataLoc()
+	require.NoError(t, err)
+	require.NoError(t, store.SetupDefault(dataDir))
+	defer func() {
+		require.NoError(t, DropViewStore("test_view"))
+	}()
+
+	s, err := GetViewStore("test_view")
+	require.NoError(t, err)
+	require.NoError(t, s.Upsert(`["d1"]`, map[string]any{"deviceId": "d1", "temp": 20.5}))
+	require.NoError(t, s.Upsert(`["d2"]`, map[string]any{"deviceId": "d2", "temp": 21.0, "count": int64(2)}))
+	require.NoError(t, s.Upsert(`["d1"]`, map[string]any{"deviceId": "d1", "temp": 22.5}))
+	exp := []map[string]any{
+		{"deviceId": "d1", "temp": 22.5},
+		{"deviceId": "d2", "temp": 21.0, "count": int64(2)},
+	}
+	require.Equal(t, exp, s.Rows())
+
+	// Simulate a restart by loading the rows from the kv store again. The value types are kept.
+	s = reloadViewStore(t, "test_view")
+	require.Equal(t, exp, s.Rows())
+
+	require.NoError(t, s.Replace(map[string]map[string]any{"": {"total": int64(3)}}))
+	require.Equal(t, []map[string]any{{"total": int64(3)}}, s.Rows())
+	require.NoError(t, s.Replace(map[string]map[string]any{"": {"total": int64(4)}}))
+	s = reloadViewStore(t, "test_view")
+	require.Equal(t, []map[string]any{{"total": int64(4)}}, s.Rows())
+
+	require.NoError(t, DropViewStore("test_view"))
+	s, err = GetViewStore("test_view")
+	require.NoError(t, err)
+	require.Empty(t, s.Rows())
+}
+
+func reloadViewStore(t *testing.T, name string) *ViewStore {
+	viewStoresMu.Lock()
+	delete(viewStores, name)
+	viewStoresMu.Unlock()
+	s, err := GetViewStore(name)
+	require.NoError(t, err)
+	return s
+}
@@ -156,6 +156,19 @@ func (s *SrcSubTopo) SubMetrics() (keys []string, values []any) {
 	return
 }
 
+func (s *SrcSubTopo) SubStats() map[string]*node.NodeStats {
+	result := make(map[string]*node.NodeStats, len(s.ops)+1)
+	if an, ok := s.source.(node.AnalyzableNode); ok {
+		result[fmt.Sprintf("source_%s", s.source.GetName())] = an.GetStats()
+	}
+	for _, so := range s.ops {
+		if an, ok := so.(node.AnalyzableNode); ok {
+			result[fmt.Sprintf("op_%s_%s", s.name, so.GetName())] = an.GetStats()
+		}
+	}
+	return result
+}
+
 func (s *SrcSubTopo) GetMetrics() []any {
 	result := s.source.GetMetrics()
 	for _, op := range s.ops {
@@ -56,6 +56,7 @@ type Topo struct {
 	options     *def.RuleOption
 	store       api.Store
 	coordinator *checkpoint.Coordinator
+	stateLayout map[string]*state.OpLayout
 	topo        *def.PrintableTopo
 	mu          sync.Mutex
 	hasOpened   atomic.Bool
@@ -259,6 +260,9 @@ func (s *Topo) Open() <-chan error {
 		if s.store, err = state.CreateStore(s.name, s.options.Qos); err != nil {
 			return fmt.Errorf("topo %s create store error %v", s.name, err)
 		}
+		if ks, ok := s.store.(*state.KVStore); ok {
+			ks.SetLayout(s.stateLayout)
+		}
 		if err := s.enableCheckpoint(s.ctx); err != nil {
 			return err
 		}
@@ -320,6 +324,18 @@ func (s *Topo) enableCheckpoint(ctx api.StreamContext) error {
 	return nil
 }
 
+// SetStateLayout sets the identity of a stateful operator so that its state can be migrated when the rule is updated
+func (s *Topo) SetStateLayout(opId string, layout *state.OpLayout) {
+	if s.stateLayout == nil {
+		s.stateLayout = make(map[string]*state.OpLayout)
+	}
+	s.stateLayout[opId] = layout
+}
+
+func (s *Topo) GetStateLayout() map[string]*state.OpLayout {
+	return s.stateLayout
+}
+
 func (s *Topo) GetCoordinator() *checkpoint.Coordinator {
 	return s.coordinator
 }
@@ -394,6 +410,32 @@ func (s *Topo) GetMetrics() (keys []string, values []any) {
 	return
 }
 
+// GetStats returns the runtime statistics of the nodes keyed by the node name in the printable topo
+func (s *Topo) GetStats() map[string]*node.NodeStats {
+	result := make(map[string]*node.NodeStats)
+	for _, sn := range s.sources {
+		switch st := sn.(type) {
+		case node.MergeableTopo:
+			for k, v := range st.SubStats() {
+				result[k] = v
+			}
+		case node.AnalyzableNode:
+			result["source_"+sn.GetName()] = st.GetStats()
+		}
+	}
+	for _, so := range s.ops {
+		if an, ok := so.(node.AnalyzableNode); ok {
+			result["op_"+so.GetName()] = an.GetStats()
+		}
+	}
+	for _, sn := range s.sinks {
+		if an, ok := sn.(node.AnalyzableNode); ok {
+			result["sink_"+sn.GetName()] = an.GetStats()
+		}
+	}
+	return result
+}
+
 func (s *Topo) RemoveMetrics() {
 	conf.Log.Infof("start removing %v metrics", s.name)
 	for _, sn := range s.sources {
@@ -139,6 +139,56 @@ func TestWindowFuncSQL(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "TestRank",
+			Sql:  `select color, size, rank() over (partition by color order by size desc) as r from demo group by countWindow(5)`,
+			R: [][]map[string]interface{}{
+				{
+					{"color": "blue", "size": 6, "r": 1},
+					{"color": "blue", "size": 2, "r": 2},
+					{"color": "red", "size": 3, "r": 1},
+					{"color": "red", "size": 1, "r": 2},
+					{"color": "yellow", "size": 4, "r": 1},
+				},
+			},
+		},
+		{
+			Name: "TestNtile",
+			Sql:  `select size, ntile(2) over (order by size) as bucket from demo group by countWindow(5)`,
+			R: [][]map[string]interface{}{
+				{
+					{"size": 1, "bucket": 1},
+					{"size": 2, "bucket": 1},
+					{"size": 3, "bucket": 1},
+					{"size": 4, "bucket": 2},
+					{"size": 6, "bucket": 2},
+				},
+			},
+		},
+		{
+			Name: "TestLead",
+			Sql:  `select size, lead(size, 1, 0) over (order by size) as next from demo group by countWindow(5)`,
+			R: [][]map[string]interface{}{
+				{
+					{"size": 1, "next": 2},
+					{"size": 2, "next": 3},
+					{"size": 3, "next": 4},
+					{"size": 4, "next": 6},
+					{"size": 6, "next": int64(0)},
+				},
+			},
+		},
+		{
+			Name: "TestTopN",
+			Sql:  `select color, size from (select color, size, dense_rank() over (partition by color order by size desc) as r from demo group by countWindow(5)) as t where r <= 1`,
+			R: [][]map[string]interface{}{
+				{
+					{"color": "blue", "size": 6},
+					{"color": "red", "size": 3},
+					{"color": "yellow", "size": 4},
+				},
+			},
+		},
 	}
 	// Data setup
 	HandleStream(true, streamList, t)
@@ -1874,6 +1924,166 @@ func TestAliasSQL(t *testing.T) {
 	}
 }
 
+func TestUnionSQL(t *testing.T) {
+	streamList := []string{"demo", "demo1"}
+	HandleStream(false, streamList, t)
+	tests := []RuleTest{
+		{
+			Name: "TestUnionRule1",
+			Sql:  `SELECT color, size FROM demo WHERE size > 3 UNION ALL SELECT temp AS size, hum FROM demo1 WHERE hum > 70`,
+			R: [][]map[string]interface{}{
+				{{
+					"color": "blue",
+					"size":  6,
+				}},
+				{{
+					"size": 28.1,
+					"hum":  75,
+				}},
+				{{
+					"color": "yellow",
+					"size":  4,
+				}},
+				{{
+					"size": 27.4,
+					"hum":  80,
+				}},
+			},
+			M: map[string]interface{}{
+				"source_demo_0_records_in_total":   int64(5),
+				"source_demo_0_records_out_total":  int64(5),
+				"source_demo1_0_records_in_total":  int64(5),
+				"source_demo1_0_records_out_total": int64(5),
+				"op_7_union_0_records_in_total":    int64(4),
+				"op_7_union_0_records_out_total":   int64(4),
+				"sink_memory_0_0_records_in_total": int64(4),
+			},
+		},
+		{
+			Name: "TestUnionRule2",
+			Sql:  `SELECT size AS v FROM demo WHERE size > 100 UNION ALL SELECT hum AS v FROM demo1 GROUP BY CountWindow(2)`,
+			R: [][]map[string]interface{}{
+				{{"v": 65}, {"v": 59}},
+				{{"v": 75}, {"v": 80}},
+			},
+		},
+	}
+	HandleStream(true, streamList, t)
+	options := []*def.RuleOption{
+		{
+			BufferLength: 100,
+			SendError:    true,
+		},
+		{
+			BufferLength:       100,
+			SendError:          true,
+			Qos:                def.AtLeastOnce,
+			CheckpointInterval: cast.DurationConf(5 * time.Second),
+		},
+	}
+	for _, opt := range options {
+		DoRuleTest(t, tests, opt, 0)
+	}
+}
+
+func TestSubQuerySQL(t *testing.T) {
+	streamList := []string{"demo", "demo1"}
+	HandleStream(false, streamList, t)
+	tests := []RuleTest{
+		{
+			Name: "TestSubQueryRule1",
+			Sql:  `WITH cleaned AS (SELECT size * 2 AS dsize FROM demo WHERE size > 1) SELECT count(*) AS c, max(dsize) AS m FROM cleaned GROUP BY CountWindow(2)`,
+			R: [][]map[string]interface{}{
+				{{
+					"c": 2,
+					"m": int64(12),
+				}},
+				{{
+					"c": 2,
+					"m": int64(8),
+				}},
+			},
+			M: map[string]interface{}{
+				"source_demo_0_records_in_total":   int64(5),
+				"source_demo_0_records_out_total":  int64(5),
+				"sink_memory_0_0_records_in_total": int64(2),
+			},
+		},
+		{
+			Name: "TestSubQueryRule2",
+			Sql:  `SELECT count(*) AS c, max(v) AS m FROM (SELECT size AS v FROM demo UNION ALL SELECT hum AS v FROM demo1) AS t GROUP BY CountWindow(4)`,
+			R: [][]map[string]interface{}{
+				{{
+					"c": 4,
+					"m": int64(65),
+				}},
+				{{
+					"c": 4,
+					"m": int64(80),
+				}},
+			},
+		},
+		{
+			Name: "TestSubQueryRule3",
+			Sql:  `SELECT t.v FROM (SELECT size AS v FROM demo) AS t WHERE v > 3`,
+			R: [][]map[string]interface{}{
+				{{
+					"v": 6,
+				}},
+				{{
+					"v": 4,
+				}},
+			},
+		},
+	}
+	HandleStream(true, streamList, t)
+	options := []*def.RuleOption{
+		{
+			BufferLength: 100,
+			SendError:    true,
+		},
+	}
+	for _, opt := range options {
+		DoRuleTest(t, tests, opt, 0)
+	}
+}
+
+func TestSemiJoinSQL(t *testing.T) {
+	// Reset
+	streamList := []string{"demo", "demoTable"}
+	HandleStream(false, streamList, t)
+	tests := []RuleTest{
+		{
+			Name: `TestSemiJoinSQL1`,
+			Sql:  `SELECT color, size FROM demo WHERE ts IN (SELECT ts FROM demoTable)`,
+			R: [][]map[string]interface{}{
+				{{
+					"color": "blue",
+					"size":  6,
+				}},
+				{{
+					"color": "yellow",
+					"size":  4,
+				}},
+				{{
+					"color": "red",
+					"size":  1,
+				}},
+			},
+		},
+	}
+	HandleStream(true, streamList, t)
+	options := []*def.RuleOption{
+		{
+			BufferLength: 100,
+			SendError:    true,
+		},
+	}
+	for _, opt := range options {
+		DoRuleTest(t, tests, opt, 0)
+	}
+}
+
 func TestRuleWaitGroup(t *testing.T) {
 	streamList := []string{"demo"}
 	HandleStream(false, streamList, t)
@@ -1596,3 +1596,70 @@ func TestEventSlidingWindow(t *testing.T) {
 		DoRuleTest(t, tests, opt, 10)
 	}
 }
+
+func TestWindowDistinct(t *testing.T) {
+	// Reset
+	streamList := []string{"demo"}
+	HandleStream(false, streamList, t)
+	tests := []RuleTest{
+		{
+			Name: `TestWindowDistinct1`,
+			Sql:  `SELECT DISTINCT color FROM demo GROUP BY COUNTWINDOW(5)`,
+			R: [][]map[string]interface{}{
+				{
+					{"color": "red"},
+					{"color": "blue"},
+					{"color": "yellow"},
+				},
+			},
+		},
+		{
+			Name: `TestWindowDistinct2`,
+			Sql:  `SELECT DISTINCT color FROM demo GROUP BY COUNTWINDOW(5) LIMIT 2`,
+			R: [][]map[string]interface{}{
+				{
+					{"color": "red"},
+					{"color": "blue"},
+				},
+			},
+		},
+		{
+			Name: `TestWindowDistinct3`,
+			Sql:  `SELECT count(DISTINCT color) AS c, count(color) AS total, collect(DISTINCT color) AS colors FROM demo GROUP BY COUNTWINDOW(5)`,
+			R: [][]map[string]interface{}{
+				{{
+					"c":      3,
+					"total":  5,
+					"colors": []interface{}{"red", "blue", "yellow"},
+				}},
+			},
+		},
+	}
+	HandleStream(true, streamList, t)
+	DoRuleTest(t, tests, &def.RuleOption{
+		BufferLength: 100,
+		SendError:    true,
+	}, 0)
+	// The incremental aggregation only keeps the distinct values
+	incTests := []RuleTest{
+		{
+			Name: `TestWindowIncDistinct`,
+			Sql:  `SELECT count(DISTINCT color) AS c, count(color) AS total, sum(DISTINCT size) AS s, collect(DISTINCT color) AS colors FROM demo GROUP BY COUNTWINDOW(5)`,
+			R: [][]map[string]interface{}{
+				{{
+					"c":      int64(3),
+					"total":  int64(5),
+					"s":      float64(16),
+					"colors": []interface{}{"red", "blue", "yellow"},
+				}},
+			},
+		},
+	}
+	DoRuleTest(t, incTests, &def.RuleOption{
+		BufferLength: 100,
+		SendError:    true,
+		PlanOptimizeStrategy: &def.PlanOptimizeStrategy{
+			EnableIncrementalWindow: true,
+		},
+	}, 0)
+}
@@ -231,6 +231,10 @@ func (s *Scanner) ScanIdent() (tok ast.Token, lit string) {
 		return ast.REPLACE, lit
 	case "EXCEPT":
 		return ast.EXCEPT, lit
+	case "UNION":
+		return ast.UNION, lit
+	case "DISTINCT":
+		return ast.DISTINCT, lit
 	case "TRUE":
 		return ast.TRUE, lit
 	case "FALSE":
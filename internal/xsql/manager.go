@@ -64,6 +64,11 @@ func init() {
 		return p.Parse()
 	})
 
+	// Select statement with common table expressions
+	Language.Handle(ast.WITH, func(p *Parser) (ast.Statement, error) {
+		return p.Parse()
+	})
+
 	Language.Handle(ast.CREATE, func(p *Parser) (statement ast.Statement, e error) {
 		return p.ParseCreateStmt()
 	})
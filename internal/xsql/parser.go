@@ -45,6 +45,10 @@ type Parser struct {
 	fn          int    // function index number
 	clause      string
 	sourceNames []string // source names in the from/join clause
+	// common table expressions defined in the WITH clause
+	ctes map[string]*ast.SelectStatement
+	// source names of the enclosing query which can be referred by the IN or EXISTS subquery
+	outerSourceNames []string
 }
 
 func (p *Parser) ParseCondition() (ast.Expr, error) {
@@ -148,14 +152,158 @@ func (p *Parser) ParseQueries() ([]ast.SelectStatement, error) {
 }
 
 func (p *Parser) Parse() (*ast.SelectStatement, error) {
-	selects := &ast.SelectStatement{}
-
-	if tok, lit := p.scanIgnoreWhitespace(); tok == ast.EOF {
+	p.ctes = nil
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok == ast.EOF {
 		return nil, nil
-	} else if tok != ast.SELECT {
+	}
+	if tok == ast.IDENT && strings.EqualFold(lit, "WITH") {
+		if err := p.parseWith(); err != nil {
+			return nil, err
+		}
+		tok, lit = p.scanIgnoreWhitespace()
+	}
+	if tok != ast.SELECT {
 		return nil, fmt.Errorf("Found %q, Expected SELECT.\n", lit)
 	}
+	selects, branchSources, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	p.clause = ""
+	tok, lit = p.scanIgnoreWhitespace()
+	if tok == ast.SEMICOLON {
+		p.unscan()
+	} else if tok != ast.EOF {
+		return nil, fmt.Errorf("found %q, expected EOF.", lit)
+	}
+	// Keep the original behavior to skip the validation for multiple statements
+	if err := validateQuery(selects, branchSources, tok != ast.SEMICOLON); err != nil {
+		return nil, err
+	}
+	return selects, nil
+}
+
+// parseQuery parses a select statement and all its UNION ALL branches after the first SELECT keyword.
+// It returns the source names of each branch to validate the fields.
+func (p *Parser) parseQuery() (*ast.SelectStatement, [][]string, error) {
+	// The source names may be injected from outside to parse part of the sql
+	injected := p.sourceNames
+	selects, err := p.parseSelect()
+	if err != nil {
+		return nil, nil, err
+	}
+	branchSources := [][]string{p.sourceNames}
+	for {
+		if tok, _ := p.scanIgnoreWhitespace(); tok != ast.UNION {
+			p.unscan()
+			break
+		}
+		if tok, lit := p.scanIgnoreWhitespace(); tok != ast.IDENT || !strings.EqualFold(lit, "ALL") {
+			return nil, nil, fmt.Errorf("found %q, expected ALL. Only UNION ALL is supported.", lit)
+		}
+		if tok, lit := p.scanIgnoreWhitespace(); tok != ast.SELECT {
+			return nil, nil, fmt.Errorf("found %q, expected SELECT after UNION ALL.", lit)
+		}
+		p.sourceNames = injected
+		branch, err := p.parseSelect()
+		if err != nil {
+			return nil, nil, err
+		}
+		selects.Unions = append(selects.Unions, branch)
+		branchSources = append(branchSources, p.sourceNames)
+	}
+	return selects, branchSources, nil
+}
+
+func validateQuery(selects *ast.SelectStatement, branchSources [][]string, validate bool) error {
+	branches := append([]*ast.SelectStatement{selects}, selects.Unions...)
+	for i, branch := range branches {
+		if validate {
+			if err := Validate(branch); err != nil {
+				return err
+			}
+		}
+		if len(branches) > 1 && (branch.SortFields != nil || branch.Limit != nil) {
+			return fmt.Errorf("ORDER BY and LIMIT are not supported in UNION ALL.")
+		}
+		validateFields(branch, branchSources[i])
+	}
+	return nil
+}
+
+// parseWith parses the common table expressions like WITH name AS (SELECT ...), name2 AS (SELECT ...)
+// The later expressions and the main query can refer to the former ones by name.
+func (p *Parser) parseWith() error {
+	for {
+		tok, name := p.scanIgnoreWhitespace()
+		if tok != ast.IDENT {
+			return fmt.Errorf("found %q, expected common table expression name.", name)
+		}
+		if tok, lit := p.scanIgnoreWhitespace(); tok != ast.AS {
+			return fmt.Errorf("found %q, expected AS.", lit)
+		}
+		if tok, lit := p.scanIgnoreWhitespace(); tok != ast.LPAREN {
+			return fmt.Errorf("found %q, expected (.", lit)
+		}
+		q, err := p.parseSubQuery()
+		if err != nil {
+			return err
+		}
+		if p.ctes == nil {
+			p.ctes = make(map[string]*ast.SelectStatement)
+		}
+		if _, ok := p.ctes[name]; ok {
+			return fmt.Errorf("common table expression %s is defined more than once.", name)
+		}
+		p.ctes[name] = q
+		if tok, _ := p.scanIgnoreWhitespace(); tok != ast.COMMA {
+			p.unscan()
+			return nil
+		}
+	}
+}
+
+// parseSubQuery parses a complete query after the left parenthesis until the right parenthesis
+func (p *Parser) parseSubQuery() (*ast.SelectStatement, error) {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != ast.SELECT {
+		return nil, fmt.Errorf("found %q, expected SELECT in subquery.", lit)
+	}
+	sourceNames, clause := p.sourceNames, p.clause
+	p.sourceNames = nil
+	q, branchSources, err := p.parseQuery()
+	p.sourceNames, p.clause = sourceNames, clause
+	if err != nil {
+		return nil, err
+	}
+	if tok, lit := p.scanIgnoreWhitespace(); tok != ast.RPAREN {
+		return nil, fmt.Errorf("found %q, expected ) to end the subquery.", lit)
+	}
+	if err := validateQuery(q, branchSources, true); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// parseCorrelatedSubQuery parses the subquery of IN or EXISTS which can refer to the sources of the enclosing query
+func (p *Parser) parseCorrelatedSubQuery() (*ast.SelectStatement, error) {
+	outer := p.outerSourceNames
+	p.outerSourceNames = p.sourceNames
+	defer func() {
+		p.outerSourceNames = outer
+	}()
+	return p.parseSubQuery()
+}
+
+// parseSelect parses a select statement after the SELECT keyword until the end of LIMIT clause
+func (p *Parser) parseSelect() (*ast.SelectStatement, error) {
+	selects := &ast.SelectStatement{}
 	p.clause = "select"
+	if tok, _ := p.scanIgnoreWhitespace(); tok == ast.DISTINCT {
+		selects.Distinct = true
+	} else {
+		p.unscan()
+	}
 	if fields, err := p.parseFields(); err != nil {
 		return nil, err
 	} else {
@@ -175,7 +323,7 @@ func (p *Parser) Parse() (*ast.SelectStatement, error) {
 	}
 	// The source names may be injected from outside to parse part of the sql
 	if p.sourceNames == nil {
-		p.sourceNames = getStreamNames(selects)
+		p.sourceNames = append(getStreamNames(selects), p.outerSourceNames...)
 	}
 	p.clause = "where"
 	if exp, err := p.ParseCondition(); err != nil {
@@ -212,19 +360,6 @@ func (p *Parser) Parse() (*ast.SelectStatement, error) {
 			selects.Limit = expr
 		}
 	}
-	p.clause = ""
-	if tok, lit := p.scanIgnoreWhitespace(); tok == ast.SEMICOLON {
-		validateFields(selects, p.sourceNames)
-		p.unscan()
-		return selects, nil
-	} else if tok != ast.EOF {
-		return nil, fmt.Errorf("found %q, expected EOF.", lit)
-	}
-
-	if err := Validate(selects); err != nil {
-		return nil, err
-	}
-	validateFields(selects, p.sourceNames)
 	return selects, nil
 }
 
@@ -234,10 +369,26 @@ func (p *Parser) parseSource() (ast.Sources, error) {
 		return nil, fmt.Errorf("found %q, expected FROM.", lit)
 	}
 
+	if tok, _ := p.scanIgnoreWhitespace(); tok == ast.LPAREN {
+		q, err := p.parseSubQuery()
+		if err != nil {
+			return nil, err
+		}
+		if tok, lit := p.scanIgnoreWhitespace(); tok != ast.AS {
+			return nil, fmt.Errorf("found %q, expected AS. Subquery in FROM must have an alias.", lit)
+		}
+		tok, alias := p.scanIgnoreWhitespace()
+		if tok != ast.IDENT {
+			return nil, fmt.Errorf("found %q, expected the alias of the subquery.", alias)
+		}
+		return append(sources, &ast.Table{Name: alias, Query: q}), nil
+	}
+	p.unscan()
+
 	if src, alias, err := p.parseSourceLiteral(); err != nil {
 		return nil, err
 	} else {
-		sources = append(sources, &ast.Table{Name: src, Alias: alias})
+		sources = append(sources, &ast.Table{Name: src, Alias: alias, Query: p.ctes[src]})
 	}
 
 	return sources, nil
@@ -337,6 +488,9 @@ func (p *Parser) ParseJoin(joinType ast.JoinType) (*ast.Join, error) {
 	if src, alias, err := p.parseSourceLiteral(); err != nil {
 		return nil, err
 	} else {
+		if _, ok := p.ctes[src]; ok {
+			return nil, fmt.Errorf("common table expression %s cannot be joined.", src)
+		}
 		j.Name = src
 		j.Alias = alias
 		if tok1, _ := p.scanIgnoreWhitespace(); tok1 == ast.ON {
@@ -649,6 +803,10 @@ func (p *Parser) parseBetween(lhs ast.Expr, op ast.Token) (ast.Expr, error) {
 
 func (p *Parser) parseUnaryExpr(isSubField bool) (ast.Expr, error) {
 	if tok1, _ := p.scanIgnoreWhitespace(); tok1 == ast.LPAREN {
+		if tok2, _ := p.scanIgnoreWhitespace(); tok2 == ast.SELECT {
+			return nil, fmt.Errorf("subquery is only supported after IN or EXISTS.")
+		}
+		p.unscan()
 		expr, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
@@ -670,8 +828,31 @@ func (p *Parser) parseUnaryExpr(isSubField bool) (ast.Expr, error) {
 	tok, lit := p.scanIgnoreWhiteSpaceWithNegativeNum()
 	if tok == ast.CASE {
 		return p.parseCaseExpr()
+	} else if tok == ast.NOT {
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != ast.IDENT || !strings.EqualFold(lit1, "EXISTS") {
+			return nil, fmt.Errorf("found %q, expected EXISTS after NOT.", lit1)
+		}
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != ast.LPAREN {
+			return nil, fmt.Errorf("found %q, expected ( after EXISTS.", lit1)
+		}
+		q, err := p.parseCorrelatedSubQuery()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ExistsExpr{Query: q, Not: true}, nil
 	} else if tok == ast.IDENT {
 		if tok1, _ := p.scanIgnoreWhitespace(); tok1 == ast.LPAREN {
+			if strings.EqualFold(lit, "EXISTS") {
+				tok2, _ := p.scanIgnoreWhitespace()
+				p.unscan()
+				if tok2 == ast.SELECT {
+					q, err := p.parseCorrelatedSubQuery()
+					if err != nil {
+						return nil, err
+					}
+					return &ast.ExistsExpr{Query: q}, nil
+				}
+			}
 			return p.parseCall(lit)
 		}
 		p.unscan() // Back the Lparen token
@@ -747,6 +928,15 @@ func (p *Parser) parseValueSetExpr() (ast.Expr, error) {
 	// IN ("A", "B") or IN expression
 	tk, _ := p.scanIgnoreWhitespace()
 	if tk == ast.LPAREN {
+		tk1, _ := p.scanIgnoreWhitespace()
+		p.unscan()
+		if tk1 == ast.SELECT {
+			q, err := p.parseCorrelatedSubQuery()
+			if err != nil {
+				return nil, err
+			}
+			return &ast.SubQueryExpr{Query: q}, nil
+		}
 		for {
 			element, err := p.ParseExpr()
 			if err != nil {
@@ -895,6 +1085,19 @@ func (p *Parser) parseCall(n string) (ast.Expr, error) {
 		return nil, fmt.Errorf("function %s can only be used inside the select clause", n)
 	}
 	var args []ast.Expr
+	distinct := false
+	if tok, _ := p.scanIgnoreWhitespace(); tok == ast.DISTINCT {
+		if ft != ast.FuncTypeAgg {
+			return nil, fmt.Errorf("DISTINCT is only supported in aggregate functions, but %s is not.", n)
+		}
+		if tok, lit := p.scanIgnoreWhitespace(); tok == ast.RPAREN || tok == ast.ASTERISK {
+			return nil, fmt.Errorf("found %q, expected an expression after DISTINCT in function %s.", lit, n)
+		}
+		p.unscan()
+		distinct = true
+	} else {
+		p.unscan()
+	}
 	for {
 		if tok, _ := p.scanIgnoreWhitespace(); tok == ast.RPAREN {
 			break
@@ -946,7 +1149,7 @@ func (p *Parser) parseCall(n string) (ast.Expr, error) {
 		if name == "deduplicate" {
 			args = append([]ast.Expr{&ast.Wildcard{Token: ast.ASTERISK}}, args...)
 		}
-		c := &ast.Call{Name: name, Args: args, FuncId: p.fn, FuncType: ft}
+		c := &ast.Call{Name: name, Args: args, FuncId: p.fn, FuncType: ft, Distinct: distinct}
 		p.fn += 1
 		e := p.parseOver(c)
 		return c, e
@@ -1149,6 +1352,8 @@ func (p *Parser) ParseCreateStmt() (ast.Statement, error) {
 		stmt := &ast.StreamStmt{}
 		lit1 = strings.ToUpper(lit1)
 		switch lit1 {
+		case ast.VIEW:
+			return p.parseViewStmt()
 		case ast.STREAM:
 			stmt.StreamType = ast.TypeStream
 		case ast.TABLE:
@@ -1188,6 +1393,27 @@ func (p *Parser) ParseCreateStmt() (ast.Statement, error) {
 	}
 }
 
+// parseViewStmt parses the rest of CREATE VIEW name AS SELECT ... after the VIEW keyword
+func (p *Parser) parseViewStmt() (ast.Statement, error) {
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok != ast.IDENT {
+		return nil, fmt.Errorf("found %q, expected view name.", lit)
+	}
+	stmt := &ast.ViewStmt{Name: lit}
+	if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != ast.AS {
+		return nil, fmt.Errorf("found %q, expected keyword as.", lit1)
+	}
+	sel, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if sel == nil {
+		return nil, fmt.Errorf("found EOF, expected select statement.")
+	}
+	stmt.Select = sel
+	return stmt, nil
+}
+
 // TODO more accurate validation for table
 func validateStream(stmt *ast.StreamStmt) error {
 	f := stmt.Options.FORMAT
@@ -1243,6 +1469,13 @@ func (p *Parser) parseShowStmt() (ast.Statement, error) {
 			} else {
 				return nil, fmt.Errorf("found %q, expected semecolon or EOF.", lit2)
 			}
+		case ast.VIEWS:
+			ss := &ast.ShowViewsStatement{}
+			if tok2, lit2 := p.scanIgnoreWhitespace(); tok2 == ast.EOF || tok2 == ast.SEMICOLON {
+				return ss, nil
+			} else {
+				return nil, fmt.Errorf("found %q, expected semecolon or EOF.", lit2)
+			}
 		default:
 			return nil, fmt.Errorf("found %q, expected keyword streams or tables.", lit1)
 		}
@@ -1275,6 +1508,14 @@ func (p *Parser) parseDescribeStmt() (ast.Statement, error) {
 			} else {
 				return nil, fmt.Errorf("found %q, expected table name.", lit2)
 			}
+		case ast.VIEW:
+			dvs := &ast.DescribeViewStatement{}
+			if tok2, lit2 := p.scanIgnoreWhitespace(); tok2 == ast.IDENT {
+				dvs.Name = lit2
+				return dvs, nil
+			} else {
+				return nil, fmt.Errorf("found %q, expected view name.", lit2)
+			}
 		default:
 			return nil, fmt.Errorf("found %q, expected keyword stream or table.", lit1)
 		}
@@ -1339,6 +1580,14 @@ func (p *Parser) parseDropStmt() (ast.Statement, error) {
 			} else {
 				return nil, fmt.Errorf("found %q, expected table name.", lit2)
 			}
+		case ast.VIEW:
+			dvs := &ast.DropViewStatement{}
+			if tok2, lit2 := p.scanIgnoreWhitespace(); tok2 == ast.IDENT {
+				dvs.Name = lit2
+				return dvs, nil
+			} else {
+				return nil, fmt.Errorf("found %q, expected view name.", lit2)
+			}
 		default:
 			return nil, fmt.Errorf("found %q, expected keyword stream or table.", lit1)
 		}
@@ -4540,3 +4540,272 @@ func TestParser_ParseLimit(t *testing.T) {
 		require.Equal(t, tt.stmt, stmt)
 	}
 }
+
+func TestParser_ParseUnion(t *testing.T) {
+	tests := []struct {
+		s    string
+		stmt *ast.SelectStatement
+		err  string
+	}{
+		{
+			s: "SELECT name FROM tbl UNION ALL SELECT temp AS name FROM tbl2 WHERE temp > 20",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "name", StreamName: ast.DefaultStream},
+						Name: "name",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+				Unions: []*ast.SelectStatement{
+					{
+						Fields: []ast.Field{
+							{
+								Expr:  &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream},
+								Name:  "temp",
+								AName: "name",
+							},
+						},
+						Sources: []ast.Source{&ast.Table{Name: "tbl2"}},
+						Condition: &ast.BinaryExpr{
+							OP:  ast.GT,
+							LHS: &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream},
+							RHS: &ast.IntegerLiteral{Val: 20},
+						},
+					},
+				},
+			},
+		},
+		{
+			s:   "SELECT name FROM tbl UNION SELECT name FROM tbl2",
+			err: "found \"SELECT\", expected ALL. Only UNION ALL is supported.",
+		},
+		{
+			s:   "SELECT name FROM tbl UNION ALL name FROM tbl2",
+			err: "found \"name\", expected SELECT after UNION ALL.",
+		},
+		{
+			s:   "SELECT name FROM tbl UNION ALL SELECT name FROM tbl2 ORDER BY name",
+			err: "ORDER BY and LIMIT are not supported in UNION ALL.",
+		},
+	}
+
+	for _, tt := range tests {
+		stmt, err := NewParser(strings.NewReader(tt.s)).Parse()
+		if tt.err != "" {
+			require.EqualError(t, err, tt.err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tt.stmt, stmt)
+	}
+}
+
+func TestParser_ParseSubQuery(t *testing.T) {
+	inner := &ast.SelectStatement{
+		Fields: []ast.Field{
+			{
+				Expr: &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream},
+				Name: "temp",
+			},
+		},
+		Sources:   []ast.Source{&ast.Table{Name: "demo"}},
+		Condition: &ast.BinaryExpr{OP: ast.GT, LHS: &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream}, RHS: &ast.IntegerLiteral{Val: 20}},
+	}
+	tests := []struct {
+		s    string
+		stmt *ast.SelectStatement
+		err  string
+	}{
+		{
+			s: "SELECT t.temp FROM (SELECT temp FROM demo WHERE temp > 20) AS t",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "temp", StreamName: "t"},
+						Name: "temp",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "t", Query: inner}},
+			},
+		},
+		{
+			s: "WITH cleaned AS (SELECT temp FROM demo WHERE temp > 20) SELECT temp FROM cleaned",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream},
+						Name: "temp",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "cleaned", Query: inner}},
+			},
+		},
+		{
+			s:   "SELECT temp FROM (SELECT temp FROM demo)",
+			err: "found \"EOF\", expected AS. Subquery in FROM must have an alias.",
+		},
+		{
+			s:   "SELECT temp FROM (SELECT temp FROM demo AS t",
+			err: "found \"EOF\", expected ) to end the subquery.",
+		},
+		{
+			s:   "WITH cleaned AS (SELECT temp FROM demo) SELECT temp FROM demo1 INNER JOIN cleaned ON demo1.temp = cleaned.temp",
+			err: "common table expression cleaned cannot be joined.",
+		},
+		{
+			s:   "WITH cleaned AS (SELECT temp FROM demo), cleaned AS (SELECT temp FROM demo1) SELECT temp FROM cleaned",
+			err: "common table expression cleaned is defined more than once.",
+		},
+	}
+
+	for _, tt := range tests {
+		stmt, err := NewParser(strings.NewReader(tt.s)).Parse()
+		if tt.err != "" {
+			require.EqualError(t, err, tt.err, tt.s)
+			continue
+		}
+		require.NoError(t, err, tt.s)
+		require.Equal(t, tt.stmt, stmt, tt.s)
+	}
+}
+
+func TestParser_ParseSubQueryExpr(t *testing.T) {
+	tests := []struct {
+		s    string
+		stmt *ast.SelectStatement
+		err  string
+	}{
+		{
+			s: "SELECT temp FROM demo WHERE id IN (SELECT id FROM tbl WHERE level > 1)",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream},
+						Name: "temp",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "demo"}},
+				Condition: &ast.BinaryExpr{
+					OP:  ast.IN,
+					LHS: &ast.FieldRef{Name: "id", StreamName: ast.DefaultStream},
+					RHS: &ast.SubQueryExpr{Query: &ast.SelectStatement{
+						Fields: []ast.Field{
+							{
+								Expr: &ast.FieldRef{Name: "id", StreamName: ast.DefaultStream},
+								Name: "id",
+							},
+						},
+						Sources:   []ast.Source{&ast.Table{Name: "tbl"}},
+						Condition: &ast.BinaryExpr{OP: ast.GT, LHS: &ast.FieldRef{Name: "level", StreamName: ast.DefaultStream}, RHS: &ast.IntegerLiteral{Val: 1}},
+					}},
+				},
+			},
+		},
+		{
+			s: "SELECT temp FROM demo WHERE NOT EXISTS (SELECT * FROM tbl WHERE tbl.id = demo.id)",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "temp", StreamName: ast.DefaultStream},
+						Name: "temp",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "demo"}},
+				Condition: &ast.ExistsExpr{
+					Not: true,
+					Query: &ast.SelectStatement{
+						Fields: []ast.Field{
+							{
+								Expr: &ast.Wildcard{Token: ast.ASTERISK},
+								Name: "*",
+							},
+						},
+						Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+						Condition: &ast.BinaryExpr{
+							OP:  ast.EQ,
+							LHS: &ast.FieldRef{Name: "id", StreamName: "tbl"},
+							RHS: &ast.FieldRef{Name: "id", StreamName: "demo"},
+						},
+					},
+				},
+			},
+		},
+		{
+			s:   "SELECT temp FROM demo WHERE temp > (SELECT max(temp) FROM tbl)",
+			err: "subquery is only supported after IN or EXISTS.",
+		},
+		{
+			s:   "SELECT temp FROM demo WHERE NOT IN (SELECT id FROM tbl)",
+			err: "found \"IN\", expected EXISTS after NOT.",
+		},
+	}
+
+	for _, tt := range tests {
+		stmt, err := NewParser(strings.NewReader(tt.s)).Parse()
+		if tt.err != "" {
+			require.EqualError(t, err, tt.err, tt.s)
+			continue
+		}
+		require.NoError(t, err, tt.s)
+		require.Equal(t, tt.stmt, stmt, tt.s)
+	}
+}
+
+func TestParser_ParseDistinct(t *testing.T) {
+	tests := []struct {
+		s    string
+		stmt *ast.SelectStatement
+		err  string
+	}{
+		{
+			s: "SELECT DISTINCT color FROM tbl",
+			stmt: &ast.SelectStatement{
+				Distinct: true,
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "color", StreamName: ast.DefaultStream},
+						Name: "color",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s: "SELECT count(DISTINCT color) AS c FROM tbl",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.Call{
+							Name:     "count",
+							FuncType: ast.FuncTypeAgg,
+							Args:     []ast.Expr{&ast.FieldRef{Name: "color", StreamName: ast.DefaultStream}},
+							Distinct: true,
+						},
+						Name:  "count",
+						AName: "c",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s:   "SELECT abs(DISTINCT a) FROM tbl",
+			err: "DISTINCT is only supported in aggregate functions, but abs is not.",
+		},
+		{
+			s:   "SELECT count(DISTINCT *) FROM tbl",
+			err: "found \"*\", expected an expression after DISTINCT in function count.",
+		},
+	}
+
+	for _, tt := range tests {
+		stmt, err := NewParser(strings.NewReader(tt.s)).Parse()
+		if tt.err != "" {
+			require.EqualError(t, err, tt.err, tt.s)
+			continue
+		}
+		require.NoError(t, err, tt.s)
+		require.Equal(t, tt.stmt, stmt, tt.s)
+	}
+}
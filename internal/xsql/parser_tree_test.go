@@ -20,6 +20,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/lf-edge/ekuiper/v2/internal/testx"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
@@ -201,6 +203,30 @@ func TestParser_ParseTree(t *testing.T) {
 			},
 			err: ``,
 		},
+		{
+			s:    `SHOW VIEWS`,
+			stmt: &ast.ShowViewsStatement{},
+		},
+		{
+			s: `DESCRIBE VIEW latest`,
+			stmt: &ast.DescribeViewStatement{
+				Name: "latest",
+			},
+		},
+		{
+			s: `DROP VIEW latest`,
+			stmt: &ast.DropViewStatement{
+				Name: "latest",
+			},
+		},
+		{
+			s:   `CREATE VIEW latest SELECT * FROM demo`,
+			err: `found "SELECT", expected keyword as.`,
+		},
+		{
+			s:   `CREATE VIEW latest AS`,
+			err: `found EOF, expected select statement.`,
+		},
 	}
 
 	fmt.Printf("The test bucket size is %d.\n\n", len(tests))
@@ -214,3 +240,14 @@ func TestParser_ParseTree(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_ParseView(t *testing.T) {
+	p := NewParser(strings.NewReader(`CREATE VIEW latest_by_device AS SELECT deviceId, last_value(temp, true) AS temp FROM demo GROUP BY deviceId`))
+	stmt, err := Language.Parse(p)
+	require.NoError(t, err)
+	vs, ok := stmt.(*ast.ViewStmt)
+	require.True(t, ok)
+	require.Equal(t, "latest_by_device", vs.Name)
+	require.Len(t, vs.Select.Fields, 2)
+	require.Equal(t, ast.Dimensions{{Expr: &ast.FieldRef{Name: "deviceId", StreamName: ast.DefaultStream}}}, vs.Select.Dimensions)
+}
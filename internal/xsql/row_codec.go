@@ -0,0 +1,220 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsql
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedRow is returned by EncodeRow for the values which are not rows
+var ErrUnsupportedRow = errors.New("unsupported row type")
+
+const (
+	kindTuple uint8 = iota + 1
+	kindRawTuple
+	kindJoinTuple
+	kindGroupedTuples
+	kindWindowTuples
+	kindJoinTuples
+	kindGroupedTuplesSet
+)
+
+// rowGob is the serializable form of the rows. The tracer contexts and the caches are not kept. The window range and
+// the aggregate flag are unexported in the rows, so they are copied explicitly.
+type rowGob struct {
+	Kind      uint8
+	Emitter   string
+	Message   map[string]any
+	Timestamp time.Time
+	Metadata  map[string]any
+	Props     map[string]string
+	Rawdata   []byte
+	CalCols   map[string]any
+	AliasMap  map[string]any
+	Rows      []*rowGob
+	HasRange  bool
+	Start     int64
+	End       int64
+	IsAgg     bool
+}
+
+// EncodeRow serializes the rows which flow between the nodes. It returns an error for the other types like the
+// control signals.
+func EncodeRow(v any) ([]byte, error) {
+	g, err := toRowGob(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeRow restores the row encoded by EncodeRow
+func DecodeRow(b []byte) (any, error) {
+	g := &rowGob{}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(g); err != nil {
+		return nil, err
+	}
+	return fromRowGob(g)
+}
+
+func toRowGob(v any) (*rowGob, error) {
+	switch vt := v.(type) {
+	case *Tuple:
+		return &rowGob{Kind: kindTuple, Emitter: vt.Emitter, Message: vt.Message, Timestamp: vt.Timestamp, Metadata: vt.Metadata, Props: vt.Props, CalCols: vt.CalCols, AliasMap: vt.AliasMap}, nil
+	case *RawTuple:
+		return &rowGob{Kind: kindRawTuple, Emitter: vt.Emitter, Timestamp: vt.Timestamp, Rawdata: vt.Rawdata, Metadata: vt.Metadata, Props: vt.Props}, nil
+	case *JoinTuple:
+		rows, err := toRowGobs(vt.Tuples)
+		if err != nil {
+			return nil, err
+		}
+		return &rowGob{Kind: kindJoinTuple, Rows: rows, CalCols: vt.CalCols, AliasMap: vt.AliasMap}, nil
+	case *GroupedTuples:
+		rows, err := toRowGobs(vt.Content)
+		if err != nil {
+			return nil, err
+		}
+		return withRange(&rowGob{Kind: kindGroupedTuples, Rows: rows, CalCols: vt.CalCols, AliasMap: vt.AliasMap}, vt.WindowRange), nil
+	case *WindowTuples:
+		rows, err := toRowGobs(vt.Content)
+		if err != nil {
+			return nil, err
+		}
+		return withRange(&rowGob{Kind: kindWindowTuples, Rows: rows, CalCols: vt.CalCols, AliasMap: vt.AliasMap, IsAgg: vt.isAgg}, vt.WindowRange), nil
+	case *JoinTuples:
+		rows := make([]*rowGob, len(vt.Content))
+		for i, jt := range vt.Content {
+			r, err := toRowGob(jt)
+			if err != nil {
+				return nil, err
+			}
+			rows[i] = r
+		}
+		return withRange(&rowGob{Kind: kindJoinTuples, Rows: rows, CalCols: vt.CalCols, AliasMap: vt.AliasMap, IsAgg: vt.isAgg}, vt.WindowRange), nil
+	case *GroupedTuplesSet:
+		rows := make([]*rowGob, len(vt.Groups))
+		for i, gt := range vt.Groups {
+			r, err := toRowGob(gt)
+			if err != nil {
+				return nil, err
+			}
+			rows[i] = r
+		}
+		return withRange(&rowGob{Kind: kindGroupedTuplesSet, Rows: rows}, vt.WindowRange), nil
+	default:
+		return nil, fmt.Errorf("%w %T", ErrUnsupportedRow, v)
+	}
+}
+
+func toRowGobs(rows []Row) ([]*rowGob, error) {
+	result := make([]*rowGob, len(rows))
+	for i, r := range rows {
+		g, err := toRowGob(r)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = g
+	}
+	return result, nil
+}
+
+func withRange(g *rowGob, r *WindowRange) *rowGob {
+	if r != nil {
+		g.HasRange = true
+		g.Start = r.windowStart
+		g.End = r.windowEnd
+	}
+	return g
+}
+
+func (g *rowGob) windowRange() *WindowRange {
+	if !g.HasRange {
+		return nil
+	}
+	return NewWindowRange(g.Start, g.End)
+}
+
+func fromRowGob(g *rowGob) (any, error) {
+	switch g.Kind {
+	case kindTuple:
+		return &Tuple{Emitter: g.Emitter, Message: g.Message, Timestamp: g.Timestamp, Metadata: g.Metadata, Props: g.Props, AffiliateRow: AffiliateRow{CalCols: g.CalCols, AliasMap: g.AliasMap}}, nil
+	case kindRawTuple:
+		return &RawTuple{Emitter: g.Emitter, Timestamp: g.Timestamp, Rawdata: g.Rawdata, Metadata: g.Metadata, Props: g.Props}, nil
+	case kindJoinTuple:
+		rows, err := fromRowGobs(g.Rows)
+		if err != nil {
+			return nil, err
+		}
+		return &JoinTuple{Tuples: rows, AffiliateRow: AffiliateRow{CalCols: g.CalCols, AliasMap: g.AliasMap}}, nil
+	case kindGroupedTuples:
+		rows, err := fromRowGobs(g.Rows)
+		if err != nil {
+			return nil, err
+		}
+		return &GroupedTuples{Content: rows, WindowRange: g.windowRange(), AffiliateRow: AffiliateRow{CalCols: g.CalCols, AliasMap: g.AliasMap}}, nil
+	case kindWindowTuples:
+		rows, err := fromRowGobs(g.Rows)
+		if err != nil {
+			return nil, err
+		}
+		return &WindowTuples{Content: rows, WindowRange: g.windowRange(), AffiliateRow: AffiliateRow{CalCols: g.CalCols, AliasMap: g.AliasMap}, isAgg: g.IsAgg}, nil
+	case kindJoinTuples:
+		content := make([]*JoinTuple, len(g.Rows))
+		for i, r := range g.Rows {
+			jt, err := fromRowGob(r)
+			if err != nil {
+				return nil, err
+			}
+			content[i] = jt.(*JoinTuple)
+		}
+		return &JoinTuples{Content: content, WindowRange: g.windowRange(), AffiliateRow: AffiliateRow{CalCols: g.CalCols, AliasMap: g.AliasMap}, isAgg: g.IsAgg}, nil
+	case kindGroupedTuplesSet:
+		groups := make([]*GroupedTuples, len(g.Rows))
+		for i, r := range g.Rows {
+			gt, err := fromRowGob(r)
+			if err != nil {
+				return nil, err
+			}
+			groups[i] = gt.(*GroupedTuples)
+		}
+		return &GroupedTuplesSet{Groups: groups, WindowRange: g.windowRange()}, nil
+	default:
+		return nil, fmt.Errorf("unknown row kind %d", g.Kind)
+	}
+}
+
+func fromRowGobs(gs []*rowGob) ([]Row, error) {
+	result := make([]Row, len(gs))
+	for i, g := range gs {
+		r, err := fromRowGob(g)
+		if err != nil {
+			return nil, err
+		}
+		row, ok := r.(Row)
+		if !ok {
+			return nil, fmt.Errorf("%T is not a row", r)
+		}
+		result[i] = row
+	}
+	return result, nil
+}
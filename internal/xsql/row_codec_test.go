@@ -0,0 +1,65 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowCodec(t *testing.T) {
+	ts := time.UnixMilli(1000).UTC()
+	w := &WindowTuples{
+		Content: []Row{
+			&Tuple{Emitter: "demo", Message: map[string]any{"a": int64(1), "b": "x"}, Timestamp: ts},
+			&Tuple{Emitter: "demo", Message: map[string]any{"a": int64(2), "b": "y"}, Timestamp: ts},
+		},
+		WindowRange: NewWindowRange(10, 20),
+	}
+	w.SetIsAgg(true)
+	tests := []struct {
+		name string
+		row  any
+	}{
+		{name: "tuple", row: &Tuple{Emitter: "demo", Message: map[string]any{"a": 1.5}, Timestamp: ts, Metadata: map[string]any{"topic": "t"}}},
+		{name: "window", row: w},
+		{name: "join", row: &JoinTuples{Content: []*JoinTuple{{Tuples: []Row{&Tuple{Emitter: "s1", Message: map[string]any{"id": int64(1)}}, &Tuple{Emitter: "s2", Message: map[string]any{"id": int64(1)}}}}}, WindowRange: NewWindowRange(0, 10)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := EncodeRow(tt.row)
+			require.NoError(t, err)
+			v, err := DecodeRow(b)
+			require.NoError(t, err)
+			switch rt := tt.row.(type) {
+			case Row:
+				r, ok := v.(Row)
+				require.True(t, ok)
+				assert.Equal(t, rt.ToMap(), r.ToMap())
+			case Collection:
+				c, ok := v.(Collection)
+				require.True(t, ok)
+				assert.Equal(t, rt.ToMaps(), c.ToMaps())
+				assert.Equal(t, rt.GetWindowRange(), c.GetWindowRange())
+			}
+		})
+	}
+	_, err := EncodeRow(&WatermarkTuple{})
+	assert.True(t, errors.Is(err, ErrUnsupportedRow))
+}
@@ -25,20 +25,62 @@ import (
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
 
-func GetStreams(stmt *ast.SelectStatement) (result []string) {
+// GetStreams returns the names of all the streams and tables that the statement reads from,
+// including the tables in the IN and EXISTS subqueries of the WHERE clause
+func GetStreams(stmt *ast.SelectStatement) []string {
+	return getStreams(stmt, true)
+}
+
+// GetSourceStreams returns the names of the streams and tables in the FROM and JOIN clauses
+// which make up the rows of the statement
+func GetSourceStreams(stmt *ast.SelectStatement) []string {
+	return getStreams(stmt, false)
+}
+
+func getStreams(stmt *ast.SelectStatement, withCondition bool) (result []string) {
 	if stmt == nil {
 		return nil
 	}
 	// TODO sources must be a stream
 	for _, source := range stmt.Sources {
 		if s, ok := source.(*ast.Table); ok {
-			result = append(result, s.Name)
+			if s.Query != nil {
+				result = append(result, getStreams(s.Query, withCondition)...)
+			} else {
+				result = append(result, s.Name)
+			}
 		}
 	}
 
 	for _, join := range stmt.Joins {
 		result = append(result, join.Name)
 	}
+	if withCondition {
+		ast.WalkFunc(stmt.Condition, func(n ast.Node) bool {
+			switch e := n.(type) {
+			case *ast.SubQueryExpr:
+				result = append(result, getStreams(e.Query, withCondition)...)
+			case *ast.ExistsExpr:
+				result = append(result, getStreams(e.Query, withCondition)...)
+			}
+			return true
+		})
+	}
+	// The same stream can be used in many branches
+	for _, union := range stmt.Unions {
+		for _, s := range getStreams(union, withCondition) {
+			exist := false
+			for _, r := range result {
+				if r == s {
+					exist = true
+					break
+				}
+			}
+			if !exist {
+				result = append(result, s)
+			}
+		}
+	}
 	return
 }
 
@@ -339,6 +339,9 @@ func (v *ValuerEval) Eval(expr ast.Expr) interface{} {
 								}
 							}
 						}
+						if expr.Distinct {
+							args = function.DistinctArgs(args)
+						}
 					case ast.FuncTypeScalar, ast.FuncTypeSrf:
 						args = make([]interface{}, len(expr.Args))
 						for i, arg := range expr.Args {
@@ -0,0 +1,55 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const LblEdgeType = "edge"
+
+var (
+	BackpressureDroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kuiper",
+		Subsystem: "backpressure",
+		Name:      "dropped",
+		Help:      "counter of the messages dropped because the downstream buffer is full",
+	}, []string{LblRuleIDType, LblOpIDType, LblEdgeType})
+
+	BackpressureBlockedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kuiper",
+		Subsystem: "backpressure",
+		Name:      "blocked_us",
+		Help:      "counter of the time in microseconds blocked by the full downstream buffer",
+	}, []string{LblRuleIDType, LblOpIDType, LblEdgeType})
+
+	BackpressureSpilledCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kuiper",
+		Subsystem: "backpressure",
+		Name:      "spilled",
+		Help:      "counter of the messages spilled to disk because the downstream buffer is full",
+	}, []string{LblRuleIDType, LblOpIDType, LblEdgeType})
+)
+
+func RegisterBackpressure() {
+	prometheus.MustRegister(BackpressureDroppedCounter)
+	prometheus.MustRegister(BackpressureBlockedCounter)
+	prometheus.MustRegister(BackpressureSpilledCounter)
+}
+
+// RemoveBackpressure removes the metrics of the edge when the node stops
+func RemoveBackpressure(ruleID, opID, edge string) {
+	BackpressureDroppedCounter.DeleteLabelValues(ruleID, opID, edge)
+	BackpressureBlockedCounter.DeleteLabelValues(ruleID, opID, edge)
+	BackpressureSpilledCounter.DeleteLabelValues(ruleID, opID, edge)
+}
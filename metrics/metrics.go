@@ -54,6 +54,7 @@ var (
 
 func init() {
 	RegisterSyncCache()
+	RegisterBackpressure()
 	prometheus.MustRegister(RuleStatusCountGauge)
 	prometheus.MustRegister(RuleStatusGauge)
 	prometheus.MustRegister(RuleCPUUsageGauge)
@@ -228,6 +228,8 @@ type Call struct {
 
 	// This is used for window functions.
 	SortFields SortFields
+	// Distinct is set for the aggregate functions to only aggregate the distinct values like count(DISTINCT a)
+	Distinct bool
 }
 
 func (c *Call) expr()    {}
@@ -249,6 +251,9 @@ func (c *Call) String() string {
 	if c.WhenExpr != nil {
 		when += ", when:{ " + c.WhenExpr.String() + " }"
 	}
+	if c.Distinct {
+		args = ", distinct:true" + args
+	}
 	return "Call:{ name:" + c.Name + args + when + " }"
 }
 
@@ -401,6 +406,48 @@ func (b *BetweenExpr) String() string {
 	return "betweenExpr:{ " + low + high + " }"
 }
 
+// SubQueryExpr is the subquery at the right side of IN like a IN (SELECT id FROM table)
+type SubQueryExpr struct {
+	Query *SelectStatement
+}
+
+func (s *SubQueryExpr) expr() {}
+func (s *SubQueryExpr) node() {}
+func (s *SubQueryExpr) String() string {
+	return "subQueryExpr:{ " + subQuerySources(s.Query) + " }"
+}
+
+// ExistsExpr tests whether the subquery returns any row like EXISTS (SELECT * FROM table WHERE ...)
+type ExistsExpr struct {
+	Query *SelectStatement
+	Not   bool
+}
+
+func (e *ExistsExpr) expr() {}
+func (e *ExistsExpr) node() {}
+func (e *ExistsExpr) String() string {
+	t := "existsExpr"
+	if e.Not {
+		t = "notExistsExpr"
+	}
+	return t + ":{ " + subQuerySources(e.Query) + " }"
+}
+
+func subQuerySources(q *SelectStatement) string {
+	info := "sources:["
+	if q != nil {
+		for i, s := range q.Sources {
+			if t, ok := s.(*Table); ok {
+				info += t.Name
+			}
+			if i != len(q.Sources)-1 {
+				info += ", "
+			}
+		}
+	}
+	return info + "]"
+}
+
 type LimitExpr struct {
 	LimitCount *IntegerLiteral
 }
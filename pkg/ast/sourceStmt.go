@@ -304,6 +304,35 @@ func (dss *DescribeTableStatement) GetName() string { return dss.Name }
 func (ess *ExplainTableStatement) GetName() string  { return ess.Name }
 func (dss *DropTableStatement) GetName() string     { return dss.Name }
 
+// PrintFieldType prints the field type in the syntax of the stream definition such as array(bigint)
+func PrintFieldType(ft FieldType) (result string) {
+	switch t := ft.(type) {
+	case *BasicType:
+		result = t.Type.String()
+	case *ArrayType:
+		result = "array("
+		if t.FieldType != nil {
+			result += PrintFieldType(t.FieldType)
+		} else {
+			result += t.Type.String()
+		}
+		result += ")"
+	case *RecType:
+		result = "struct("
+		isFirst := true
+		for _, f := range t.StreamFields {
+			if isFirst {
+				isFirst = false
+			} else {
+				result += ", "
+			}
+			result = result + f.Name + " " + PrintFieldType(f.FieldType)
+		}
+		result += ")"
+	}
+	return
+}
+
 func printFieldTypeForJson(ft FieldType) (result interface{}) {
 	r, q := doPrintFieldTypeForJson(ft)
 	if q {
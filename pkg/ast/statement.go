@@ -30,6 +30,11 @@ type SelectStatement struct {
 	Dimensions Dimensions
 	Having     Expr
 	SortFields SortFields
+	// Unions are the following branches of UNION ALL. Each branch is a complete select statement
+	// whose results are merged with this statement by column name.
+	Unions []*SelectStatement
+	// Distinct removes the duplicate rows of the results by SELECT DISTINCT
+	Distinct bool
 
 	Statement
 }
@@ -108,6 +113,9 @@ type Source interface {
 type Table struct {
 	Name  string
 	Alias string
+	// Query is set if the source is a subquery or refers to a common table expression.
+	// For subquery, the Name is its alias.
+	Query *SelectStatement
 	Source
 }
 
@@ -118,6 +118,9 @@ const (
 	MI
 	SS
 	MS
+
+	UNION
+	DISTINCT
 )
 
 var Tokens = []string{
@@ -186,6 +189,8 @@ var Tokens = []string{
 	END:       "END",
 	OVER:      "OVER",
 	PARTITION: "PARTITION",
+	UNION:     "UNION",
+	DISTINCT:  "DISTINCT",
 
 	AND:        "AND",
 	OR:         "OR",
@@ -217,6 +222,8 @@ const (
 	TABLE      = "TABLE"
 	STREAMS    = "STREAMS"
 	TABLES     = "TABLES"
+	VIEW       = "VIEW"
+	VIEWS      = "VIEWS"
 	WITH       = "WITH"
 
 	DATASOURCE        = "DATASOURCE"
@@ -0,0 +1,43 @@
+// Copyright 2024 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+// ViewStmt is the statement of CREATE VIEW name AS SELECT ...
+// The select statement is maintained continuously and its latest results can be queried by the view name.
+type ViewStmt struct {
+	Name   string
+	Select *SelectStatement
+
+	Statement
+}
+
+type ShowViewsStatement struct {
+	Statement
+}
+
+type DescribeViewStatement struct {
+	Name string
+
+	Statement
+}
+
+type DropViewStatement struct {
+	Name string
+
+	Statement
+}
+
+func (dvs *DescribeViewStatement) GetName() string { return dvs.Name }
+func (dvs *DropViewStatement) GetName() string     { return dvs.Name }
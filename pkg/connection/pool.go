@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/failpoint"
 
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/secret"
 	topoContext "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
 	"github.com/lf-edge/ekuiper/v2/pkg/modules"
@@ -341,7 +342,12 @@ func createConnection(connCtx api.StreamContext, meta *Meta) (modules.Connection
 	}
 	conn = connRegister(connCtx)
 	sc, isStateful := conn.(modules.StatefulDialer)
-	err = conn.Provision(connCtx, meta.ID, meta.Props)
+	// the meta keeps the secret references for the responses and the store
+	props, err := secret.Resolve(meta.Props)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Provision(connCtx, meta.ID, props)
 	if err != nil {
 		return nil, err
 	}
@@ -18,6 +18,8 @@ import (
 	"io"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
 // Candidate for API. Currently only use internally
@@ -50,3 +52,12 @@ type UniqueSub interface {
 type UniqueConn interface {
 	ConnId(props map[string]any) string
 }
+
+// PushDownSource can filter and project the data by itself, such as in the query of a database.
+// The planner offers the conjuncts of the WHERE clause which only refer to the source and the columns read by the rule.
+// The rule still evaluates all the conditions, so the source can accept part of them.
+type PushDownSource interface {
+	// PushDown is called with the source properties before provision. It returns the accepted conditions and columns.
+	// The source must apply the accepted ones after provision. Return nil columns to read all the columns.
+	PushDown(props map[string]any, conditions []ast.Expr, columns []string) ([]ast.Expr, []string)
+}